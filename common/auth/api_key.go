@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/imylam/delivery-test/configs"
+)
+
+type apiKeyAuthenticator struct {
+	// keys are indexed by their SHA-256 digest so the lookup time does not
+	// depend on how much of a guessed key matches a real one
+	keys map[[sha256.Size]byte]configs.APIKeyConfig
+}
+
+func newAPIKeyAuthenticator(keys []configs.APIKeyConfig) (*apiKeyAuthenticator, error) {
+	a := &apiKeyAuthenticator{keys: make(map[[sha256.Size]byte]configs.APIKeyConfig, len(keys))}
+	for _, k := range keys {
+		if err := validateRoles(k.Roles); err != nil {
+			return nil, fmt.Errorf("API key of %s: %w", k.Subject, err)
+		}
+		if k.Tenant != "" && !tenantPattern.MatchString(k.Tenant) {
+			return nil, fmt.Errorf("API key of %s: %w %q", k.Subject, ErrInvalidTenant, k.Tenant)
+		}
+		a.keys[sha256.Sum256([]byte(k.Key))] = k
+	}
+
+	return a, nil
+}
+
+func (a *apiKeyAuthenticator) authenticate(key string) (*Identity, error) {
+	k, ok := a.keys[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Identity{Subject: k.Subject, Roles: k.Roles, Tenant: k.Tenant, Method: MethodAPIKey}, nil
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/imylam/delivery-test/configs"
+)
+
+const (
+	HeaderAPIKey string = "X-API-Key"
+
+	schemeBearer string = "bearer"
+	schemeAPIKey string = "apikey"
+)
+
+var (
+	ErrNoCredentials      = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrNoIdentity         = errors.New("caller not authenticated")
+)
+
+// Authenticator resolves the caller of a request
+type Authenticator interface {
+	Authenticate(*http.Request) (*Identity, error)
+}
+
+type authenticator struct {
+	apiKeys *apiKeyAuthenticator
+	jwt     *jwtAuthenticator
+}
+
+// NewAuthenticator creates an Authenticator accepting the API keys and JWTs described by cfg
+func NewAuthenticator(cfg configs.AuthConfig) (Authenticator, error) {
+	apiKeys, err := newAPIKeyAuthenticator(cfg.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+	a := &authenticator{apiKeys: apiKeys}
+
+	if cfg.JWT.HS256Secret != "" || cfg.JWT.JWKSFile != "" || cfg.JWT.JWKSURL != "" {
+		jwtAuth, err := newJWTAuthenticator(cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+		a.jwt = jwtAuth
+	}
+
+	return a, nil
+}
+
+// Authenticate checks the X-API-Key header or the Authorization header, which
+// may carry either "Bearer <jwt>" or "ApiKey <key>"
+func (a *authenticator) Authenticate(r *http.Request) (*Identity, error) {
+	if key := r.Header.Get(HeaderAPIKey); key != "" {
+		return a.apiKeys.authenticate(key)
+	}
+
+	scheme, credentials, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || credentials == "" {
+		return nil, ErrNoCredentials
+	}
+
+	switch strings.ToLower(scheme) {
+	case schemeAPIKey:
+		return a.apiKeys.authenticate(strings.TrimSpace(credentials))
+	case schemeBearer:
+		if a.jwt == nil {
+			return nil, ErrInvalidCredentials
+		}
+		return a.jwt.authenticate(strings.TrimSpace(credentials))
+	default:
+		return nil, ErrNoCredentials
+	}
+}
@@ -0,0 +1,364 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/imylam/delivery-test/configs"
+)
+
+const (
+	mockHMACSecret string = "secret"
+	mockKid        string = "key-1"
+)
+
+func TestAuthenticate(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksFile := writeJWKSFile(t, mockKid, &rsaKey.PublicKey)
+
+	authenticator, err := NewAuthenticator(configs.AuthConfig{
+		APIKeys: []configs.APIKeyConfig{{Key: "abc", Subject: "merchant-1", Roles: []string{RoleMerchant}, Tenant: "brand-a"}},
+		JWT: configs.JWTConfig{
+			HS256Secret: mockHMACSecret,
+			JWKSFile:    jwksFile,
+			Audience:    "delivery",
+			Leeway:      time.Second,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error '%s' when creating authenticator", err.Error())
+	}
+
+	t.Run("api-key-header", func(t *testing.T) {
+		req := createMockRequest(HeaderAPIKey, "abc")
+
+		identity, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, "merchant-1", identity.Subject)
+		assert.Equal(t, []string{RoleMerchant}, identity.Roles)
+		assert.Equal(t, "brand-a", identity.Tenant)
+		assert.Equal(t, MethodAPIKey, identity.Method)
+	})
+
+	t.Run("api-key-authorization", func(t *testing.T) {
+		req := createMockRequest("Authorization", "ApiKey abc")
+
+		identity, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, "merchant-1", identity.Subject)
+	})
+
+	t.Run("invalid-api-key", func(t *testing.T) {
+		req := createMockRequest(HeaderAPIKey, "abd")
+
+		_, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, ErrInvalidCredentials, err)
+	})
+
+	t.Run("no-credentials", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/orders", nil)
+
+		_, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, ErrNoCredentials, err)
+	})
+
+	t.Run("hs256", func(t *testing.T) {
+		token := signMockToken(t, jwt.SigningMethodHS256, []byte(mockHMACSecret), "", createMockClaims("courier-1"))
+		req := createMockRequest("Authorization", "Bearer "+token)
+
+		identity, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, "courier-1", identity.Subject)
+		assert.Equal(t, []string{RoleCourier}, identity.Roles)
+		assert.Equal(t, "", identity.Tenant)
+		assert.Equal(t, MethodJWT, identity.Method)
+	})
+
+	t.Run("tenant-claim", func(t *testing.T) {
+		claims := createMockClaims("courier-1")
+		claims.Tenant = "brand-b"
+		token := signMockToken(t, jwt.SigningMethodHS256, []byte(mockHMACSecret), "", claims)
+		req := createMockRequest("Authorization", "Bearer "+token)
+
+		identity, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, "brand-b", identity.Tenant)
+	})
+
+	t.Run("invalid-tenant-claim", func(t *testing.T) {
+		claims := createMockClaims("courier-1")
+		claims.Tenant = "brand a"
+		token := signMockToken(t, jwt.SigningMethodHS256, []byte(mockHMACSecret), "", claims)
+		req := createMockRequest("Authorization", "Bearer "+token)
+
+		_, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, true, errors.Is(err, ErrInvalidCredentials))
+	})
+
+	t.Run("rs256", func(t *testing.T) {
+		token := signMockToken(t, jwt.SigningMethodRS256, rsaKey, mockKid, createMockClaims("courier-2"))
+		req := createMockRequest("Authorization", "Bearer "+token)
+
+		identity, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, "courier-2", identity.Subject)
+	})
+
+	t.Run("rs256-unknown-kid", func(t *testing.T) {
+		token := signMockToken(t, jwt.SigningMethodRS256, rsaKey, "key-2", createMockClaims("courier-2"))
+		req := createMockRequest("Authorization", "Bearer "+token)
+
+		_, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, false, err == nil)
+	})
+
+	t.Run("wrong-secret", func(t *testing.T) {
+		token := signMockToken(t, jwt.SigningMethodHS256, []byte("guess"), "", createMockClaims("courier-1"))
+		req := createMockRequest("Authorization", "Bearer "+token)
+
+		_, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, false, err == nil)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		claims := createMockClaims("courier-1")
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		token := signMockToken(t, jwt.SigningMethodHS256, []byte(mockHMACSecret), "", claims)
+		req := createMockRequest("Authorization", "Bearer "+token)
+
+		_, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, false, err == nil)
+	})
+
+	t.Run("wrong-audience", func(t *testing.T) {
+		claims := createMockClaims("courier-1")
+		claims.Audience = jwt.ClaimStrings{"billing"}
+		token := signMockToken(t, jwt.SigningMethodHS256, []byte(mockHMACSecret), "", claims)
+		req := createMockRequest("Authorization", "Bearer "+token)
+
+		_, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, false, err == nil)
+	})
+
+	t.Run("unknown-role", func(t *testing.T) {
+		claims := createMockClaims("courier-1")
+		claims.Roles = jwt.ClaimStrings{"superuser"}
+		token := signMockToken(t, jwt.SigningMethodHS256, []byte(mockHMACSecret), "", claims)
+		req := createMockRequest("Authorization", "Bearer "+token)
+
+		_, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, false, err == nil)
+	})
+
+	t.Run("alg-none", func(t *testing.T) {
+		token := signMockToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", createMockClaims("courier-1"))
+		req := createMockRequest("Authorization", "Bearer "+token)
+
+		_, err := authenticator.Authenticate(req)
+
+		assert.Equal(t, false, err == nil)
+	})
+}
+
+func TestJWKSURL(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(createMockJWKS(mockKid, &rsaKey.PublicKey))
+	}))
+	defer server.Close()
+
+	authenticator, err := NewAuthenticator(configs.AuthConfig{
+		JWT: configs.JWTConfig{JWKSURL: server.URL, JWKSRefreshInterval: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error '%s' when creating authenticator", err.Error())
+	}
+
+	token := signMockToken(t, jwt.SigningMethodRS256, rsaKey, mockKid, createMockClaims("courier-3"))
+	req := createMockRequest("Authorization", "Bearer "+token)
+
+	identity, err := authenticator.Authenticate(req)
+
+	assert.Equal(t, true, err == nil)
+	assert.Equal(t, "courier-3", identity.Subject)
+	assert.Equal(t, 1, requests)
+}
+
+func TestJWKSRefreshFailure(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var requests int32
+	failing := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(createMockJWKS(mockKid, &rsaKey.PublicKey))
+	}))
+	defer server.Close()
+
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	source := newJWKSSource("", server.URL, 15*time.Minute)
+	source.now = func() time.Time { return now }
+	if err := source.refresh(); err != nil {
+		t.Fatalf("unexpected error '%s' when loading JWKS", err.Error())
+	}
+	atomic.StoreInt32(&failing, 1)
+
+	// the keys are stale and the endpoint is down: a single attempt is made
+	// and the stale keys keep being served
+	now = now.Add(16 * time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = source.key("unknown")
+			k, err := source.key(mockKid)
+			assert.Equal(t, true, err == nil)
+			assert.Equal(t, true, k != nil)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// no new attempt before jwksMinRefreshInterval has gone by
+	now = now.Add(30 * time.Second)
+	_, _ = source.key("unknown")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	now = now.Add(jwksMinRefreshInterval)
+	_, _ = source.key("unknown")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func createMockRequest(header, value string) *http.Request {
+	req, _ := http.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(header, value)
+
+	return req
+}
+
+func createMockClaims(subject string) *claims {
+	return &claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{"delivery"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Roles: jwt.ClaimStrings{RoleCourier},
+	}
+}
+
+func signMockToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, c *claims) string {
+	token := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("fail to sign token: %s", err.Error())
+	}
+
+	return s
+}
+
+func createMockJWKS(kid string, key *rsa.PublicKey) []byte {
+	raw, _ := json.Marshal(jwkSet{Keys: []jwk{{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+
+	return raw
+}
+
+func writeJWKSFile(t *testing.T, kid string, key *rsa.PublicKey) string {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, createMockJWKS(kid, key), 0o600); err != nil {
+		t.Fatalf("fail to write JWKS file: %s", err.Error())
+	}
+
+	return path
+}
+
+func TestCan(t *testing.T) {
+	merchant := &Identity{Subject: "merchant-1", Roles: []string{RoleMerchant}}
+	courier := &Identity{Subject: "courier-1", Roles: []string{RoleCourier}}
+	admin := &Identity{Subject: "admin-1", Roles: []string{RoleAdmin}}
+
+	assert.Equal(t, true, merchant.Can(PermissionOrderPlace))
+	assert.Equal(t, false, merchant.Can(PermissionOrderTake))
+	assert.Equal(t, true, courier.Can(PermissionOrderTake))
+	assert.Equal(t, false, courier.Can(PermissionOrderPlace))
+	assert.Equal(t, false, courier.Can(PermissionAdmin))
+	assert.Equal(t, true, admin.Can(PermissionOrderPlace))
+	assert.Equal(t, true, admin.Can(PermissionAdmin))
+}
+
+func TestResolveTenant(t *testing.T) {
+	bound := &Identity{Subject: "merchant-1", Roles: []string{RoleMerchant}, Tenant: "brand-a"}
+	unbound := &Identity{Subject: "admin-1", Roles: []string{RoleAdmin}}
+	unboundMerchant := &Identity{Subject: "merchant-2", Roles: []string{RoleMerchant}}
+	unboundCourier := &Identity{Subject: "courier-2", Roles: []string{RoleCourier}}
+
+	tests := []struct {
+		name     string
+		identity *Identity
+		header   string
+		tenant   string
+		err      error
+	}{
+		{"bound", bound, "", "brand-a", nil},
+		{"bound-same-header", bound, "brand-a", "brand-a", nil},
+		{"bound-other-header", bound, "brand-b", "", ErrForbidden},
+		{"unbound-header", unbound, "brand-b", "brand-b", nil},
+		{"unbound-no-header", unbound, "", "", ErrNoTenant},
+		{"invalid-header", unbound, "brand/b", "", ErrInvalidTenant},
+		{"unbound-merchant-header", unboundMerchant, "brand-b", "", ErrForbidden},
+		{"unbound-merchant-no-header", unboundMerchant, "", "", ErrForbidden},
+		{"unbound-courier-header", unboundCourier, "brand-b", "", ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenant, err := ResolveTenant(tt.identity, tt.header)
+
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.tenant, tenant)
+		})
+	}
+}
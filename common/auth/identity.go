@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+)
+
+const (
+	MethodAPIKey string = "api_key"
+	MethodJWT    string = "jwt"
+
+	// ContextKeyIdentity is the gin context key holding the caller *Identity
+	ContextKeyIdentity string = "auth.identity"
+)
+
+type identityKey struct{}
+
+// Identity represents an authenticated caller, Tenant is empty for callers
+// not bound to a tenant until one is resolved for the request
+type Identity struct {
+	Subject string
+	Roles   []string
+	Tenant  string
+	Method  string
+}
+
+// WithIdentity returns a copy of ctx carrying id
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the caller identity carried by ctx, if any
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
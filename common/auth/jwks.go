@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// jwksMinRefreshInterval limits how often a token with an unknown kid or a
+// failing JWKS endpoint can trigger a refresh, so forged tokens or an outage
+// cannot hammer the endpoint or block every request on its timeout
+const jwksMinRefreshInterval time.Duration = time.Minute
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// jwksSource holds the RSA public keys of a JWKS file or URL, reloading them
+// once they are older than refreshInterval or when an unknown kid shows up.
+// Only one refresh runs at a time and attempts, failed or not, are spaced by
+// jwksMinRefreshInterval at least
+type jwksSource struct {
+	file            string
+	url             string
+	refreshInterval time.Duration
+	client          *http.Client
+	now             func() time.Time
+
+	refreshMu   sync.Mutex
+	mu          sync.RWMutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+func newJWKSSource(file, url string, refreshInterval time.Duration) *jwksSource {
+	return &jwksSource{
+		file:            file,
+		url:             url,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		now:             time.Now,
+	}
+}
+
+func (s *jwksSource) key(kid string) (*rsa.PublicKey, error) {
+	k, found, due := s.lookup(kid)
+	if due {
+		s.refreshMu.Lock()
+		// a concurrent caller may have refreshed while we waited
+		if _, _, due = s.lookup(kid); due {
+			// keep serving the keys we have if the refresh fails
+			_ = s.refresh()
+		}
+		s.refreshMu.Unlock()
+
+		k, found, _ = s.lookup(kid)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return k, nil
+}
+
+// lookup returns the key of kid and whether the keys are due for a refresh
+func (s *jwksSource) lookup(kid string) (*rsa.PublicKey, bool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	k, found := s.keys[kid]
+	now := s.now()
+	sinceAttempt := now.Sub(s.attemptedAt)
+
+	retryAfter := jwksMinRefreshInterval
+	if s.refreshInterval > 0 && s.refreshInterval < retryAfter {
+		retryAfter = s.refreshInterval
+	}
+	stale := s.refreshInterval > 0 && now.Sub(s.fetchedAt) > s.refreshInterval && sinceAttempt > retryAfter
+
+	return k, found, stale || (!found && sinceAttempt > jwksMinRefreshInterval)
+}
+
+func (s *jwksSource) refresh() error {
+	s.mu.Lock()
+	s.attemptedAt = s.now()
+	s.mu.Unlock()
+
+	raw, err := s.fetch()
+	if err != nil {
+		return fmt.Errorf("fail to load JWKS: %w", err)
+	}
+
+	keys, err := parseJWKS(raw)
+	if err != nil {
+		return fmt.Errorf("fail to parse JWKS: %w", err)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = s.now()
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *jwksSource) fetch() ([]byte, error) {
+	if s.file != "" {
+		return os.ReadFile(s.file)
+	}
+
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// parseJWKS returns the RSA signing keys of a JWKS document indexed by kid
+func parseJWKS(raw []byte) (map[string]*rsa.PublicKey, error) {
+	var set jwkSet
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid modulus", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("key %q: invalid exponent", k.Kid)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no RSA signing keys found")
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/imylam/delivery-test/configs"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// claims represents the JWT claims the service understands, roles may be
+// given as a single string or an array
+type claims struct {
+	jwt.RegisteredClaims
+	Roles  jwt.ClaimStrings `json:"roles,omitempty"`
+	Tenant string           `json:"tenant_id,omitempty"`
+}
+
+type jwtAuthenticator struct {
+	parser     *jwt.Parser
+	hmacSecret []byte
+	jwks       *jwksSource
+}
+
+func newJWTAuthenticator(cfg configs.JWTConfig) (*jwtAuthenticator, error) {
+	a := &jwtAuthenticator{}
+
+	var methods []string
+	if cfg.HS256Secret != "" {
+		a.hmacSecret = []byte(cfg.HS256Secret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.JWKSFile != "" || cfg.JWKSURL != "" {
+		a.jwks = newJWKSSource(cfg.JWKSFile, cfg.JWKSURL, cfg.JWKSRefreshInterval)
+		if err := a.jwks.refresh(); err != nil {
+			return nil, err
+		}
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+
+	return a, nil
+}
+
+func (a *jwtAuthenticator) authenticate(tokenString string) (*Identity, error) {
+	var c claims
+	_, err := a.parser.ParseWithClaims(tokenString, &c, a.keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCredentials, err.Error())
+	}
+	if c.Subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+	if err := validateRoles(c.Roles); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCredentials, err.Error())
+	}
+
+	if c.Tenant != "" && !tenantPattern.MatchString(c.Tenant) {
+		return nil, fmt.Errorf("%w: %s %q", ErrInvalidCredentials, ErrInvalidTenant.Error(), c.Tenant)
+	}
+
+	return &Identity{Subject: c.Subject, Roles: c.Roles, Tenant: c.Tenant, Method: MethodJWT}, nil
+}
+
+func (a *jwtAuthenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return a.hmacSecret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := token.Header["kid"].(string)
+		return a.jwks.key(kid)
+	default:
+		return nil, errors.New("unexpected signing method")
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	RoleMerchant string = "merchant"
+	RoleCourier  string = "courier"
+	RoleAdmin    string = "admin"
+)
+
+// Permission represents an action a caller may be allowed to perform
+type Permission string
+
+const (
+	PermissionOrderPlace Permission = "order:place"
+	PermissionOrderTake  Permission = "order:take"
+	PermissionOrderList  Permission = "order:list"
+	PermissionOrderView  Permission = "order:view"
+	PermissionWebhook    Permission = "webhook:manage"
+	PermissionAdmin      Permission = "admin"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// rolePermissions lists what each role may do, admins may do everything
+var rolePermissions = map[string][]Permission{
+	RoleMerchant: {PermissionOrderPlace, PermissionOrderList, PermissionOrderView, PermissionWebhook},
+	RoleCourier:  {PermissionOrderTake, PermissionOrderList, PermissionOrderView},
+	RoleAdmin:    nil,
+}
+
+// HasRole tells whether the caller holds role
+func (id *Identity) HasRole(role string) bool {
+	for _, r := range id.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Can tells whether any role of the caller grants perm
+func (id *Identity) Can(perm Permission) bool {
+	if id.HasRole(RoleAdmin) {
+		return true
+	}
+
+	for _, r := range id.Roles {
+		for _, p := range rolePermissions[r] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize returns the caller carried by ctx if it is allowed to perform perm
+func Authorize(ctx context.Context, perm Permission) (*Identity, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return nil, ErrNoIdentity
+	}
+	if !id.Can(perm) {
+		return nil, ErrForbidden
+	}
+
+	return id, nil
+}
+
+func validateRoles(roles []string) error {
+	if len(roles) == 0 {
+		return errors.New("no roles given")
+	}
+	for _, r := range roles {
+		if _, ok := rolePermissions[r]; !ok {
+			return fmt.Errorf("unknown role %q", r)
+		}
+	}
+	return nil
+}
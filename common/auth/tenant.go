@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"errors"
+	"regexp"
+)
+
+// HeaderTenantID names the tenant an admin not bound to one acts on
+const HeaderTenantID string = "X-Tenant-ID"
+
+var (
+	ErrNoTenant      = errors.New("no tenant")
+	ErrInvalidTenant = errors.New("invalid tenant")
+
+	tenantPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+)
+
+// ResolveTenant returns the tenant the caller acts on. Callers bound to a
+// tenant always act on it and may only repeat it in the header, unbound
+// admins pick one with the header and other unbound callers act on none
+func ResolveTenant(id *Identity, header string) (string, error) {
+	if header != "" && !tenantPattern.MatchString(header) {
+		return "", ErrInvalidTenant
+	}
+
+	if id.Tenant != "" {
+		if header != "" && header != id.Tenant {
+			return "", ErrForbidden
+		}
+		return id.Tenant, nil
+	}
+
+	if !id.HasRole(RoleAdmin) {
+		return "", ErrForbidden
+	}
+	if header == "" {
+		return "", ErrNoTenant
+	}
+
+	return header, nil
+}
@@ -0,0 +1,45 @@
+// Package lease lets the background jobs of every instance compete for a
+// named lease, so that only its holder runs them
+package lease
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NewHolder returns a random holder ID, unique to the job of an instance
+func NewHolder() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// AcquireMysql takes or renews the lease called name for holder in the
+// outbox_leases table, named after the outbox relay that used it first. It
+// returns false while another holder has an unexpired lease
+func AcquireMysql(db sqlx.Execer, name, holder string, lease time.Duration) (bool, error) {
+	q1 := "INSERT IGNORE INTO outbox_leases (name, holder, expires_at) VALUES (?, '', now(6))"
+	q2 := "UPDATE outbox_leases SET holder=?, expires_at=now(6) + INTERVAL ? MICROSECOND " +
+		"WHERE name=? AND (holder=? OR expires_at<now(6))"
+
+	_, err := db.Exec(q1, name)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.Exec(q2, holder, lease.Microseconds(), name, holder)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
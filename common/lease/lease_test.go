@@ -0,0 +1,52 @@
+package lease
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestNewHolder(t *testing.T) {
+	holder := NewHolder()
+
+	assert.Equal(t, 32, len(holder))
+	assert.NotEqual(t, holder, NewHolder())
+}
+
+func TestAcquireMysql(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q1 := "INSERT IGNORE INTO outbox_leases"
+	q2 := "UPDATE outbox_leases SET holder=\\?, expires_at=now\\(6\\) \\+ INTERVAL \\? MICROSECOND " +
+		"WHERE name=\\? AND \\(holder=\\? OR expires_at<now\\(6\\)\\)"
+
+	t.Run("acquired", func(t *testing.T) {
+		mock.ExpectExec(q1).WithArgs("outbox-relay").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(q2).WithArgs("relay-1", int64(30000000), "outbox-relay", "relay-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		held, err := AcquireMysql(sqlxDB, "outbox-relay", "relay-1", 30*time.Second)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, held)
+	})
+
+	t.Run("held-elsewhere", func(t *testing.T) {
+		mock.ExpectExec(q1).WithArgs("order-scheduler").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(q2).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		held, err := AcquireMysql(sqlxDB, "order-scheduler", "scheduler-1", 30*time.Second)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, held)
+	})
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"github.com/imylam/delivery-test/common/auth"
+	resterrors "github.com/imylam/delivery-test/common/rest_errors"
+	"github.com/imylam/delivery-test/logger"
+	"go.uber.org/zap"
+
+	"github.com/gin-gonic/gin"
+)
+
+const errUnauthorized string = "unauthorized"
+
+// Authenticate rejects requests without valid credentials and attaches the
+// caller identity to both the gin context and the request context
+func Authenticate(authenticator auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, err := authenticator.Authenticate(c.Request)
+		if err != nil {
+			logger.Logger.Debug("fail to authenticate request", zap.String("error", err.Error()))
+
+			c.Header("WWW-Authenticate", `Bearer realm="delivery"`)
+			c.Error(resterrors.NewUnauthorizedError(errUnauthorized))
+			c.Abort()
+			return
+		}
+
+		c.Set(auth.ContextKeyIdentity, identity)
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+
+		c.Next()
+	}
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/logger"
+)
+
+func TestAuthenticate(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	authenticator, _ := auth.NewAuthenticator(configs.AuthConfig{
+		APIKeys: []configs.APIKeyConfig{
+			{Key: "abc", Subject: "merchant-1", Roles: []string{auth.RoleMerchant}},
+			{Key: "def", Subject: "courier-1", Roles: []string{auth.RoleCourier}},
+		},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(HandleRestError)
+	router.Use(Authenticate(authenticator))
+	router.GET("/whoami", RequirePermission(auth.PermissionOrderPlace), func(c *gin.Context) {
+		identity, _ := auth.FromContext(c.Request.Context())
+		c.String(http.StatusOK, identity.Subject)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set(auth.HeaderAPIKey, "abc")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "merchant-1", w.Body.String())
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set(auth.HeaderAPIKey, "def")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "403", w.Header().Get("HTTP"))
+		assert.Equal(t, `{"error":"forbidden"}`, w.Body.String())
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set(auth.HeaderAPIKey, "abd")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "401", w.Header().Get("HTTP"))
+		assert.Equal(t, `{"error":"unauthorized"}`, w.Body.String())
+		assert.Equal(t, `Bearer realm="delivery"`, w.Header().Get("WWW-Authenticate"))
+	})
+}
+
+func TestResolveTenant(t *testing.T) {
+	authenticator, _ := auth.NewAuthenticator(configs.AuthConfig{
+		APIKeys: []configs.APIKeyConfig{
+			{Key: "abc", Subject: "merchant-1", Roles: []string{auth.RoleMerchant}, Tenant: "brand-a"},
+			{Key: "xyz", Subject: "admin-1", Roles: []string{auth.RoleAdmin}},
+			{Key: "def", Subject: "courier-1", Roles: []string{auth.RoleCourier}},
+		},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(HandleRestError)
+	router.Use(Authenticate(authenticator))
+	router.GET("/tenant", ResolveTenant(), func(c *gin.Context) {
+		identity, _ := auth.FromContext(c.Request.Context())
+		c.String(http.StatusOK, identity.Tenant)
+	})
+
+	tests := []struct {
+		name   string
+		key    string
+		tenant string
+		code   int
+		body   string
+	}{
+		{"bound-key", "abc", "", http.StatusOK, "brand-a"},
+		{"bound-key-other-tenant", "abc", "brand-b", http.StatusForbidden, `{"error":"forbidden"}`},
+		{"unbound-key", "xyz", "brand-b", http.StatusOK, "brand-b"},
+		{"unbound-key-no-tenant", "xyz", "", http.StatusBadRequest, `{"error":"tenant required"}`},
+		{"invalid-tenant", "xyz", "brand b", http.StatusBadRequest, `{"error":"invalid tenant"}`},
+		{"unbound-courier-key", "def", "brand-b", http.StatusForbidden, `{"error":"forbidden"}`},
+		{"unbound-courier-key-no-tenant", "def", "", http.StatusForbidden, `{"error":"forbidden"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/tenant", nil)
+			req.Header.Set(auth.HeaderAPIKey, tt.key)
+			if tt.tenant != "" {
+				req.Header.Set(auth.HeaderTenantID, tt.tenant)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.body, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/imylam/delivery-test/common/auth"
+	resterrors "github.com/imylam/delivery-test/common/rest_errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+const errForbidden string = "forbidden"
+
+// RequirePermission rejects callers whose roles do not grant perm, it must
+// run after Authenticate
+func RequirePermission(perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, err := auth.Authorize(c.Request.Context(), perm)
+		if err == auth.ErrNoIdentity {
+			c.Error(resterrors.NewUnauthorizedError(errUnauthorized))
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.Error(resterrors.NewForbiddenError(errForbidden))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -11,13 +11,17 @@ import (
 func HandleRestError(c *gin.Context) {
 	c.Next()
 
-	if c.Errors == nil {
+	if len(c.Errors) == 0 {
 		return
 	}
 
 	err := c.Errors.Last().Err
 
-	if restErr, ok := err.(resterrors.RestError); ok {
+	if validationErr, ok := err.(*resterrors.ValidationError); ok {
+		c.Header("HTTP", validationErr.HttpStatusCodeString())
+		c.JSON(validationErr.HttpStatusCode(), gin.H{"error": validationErr.Error(), "violations": validationErr.Violations})
+		return
+	} else if restErr, ok := err.(resterrors.RestError); ok {
 		c.Header("HTTP", restErr.HttpStatusCodeString())
 		c.JSON(restErr.HttpStatusCode(), gin.H{"error": restErr.Error()})
 		return
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/common/ratelimit"
+	resterrors "github.com/imylam/delivery-test/common/rest_errors"
+	"github.com/imylam/delivery-test/logger"
+	"go.uber.org/zap"
+
+	"github.com/gin-gonic/gin"
+)
+
+const errTooManyRequests string = "too many requests"
+
+// RateLimitIP limits the requests of each IP across routes. It goes before
+// Authenticate so that requests failing it, such as credential guesses, are
+// limited as well
+func RateLimitIP(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res, limited, err := limiter.AllowIP(c.Request.Context(), c.ClientIP())
+		applyRateLimit(c, res, limited, err)
+	}
+}
+
+// RateLimit limits requests per route and client, clients are told apart by
+// their authentication method, tenant and subject, or by IP when the route is
+// public. Headers follow the IETF RateLimit header fields draft
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := "ip:" + c.ClientIP()
+		if identity, ok := auth.FromContext(c.Request.Context()); ok {
+			caller = "sub:" + identity.Method + ":" + identity.Tenant + ":" + identity.Subject
+		}
+
+		res, limited, err := limiter.Allow(c.Request.Context(), c.Request.Method+" "+c.FullPath(), caller)
+		applyRateLimit(c, res, limited, err)
+	}
+}
+
+// applyRateLimit sets the headers of a limited request and aborts it when it
+// is over the limit. The headers of a later limit replace those of an earlier one
+func applyRateLimit(c *gin.Context, res ratelimit.Result, limited bool, err error) {
+	if err != nil {
+		// fail open, an unavailable limiter backend must not take the API down
+		logger.Logger.Error("fail to apply rate limit", zap.String("error", err.Error()))
+	}
+	if !limited {
+		c.Next()
+		return
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("RateLimit-Reset", durationToSeconds(res.Reset))
+
+	if !res.Allowed {
+		c.Header("Retry-After", durationToSeconds(res.RetryAfter))
+		c.Error(resterrors.NewTooManyRequestsError(errTooManyRequests))
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+func durationToSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/common/ratelimit"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/logger"
+)
+
+func TestRateLimit(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), configs.RateLimitConfig{
+		Routes: map[string]configs.RateLimitRule{
+			"PATCH /orders/:id": {Limit: 1, Period: time.Minute},
+		},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(HandleRestError)
+	router.Use(RateLimit(limiter))
+	router.PATCH("/orders/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	t.Run("allowed", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPatch, "/orders/1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+	})
+
+	t.Run("too-many-requests", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPatch, "/orders/2", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "429", w.Header().Get("HTTP"))
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+		assert.Equal(t, `{"error":"too many requests"}`, w.Body.String())
+	})
+
+	t.Run("unlimited-route", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/orders", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "", w.Header().Get("RateLimit-Limit"))
+	})
+}
+
+func TestRateLimitPerTenant(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), configs.RateLimitConfig{
+		Routes: map[string]configs.RateLimitRule{
+			"PATCH /orders/:id": {Limit: 1, Period: time.Minute},
+		},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(HandleRestError)
+	router.Use(func(c *gin.Context) {
+		// the same subject name in every tenant
+		identity := &auth.Identity{Subject: "courier-1", Roles: []string{auth.RoleCourier},
+			Tenant: c.GetHeader("X-Tenant-ID"), Method: auth.MethodAPIKey}
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+	})
+	router.Use(RateLimit(limiter))
+	router.PATCH("/orders/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		tenant string
+		code   int
+	}{
+		{"brand-a", "brand-a", http.StatusOK},
+		{"brand-b", "brand-b", http.StatusOK},
+		{"brand-a-again", "brand-a", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPatch, "/orders/1", nil)
+			req.Header.Set("X-Tenant-ID", tt.tenant)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
+
+func TestRateLimitIP(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), configs.RateLimitConfig{IPLimit: 2, IPPeriod: time.Minute})
+	authenticator, _ := auth.NewAuthenticator(configs.AuthConfig{
+		APIKeys: []configs.APIKeyConfig{{Key: "abc", Subject: "merchant-1", Roles: []string{auth.RoleMerchant}}},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(HandleRestError)
+	router.Use(RateLimitIP(limiter))
+	router.Use(Authenticate(authenticator))
+	router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	codes := make([]int, 3)
+	for i := range codes {
+		req, _ := http.NewRequest(http.MethodGet, "/orders", nil)
+		req.Header.Set(auth.HeaderAPIKey, "guess")
+		req.RemoteAddr = "203.0.113.7:4321"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		codes[i] = w.Code
+	}
+
+	// the failed guesses used up the bucket of the IP
+	assert.Equal(t, []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}, codes)
+
+	req, _ := http.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(auth.HeaderAPIKey, "abc")
+	req.RemoteAddr = "198.51.100.1:4321"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"github.com/imylam/delivery-test/common/auth"
+	resterrors "github.com/imylam/delivery-test/common/rest_errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	errTenantRequired string = "tenant required"
+	errInvalidTenant  string = "invalid tenant"
+)
+
+// ResolveTenant resolves the tenant the authenticated caller acts on from its
+// credentials or the X-Tenant-ID header and attaches it to the caller identity
+func ResolveTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := auth.FromContext(c.Request.Context())
+		if !ok {
+			c.Error(resterrors.NewUnauthorizedError(errUnauthorized))
+			c.Abort()
+			return
+		}
+
+		tenant, err := auth.ResolveTenant(identity, c.GetHeader(auth.HeaderTenantID))
+		switch err {
+		case nil:
+		case auth.ErrNoTenant:
+			c.Error(resterrors.NewBadRequestError(errTenantRequired))
+			c.Abort()
+			return
+		case auth.ErrInvalidTenant:
+			c.Error(resterrors.NewBadRequestError(errInvalidTenant))
+			c.Abort()
+			return
+		default:
+			c.Error(resterrors.NewForbiddenError(errForbidden))
+			c.Abort()
+			return
+		}
+
+		scoped := *identity
+		scoped.Tenant = tenant
+		c.Set(auth.ContextKeyIdentity, &scoped)
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), &scoped))
+
+		c.Next()
+	}
+}
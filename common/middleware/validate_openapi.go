@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	resterrors "github.com/imylam/delivery-test/common/rest_errors"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/openapi"
+	"go.uber.org/zap"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	errInvalidRequest       string = "invalid request params"
+	errResponseSpecMismatch string = "response does not match the OpenAPI spec"
+)
+
+// ValidateOpenAPI rejects requests to documented routes that do not match the
+// OpenAPI spec with 400. When the validator checks responses too, responses
+// that do not match are replaced with 500 so tests catch the drift. Streams of
+// Server-Sent Events are passed through as they are written
+func ValidateOpenAPI(v *openapi.Validator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		params := make(map[string]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = p.Value
+		}
+
+		op, found := v.FindOperation(c.Request, c.FullPath(), params)
+		if !found {
+			c.Next()
+			return
+		}
+
+		// handlers bind bodies as JSON whatever the content type, so do the same
+		if c.Request.Header.Get("Content-Type") == "" && c.Request.ContentLength != 0 {
+			c.Request.Header.Set("Content-Type", "application/json")
+		}
+
+		if err := v.ValidateRequest(c.Request.Context(), op); err != nil {
+			c.Error(resterrors.NewBadRequestError(errInvalidRequest + ": " + requestErrorReason(err)))
+			c.Abort()
+			return
+		}
+
+		if !v.ValidatesResponses() || v.Streams(op) {
+			c.Next()
+			return
+		}
+
+		w := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		// errors are rendered by HandleRestError once this middleware returns
+		if len(c.Errors) > 0 && w.body.Len() == 0 {
+			return
+		}
+
+		err := v.ValidateResponse(c.Request.Context(), op, c.Writer.Status(), c.Writer.Header(), w.body.Bytes())
+		if err != nil {
+			logger.Logger.Error("response does not match the OpenAPI spec",
+				zap.String("route", c.Request.Method+" "+c.FullPath()),
+				zap.String("error", err.Error()))
+
+			c.Header("HTTP", "500")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": errResponseSpecMismatch + ": " + err.Error()})
+			return
+		}
+
+		_, _ = c.Writer.Write(w.body.Bytes())
+	}
+}
+
+// requestErrorReason describes what is wrong with a request without echoing the spec
+func requestErrorReason(err error) string {
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		switch {
+		case reqErr.Parameter != nil:
+			return "parameter " + reqErr.Parameter.Name + " in " + reqErr.Parameter.In + " is invalid"
+		case reqErr.RequestBody != nil:
+			return "request body is invalid"
+		}
+	}
+
+	return "request is invalid"
+}
+
+// bufferedWriter holds the response body back so it can be checked before it is sent
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
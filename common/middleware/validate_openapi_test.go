@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/openapi"
+)
+
+func TestValidateOpenAPI(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("unexpected error '%s' when loading spec", err.Error())
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(HandleRestError)
+	router.Use(ValidateOpenAPI(openapi.NewValidator(spec, true)))
+	router.PATCH("/orders/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "SUCCESS"})
+	})
+	router.GET("/orders/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": 1, "status": "LOST"})
+	})
+	router.GET("/undocumented", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPatch, "/orders/1", bytes.NewBufferString(`{"status":"TAKEN"}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"status":"SUCCESS"}`, w.Body.String())
+	})
+
+	t.Run("invalid-body", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPatch, "/orders/1", bytes.NewBufferString(`{"status":"LOST"}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"error":"invalid request params: request body is invalid"}`, w.Body.String())
+	})
+
+	t.Run("invalid-path-param", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPatch, "/orders/abc", bytes.NewBufferString(`{"status":"TAKEN"}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"error":"invalid request params: parameter id in path is invalid"}`, w.Body.String())
+	})
+
+	t.Run("response-drift", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/orders/1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "500", w.Header().Get("HTTP"))
+	})
+
+	t.Run("undocumented-route", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/undocumented", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "ok", w.Body.String())
+	})
+}
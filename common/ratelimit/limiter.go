@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/imylam/delivery-test/configs"
+)
+
+// Limiter applies the rule of a route to each caller of it
+type Limiter struct {
+	store       Store
+	defaultRule Rule
+	ipRule      Rule
+	routes      map[string]Rule
+	now         func() time.Time
+}
+
+// NewLimiter creates a Limiter taking tokens from store with the rules of cfg
+func NewLimiter(store Store, cfg configs.RateLimitConfig) *Limiter {
+	l := &Limiter{
+		store:       store,
+		defaultRule: Rule{Limit: cfg.DefaultLimit, Period: cfg.DefaultPeriod, Burst: cfg.DefaultBurst},
+		ipRule:      Rule{Limit: cfg.IPLimit, Period: cfg.IPPeriod, Burst: cfg.IPBurst},
+		routes:      make(map[string]Rule, len(cfg.Routes)),
+		now:         time.Now,
+	}
+	for route, r := range cfg.Routes {
+		l.routes[route] = Rule{Limit: r.Limit, Period: r.Period, Burst: r.Burst}
+	}
+
+	return l
+}
+
+// Allow takes a token from the bucket of caller on route, a route is given
+// as "<METHOD> <path pattern>", e.g. "PATCH /orders/:id". The returned bool
+// is false when no limit applies to the route
+func (l *Limiter) Allow(ctx context.Context, route, caller string) (Result, bool, error) {
+	rule, ok := l.routes[route]
+	if !ok {
+		rule = l.defaultRule
+	}
+
+	return l.take(ctx, route+"|"+caller, rule)
+}
+
+// AllowIP takes a token from the bucket of ip, shared by every route. The
+// returned bool is false when no IP limit is set
+func (l *Limiter) AllowIP(ctx context.Context, ip string) (Result, bool, error) {
+	return l.take(ctx, "ip|"+ip, l.ipRule)
+}
+
+func (l *Limiter) take(ctx context.Context, key string, rule Rule) (Result, bool, error) {
+	if rule.Limit <= 0 || rule.Period <= 0 {
+		return Result{Allowed: true}, false, nil
+	}
+
+	res, err := l.store.Take(ctx, key, rule, l.now())
+	if err != nil {
+		return Result{Allowed: true}, false, err
+	}
+
+	return res, true, nil
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// cleanupInterval is how often buckets full again are dropped, a dropped
+// bucket is recreated full so dropping it changes nothing for its caller
+const cleanupInterval time.Duration = 10 * time.Minute
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+type memoryStore struct {
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+// NewMemoryStore creates a Store keeping buckets in process memory, limits
+// are then enforced per replica
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: map[string]*bucket{}}
+}
+
+func (s *memoryStore) Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cleanup(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: rule.capacity(), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	var res Result
+	b.tokens, res = take(b.tokens, b.updatedAt, now, rule)
+	b.updatedAt = now
+	b.fullAt = now.Add(res.Reset)
+
+	return res, nil
+}
+
+// cleanup drops the buckets refilled to their capacity, at most once per
+// cleanupInterval. Buckets of long periods are kept for as long as they refill
+func (s *memoryStore) cleanup(now time.Time) {
+	if now.Sub(s.lastCleanup) < cleanupInterval {
+		return
+	}
+
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastCleanup = now
+}
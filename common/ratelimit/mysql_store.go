@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type mysqlStore struct {
+	MysqlConn *sqlx.DB
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+// NewMysqlStore creates a Store keeping buckets in the rate_limit_buckets
+// table, so limits hold across every replica sharing the database
+func NewMysqlStore(mysqlConn *sqlx.DB) Store {
+	return &mysqlStore{MysqlConn: mysqlConn}
+}
+
+func (s *mysqlStore) Take(ctx context.Context, key string, rule Rule, now time.Time) (res Result, err error) {
+	if err = s.cleanup(ctx, now); err != nil {
+		return
+	}
+
+	q1 := "INSERT IGNORE INTO rate_limit_buckets (bucket_key, tokens, updated_at, full_at) VALUES (?,?,?,?)"
+	q2 := "SELECT tokens, updated_at FROM rate_limit_buckets WHERE bucket_key=? FOR UPDATE"
+	q3 := "UPDATE rate_limit_buckets SET tokens=?, updated_at=?, full_at=? WHERE bucket_key=?"
+
+	tx, err := s.MysqlConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, q1, key, rule.capacity(), now, now); err != nil {
+		return
+	}
+
+	var tokens float64
+	var updatedAt time.Time
+	if err = tx.QueryRowxContext(ctx, q2, key).Scan(&tokens, &updatedAt); err != nil {
+		return
+	}
+
+	tokens, res = take(tokens, updatedAt, now, rule)
+
+	if _, err = tx.ExecContext(ctx, q3, tokens, now, now.Add(res.Reset), key); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+// cleanup deletes the buckets refilled to their capacity, at most once per
+// cleanupInterval on each replica
+func (s *mysqlStore) cleanup(ctx context.Context, now time.Time) error {
+	q := "DELETE FROM rate_limit_buckets WHERE full_at<=?"
+
+	s.mu.Lock()
+	if now.Sub(s.lastCleanup) < cleanupInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastCleanup = now
+	s.mu.Unlock()
+
+	_, err := s.MysqlConn.ExecContext(ctx, q, now)
+	return err
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+const (
+	BackendMemory string = "memory"
+	BackendMysql  string = "mysql"
+)
+
+// Rule allows Limit requests per Period, with bursts of up to Burst requests
+type Rule struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// Result represents the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets, implementations must take tokens atomically
+type Store interface {
+	Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error)
+}
+
+func (r Rule) capacity() float64 {
+	if r.Burst > 0 {
+		return float64(r.Burst)
+	}
+	return float64(r.Limit)
+}
+
+// refillRate returns the tokens added to a bucket per second
+func (r Rule) refillRate() float64 {
+	return float64(r.Limit) / r.Period.Seconds()
+}
+
+// take refills a bucket holding tokens since updatedAt and takes one token
+// out of it, returning the tokens left
+func take(tokens float64, updatedAt time.Time, now time.Time, rule Rule) (float64, Result) {
+	capacity := rule.capacity()
+	rate := rule.refillRate()
+
+	if elapsed := now.Sub(updatedAt).Seconds(); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed*rate)
+	}
+
+	res := Result{Limit: rule.Limit}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+
+	res.Remaining = int(math.Floor(tokens))
+	res.Reset = secondsToDuration((capacity - tokens) / rate)
+
+	return tokens, res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
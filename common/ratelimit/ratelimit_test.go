@@ -0,0 +1,192 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/configs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestTake(t *testing.T) {
+	rule := Rule{Limit: 2, Period: time.Second}
+	now := time.Now()
+
+	t.Run("allowed", func(t *testing.T) {
+		tokens, res := take(2, now, now, rule)
+
+		assert.Equal(t, true, res.Allowed)
+		assert.Equal(t, 1.0, tokens)
+		assert.Equal(t, 1, res.Remaining)
+		assert.Equal(t, 500*time.Millisecond, res.Reset)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		tokens, res := take(0, now, now, rule)
+
+		assert.Equal(t, false, res.Allowed)
+		assert.Equal(t, 0.0, tokens)
+		assert.Equal(t, 0, res.Remaining)
+		assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+	})
+
+	t.Run("refilled", func(t *testing.T) {
+		tokens, res := take(0, now, now.Add(time.Second), rule)
+
+		assert.Equal(t, true, res.Allowed)
+		assert.Equal(t, 1.0, tokens)
+	})
+
+	t.Run("capped-at-burst", func(t *testing.T) {
+		tokens, res := take(0, now, now.Add(time.Hour), Rule{Limit: 2, Period: time.Second, Burst: 5})
+
+		assert.Equal(t, true, res.Allowed)
+		assert.Equal(t, 4.0, tokens)
+	})
+}
+
+func TestLimiter(t *testing.T) {
+	cfg := configs.RateLimitConfig{
+		DefaultLimit:  2,
+		DefaultPeriod: time.Minute,
+		Routes: map[string]configs.RateLimitRule{
+			"PATCH /orders/:id": {Limit: 1, Period: time.Minute},
+			"GET /orders":       {Limit: 0},
+		},
+	}
+	now := time.Now()
+
+	t.Run("route-rule", func(t *testing.T) {
+		limiter := NewLimiter(NewMemoryStore(), cfg)
+		limiter.now = func() time.Time { return now }
+
+		res1, limited, _ := limiter.Allow(context.Background(), "PATCH /orders/:id", "sub:courier-1")
+		res2, _, _ := limiter.Allow(context.Background(), "PATCH /orders/:id", "sub:courier-1")
+		res3, _, _ := limiter.Allow(context.Background(), "PATCH /orders/:id", "sub:courier-2")
+
+		assert.Equal(t, true, limited)
+		assert.Equal(t, true, res1.Allowed)
+		assert.Equal(t, false, res2.Allowed)
+		assert.Equal(t, true, res3.Allowed)
+	})
+
+	t.Run("default-rule", func(t *testing.T) {
+		limiter := NewLimiter(NewMemoryStore(), cfg)
+		limiter.now = func() time.Time { return now }
+
+		res, limited, _ := limiter.Allow(context.Background(), "POST /orders", "sub:merchant-1")
+
+		assert.Equal(t, true, limited)
+		assert.Equal(t, 2, res.Limit)
+		assert.Equal(t, 1, res.Remaining)
+	})
+
+	t.Run("disabled-route", func(t *testing.T) {
+		limiter := NewLimiter(NewMemoryStore(), cfg)
+
+		res, limited, _ := limiter.Allow(context.Background(), "GET /orders", "sub:merchant-1")
+
+		assert.Equal(t, false, limited)
+		assert.Equal(t, true, res.Allowed)
+	})
+
+	t.Run("store-error", func(t *testing.T) {
+		limiter := NewLimiter(&failingStore{}, cfg)
+
+		res, limited, err := limiter.Allow(context.Background(), "POST /orders", "sub:merchant-1")
+
+		assert.Equal(t, false, err == nil)
+		assert.Equal(t, false, limited)
+		assert.Equal(t, true, res.Allowed)
+	})
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Now()
+
+	t.Run("long-period-bucket-kept", func(t *testing.T) {
+		rule := Rule{Limit: 10, Period: time.Hour}
+		store := NewMemoryStore()
+		for i := 0; i < 10; i++ {
+			_, _ = store.Take(context.Background(), "sub:merchant-1", rule, now)
+		}
+
+		// past the cleanup interval the bucket got back less than 2 of its 10 tokens
+		res, _ := store.Take(context.Background(), "sub:merchant-1", rule, now.Add(11*time.Minute))
+
+		assert.Equal(t, true, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+	})
+
+	t.Run("full-bucket-dropped", func(t *testing.T) {
+		rule := Rule{Limit: 10, Period: time.Minute}
+		store := NewMemoryStore()
+		_, _ = store.Take(context.Background(), "sub:merchant-1", rule, now)
+
+		_, _ = store.Take(context.Background(), "sub:merchant-2", rule, now.Add(11*time.Minute))
+
+		assert.Equal(t, 1, len(store.(*memoryStore).buckets))
+	})
+}
+
+func TestMysqlStoreTake(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rule := Rule{Limit: 10, Period: time.Minute}
+	mockKey := "POST /orders|sub:merchant-1"
+	now := time.Now()
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM rate_limit_buckets WHERE full_at<=\\?").
+			WithArgs(now).WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT IGNORE INTO rate_limit_buckets").
+			WithArgs(mockKey, 10.0, now, now).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT (.+) FROM rate_limit_buckets WHERE bucket_key=(.+) FOR UPDATE").
+			WithArgs(mockKey).
+			WillReturnRows(sqlmock.NewRows([]string{"tokens", "updated_at"}).AddRow(3.0, now))
+		// 8 tokens refill in 48s at 10 per minute
+		mock.ExpectExec("UPDATE rate_limit_buckets SET").
+			WithArgs(2.0, now, now.Add(48*time.Second), mockKey).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		store := NewMysqlStore(sqlxDB)
+		res, err := store.Take(context.Background(), mockKey, rule, now)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, true, res.Allowed)
+		assert.Equal(t, 2, res.Remaining)
+		assert.Equal(t, true, mock.ExpectationsWereMet() == nil)
+	})
+
+	t.Run("select-error", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM rate_limit_buckets").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT IGNORE INTO rate_limit_buckets").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT (.+) FROM rate_limit_buckets").WillReturnError(errors.New("deadlock"))
+		mock.ExpectRollback()
+
+		store := NewMysqlStore(sqlxDB)
+		_, err := store.Take(context.Background(), mockKey, rule, now)
+
+		assert.Equal(t, false, err == nil)
+		assert.Equal(t, true, mock.ExpectationsWereMet() == nil)
+	})
+}
+
+type failingStore struct{}
+
+func (s *failingStore) Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	return Result{}, errors.New("store unavailable")
+}
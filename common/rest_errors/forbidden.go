@@ -0,0 +1,24 @@
+package resterrors
+
+import "strconv"
+
+type ForbiddenError struct {
+	StatusCode int
+	ErrMsg     string
+}
+
+func NewForbiddenError(errMsg string) *ForbiddenError {
+	return &ForbiddenError{StatusCode: 403, ErrMsg: errMsg}
+}
+
+func (e *ForbiddenError) HttpStatusCode() int {
+	return e.StatusCode
+}
+
+func (e *ForbiddenError) HttpStatusCodeString() string {
+	return strconv.Itoa(e.StatusCode)
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.ErrMsg
+}
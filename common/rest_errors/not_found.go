@@ -0,0 +1,24 @@
+package resterrors
+
+import "strconv"
+
+type NotFoundError struct {
+	StatusCode int
+	ErrMsg     string
+}
+
+func NewNotFoundError(errMsg string) *NotFoundError {
+	return &NotFoundError{StatusCode: 404, ErrMsg: errMsg}
+}
+
+func (e *NotFoundError) HttpStatusCode() int {
+	return e.StatusCode
+}
+
+func (e *NotFoundError) HttpStatusCodeString() string {
+	return strconv.Itoa(e.StatusCode)
+}
+
+func (e *NotFoundError) Error() string {
+	return e.ErrMsg
+}
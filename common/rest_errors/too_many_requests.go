@@ -0,0 +1,24 @@
+package resterrors
+
+import "strconv"
+
+type TooManyRequestsError struct {
+	StatusCode int
+	ErrMsg     string
+}
+
+func NewTooManyRequestsError(errMsg string) *TooManyRequestsError {
+	return &TooManyRequestsError{StatusCode: 429, ErrMsg: errMsg}
+}
+
+func (e *TooManyRequestsError) HttpStatusCode() int {
+	return e.StatusCode
+}
+
+func (e *TooManyRequestsError) HttpStatusCodeString() string {
+	return strconv.Itoa(e.StatusCode)
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return e.ErrMsg
+}
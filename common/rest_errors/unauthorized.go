@@ -0,0 +1,24 @@
+package resterrors
+
+import "strconv"
+
+type UnauthorizedError struct {
+	StatusCode int
+	ErrMsg     string
+}
+
+func NewUnauthorizedError(errMsg string) *UnauthorizedError {
+	return &UnauthorizedError{StatusCode: 401, ErrMsg: errMsg}
+}
+
+func (e *UnauthorizedError) HttpStatusCode() int {
+	return e.StatusCode
+}
+
+func (e *UnauthorizedError) HttpStatusCodeString() string {
+	return strconv.Itoa(e.StatusCode)
+}
+
+func (e *UnauthorizedError) Error() string {
+	return e.ErrMsg
+}
@@ -0,0 +1,24 @@
+package resterrors
+
+import "strconv"
+
+type UnprocessableEntityError struct {
+	StatusCode int
+	ErrMsg     string
+}
+
+func NewUnprocessableEntityError(errMsg string) *UnprocessableEntityError {
+	return &UnprocessableEntityError{StatusCode: 422, ErrMsg: errMsg}
+}
+
+func (e *UnprocessableEntityError) HttpStatusCode() int {
+	return e.StatusCode
+}
+
+func (e *UnprocessableEntityError) HttpStatusCodeString() string {
+	return strconv.Itoa(e.StatusCode)
+}
+
+func (e *UnprocessableEntityError) Error() string {
+	return e.ErrMsg
+}
@@ -0,0 +1,26 @@
+package resterrors
+
+import "strconv"
+
+// ValidationError is an unprocessable entity listing what made it so
+type ValidationError struct {
+	StatusCode int
+	ErrMsg     string
+	Violations interface{}
+}
+
+func NewValidationError(errMsg string, violations interface{}) *ValidationError {
+	return &ValidationError{StatusCode: 422, ErrMsg: errMsg, Violations: violations}
+}
+
+func (e *ValidationError) HttpStatusCode() int {
+	return e.StatusCode
+}
+
+func (e *ValidationError) HttpStatusCodeString() string {
+	return strconv.Itoa(e.StatusCode)
+}
+
+func (e *ValidationError) Error() string {
+	return e.ErrMsg
+}
@@ -1,20 +1,696 @@
 package configs
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
 )
 
 const (
-	KeyAppEnv          string = "APP_ENV"
-	KeyAppPort         string = "APP_PORT"
-	KeyMysqlDbBame     string = "MYSQL_DBNAME"
-	KeyMysqlHost       string = "MYSQL_HOST"
-	KeyMysqlUser       string = "MYSQL_USER"
-	KeyMysqlPw         string = "MYSQL_PASSWORD"
-	KeyGoogleMapAPIKey string = "GOOGLE_MAP_API_KEY"
+	EnvProduction      string = "production"
+	EnvIntegrationTest string = "integration-test"
+
+	// KeyConfigFile is the environment variable pointing to the config file,
+	// the -config flag takes precedence over it
+	KeyConfigFile string = "CONFIG_FILE"
+
+	redacted string = "******"
 )
 
-// Get get value from configs
-func Get(key string) string {
-	return os.Getenv(key)
+// Config represents the effective configuration of the service. Every field
+// is resolved from, in increasing precedence: the `default` tag, the YAML
+// config file, the environment variable named by the `env` tag and the CLI
+// flag named after the YAML path, e.g. -mysql.host
+type Config struct {
+	App       AppConfig       `yaml:"app"`
+	GRPC      GRPCConfig      `yaml:"grpc"`
+	Log       LogConfig       `yaml:"log"`
+	MySQL     MySQLConfig     `yaml:"mysql"`
+	GoogleMap GoogleMapConfig `yaml:"google_map"`
+	Auth      AuthConfig      `yaml:"auth"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Webhook   WebhookConfig   `yaml:"webhook"`
+	Outbox    OutboxConfig    `yaml:"outbox"`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+	Events    EventsConfig    `yaml:"events"`
+	Pricing   PricingConfig   `yaml:"pricing"`
+	Rules     RulesConfig     `yaml:"rules"`
+	Orders    OrdersConfig    `yaml:"orders"`
+}
+
+// AppConfig represents the settings of the service itself
+type AppConfig struct {
+	Env  string `yaml:"env" env:"APP_ENV" default:"production"`
+	Port int    `yaml:"port" env:"APP_PORT" default:"8080" validate:"required"`
+}
+
+// GRPCConfig represents the settings of the gRPC server, a port of 0 disables it
+type GRPCConfig struct {
+	Port       int  `yaml:"port" env:"GRPC_PORT" default:"9090"`
+	Reflection bool `yaml:"reflection" env:"GRPC_REFLECTION" default:"true"`
+}
+
+// LogConfig represents the settings of the logger
+type LogConfig struct {
+	Level              string   `yaml:"level" env:"LOG_LEVEL" default:"info"`
+	Format             string   `yaml:"format" env:"LOG_FORMAT" default:"json"`
+	Outputs            []string `yaml:"outputs" env:"LOG_OUTPUT" default:"stderr"`
+	Sampling           bool     `yaml:"sampling" env:"LOG_SAMPLING" default:"true"`
+	SamplingInitial    int      `yaml:"sampling_initial" env:"LOG_SAMPLING_INITIAL" default:"100"`
+	SamplingThereafter int      `yaml:"sampling_thereafter" env:"LOG_SAMPLING_THEREAFTER" default:"100"`
+}
+
+// MySQLConfig represents the settings of the database connection
+type MySQLConfig struct {
+	Host     string `yaml:"host" env:"MYSQL_HOST" validate:"required"`
+	DBName   string `yaml:"dbname" env:"MYSQL_DBNAME" validate:"required"`
+	User     string `yaml:"user" env:"MYSQL_USER" validate:"required"`
+	Password string `yaml:"password" env:"MYSQL_PASSWORD" secret:"true"`
+	Loc      string `yaml:"loc" env:"MYSQL_LOC" default:"UTC"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"MYSQL_MAX_OPEN_CONNS" default:"25"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"MYSQL_MAX_IDLE_CONNS" default:"10"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"MYSQL_CONN_MAX_LIFETIME" default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"MYSQL_CONN_MAX_IDLE_TIME" default:"5m"`
+
+	DialTimeout  time.Duration `yaml:"dial_timeout" env:"MYSQL_DIAL_TIMEOUT" default:"5s"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"MYSQL_READ_TIMEOUT" default:"30s"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"MYSQL_WRITE_TIMEOUT" default:"30s"`
+
+	TLS           string `yaml:"tls" env:"MYSQL_TLS" default:"false"`
+	TLSCAFile     string `yaml:"tls_ca_file" env:"MYSQL_TLS_CA_FILE"`
+	TLSCertFile   string `yaml:"tls_cert_file" env:"MYSQL_TLS_CERT_FILE"`
+	TLSKeyFile    string `yaml:"tls_key_file" env:"MYSQL_TLS_KEY_FILE"`
+	TLSServerName string `yaml:"tls_server_name" env:"MYSQL_TLS_SERVER_NAME"`
+
+	ConnectRetries          int           `yaml:"connect_retries" env:"MYSQL_CONNECT_RETRIES" default:"10"`
+	ConnectRetryInterval    time.Duration `yaml:"connect_retry_interval" env:"MYSQL_CONNECT_RETRY_INTERVAL" default:"1s"`
+	ConnectRetryMaxInterval time.Duration `yaml:"connect_retry_max_interval" env:"MYSQL_CONNECT_RETRY_MAX_INTERVAL" default:"30s"`
+}
+
+// GoogleMapConfig represents the settings of the Google Maps client, a
+// distance lookup waits up to CoalesceWindow for others to be sent along in a
+// single request, a window of 0 sends every lookup on its own. Google bills
+// every origin and destination pair of a request, so lookups with different
+// ends cost more together than apart; the window is off unless they mostly
+// share an end. TrafficAware estimates durations with the traffic at the time
+// of the lookup. GeocodeFixtures is the file integration tests geocode
+// addresses from
+type GoogleMapConfig struct {
+	APIKey          string        `yaml:"api_key" env:"GOOGLE_MAP_API_KEY" secret:"true"`
+	CoalesceWindow  time.Duration `yaml:"coalesce_window" env:"GOOGLE_MAP_COALESCE_WINDOW" default:"0s"`
+	TrafficAware    bool          `yaml:"traffic_aware" env:"GOOGLE_MAP_TRAFFIC_AWARE" default:"false"`
+	GeocodeFixtures string        `yaml:"geocode_fixtures" env:"GOOGLE_MAP_GEOCODE_FIXTURES"`
+}
+
+// AuthConfig represents the accepted credentials, every request must carry
+// either one of the API keys or a bearer JWT verifiable with the JWT settings
+type AuthConfig struct {
+	APIKeys []APIKeyConfig `yaml:"api_keys" env:"AUTH_API_KEYS"`
+	JWT     JWTConfig      `yaml:"jwt"`
+}
+
+// APIKeyConfig represents a static API key, the caller it identifies, the
+// roles granted to it: merchant, courier or admin, and the tenant it is bound
+// to. Keys without a tenant pick one per request with the X-Tenant-ID header
+type APIKeyConfig struct {
+	Key     string   `yaml:"key" secret:"true" validate:"required"`
+	Subject string   `yaml:"subject" validate:"required"`
+	Roles   []string `yaml:"roles" validate:"required"`
+	Tenant  string   `yaml:"tenant"`
+}
+
+// JWTConfig represents the settings to verify bearer tokens. HS256 tokens are
+// verified with HS256Secret, RS256 tokens with the keys of the JWKS
+type JWTConfig struct {
+	HS256Secret         string        `yaml:"hs256_secret" env:"AUTH_JWT_HS256_SECRET" secret:"true"`
+	JWKSFile            string        `yaml:"jwks_file" env:"AUTH_JWT_JWKS_FILE"`
+	JWKSURL             string        `yaml:"jwks_url" env:"AUTH_JWT_JWKS_URL"`
+	JWKSRefreshInterval time.Duration `yaml:"jwks_refresh_interval" env:"AUTH_JWT_JWKS_REFRESH_INTERVAL" default:"15m"`
+	Issuer              string        `yaml:"issuer" env:"AUTH_JWT_ISSUER"`
+	Audience            string        `yaml:"audience" env:"AUTH_JWT_AUDIENCE"`
+	Leeway              time.Duration `yaml:"leeway" env:"AUTH_JWT_LEEWAY" default:"30s"`
+}
+
+// RateLimitConfig represents the request limits applied per client, routes
+// are keyed by "<METHOD> <path pattern>" and fall back to the default rule.
+// The IP rule applies to every request of an IP before it is authenticated,
+// so credential guesses are limited too. A limit of 0 disables limiting
+type RateLimitConfig struct {
+	Backend       string                   `yaml:"backend" env:"RATE_LIMIT_BACKEND" default:"memory"`
+	DefaultLimit  int                      `yaml:"default_limit" env:"RATE_LIMIT_DEFAULT_LIMIT" default:"100"`
+	DefaultPeriod time.Duration            `yaml:"default_period" env:"RATE_LIMIT_DEFAULT_PERIOD" default:"1m"`
+	DefaultBurst  int                      `yaml:"default_burst" env:"RATE_LIMIT_DEFAULT_BURST"`
+	Routes        map[string]RateLimitRule `yaml:"routes" env:"RATE_LIMIT_ROUTES"`
+	IPLimit       int                      `yaml:"ip_limit" env:"RATE_LIMIT_IP_LIMIT" default:"300"`
+	IPPeriod      time.Duration            `yaml:"ip_period" env:"RATE_LIMIT_IP_PERIOD" default:"1m"`
+	IPBurst       int                      `yaml:"ip_burst" env:"RATE_LIMIT_IP_BURST"`
+}
+
+// RateLimitRule allows Limit requests per Period with bursts of up to Burst
+// requests, Burst defaults to Limit
+type RateLimitRule struct {
+	Limit  int           `yaml:"limit"`
+	Period time.Duration `yaml:"period"`
+	Burst  int           `yaml:"burst"`
+}
+
+// WebhookConfig represents the settings of the webhook delivery worker, a poll
+// interval of 0 disables the worker on this instance
+type WebhookConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL" default:"1s"`
+	BatchSize    int           `yaml:"batch_size" env:"WEBHOOK_BATCH_SIZE" default:"20"`
+	Timeout      time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT" default:"10s"`
+	Lease        time.Duration `yaml:"lease" env:"WEBHOOK_LEASE" default:"1m"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	BackoffBase  time.Duration `yaml:"backoff_base" env:"WEBHOOK_BACKOFF_BASE" default:"10s"`
+	BackoffMax   time.Duration `yaml:"backoff_max" env:"WEBHOOK_BACKOFF_MAX" default:"1h"`
+	// AllowPrivateNetworks lets subscriptions reach internal addresses, for
+	// development and tests only
+	AllowPrivateNetworks bool `yaml:"allow_private_networks" env:"WEBHOOK_ALLOW_PRIVATE_NETWORKS" default:"false"`
+}
+
+// OutboxConfig represents the settings of the relay publishing outbox
+// messages to the sink, a poll interval of 0 disables the relay on this instance
+type OutboxConfig struct {
+	PollInterval  time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL" default:"1s"`
+	BatchSize     int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" default:"100"`
+	Lease         time.Duration `yaml:"lease" env:"OUTBOX_LEASE" default:"30s"`
+	RetryInterval time.Duration `yaml:"retry_interval" env:"OUTBOX_RETRY_INTERVAL" default:"5s"`
+	MaxAttempts   int           `yaml:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS" default:"10"`
+	Retention     time.Duration `yaml:"retention" env:"OUTBOX_RETENTION" default:"168h"`
+	Sink          string        `yaml:"sink" env:"OUTBOX_SINK" default:"log"`
+	HTTPURL       string        `yaml:"http_url" env:"OUTBOX_HTTP_URL" secret:"true"`
+	HTTPTimeout   time.Duration `yaml:"http_timeout" env:"OUTBOX_HTTP_TIMEOUT" default:"10s"`
+}
+
+// SchedulerConfig represents the settings of the scheduler releasing
+// scheduled orders Lead before they are picked up, a poll interval of 0
+// disables the scheduler on this instance
+type SchedulerConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"SCHEDULER_POLL_INTERVAL" default:"10s"`
+	BatchSize    int           `yaml:"batch_size" env:"SCHEDULER_BATCH_SIZE" default:"100"`
+	Lease        time.Duration `yaml:"lease" env:"SCHEDULER_LEASE" default:"30s"`
+	Lead         time.Duration `yaml:"lead" env:"SCHEDULER_LEAD" default:"30m"`
+}
+
+// EventsConfig represents the settings of the message broker order events
+// are published to, the none broker drops them
+type EventsConfig struct {
+	Broker            string        `yaml:"broker" env:"EVENTS_BROKER" default:"none"`
+	Timeout           time.Duration `yaml:"timeout" env:"EVENTS_TIMEOUT" default:"5s"`
+	NATSURL           string        `yaml:"nats_url" env:"EVENTS_NATS_URL" secret:"true"`
+	NATSSubjectPrefix string        `yaml:"nats_subject_prefix" env:"EVENTS_NATS_SUBJECT_PREFIX" default:"orders"`
+	KafkaRESTURL      string        `yaml:"kafka_rest_url" env:"EVENTS_KAFKA_REST_URL" secret:"true"`
+	KafkaTopic        string        `yaml:"kafka_topic" env:"EVENTS_KAFKA_TOPIC" default:"order-events"`
+}
+
+// PricingConfig represents the tariffs orders are priced with, per tenant.
+// Tenants without a tariff of their own use Default, their orders are not
+// priced when there is no default either. QuoteTTL is how long an order can be
+// placed from a quote
+type PricingConfig struct {
+	Default  *TariffConfig           `yaml:"default" env:"PRICING_DEFAULT"`
+	Tariffs  map[string]TariffConfig `yaml:"tariffs" env:"PRICING_TARIFFS"`
+	QuoteTTL time.Duration           `yaml:"quote_ttl" env:"PRICING_QUOTE_TTL" default:"5m"`
+}
+
+// OrdersConfig represents the limits of placing orders. MaxBatchSize is the
+// most orders a batch places in one transaction, up to the 500 of the API
+type OrdersConfig struct {
+	MaxBatchSize int `yaml:"max_batch_size" env:"ORDERS_MAX_BATCH_SIZE" default:"100"`
+}
+
+// TariffConfig prices a delivery at BaseFare plus PerKM per kilometer and
+// PerMinute per minute of travel, at least Minimum, times the multiplier of
+// the period of the day the order is placed in and Surge, 1 when unset.
+// Amounts are in the minor unit of Currency, e.g. cents. Orders store
+// Version, to be changed along with the rules
+type TariffConfig struct {
+	Version   string         `yaml:"version"`
+	Currency  string         `yaml:"currency"`
+	BaseFare  int64          `yaml:"base_fare"`
+	PerKM     int64          `yaml:"per_km"`
+	PerMinute int64          `yaml:"per_minute"`
+	Minimum   int64          `yaml:"minimum"`
+	Surge     float64        `yaml:"surge"`
+	TimeZone  string         `yaml:"time_zone"`
+	Periods   []TariffPeriod `yaml:"periods"`
+}
+
+// TariffPeriod applies Multiplier to orders placed from From to To, as
+// "15:04" in the time zone of the tariff. A period ending before it starts
+// spans midnight
+type TariffPeriod struct {
+	From       string  `yaml:"from"`
+	To         string  `yaml:"to"`
+	Multiplier float64 `yaml:"multiplier"`
+}
+
+// RulesConfig represents the business rules orders are checked against before
+// they are placed, per tenant. Tenants without rules of their own follow
+// Default, orders are not checked when there is no default either
+type RulesConfig struct {
+	Default *RuleSetConfig           `yaml:"default" env:"RULES_DEFAULT"`
+	Tenants map[string]RuleSetConfig `yaml:"tenants" env:"RULES_TENANTS"`
+}
+
+// RuleSetConfig rejects orders travelling less than MinDistance or more than
+// MaxDistance meters, 0 for no limit, starting where they end when
+// DistinctEnds is set, starting or ending in a BlockedZones or placed outside
+// OperatingHours
+type RuleSetConfig struct {
+	MinDistance    int                   `yaml:"min_distance"`
+	MaxDistance    int                   `yaml:"max_distance"`
+	DistinctEnds   bool                  `yaml:"distinct_ends"`
+	BlockedZones   []ZoneConfig          `yaml:"blocked_zones"`
+	OperatingHours *OperatingHoursConfig `yaml:"operating_hours"`
+}
+
+// ZoneConfig is the circle of Radius meters around Lat, Lng
+type ZoneConfig struct {
+	Name   string  `yaml:"name"`
+	Lat    float64 `yaml:"lat"`
+	Lng    float64 `yaml:"lng"`
+	Radius int     `yaml:"radius"`
+}
+
+// OperatingHoursConfig accepts orders from From to To, as "15:04" in
+// TimeZone. Hours ending before they start span midnight
+type OperatingHoursConfig struct {
+	From     string `yaml:"from"`
+	To       string `yaml:"to"`
+	TimeZone string `yaml:"time_zone"`
+}
+
+// IsIntegrationTest tells whether the service runs against the integration test suite
+func (c *Config) IsIntegrationTest() bool {
+	return strings.EqualFold(c.App.Env, EnvIntegrationTest)
+}
+
+// Validate checks the effective config, reporting every problem found at once
+func (c *Config) Validate() error {
+	errs := validateRequired(c)
+
+	if c.App.Port < 1 || c.App.Port > 65535 {
+		errs = append(errs, fmt.Sprintf("app.port: %d is not a valid port", c.App.Port))
+	}
+
+	if c.GRPC.Port < 0 || c.GRPC.Port > 65535 {
+		errs = append(errs, fmt.Sprintf("grpc.port: %d is not a valid port", c.GRPC.Port))
+	}
+	if c.GRPC.Port == c.App.Port {
+		errs = append(errs, fmt.Sprintf("grpc.port: %d is already used by app.port", c.GRPC.Port))
+	}
+
+	switch strings.ToLower(c.Log.Format) {
+	case "json", "console":
+	default:
+		errs = append(errs, fmt.Sprintf("log.format: %q must be json or console", c.Log.Format))
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error", "dpanic", "panic", "fatal":
+	default:
+		errs = append(errs, fmt.Sprintf("log.level: %q is not a valid level", c.Log.Level))
+	}
+
+	if c.Log.SamplingInitial < 0 || c.Log.SamplingThereafter < 0 {
+		errs = append(errs, "log.sampling_initial, log.sampling_thereafter: must not be negative")
+	}
+
+	errs = append(errs, c.MySQL.validate()...)
+	errs = append(errs, c.Auth.validate()...)
+	errs = append(errs, c.RateLimit.validate()...)
+	errs = append(errs, c.Webhook.validate()...)
+	errs = append(errs, c.Outbox.validate()...)
+	errs = append(errs, c.Scheduler.validate()...)
+	errs = append(errs, c.Events.validate()...)
+	errs = append(errs, c.Pricing.validate()...)
+	errs = append(errs, c.Rules.validate()...)
+	errs = append(errs, c.Orders.validate()...)
+
+	if c.Outbox.Sink == "broker" {
+		if c.Events.Broker == "none" {
+			errs = append(errs, "outbox.sink: broker requires events.broker nats or kafka")
+		}
+		// as for the http sink, a message must be sent in half of the lease
+		if c.Outbox.Lease < 2*c.Events.Timeout {
+			errs = append(errs, fmt.Sprintf("outbox.lease: %s must be at least twice events.timeout %s", c.Outbox.Lease, c.Events.Timeout))
+		}
+	}
+
+	if c.GoogleMap.APIKey == "" && !c.IsIntegrationTest() {
+		errs = append(errs, "google_map.api_key: value required")
+	}
+	if c.GoogleMap.GeocodeFixtures == "" && c.IsIntegrationTest() {
+		errs = append(errs, "google_map.geocode_fixtures: value required in integration tests")
+	}
+	if c.GoogleMap.CoalesceWindow < 0 {
+		errs = append(errs, "google_map.coalesce_window: must not be negative")
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid config: " + strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (c *MySQLConfig) validate() []string {
+	var errs []string
+
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 {
+		errs = append(errs, "mysql.max_open_conns, mysql.max_idle_conns: must not be negative")
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		errs = append(errs, fmt.Sprintf("mysql.max_idle_conns: %d exceeds mysql.max_open_conns %d", c.MaxIdleConns, c.MaxOpenConns))
+	}
+
+	if _, err := time.LoadLocation(c.Loc); err != nil {
+		errs = append(errs, fmt.Sprintf("mysql.loc: %q is not a valid time zone", c.Loc))
+	}
+
+	switch c.TLS {
+	case "false", "true", "skip-verify", "preferred":
+	default:
+		errs = append(errs, fmt.Sprintf("mysql.tls: %q must be false, true, skip-verify or preferred", c.TLS))
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, "mysql.tls_cert_file, mysql.tls_key_file: must be given together")
+	}
+
+	if c.ConnectRetries < 0 {
+		errs = append(errs, "mysql.connect_retries: must not be negative")
+	}
+	if c.ConnectRetryInterval <= 0 {
+		errs = append(errs, "mysql.connect_retry_interval: must be positive")
+	}
+
+	return errs
+}
+
+func (c *AuthConfig) validate() []string {
+	var errs []string
+
+	jwt := c.JWT
+	if len(c.APIKeys) == 0 && jwt.HS256Secret == "" && jwt.JWKSFile == "" && jwt.JWKSURL == "" {
+		errs = append(errs, "auth: at least one API key, auth.jwt.hs256_secret or a JWKS is required")
+	}
+	if jwt.JWKSFile != "" && jwt.JWKSURL != "" {
+		errs = append(errs, "auth.jwt.jwks_file, auth.jwt.jwks_url: only one may be given")
+	}
+
+	seen := map[string]bool{}
+	for i, k := range c.APIKeys {
+		if seen[k.Key] {
+			errs = append(errs, fmt.Sprintf("auth.api_keys[%d].key: duplicated key", i))
+		}
+		seen[k.Key] = true
+	}
+
+	return errs
+}
+
+func (c *WebhookConfig) validate() []string {
+	var errs []string
+
+	if c.PollInterval < 0 {
+		errs = append(errs, "webhook.poll_interval: must not be negative")
+	}
+	if c.BatchSize < 1 {
+		errs = append(errs, "webhook.batch_size: must be at least 1")
+	}
+	if c.MaxAttempts < 1 {
+		errs = append(errs, "webhook.max_attempts: must be at least 1")
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, "webhook.timeout: must be positive")
+	}
+	if c.BackoffBase <= 0 {
+		errs = append(errs, "webhook.backoff_base: must be positive")
+	}
+	if c.BackoffMax < c.BackoffBase {
+		errs = append(errs, fmt.Sprintf("webhook.backoff_max: %s must be at least webhook.backoff_base %s", c.BackoffMax, c.BackoffBase))
+	}
+	// a delivery still being sent must not be claimed by another worker
+	if c.Lease <= c.Timeout {
+		errs = append(errs, fmt.Sprintf("webhook.lease: %s must exceed webhook.timeout %s", c.Lease, c.Timeout))
+	}
+
+	return errs
+}
+
+func (c *OutboxConfig) validate() []string {
+	var errs []string
+
+	if c.PollInterval < 0 {
+		errs = append(errs, "outbox.poll_interval: must not be negative")
+	}
+	if c.BatchSize < 1 {
+		errs = append(errs, "outbox.batch_size: must be at least 1")
+	}
+	if c.RetryInterval <= 0 {
+		errs = append(errs, "outbox.retry_interval: must be positive")
+	}
+	if c.MaxAttempts < 1 {
+		errs = append(errs, "outbox.max_attempts: must be at least 1")
+	}
+	if c.Retention <= 0 {
+		errs = append(errs, "outbox.retention: must be positive")
+	}
+
+	switch c.Sink {
+	case "log":
+	case "http":
+		if u, err := url.Parse(c.HTTPURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("outbox.http_url: %q must be an absolute URL", c.HTTPURL))
+		}
+		if c.HTTPTimeout <= 0 {
+			errs = append(errs, "outbox.http_timeout: must be positive")
+		}
+		// the lease is renewed between messages once half of it has gone by,
+		// a message must be sent in the other half
+		if c.Lease < 2*c.HTTPTimeout {
+			errs = append(errs, fmt.Sprintf("outbox.lease: %s must be at least twice outbox.http_timeout %s", c.Lease, c.HTTPTimeout))
+		}
+	case "broker":
+	default:
+		errs = append(errs, fmt.Sprintf("outbox.sink: %q must be log, http or broker", c.Sink))
+	}
+	if c.Lease <= 0 {
+		errs = append(errs, "outbox.lease: must be positive")
+	}
+
+	return errs
+}
+
+func (c *SchedulerConfig) validate() []string {
+	var errs []string
+
+	if c.PollInterval < 0 {
+		errs = append(errs, "scheduler.poll_interval: must not be negative")
+	}
+	if c.BatchSize < 1 {
+		errs = append(errs, "scheduler.batch_size: must be at least 1")
+	}
+	if c.Lease <= 0 {
+		errs = append(errs, "scheduler.lease: must be positive")
+	}
+	if c.Lead < 0 {
+		errs = append(errs, "scheduler.lead: must not be negative")
+	}
+
+	return errs
+}
+
+func (c *EventsConfig) validate() []string {
+	var errs []string
+
+	switch c.Broker {
+	case "none":
+	case "nats":
+		if u, err := url.Parse(c.NATSURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("events.nats_url: %q must be an absolute URL", c.NATSURL))
+		}
+		if c.NATSSubjectPrefix == "" {
+			errs = append(errs, "events.nats_subject_prefix: value required")
+		}
+	case "kafka":
+		if u, err := url.Parse(c.KafkaRESTURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("events.kafka_rest_url: %q must be an absolute URL", c.KafkaRESTURL))
+		}
+		if c.KafkaTopic == "" {
+			errs = append(errs, "events.kafka_topic: value required")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("events.broker: %q must be none, nats or kafka", c.Broker))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, "events.timeout: must be positive")
+	}
+
+	return errs
+}
+
+func (c *PricingConfig) validate() []string {
+	var errs []string
+
+	if c.QuoteTTL <= 0 {
+		errs = append(errs, "pricing.quote_ttl: must be positive")
+	}
+
+	if c.Default != nil {
+		errs = append(errs, c.Default.validate("pricing.default")...)
+	}
+
+	tenants := make([]string, 0, len(c.Tariffs))
+	for tenant := range c.Tariffs {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	for _, tenant := range tenants {
+		t := c.Tariffs[tenant]
+		errs = append(errs, t.validate("pricing.tariffs."+tenant)...)
+	}
+
+	return errs
+}
+
+func (c *TariffConfig) validate(path string) []string {
+	var errs []string
+
+	if c.Version == "" {
+		errs = append(errs, path+".version: value required")
+	}
+	if len(c.Currency) != 3 {
+		errs = append(errs, fmt.Sprintf("%s.currency: %q must be an ISO 4217 code", path, c.Currency))
+	}
+	if c.BaseFare < 0 || c.PerKM < 0 || c.PerMinute < 0 || c.Minimum < 0 {
+		errs = append(errs, path+": amounts must not be negative")
+	}
+	if c.Surge < 0 {
+		errs = append(errs, path+".surge: must not be negative")
+	}
+	if _, err := time.LoadLocation(c.TimeZone); err != nil {
+		errs = append(errs, fmt.Sprintf("%s.time_zone: %q is not a valid time zone", path, c.TimeZone))
+	}
+	for i, p := range c.Periods {
+		_, errFrom := time.Parse("15:04", p.From)
+		_, errTo := time.Parse("15:04", p.To)
+		if errFrom != nil || errTo != nil {
+			errs = append(errs, fmt.Sprintf("%s.periods[%d]: from and to must look like 15:04", path, i))
+		}
+		if p.Multiplier <= 0 {
+			errs = append(errs, fmt.Sprintf("%s.periods[%d].multiplier: must be positive", path, i))
+		}
+	}
+
+	return errs
+}
+
+// maxOrdersBatchSize is the most orders the API accepts in a batch
+const maxOrdersBatchSize int = 500
+
+func (c *OrdersConfig) validate() []string {
+	var errs []string
+
+	if c.MaxBatchSize < 1 || c.MaxBatchSize > maxOrdersBatchSize {
+		errs = append(errs, fmt.Sprintf("orders.max_batch_size: must be from 1 to %d", maxOrdersBatchSize))
+	}
+
+	return errs
+}
+
+func (c *RulesConfig) validate() []string {
+	var errs []string
+
+	if c.Default != nil {
+		errs = append(errs, c.Default.validate("rules.default")...)
+	}
+
+	tenants := make([]string, 0, len(c.Tenants))
+	for tenant := range c.Tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	for _, tenant := range tenants {
+		r := c.Tenants[tenant]
+		errs = append(errs, r.validate("rules.tenants."+tenant)...)
+	}
+
+	return errs
+}
+
+func (c *RuleSetConfig) validate(path string) []string {
+	var errs []string
+
+	if c.MinDistance < 0 || c.MaxDistance < 0 {
+		errs = append(errs, path+": distances must not be negative")
+	}
+	if c.MaxDistance > 0 && c.MinDistance > c.MaxDistance {
+		errs = append(errs, path+".min_distance: must not be over max_distance")
+	}
+	for i, z := range c.BlockedZones {
+		if z.Name == "" {
+			errs = append(errs, fmt.Sprintf("%s.blocked_zones[%d].name: value required", path, i))
+		}
+		if z.Lat < -90 || z.Lat > 90 || z.Lng < -180 || z.Lng > 180 {
+			errs = append(errs, fmt.Sprintf("%s.blocked_zones[%d]: lat and lng must be valid coordinates", path, i))
+		}
+		if z.Radius <= 0 {
+			errs = append(errs, fmt.Sprintf("%s.blocked_zones[%d].radius: must be positive", path, i))
+		}
+	}
+	if h := c.OperatingHours; h != nil {
+		_, errFrom := time.Parse("15:04", h.From)
+		_, errTo := time.Parse("15:04", h.To)
+		if errFrom != nil || errTo != nil {
+			errs = append(errs, path+".operating_hours: from and to must look like 15:04")
+		}
+		if _, err := time.LoadLocation(h.TimeZone); err != nil {
+			errs = append(errs, fmt.Sprintf("%s.operating_hours.time_zone: %q is not a valid time zone", path, h.TimeZone))
+		}
+	}
+
+	return errs
+}
+
+func (c *RateLimitConfig) validate() []string {
+	var errs []string
+
+	switch c.Backend {
+	case "memory", "mysql":
+	default:
+		errs = append(errs, fmt.Sprintf("rate_limit.backend: %q must be memory or mysql", c.Backend))
+	}
+
+	checkRule := func(path string, r RateLimitRule) {
+		if r.Limit < 0 || r.Burst < 0 {
+			errs = append(errs, path+": limit and burst must not be negative")
+		}
+		if r.Limit > 0 && r.Period <= 0 {
+			errs = append(errs, path+": period required")
+		}
+	}
+
+	checkRule("rate_limit.default", RateLimitRule{Limit: c.DefaultLimit, Period: c.DefaultPeriod, Burst: c.DefaultBurst})
+	checkRule("rate_limit.ip", RateLimitRule{Limit: c.IPLimit, Period: c.IPPeriod, Burst: c.IPBurst})
+
+	routes := make([]string, 0, len(c.Routes))
+	for route := range c.Routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		if _, _, found := strings.Cut(route, " "); !found {
+			errs = append(errs, fmt.Sprintf("rate_limit.routes: %q must look like \"<METHOD> <path>\"", route))
+		}
+		checkRule("rate_limit.routes."+route, c.Routes[route])
+	}
+
+	return errs
 }
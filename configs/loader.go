@@ -0,0 +1,306 @@
+package configs
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Load resolves the effective config from defaults, the config file,
+// environment variables and the given CLI args, then validates it
+func Load(args []string) (*Config, error) {
+	return load(args, os.LookupEnv)
+}
+
+func load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := &Config{}
+
+	err := walkFields(cfg, func(f reflect.StructField, v reflect.Value, path string) error {
+		if def, ok := f.Tag.Lookup("default"); ok {
+			if err := setValue(v, def); err != nil {
+				return fmt.Errorf("%s: invalid default %q: %w", path, def, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fs, configFile := newFlagSet(cfg)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile == "" {
+		*configFile, _ = lookupEnv(KeyConfigFile)
+	}
+	if *configFile != "" {
+		if err := loadFile(cfg, *configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	err = walkFields(cfg, func(f reflect.StructField, v reflect.Value, path string) error {
+		key, ok := f.Tag.Lookup("env")
+		if !ok {
+			return nil
+		}
+		if val, found := lookupEnv(key); found {
+			if err := setValue(v, val); err != nil {
+				return fmt.Errorf("%s: invalid value %q: %w", key, val, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// flags are parsed before the file and env are applied, so assign the
+	// ones explicitly set last to give them the highest precedence
+	setFlags := map[string]string{}
+	fs.Visit(func(fl *flag.Flag) {
+		setFlags[fl.Name] = fl.Value.String()
+	})
+	err = walkFields(cfg, func(f reflect.StructField, v reflect.Value, path string) error {
+		if val, found := setFlags[path]; found {
+			return setValue(v, val)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Print writes the config as YAML with every secret field redacted
+func Print(w io.Writer, cfg *Config) error {
+	// deep copy through YAML so redacting list items leaves cfg untouched
+	raw, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	var cp Config
+	if err = yaml.Unmarshal(raw, &cp); err != nil {
+		return err
+	}
+
+	err = walkFields(&cp, func(f reflect.StructField, v reflect.Value, path string) error {
+		if f.Tag.Get("secret") == "true" && !v.IsZero() {
+			return setValue(v, redacted)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&cp); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+func loadFile(cfg *Config, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("fail to read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("fail to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// newFlagSet registers a flag for every field that can be set from the environment
+func newFlagSet(cfg *Config) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	configFile := fs.String("config", "", "path to the YAML config file, overrides "+KeyConfigFile)
+
+	_ = walkFields(cfg, func(f reflect.StructField, v reflect.Value, path string) error {
+		key, ok := f.Tag.Lookup("env")
+		if !ok {
+			return nil
+		}
+		fs.Var(&fieldFlag{typ: v.Type()}, path, "overrides "+key)
+		return nil
+	})
+
+	return fs, configFile
+}
+
+// fieldFlag holds the raw value of a flag, checked against the type of its field
+type fieldFlag struct {
+	typ reflect.Type
+	raw string
+}
+
+func (ff *fieldFlag) String() string {
+	return ff.raw
+}
+
+// IsBoolFlag lets bool fields be set by their flag alone, e.g. -log.sampling
+func (ff *fieldFlag) IsBoolFlag() bool {
+	return ff.typ.Kind() == reflect.Bool
+}
+
+func (ff *fieldFlag) Set(s string) error {
+	if err := setValue(reflect.New(ff.typ).Elem(), s); err != nil {
+		return err
+	}
+	ff.raw = s
+	return nil
+}
+
+// walkFields calls fn for every leaf field of cfg with its dotted YAML path
+func walkFields(cfg interface{}, fn func(reflect.StructField, reflect.Value, string) error) error {
+	return walkStruct(reflect.ValueOf(cfg).Elem(), "", fn)
+}
+
+func walkStruct(v reflect.Value, prefix string, fn func(reflect.StructField, reflect.Value, string) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			if err := walkStruct(fv, path, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := fn(f, fv, path); err != nil {
+			return err
+		}
+
+		// items of struct lists are walked after the list itself, so
+		// secrets and required fields inside them are covered too
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct {
+			for j := 0; j < fv.Len(); j++ {
+				if err := walkStruct(fv.Index(j), fmt.Sprintf("%s[%d]", path, j), fn); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return setYAMLValue(v, s)
+		}
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return setYAMLValue(v, s)
+	}
+
+	return nil
+}
+
+// setYAMLValue parses structured values given in env or flags as inline YAML,
+// e.g. AUTH_API_KEYS='[{key: abc, subject: merchant-1}]'
+func setYAMLValue(v reflect.Value, s string) error {
+	ptr := reflect.New(v.Type())
+	if err := yaml.Unmarshal([]byte(s), ptr.Interface()); err != nil {
+		return err
+	}
+	v.Set(ptr.Elem())
+
+	return nil
+}
+
+func validateRequired(cfg *Config) []string {
+	var errs []string
+
+	_ = walkFields(cfg, func(f reflect.StructField, v reflect.Value, path string) error {
+		if strings.Contains(f.Tag.Get("validate"), "required") && v.IsZero() {
+			errs = append(errs, path+": value required")
+		}
+		return nil
+	})
+
+	return errs
+}
@@ -1,27 +1,35 @@
 package db
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/imylam/delivery-test/configs"
 	"github.com/imylam/delivery-test/logger"
 	"go.uber.org/zap"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 
-	// For mysql connection
-	_ "github.com/go-sql-driver/mysql"
+	// Time zones for the loc DSN option, the runtime image ships without tzdata
+	_ "time/tzdata"
 )
 
+const customTLSConfigName string = "custom"
+
 var mysqlConn *sqlx.DB
 
 // InitDBConn initialize database connection
-func InitDBConn() {
+func InitDBConn(cfg configs.MySQLConfig) {
 	if mysqlConn == nil {
-		mysqlConn = connectMysql()
+		mysqlConn = connectMysql(cfg)
 	}
 
-	err := mysqlConn.Ping()
+	err := pingWithRetry(mysqlConn.Ping, cfg, time.Sleep)
 	if err != nil {
 		logger.Logger.Fatal("Error on connecting to database", zap.String("error", err.Error()))
 	}
@@ -35,24 +43,113 @@ func GetDBConnection() *sqlx.DB {
 }
 
 // connectMysql connects to mysql/mariadb database
-func connectMysql() *sqlx.DB {
-	dbHost, dbName, dbPassword, dbUser := getDbConfigs()
-	//connectionStr := fmt.Sprintf("%s:%s@/%s?charset=utf8&parseTime=True", dbUser, dbPassword, dbName)
-	connectionStr := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8&parseTime=True", dbUser, dbPassword, dbHost, dbName)
+func connectMysql(cfg configs.MySQLConfig) *sqlx.DB {
+	connectionStr, err := buildDSN(cfg)
+	if err != nil {
+		logger.Logger.Fatal("Error building DB connection string", zap.String("error", err.Error()))
+	}
 
 	mysqlCon, err := sqlx.Open("mysql", connectionStr)
 	if err != nil {
 		logger.Logger.Fatal("Error opening DB connection", zap.String("error", err.Error()))
 	}
 
+	mysqlCon.SetMaxOpenConns(cfg.MaxOpenConns)
+	mysqlCon.SetMaxIdleConns(cfg.MaxIdleConns)
+	mysqlCon.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	mysqlCon.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
 	return mysqlCon
 }
 
-func getDbConfigs() (dbHost, dbName, dbPassword, dbUser string) {
-	dbHost = configs.Get(configs.KeyMysqlHost)
-	dbName = configs.Get(configs.KeyMysqlDbBame)
-	dbPassword = configs.Get(configs.KeyMysqlPw)
-	dbUser = configs.Get(configs.KeyMysqlUser)
+// buildDSN turns cfg into a go-sql-driver connection string, registering a
+// custom TLS config when certificates are given
+func buildDSN(cfg configs.MySQLConfig) (string, error) {
+	loc, err := time.LoadLocation(cfg.Loc)
+	if err != nil {
+		return "", err
+	}
+
+	dsnCfg := mysql.NewConfig()
+	dsnCfg.Net = "tcp"
+	dsnCfg.Addr = cfg.Host
+	dsnCfg.DBName = cfg.DBName
+	dsnCfg.User = cfg.User
+	dsnCfg.Passwd = cfg.Password
+	dsnCfg.Params = map[string]string{"charset": "utf8"}
+	dsnCfg.ParseTime = true
+	dsnCfg.Loc = loc
+	dsnCfg.Timeout = cfg.DialTimeout
+	dsnCfg.ReadTimeout = cfg.ReadTimeout
+	dsnCfg.WriteTimeout = cfg.WriteTimeout
+	dsnCfg.TLSConfig = cfg.TLS
+
+	if cfg.TLS != "false" && (cfg.TLSCAFile != "" || cfg.TLSCertFile != "" || cfg.TLSServerName != "") {
+		tlsCfg, err := buildTLSConfig(cfg)
+		if err != nil {
+			return "", err
+		}
+		if err = mysql.RegisterTLSConfig(customTLSConfigName, tlsCfg); err != nil {
+			return "", err
+		}
+		dsnCfg.TLSConfig = customTLSConfigName
+	}
+
+	return dsnCfg.FormatDSN(), nil
+}
 
-	return
+func buildTLSConfig(cfg configs.MySQLConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		ServerName:         cfg.TLSServerName,
+		InsecureSkipVerify: cfg.TLS == "skip-verify",
+	}
+
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("fail to read MySQL CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("fail to parse MySQL CA file")
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("fail to load MySQL client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
+
+// pingWithRetry pings the database until it answers, doubling the wait after
+// every failure up to cfg.ConnectRetryMaxInterval
+func pingWithRetry(ping func() error, cfg configs.MySQLConfig, sleep func(time.Duration)) error {
+	interval := cfg.ConnectRetryInterval
+
+	for attempt := 0; ; attempt++ {
+		err := ping()
+		if err == nil {
+			return nil
+		}
+		if attempt >= cfg.ConnectRetries {
+			return fmt.Errorf("database unreachable after %d attempts: %w", attempt+1, err)
+		}
+
+		logger.Logger.Warn("Database not ready, retrying",
+			zap.String("error", err.Error()),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", interval))
+		sleep(interval)
+
+		interval *= 2
+		if interval > cfg.ConnectRetryMaxInterval {
+			interval = cfg.ConnectRetryMaxInterval
+		}
+	}
 }
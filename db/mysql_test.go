@@ -0,0 +1,106 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/logger"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestBuildDSN(t *testing.T) {
+	mockConfig := configs.MySQLConfig{
+		Host:         "mariadb:3306",
+		DBName:       "delivery",
+		User:         "delivery",
+		Password:     "password",
+		Loc:          "Asia/Hong_Kong",
+		DialTimeout:  5 * time.Second,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		TLS:          "false",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		dsn, err := buildDSN(mockConfig)
+		assert.Equal(t, true, err == nil)
+
+		parsed, err := mysql.ParseDSN(dsn)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, "mariadb:3306", parsed.Addr)
+		assert.Equal(t, "delivery", parsed.DBName)
+		assert.Equal(t, true, parsed.ParseTime)
+		assert.Equal(t, "Asia/Hong_Kong", parsed.Loc.String())
+		assert.Equal(t, 5*time.Second, parsed.Timeout)
+		assert.Equal(t, 30*time.Second, parsed.ReadTimeout)
+		assert.Equal(t, 10*time.Second, parsed.WriteTimeout)
+		assert.Equal(t, "utf8", parsed.Params["charset"])
+	})
+
+	t.Run("tls-mode", func(t *testing.T) {
+		tempConfig := mockConfig
+		tempConfig.TLS = "skip-verify"
+
+		dsn, err := buildDSN(tempConfig)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, true, strings.Contains(dsn, "tls=skip-verify"))
+	})
+
+	t.Run("missing-ca-file", func(t *testing.T) {
+		tempConfig := mockConfig
+		tempConfig.TLS = "true"
+		tempConfig.TLSCAFile = "/nonexistent/ca.pem"
+
+		_, err := buildDSN(tempConfig)
+
+		assert.Equal(t, false, err == nil)
+	})
+}
+
+func TestPingWithRetry(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	mockConfig := configs.MySQLConfig{
+		ConnectRetries:          4,
+		ConnectRetryInterval:    time.Second,
+		ConnectRetryMaxInterval: 3 * time.Second,
+	}
+
+	t.Run("success-after-retries", func(t *testing.T) {
+		var waits []time.Duration
+		calls := 0
+		ping := func() error {
+			calls++
+			if calls < 4 {
+				return errors.New("connection refused")
+			}
+			return nil
+		}
+
+		err := pingWithRetry(ping, mockConfig, func(d time.Duration) { waits = append(waits, d) })
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, 4, calls)
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, waits)
+	})
+
+	t.Run("retries-exhausted", func(t *testing.T) {
+		calls := 0
+		ping := func() error {
+			calls++
+			return errors.New("connection refused")
+		}
+
+		err := pingWithRetry(ping, mockConfig, func(time.Duration) {})
+
+		assert.Equal(t, false, err == nil)
+		assert.Equal(t, 5, calls)
+	})
+}
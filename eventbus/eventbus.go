@@ -0,0 +1,103 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/imylam/delivery-test/order"
+)
+
+// SchemaVersion is the version of the event schema, bumped on any change
+// consumers must not read with the previous version
+const SchemaVersion int = 1
+
+const (
+	EventOrderPlaced   string = "OrderPlaced"
+	EventOrderReleased string = "OrderReleased"
+	EventOrderTaken    string = "OrderTaken"
+)
+
+// Event is the envelope of every event put on the bus, Type tells what the
+// event is about and Order is a snapshot of the order right after it
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Version    int       `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+	TenantID   string    `json:"tenant_id"`
+	Order      Order     `json:"order"`
+}
+
+// Order is the order as described by events, kept apart from order.Order so
+// that the API and the event schema can change independently
+type Order struct {
+	ID         int64    `json:"id"`
+	Status     string   `json:"status"`
+	Distance   int      `json:"distance"`
+	MerchantID string   `json:"merchant_id"`
+	CourierID  string   `json:"courier_id,omitempty"`
+	Origin     Location `json:"origin"`
+}
+
+// Location is a point on the map
+type Location struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Key is what the brokers partition events on, every event of an order
+// shares it so that they are consumed in the order they were published
+func (e *Event) Key() string {
+	return strconv.FormatInt(e.Order.ID, 10)
+}
+
+// EventPublisher puts events on a message bus
+type EventPublisher interface {
+	Publish(context.Context, *Event) error
+	Close() error
+}
+
+// NewOrderPlaced returns the event of o being placed at t
+func NewOrderPlaced(o *order.Order, t time.Time) *Event {
+	return newEvent(EventOrderPlaced, o, t)
+}
+
+// NewOrderReleased returns the event of the scheduled order o being released
+// to couriers at t
+func NewOrderReleased(o *order.Order, t time.Time) *Event {
+	return newEvent(EventOrderReleased, o, t)
+}
+
+// NewOrderTaken returns the event of o being taken at t
+func NewOrderTaken(o *order.Order, t time.Time) *Event {
+	return newEvent(EventOrderTaken, o, t)
+}
+
+func newEvent(eventType string, o *order.Order, t time.Time) *Event {
+	return &Event{
+		ID:         newEventID(),
+		Type:       eventType,
+		Version:    SchemaVersion,
+		OccurredAt: t.UTC(),
+		TenantID:   o.TenantID,
+		Order: Order{
+			ID:         o.ID,
+			Status:     o.Status,
+			Distance:   o.Distance,
+			MerchantID: o.MerchantID,
+			CourierID:  o.CourierID,
+			Origin:     Location{Lat: o.OriginLat, Lng: o.OriginLng},
+		},
+	}
+}
+
+// newEventID returns a random ID consumers dedupe redelivered events on
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,31 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/order"
+)
+
+func TestNewOrderPlaced(t *testing.T) {
+	at := time.Date(2022, 10, 1, 20, 0, 0, 0, time.FixedZone("HKT", 8*60*60))
+	o := &order.Order{ID: 5, TenantID: "brand-a", Distance: 888, Status: order.StatusUnassigned,
+		MerchantID: "merchant-1", OriginLat: 22.300789, OriginLng: 114.167815}
+
+	e := NewOrderPlaced(o, at)
+	e.ID = "4f1c"
+	b, err := json.Marshal(e)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"id":"4f1c","type":"OrderPlaced","version":1,"occurred_at":"2022-10-01T12:00:00Z",`+
+		`"tenant_id":"brand-a","order":{"id":5,"status":"UNASSIGNED","distance":888,"merchant_id":"merchant-1",`+
+		`"origin":{"lat":22.300789,"lng":114.167815}}}`, string(b))
+	assert.Equal(t, "5", e.Key())
+}
+
+func TestNewEventID(t *testing.T) {
+	assert.Equal(t, 32, len(newEventID()))
+	assert.NotEqual(t, newEventID(), newEventID())
+}
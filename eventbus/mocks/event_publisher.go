@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/imylam/delivery-test/eventbus"
+	"github.com/stretchr/testify/mock"
+)
+
+// EventPublisher is a mock type for the EventPublisher type
+type EventPublisher struct {
+	mock.Mock
+}
+
+// Close provides a mock function with given fields:
+func (_m *EventPublisher) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Publish provides a mock function with given fields: ctx, e
+func (_m *EventPublisher) Publish(ctx context.Context, e *eventbus.Event) error {
+	ret := _m.Called(ctx, e)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *eventbus.Event) error); ok {
+		r0 = rf(ctx, e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,39 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/imylam/delivery-test/eventbus"
+)
+
+// ChannelPublisher hands events over to the same process through a channel,
+// mostly for tests to see what was published
+type ChannelPublisher struct {
+	events chan *eventbus.Event
+}
+
+// NewChannelPublisher returns a publisher buffering up to size events,
+// Publish blocks once the buffer is full until an event is received
+func NewChannelPublisher(size int) *ChannelPublisher {
+	return &ChannelPublisher{events: make(chan *eventbus.Event, size)}
+}
+
+// Events returns the channel the published events are received from, it is
+// closed by Close
+func (p *ChannelPublisher) Events() <-chan *eventbus.Event {
+	return p.events
+}
+
+func (p *ChannelPublisher) Publish(ctx context.Context, e *eventbus.Event) error {
+	select {
+	case p.events <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *ChannelPublisher) Close() error {
+	close(p.events)
+	return nil
+}
@@ -0,0 +1,43 @@
+package publisher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/eventbus"
+	"github.com/imylam/delivery-test/order"
+)
+
+func TestChannelPublisher(t *testing.T) {
+	e := eventbus.NewOrderTaken(&order.Order{ID: 5, TenantID: "brand-a", Status: order.StatusTaken}, time.Now())
+
+	t.Run("success", func(t *testing.T) {
+		p := NewChannelPublisher(1)
+
+		err := p.Publish(context.Background(), e)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, e, <-p.Events())
+	})
+
+	t.Run("full", func(t *testing.T) {
+		p := NewChannelPublisher(0)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := p.Publish(ctx, e)
+
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		p := NewChannelPublisher(1)
+		_ = p.Close()
+
+		_, ok := <-p.Events()
+
+		assert.Equal(t, false, ok)
+	})
+}
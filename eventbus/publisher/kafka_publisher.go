@@ -0,0 +1,93 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/imylam/delivery-test/eventbus"
+)
+
+// contentTypeKafkaJSON is the content type of JSON records for the v2 API of
+// the Kafka REST Proxy
+const contentTypeKafkaJSON string = "application/vnd.kafka.json.v2+json"
+
+type kafkaPublisher struct {
+	url    string
+	client *http.Client
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value *eventbus.Event `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int    `json:"partition"`
+		Offset    int64  `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// NewKafkaPublisher returns a publisher producing every event to topic
+// through the Kafka REST Proxy at restURL, keyed by order so that the events
+// of an order land in the same partition
+func NewKafkaPublisher(restURL, topic string, timeout time.Duration) eventbus.EventPublisher {
+	return &kafkaPublisher{
+		url:    strings.TrimSuffix(restURL, "/") + "/topics/" + url.PathEscape(topic),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, e *eventbus.Event) error {
+	body, err := json.Marshal(kafkaProduceRequest{Records: []kafkaRecord{{Key: e.Key(), Value: e}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentTypeKafkaJSON)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	// the proxy answers 200 even when a record could not be produced
+	var produced kafkaProduceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&produced); err != nil {
+		return err
+	}
+	for _, o := range produced.Offsets {
+		if o.ErrorCode != nil {
+			return fmt.Errorf("kafka error %d: %s", *o.ErrorCode, o.Error)
+		}
+	}
+
+	return nil
+}
+
+func (p *kafkaPublisher) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,61 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/eventbus"
+	"github.com/imylam/delivery-test/order"
+)
+
+func TestKafkaPublisher(t *testing.T) {
+	e := eventbus.NewOrderPlaced(&order.Order{ID: 5, TenantID: "brand-a", Status: order.StatusUnassigned}, time.Now())
+
+	t.Run("success", func(t *testing.T) {
+		var path, contentType string
+		var received map[string][]map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+			body, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &received)
+			_, _ = w.Write([]byte(`{"offsets":[{"partition":0,"offset":12,"error_code":null,"error":null}]}`))
+		}))
+		defer server.Close()
+
+		err := NewKafkaPublisher(server.URL+"/", "order-events", time.Second).Publish(context.Background(), e)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "/topics/order-events", path)
+		assert.Equal(t, contentTypeKafkaJSON, contentType)
+		assert.Equal(t, "5", received["records"][0]["key"])
+		assert.Equal(t, eventbus.EventOrderPlaced, received["records"][0]["value"].(map[string]interface{})["type"])
+	})
+
+	t.Run("record-error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"offsets":[{"partition":null,"offset":null,"error_code":50002,"error":"Kafka error"}]}`))
+		}))
+		defer server.Close()
+
+		err := NewKafkaPublisher(server.URL, "order-events", time.Second).Publish(context.Background(), e)
+
+		assert.Equal(t, "kafka error 50002: Kafka error", err.Error())
+	})
+
+	t.Run("unexpected-status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		err := NewKafkaPublisher(server.URL, "order-events", time.Second).Publish(context.Background(), e)
+
+		assert.Equal(t, "unexpected status 404", err.Error())
+	})
+}
@@ -0,0 +1,68 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/imylam/delivery-test/eventbus"
+	"github.com/nats-io/nats.go"
+)
+
+// natsClientName is how the service introduces itself to the NATS server
+const natsClientName string = "delivery-service"
+
+type natsPublisher struct {
+	conn          *nats.Conn
+	subjectPrefix string
+	timeout       time.Duration
+}
+
+// NewNATSPublisher returns a publisher sending every event to the NATS
+// server at url, on the subject <subjectPrefix>.<tenant>.<type>. The
+// connection is retried in the background when the server is not up yet
+func NewNATSPublisher(url, subjectPrefix string, timeout time.Duration) (eventbus.EventPublisher, error) {
+	conn, err := nats.Connect(url,
+		nats.Name(natsClientName),
+		nats.Timeout(timeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &natsPublisher{
+		conn:          conn,
+		subjectPrefix: subjectPrefix,
+		timeout:       timeout,
+	}, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, e *eventbus.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if err := p.conn.Publish(natsSubject(p.subjectPrefix, e), data); err != nil {
+		return err
+	}
+
+	// Publish only buffers the event, flushing tells whether the server got it
+	timeout := p.timeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+	return p.conn.FlushTimeout(timeout)
+}
+
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
+
+// natsSubject lets subscribers pick events by tenant and type with wildcards,
+// e.g. orders.*.OrderPlaced
+func natsSubject(prefix string, e *eventbus.Event) string {
+	return prefix + "." + e.TenantID + "." + e.Type
+}
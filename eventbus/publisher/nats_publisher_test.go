@@ -0,0 +1,16 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/eventbus"
+	"github.com/imylam/delivery-test/order"
+)
+
+func TestNATSSubject(t *testing.T) {
+	e := eventbus.NewOrderTaken(&order.Order{ID: 5, TenantID: "brand-a"}, time.Now())
+
+	assert.Equal(t, "orders.brand-a.OrderTaken", natsSubject("orders", e))
+}
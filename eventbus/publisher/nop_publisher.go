@@ -0,0 +1,22 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/imylam/delivery-test/eventbus"
+)
+
+type nopPublisher struct{}
+
+// NewNopPublisher returns a publisher dropping every event
+func NewNopPublisher() eventbus.EventPublisher {
+	return nopPublisher{}
+}
+
+func (nopPublisher) Publish(context.Context, *eventbus.Event) error {
+	return nil
+}
+
+func (nopPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,28 @@
+package publisher
+
+import (
+	"fmt"
+
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/eventbus"
+)
+
+const (
+	BrokerNone  string = "none"
+	BrokerNATS  string = "nats"
+	BrokerKafka string = "kafka"
+)
+
+// New returns the publisher of the broker named by cfg.Broker
+func New(cfg configs.EventsConfig) (eventbus.EventPublisher, error) {
+	switch cfg.Broker {
+	case BrokerNone:
+		return NewNopPublisher(), nil
+	case BrokerNATS:
+		return NewNATSPublisher(cfg.NATSURL, cfg.NATSSubjectPrefix, cfg.Timeout)
+	case BrokerKafka:
+		return NewKafkaPublisher(cfg.KafkaRESTURL, cfg.KafkaTopic, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("events: unknown broker %q", cfg.Broker)
+	}
+}
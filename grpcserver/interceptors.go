@@ -0,0 +1,87 @@
+package grpcserver
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/logger"
+	"go.uber.org/zap"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	errUnauthorized   string = "unauthorized"
+	errForbidden      string = "forbidden"
+	errTenantRequired string = "tenant required"
+	errInvalidTenant  string = "invalid tenant"
+	errInternalServer string = "internal server error"
+)
+
+// publicServices are served without credentials, so probes and tooling work
+var publicServices = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.",
+}
+
+// authenticateUnary authenticates calls with the same credentials as the REST
+// API, read from the x-api-key and authorization metadata, and attaches the
+// caller identity scoped to the tenant resolved from the x-tenant-id metadata
+func authenticateUnary(authenticator auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for _, prefix := range publicServices {
+			if strings.HasPrefix(info.FullMethod, prefix) {
+				return handler(ctx, req)
+			}
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		header := http.Header{}
+		for key, values := range md {
+			header[http.CanonicalHeaderKey(key)] = values
+		}
+
+		identity, err := authenticator.Authenticate(&http.Request{Header: header})
+		if err != nil {
+			logger.Logger.Debug("fail to authenticate call", zap.String("error", err.Error()))
+			return nil, status.Error(codes.Unauthenticated, errUnauthorized)
+		}
+
+		tenant, err := auth.ResolveTenant(identity, header.Get(auth.HeaderTenantID))
+		switch err {
+		case nil:
+		case auth.ErrNoTenant:
+			return nil, status.Error(codes.InvalidArgument, errTenantRequired)
+		case auth.ErrInvalidTenant:
+			return nil, status.Error(codes.InvalidArgument, errInvalidTenant)
+		default:
+			return nil, status.Error(codes.PermissionDenied, errForbidden)
+		}
+
+		scoped := *identity
+		scoped.Tenant = tenant
+
+		return handler(auth.WithIdentity(ctx, &scoped), req)
+	}
+}
+
+// recoverUnary turns panics into Internal errors instead of crashing the server
+func recoverUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Logger.Error("panic in gRPC handler",
+				zap.String("method", info.FullMethod),
+				zap.Any("panic", r),
+				zap.ByteString("stack", debug.Stack()))
+			err = status.Error(codes.Internal, errInternalServer)
+		}
+	}()
+
+	return handler(ctx, req)
+}
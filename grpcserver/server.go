@@ -0,0 +1,38 @@
+package grpcserver
+
+import (
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/order"
+	_orderServer "github.com/imylam/delivery-test/order/api/grpc"
+	"github.com/imylam/delivery-test/order/api/grpc/orderpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// InitServer creates the gRPC server exposing the order API, along with the
+// standard health service and, if enabled, server reflection
+func InitServer(cfg configs.GRPCConfig, authenticator auth.Authenticator, orderUC order.OrderUsecase) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			recoverUnary,
+			authenticateUnary(authenticator),
+		),
+	)
+
+	_orderServer.NewOrderServer(server, orderUC)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(orderpb.OrderService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	if cfg.Reflection {
+		reflection.Register(server)
+	}
+
+	return server
+}
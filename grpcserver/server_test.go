@@ -0,0 +1,157 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/order/api/grpc/orderpb"
+	"github.com/imylam/delivery-test/order/mocks"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestServer(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	authenticator, _ := auth.NewAuthenticator(configs.AuthConfig{
+		APIKeys: []configs.APIKeyConfig{
+			{Key: "abc", Subject: "merchant-1", Roles: []string{auth.RoleMerchant}, Tenant: "brand-a"},
+			{Key: "xyz", Subject: "admin-1", Roles: []string{auth.RoleAdmin}},
+			{Key: "def", Subject: "merchant-2", Roles: []string{auth.RoleMerchant}},
+		},
+	})
+
+	mockOrderUC := new(mocks.OrderUsecase)
+	mockOrderUC.On("GetOrder", mock.Anything, int64(1)).Return(
+		func(ctx context.Context, id int64) *order.Order {
+			identity, _ := auth.FromContext(ctx)
+			return &order.Order{ID: id, Status: order.StatusUnassigned, MerchantID: identity.Tenant}
+		},
+		nil,
+	)
+
+	conn := dialServer(t, InitServer(configs.GRPCConfig{Reflection: true}, authenticator, mockOrderUC))
+	client := orderpb.NewOrderServiceClient(conn)
+
+	t.Run("success", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "abc")
+
+		resp, err := client.GetOrder(ctx, &orderpb.GetOrderRequest{Id: 1})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(1), resp.GetId())
+		assert.Equal(t, "brand-a", resp.GetMerchantId())
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "abd")
+
+		_, err := client.GetOrder(ctx, &orderpb.GetOrderRequest{Id: 1})
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("tenant-required", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "xyz")
+
+		_, err := client.GetOrder(ctx, &orderpb.GetOrderRequest{Id: 1})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("tenant-from-metadata", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "xyz", "x-tenant-id", "brand-b")
+
+		resp, err := client.GetOrder(ctx, &orderpb.GetOrderRequest{Id: 1})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "brand-b", resp.GetMerchantId())
+	})
+
+	t.Run("tenant-mismatch", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "abc", "x-tenant-id", "brand-b")
+
+		_, err := client.GetOrder(ctx, &orderpb.GetOrderRequest{Id: 1})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("unbound-merchant-tenant-from-metadata", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "def", "x-tenant-id", "brand-b")
+
+		_, err := client.GetOrder(ctx, &orderpb.GetOrderRequest{Id: 1})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("health-without-credentials", func(t *testing.T) {
+		resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{
+			Service: orderpb.OrderService_ServiceDesc.ServiceName,
+		})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	})
+
+	t.Run("reflection", func(t *testing.T) {
+		stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+		assert.Equal(t, nil, err)
+
+		err = stream.Send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+		})
+		assert.Equal(t, nil, err)
+		resp, err := stream.Recv()
+		assert.Equal(t, nil, err)
+
+		services := map[string]bool{}
+		for _, s := range resp.GetListServicesResponse().GetService() {
+			services[s.GetName()] = true
+		}
+		assert.Equal(t, true, services[orderpb.OrderService_ServiceDesc.ServiceName])
+	})
+}
+
+func TestRecoverUnary(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/test/Panic"}
+	_, err := recoverUnary(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func dialServer(t *testing.T, server *grpc.Server) *grpc.ClientConn {
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("fail to dial bufnet: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
@@ -1,29 +1,70 @@
 package httpserver
 
 import (
+	"github.com/imylam/delivery-test/common/auth"
 	"github.com/imylam/delivery-test/common/middleware"
+	"github.com/imylam/delivery-test/common/ratelimit"
+	"github.com/imylam/delivery-test/configs"
 	"github.com/imylam/delivery-test/db"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/openapi"
+	"github.com/imylam/delivery-test/order"
 	_orderHandler "github.com/imylam/delivery-test/order/api/rest"
-	"github.com/imylam/delivery-test/order/infrastructure/googlemap"
-	_orderRepo "github.com/imylam/delivery-test/order/infrastructure/mysql"
-	_orderUsecase "github.com/imylam/delivery-test/order/usecase"
+	_dispatchHandler "github.com/imylam/delivery-test/order/api/ws"
+	"github.com/imylam/delivery-test/servicearea"
+	_serviceAreaHandler "github.com/imylam/delivery-test/servicearea/api/rest"
+	"github.com/imylam/delivery-test/webhook"
+	_webhookHandler "github.com/imylam/delivery-test/webhook/api/rest"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // InitRoutes creates routes to receive and respond to http requests
-func InitRoutes() *gin.Engine {
+func InitRoutes(cfg *configs.Config, authenticator auth.Authenticator, orderUC order.OrderUsecase, webhookUC webhook.WebhookUsecase,
+	serviceAreaUC servicearea.ServiceAreaUsecase) *gin.Engine {
 	mysqlConn := db.GetDBConnection()
-	mapClient := googlemap.NewMapClient()
 
-	orderRepo := _orderRepo.NewOrderRepositoryMysql(mysqlConn)
-	orderUC := _orderUsecase.NewOrderUsecase(orderRepo, mapClient)
+	var rateLimitStore ratelimit.Store
+	if cfg.RateLimit.Backend == ratelimit.BackendMysql {
+		rateLimitStore = ratelimit.NewMysqlStore(mysqlConn)
+	} else {
+		rateLimitStore = ratelimit.NewMemoryStore()
+	}
+	limiter := ratelimit.NewLimiter(rateLimitStore, cfg.RateLimit)
+
+	spec, err := openapi.Load()
+	if err != nil {
+		logger.Logger.Fatal("Error loading OpenAPI spec", zap.String("error", err.Error()))
+	}
+	specHandler, err := openapi.SpecHandler(spec)
+	if err != nil {
+		logger.Logger.Fatal("Error encoding OpenAPI spec", zap.String("error", err.Error()))
+	}
+	// responses are only checked against the spec while integration tests run
+	validator := openapi.NewValidator(spec, cfg.IsIntegrationTest())
 
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
 	router.Use(middleware.HandleRestError)
+	router.Use(middleware.RateLimitIP(limiter))
+
+	// the API description is public, routes registered before Authenticate skip it
+	router.GET("/openapi.json", specHandler)
+	router.GET("/docs", openapi.DocsHandler)
+
+	router.Use(middleware.Authenticate(authenticator))
+	router.Use(middleware.RateLimit(limiter))
+	router.Use(middleware.ValidateOpenAPI(validator))
+
+	_orderHandler.NewOrderHandler(router, orderUC, cfg.Orders.MaxBatchSize)
+	_dispatchHandler.NewDispatchHandler(router, orderUC)
+	_webhookHandler.NewWebhookHandler(router, webhookUC)
+	_serviceAreaHandler.NewServiceAreaHandler(router, serviceAreaUC)
 
-	_orderHandler.NewOrderHandler(router, orderUC)
+	admin := router.Group("/admin", middleware.RequirePermission(auth.PermissionAdmin))
+	admin.GET("/log-level", gin.WrapH(logger.LevelHandler()))
+	admin.PUT("/log-level", gin.WrapH(logger.LevelHandler()))
 
 	return router
 }
@@ -23,10 +23,190 @@ FLUSH PRIVILEGES;
 
 CREATE TABLE IF NOT EXISTS `delivery`.orders (
   id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  tenant_id VARCHAR(64) NOT NULL,
   distance INT UNSIGNED NOT NULL,
+  travel_mode VARCHAR(20) NOT NULL DEFAULT 'driving',
+  estimated_duration INT UNSIGNED NOT NULL DEFAULT 0,
+  price BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  currency CHAR(3) NOT NULL DEFAULT '',
+  tariff_version VARCHAR(64) NOT NULL DEFAULT '',
+  quote_id CHAR(32) NOT NULL DEFAULT '',
   status VARCHAR(20) NOT NULL,
+  merchant_id VARCHAR(255) NOT NULL,
+  courier_id VARCHAR(255) NOT NULL DEFAULT '',
+  origin_address VARCHAR(255) NOT NULL DEFAULT '',
+  origin_lat DOUBLE NOT NULL DEFAULT 0,
+  origin_lng DOUBLE NOT NULL DEFAULT 0,
+  destination_address VARCHAR(255) NOT NULL DEFAULT '',
+  destination_lat DOUBLE NOT NULL DEFAULT 0,
+  destination_lng DOUBLE NOT NULL DEFAULT 0,
+  scheduled_for TIMESTAMP NULL,
   created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP(),
   updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP() ON UPDATE CURRENT_TIMESTAMP(),
-  CONSTRAINT order_PK PRIMARY KEY (id)
+  CONSTRAINT order_PK PRIMARY KEY (id),
+  INDEX order_tenant_status_IDX (tenant_id, status),
+  INDEX order_tenant_merchant_IDX (tenant_id, merchant_id),
+  INDEX order_status_scheduled_for_IDX (status, scheduled_for)
+)
+ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS `delivery`.order_stops (
+  tenant_id VARCHAR(64) NOT NULL,
+  order_id BIGINT UNSIGNED NOT NULL,
+  position TINYINT UNSIGNED NOT NULL,
+  type VARCHAR(20) NOT NULL,
+  address VARCHAR(255) NOT NULL DEFAULT '',
+  lat DOUBLE NOT NULL,
+  lng DOUBLE NOT NULL,
+  distance INT UNSIGNED NOT NULL DEFAULT 0,
+  completed_at TIMESTAMP(6) NULL,
+  CONSTRAINT order_stop_PK PRIMARY KEY (tenant_id, order_id, position)
+)
+ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS `delivery`.order_events (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  tenant_id VARCHAR(64) NOT NULL,
+  type VARCHAR(32) NOT NULL,
+  order_id BIGINT UNSIGNED NOT NULL,
+  distance INT UNSIGNED NOT NULL,
+  travel_mode VARCHAR(20) NOT NULL DEFAULT 'driving',
+  status VARCHAR(20) NOT NULL,
+  merchant_id VARCHAR(255) NOT NULL,
+  courier_id VARCHAR(255) NOT NULL DEFAULT '',
+  origin_lat DOUBLE NOT NULL,
+  origin_lng DOUBLE NOT NULL,
+  created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  position BIGINT UNSIGNED NULL,
+  CONSTRAINT order_event_PK PRIMARY KEY (id),
+  CONSTRAINT order_event_position_UK UNIQUE (tenant_id, position)
+)
+ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS `delivery`.order_event_positions (
+  tenant_id VARCHAR(64) NOT NULL,
+  position BIGINT UNSIGNED NOT NULL,
+  CONSTRAINT order_event_position_PK PRIMARY KEY (tenant_id)
+)
+ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS `delivery`.quotes (
+  id CHAR(32) NOT NULL,
+  tenant_id VARCHAR(64) NOT NULL,
+  merchant_id VARCHAR(255) NOT NULL,
+  distance INT UNSIGNED NOT NULL,
+  travel_mode VARCHAR(20) NOT NULL,
+  estimated_duration INT UNSIGNED NOT NULL DEFAULT 0,
+  origin_address VARCHAR(255) NOT NULL DEFAULT '',
+  origin_lat DOUBLE NOT NULL,
+  origin_lng DOUBLE NOT NULL,
+  destination_address VARCHAR(255) NOT NULL DEFAULT '',
+  destination_lat DOUBLE NOT NULL,
+  destination_lng DOUBLE NOT NULL,
+  price BIGINT UNSIGNED NOT NULL,
+  currency CHAR(3) NOT NULL,
+  tariff_version VARCHAR(64) NOT NULL,
+  order_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP(6) NOT NULL,
+  created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  CONSTRAINT quote_PK PRIMARY KEY (tenant_id, id)
+)
+ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS `delivery`.service_areas (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  tenant_id VARCHAR(64) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  geometry MEDIUMTEXT NOT NULL,
+  created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  CONSTRAINT service_area_PK PRIMARY KEY (id),
+  INDEX service_area_tenant_IDX (tenant_id)
+)
+ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS `delivery`.webhook_subscriptions (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  tenant_id VARCHAR(64) NOT NULL,
+  owner_id VARCHAR(255) NOT NULL,
+  merchant_id VARCHAR(255) NOT NULL DEFAULT '',
+  url VARCHAR(2048) NOT NULL,
+  events VARCHAR(255) NOT NULL DEFAULT '',
+  secret VARCHAR(255) NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  CONSTRAINT webhook_subscription_PK PRIMARY KEY (id),
+  INDEX webhook_subscription_tenant_owner_IDX (tenant_id, owner_id)
+)
+ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS `delivery`.webhook_deliveries (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  tenant_id VARCHAR(64) NOT NULL,
+  subscription_id BIGINT UNSIGNED NOT NULL,
+  event_id BIGINT UNSIGNED NOT NULL,
+  event_type VARCHAR(32) NOT NULL,
+  payload BLOB NOT NULL,
+  status VARCHAR(20) NOT NULL,
+  attempts INT UNSIGNED NOT NULL DEFAULT 0,
+  last_status_code INT NOT NULL DEFAULT 0,
+  last_error VARCHAR(255) NOT NULL DEFAULT '',
+  next_attempt_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  locked_by VARCHAR(32) NULL,
+  locked_until TIMESTAMP(6) NULL,
+  created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  CONSTRAINT webhook_delivery_PK PRIMARY KEY (id),
+  CONSTRAINT webhook_delivery_subscription_event_UK UNIQUE (subscription_id, event_id),
+  CONSTRAINT webhook_delivery_subscription_FK FOREIGN KEY (subscription_id)
+    REFERENCES `delivery`.webhook_subscriptions (id) ON DELETE CASCADE,
+  INDEX webhook_delivery_due_IDX (status, next_attempt_at),
+  INDEX webhook_delivery_locked_by_IDX (locked_by)
+)
+ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS `delivery`.webhook_cursors (
+  tenant_id VARCHAR(64) NOT NULL,
+  position BIGINT UNSIGNED NOT NULL,
+  CONSTRAINT webhook_cursor_PK PRIMARY KEY (tenant_id)
+)
+ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS `delivery`.outbox_messages (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  tenant_id VARCHAR(64) NOT NULL,
+  aggregate_type VARCHAR(32) NOT NULL,
+  aggregate_id BIGINT UNSIGNED NOT NULL,
+  event_type VARCHAR(32) NOT NULL,
+  payload BLOB NOT NULL,
+  attempts INT UNSIGNED NOT NULL DEFAULT 0,
+  last_error VARCHAR(255) NOT NULL DEFAULT '',
+  next_attempt_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  published_at TIMESTAMP(6) NULL,
+  buried_at TIMESTAMP(6) NULL,
+  CONSTRAINT outbox_message_PK PRIMARY KEY (id),
+  INDEX outbox_message_published_at_IDX (published_at),
+  INDEX outbox_message_pending_IDX (published_at, buried_at, next_attempt_at),
+  INDEX outbox_message_aggregate_IDX (tenant_id, aggregate_type, aggregate_id, id)
+)
+ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS `delivery`.outbox_leases (
+  name VARCHAR(64) NOT NULL,
+  holder VARCHAR(32) NOT NULL,
+  expires_at TIMESTAMP(6) NOT NULL,
+  CONSTRAINT outbox_lease_PK PRIMARY KEY (name)
+)
+ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS `delivery`.rate_limit_buckets (
+  bucket_key VARCHAR(512) NOT NULL,
+  tokens DOUBLE NOT NULL,
+  updated_at TIMESTAMP(6) NOT NULL,
+  full_at TIMESTAMP(6) NOT NULL,
+  CONSTRAINT rate_limit_bucket_PK PRIMARY KEY (bucket_key),
+  INDEX rate_limit_bucket_full_at_IDX (full_at)
 )
 ENGINE=InnoDB;
\ No newline at end of file
@@ -4,16 +4,29 @@
 package integrationtests_test
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-playground/assert/v2"
 	"github.com/go-resty/resty/v2"
+	"github.com/gorilla/websocket"
 
 	"github.com/imylam/delivery-test/order"
 	"github.com/imylam/delivery-test/order/api/rest"
+	"github.com/imylam/delivery-test/order/api/ws"
+	"github.com/imylam/delivery-test/servicearea"
+	"github.com/imylam/delivery-test/webhook"
 )
 
 func Test_ListOrders(t *testing.T) {
@@ -67,6 +80,176 @@ func Test_PlaceOrders(t *testing.T) {
 		assert.Equal(t, "200", resp.Header().Get("HTTP"))
 		assert.Equal(t, true, placeOrderResponose.ID > 0)
 		assert.Equal(t, placeOrderResponose.Status, "UNASSIGNED")
+		assert.Equal(t, "driving", placeOrderResponose.TravelMode)
+		assert.Equal(t, 120, placeOrderResponose.EstimatedDuration)
+		assert.Equal(t, true, placeOrderResponose.EstimatedDeliveryAt != nil)
+		assert.Equal(t, int64(1704), placeOrderResponose.Price)
+		assert.Equal(t, "HKD", placeOrderResponose.Currency)
+		assert.Equal(t, "it-1", placeOrderResponose.TariffVersion)
+	})
+
+	t.Run("GIVEN_a_batch_with_an_invalid_order_WHEN_place_orders_THEN_the_others_should_be_placed", func(t *testing.T) {
+
+		placeOrdersResponse := &rest.PlaceOrdersResponse{}
+
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetBody(`{"orders": [{"origin": ["0.00", "0.00"], "destination": ["1.00", "0.00"]}, ` +
+				`{"origin": ["91.00", "0.00"], "destination": ["1.00", "0.00"]}, ` +
+				`{"origin": ["0.00", "0.00"], "destination": ["2.00", "0.00"]}]}`).
+			SetResult(placeOrdersResponse).
+			Post(fmt.Sprintf("%s/orders/batch", getBaseUrl()))
+
+		assert.Equal(t, 200, resp.StatusCode())
+		assert.Equal(t, 2, placeOrdersResponse.Placed)
+		assert.Equal(t, 1, placeOrdersResponse.Failed)
+		assert.Equal(t, "invalid coordinates", placeOrdersResponse.Results[1].Error)
+		assert.Equal(t, placeOrdersResponse.Results[0].Order.ID+1, placeOrdersResponse.Results[2].Order.ID)
+	})
+
+	t.Run("GIVEN_addresses_WHEN_place_order_THEN_the_formatted_addresses_should_be_returned", func(t *testing.T) {
+
+		placeOrderResponose := &rest.PlaceOrderReponse{}
+
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetBody(`{"origin_address": "1 Austin Road West, Tsim Sha Tsui", "destination_address": "2 Finance Street, Central"}`).
+			SetResult(placeOrderResponose).
+			Post(fmt.Sprintf("%s/orders", getBaseUrl()))
+
+		assert.Equal(t, 200, resp.StatusCode())
+		assert.Equal(t, "1 Austin Rd W, Tsim Sha Tsui, Hong Kong", placeOrderResponose.OriginAddress)
+		assert.Equal(t, "2 Finance St, Central, Hong Kong", placeOrderResponose.DestinationAddress)
+	})
+
+	t.Run("GIVEN_an_ambiguous_address_WHEN_place_order_THEN_422_should_be_returned", func(t *testing.T) {
+
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetBody(`{"origin_address": "Nathan Road", "destination": ["1.00", "0.00"]}`).
+			Post(fmt.Sprintf("%s/orders", getBaseUrl()))
+
+		assert.Equal(t, 422, resp.StatusCode())
+		assert.Equal(t, "422", resp.Header().Get("HTTP"))
+	})
+}
+
+func Test_Quotes(t *testing.T) {
+
+	client := resty.New()
+
+	t.Run("GIVEN_vaild_PlaceOrderRequest_body_WHEN_quote_order_THEN_price_should_be_returned", func(t *testing.T) {
+
+		quote := &order.Quote{}
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetBody(`{"origin_address": "1 Austin Road West, Tsim Sha Tsui", "destination": ["1.00", "0.00"]}`).
+			SetResult(quote).
+			Post(fmt.Sprintf("%s/quotes", getBaseUrl()))
+
+		assert.Equal(t, 200, resp.StatusCode())
+		assert.Equal(t, int64(1704), quote.Price)
+		assert.Equal(t, "HKD", quote.Currency)
+		assert.Equal(t, "it-1", quote.TariffVersion)
+		assert.Equal(t, "1 Austin Rd W, Tsim Sha Tsui, Hong Kong", quote.OriginAddress)
+		assert.Equal(t, true, quote.ExpiresAt.After(time.Now()))
+	})
+
+	t.Run("GIVEN_a_quote_WHEN_place_order_from_it_twice_THEN_the_second_should_be_rejected", func(t *testing.T) {
+
+		quote := &order.Quote{}
+		_, _ = client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetBody(`{"origin": ["0.00", "0.00"], "destination": ["1.00", "0.00"]}`).
+			SetResult(quote).
+			Post(fmt.Sprintf("%s/quotes", getBaseUrl()))
+
+		placeOrderResponose := &rest.PlaceOrderReponse{}
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetBody(fmt.Sprintf(`{"quote_id": %q}`, quote.ID)).
+			SetResult(placeOrderResponose).
+			Post(fmt.Sprintf("%s/orders", getBaseUrl()))
+
+		assert.Equal(t, 200, resp.StatusCode())
+		assert.Equal(t, quote.ID, placeOrderResponose.QuoteID)
+		assert.Equal(t, quote.Price, placeOrderResponose.Price)
+		assert.Equal(t, quote.Distance, placeOrderResponose.Distance)
+
+		resp, _ = client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetBody(fmt.Sprintf(`{"quote_id": %q}`, quote.ID)).
+			Post(fmt.Sprintf("%s/orders", getBaseUrl()))
+
+		assert.Equal(t, 422, resp.StatusCode())
+		assert.Equal(t, true, strings.Contains(string(resp.Body()), "quote already used"))
+	})
+}
+
+func Test_ServiceAreas(t *testing.T) {
+
+	client := resty.New()
+
+	t.Run("GIVEN_service_area_WHEN_place_order_outside_THEN_unprocessable_entity_should_be_returned", func(t *testing.T) {
+
+		area := servicearea.ServiceArea{}
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("ADMIN_API_KEY", "admin-key")).
+			SetHeader("X-Tenant-ID", "brand-a").
+			SetBody(`{"name": "Null Island", "geometry": {"type": "Polygon", "coordinates": [[[-1, -1], [1, -1], [1, 2], [-1, 2], [-1, -1]]]}}`).
+			SetResult(&area).
+			Post(fmt.Sprintf("%s/admin/service-areas", getBaseUrl()))
+		assert.Equal(t, 201, resp.StatusCode())
+		defer client.R().
+			SetHeader("X-API-Key", getAPIKey("ADMIN_API_KEY", "admin-key")).
+			SetHeader("X-Tenant-ID", "brand-a").
+			Delete(fmt.Sprintf("%s/admin/service-areas/%d", getBaseUrl(), area.ID))
+
+		placeOrderResponose := &rest.PlaceOrderReponse{}
+		resp = placeOrder(placeOrderResponose, client)
+		assert.Equal(t, 200, resp.StatusCode())
+
+		resp, _ = client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetBody(`{"origin": ["0.00", "0.00"], "destination": ["10.00", "10.00"]}`).
+			Post(fmt.Sprintf("%s/orders", getBaseUrl()))
+		assert.Equal(t, 422, resp.StatusCode())
+		assert.Equal(t, true, strings.Contains(string(resp.Body()), "destination: outside the service area"))
+	})
+
+	t.Run("GIVEN_merchant_WHEN_create_service_area_THEN_forbidden_should_be_returned", func(t *testing.T) {
+
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetBody(`{"name": "Null Island", "geometry": {"type": "Polygon", "coordinates": [[[-1, -1], [1, -1], [1, 2], [-1, -1]]]}}`).
+			Post(fmt.Sprintf("%s/admin/service-areas", getBaseUrl()))
+		assert.Equal(t, 403, resp.StatusCode())
+	})
+}
+
+func Test_BusinessRules(t *testing.T) {
+
+	client := resty.New()
+
+	t.Run("GIVEN_same_origin_and_destination_WHEN_place_order_THEN_unprocessable_entity_with_violations_should_be_returned", func(t *testing.T) {
+
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetBody(`{"origin": ["0.00", "0.00"], "destination": ["0.00", "0.00"]}`).
+			Post(fmt.Sprintf("%s/orders", getBaseUrl()))
+		assert.Equal(t, 422, resp.StatusCode())
+		assert.Equal(t, true, strings.Contains(string(resp.Body()), `"rule":"distinct_ends"`))
 	})
 }
 
@@ -105,9 +288,341 @@ func Test_TakeOrder(t *testing.T) {
 	})
 }
 
+func Test_MultiStopOrders(t *testing.T) {
+
+	client := resty.New()
+
+	t.Run("GIVEN_multi_stop_order_taken_WHEN_complete_stop_twice_THEN_conflict_should_be_returned", func(t *testing.T) {
+
+		placeOrderResponose := &rest.PlaceOrderReponse{}
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetBody(`{"stops": [{"type": "pickup", "coordinates": ["0.00", "0.00"]}, {"type": "dropoff", "coordinates": ["0.50", "0.00"]}, {"type": "dropoff", "coordinates": ["1.00", "0.00"]}]}`).
+			SetResult(placeOrderResponose).
+			Post(fmt.Sprintf("%s/orders", getBaseUrl()))
+		assert.Equal(t, 200, resp.StatusCode())
+		assert.Equal(t, 3, len(placeOrderResponose.Stops))
+
+		orderId := placeOrderResponose.ID
+		takeOrder(orderId, &rest.TakeOrderResponse{}, client)
+
+		stop := &order.Stop{}
+		resp = completeStop(orderId, 1, stop, client)
+		assert.Equal(t, 200, resp.StatusCode())
+		assert.Equal(t, true, stop.CompletedAt != nil)
+
+		resp = completeStop(orderId, 1, &order.Stop{}, client)
+		assert.Equal(t, 409, resp.StatusCode())
+	})
+}
+
+func Test_ScheduledOrders(t *testing.T) {
+
+	client := resty.New()
+
+	t.Run("GIVEN_order_scheduled_later_WHEN_courier_gets_or_takes_order_THEN_it_should_be_hidden", func(t *testing.T) {
+
+		placeOrderResponose := &rest.PlaceOrderReponse{}
+		resp := placeScheduledOrder(time.Now().Add(2*time.Hour), placeOrderResponose, client)
+		assert.Equal(t, 200, resp.StatusCode())
+		assert.Equal(t, order.StatusScheduled, placeOrderResponose.Status)
+		assert.Equal(t, true, placeOrderResponose.ScheduledFor != nil)
+
+		resp, _ = client.R().
+			SetHeader("X-API-Key", getAPIKey("COURIER_API_KEY", "courier-key")).
+			Get(fmt.Sprintf("%s/orders/%d", getBaseUrl(), placeOrderResponose.ID))
+		assert.Equal(t, 403, resp.StatusCode())
+
+		resp = takeOrder(placeOrderResponose.ID, &rest.TakeOrderResponse{}, client)
+		assert.Equal(t, 404, resp.StatusCode())
+	})
+
+	t.Run("GIVEN_order_scheduled_within_lead_time_WHEN_scheduler_polls_THEN_order_should_be_released", func(t *testing.T) {
+
+		placeOrderResponose := &rest.PlaceOrderReponse{}
+		resp := placeScheduledOrder(time.Now().Add(time.Minute), placeOrderResponose, client)
+		assert.Equal(t, 200, resp.StatusCode())
+
+		released := &order.Order{}
+		timeout := time.After(10 * time.Second)
+		for released.Status != order.StatusUnassigned {
+			select {
+			case <-time.After(500 * time.Millisecond):
+				client.R().
+					SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+					SetResult(released).
+					Get(fmt.Sprintf("%s/orders/%d", getBaseUrl(), placeOrderResponose.ID))
+			case <-timeout:
+				t.Fatalf("order %d not released", placeOrderResponose.ID)
+			}
+		}
+
+		resp = takeOrder(placeOrderResponose.ID, &rest.TakeOrderResponse{}, client)
+		assert.Equal(t, 200, resp.StatusCode())
+	})
+}
+
+func Test_Authentication(t *testing.T) {
+
+	client := resty.New()
+
+	t.Run("GIVEN_no_api_key_WHEN_list_order_THEN_unauthorized_should_be_returned", func(t *testing.T) {
+
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			Get(fmt.Sprintf("%s/orders?page=1&limit=5", getBaseUrl()))
+
+		assert.Equal(t, 401, resp.StatusCode())
+		assert.Equal(t, "401", resp.Header().Get("HTTP"))
+	})
+
+	t.Run("GIVEN_courier_api_key_WHEN_place_order_THEN_forbidden_should_be_returned", func(t *testing.T) {
+
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("COURIER_API_KEY", "courier-key")).
+			SetBody(`{"origin": ["0.00", "0.00"], "destination": ["1.00", "0.00"]}`).
+			Post(fmt.Sprintf("%s/orders", getBaseUrl()))
+
+		assert.Equal(t, 403, resp.StatusCode())
+		assert.Equal(t, "403", resp.Header().Get("HTTP"))
+	})
+}
+
+func Test_OpenAPI(t *testing.T) {
+
+	client := resty.New()
+
+	t.Run("GIVEN_no_api_key_WHEN_get_spec_THEN_spec_should_be_returned", func(t *testing.T) {
+
+		resp, _ := client.R().Get(fmt.Sprintf("%s/openapi.json", getBaseUrl()))
+
+		var spec map[string]interface{}
+		err := json.Unmarshal(resp.Body(), &spec)
+
+		assert.Equal(t, 200, resp.StatusCode())
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, "3.0.3", spec["openapi"])
+	})
+
+	t.Run("GIVEN_invalid_body_WHEN_take_order_THEN_bad_request_should_be_returned", func(t *testing.T) {
+
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("COURIER_API_KEY", "courier-key")).
+			SetBody(`{"status":"DELIVERED"}`).
+			Patch(fmt.Sprintf("%s/orders/1", getBaseUrl()))
+
+		assert.Equal(t, 400, resp.StatusCode())
+		assert.Equal(t, "400", resp.Header().Get("HTTP"))
+	})
+}
+
+func Test_TenantIsolation(t *testing.T) {
+
+	client := resty.New()
+
+	t.Run("GIVEN_orders_of_another_tenant_WHEN_list_order_THEN_empty_array_json_should_be_returned", func(t *testing.T) {
+
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("ADMIN_API_KEY", "admin-key")).
+			SetHeader("X-Tenant-ID", "brand-b").
+			Get(fmt.Sprintf("%s/orders?page=1&limit=5", getBaseUrl()))
+
+		assert.Equal(t, 200, resp.StatusCode())
+		assert.Equal(t, "[]", string(resp.Body()))
+	})
+
+	t.Run("GIVEN_order_of_another_tenant_WHEN_get_order_THEN_not_found_should_be_returned", func(t *testing.T) {
+
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("ADMIN_API_KEY", "admin-key")).
+			SetHeader("X-Tenant-ID", "brand-b").
+			Get(fmt.Sprintf("%s/orders/1", getBaseUrl()))
+
+		assert.Equal(t, 404, resp.StatusCode())
+		assert.Equal(t, "404", resp.Header().Get("HTTP"))
+	})
+
+	t.Run("GIVEN_tenant_bound_api_key_WHEN_other_tenant_requested_THEN_forbidden_should_be_returned", func(t *testing.T) {
+
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetHeader("X-Tenant-ID", "brand-b").
+			Get(fmt.Sprintf("%s/orders?page=1&limit=5", getBaseUrl()))
+
+		assert.Equal(t, 403, resp.StatusCode())
+		assert.Equal(t, "403", resp.Header().Get("HTTP"))
+	})
+}
+
+func Test_OrderEvents(t *testing.T) {
+
+	client := resty.New()
+
+	t.Run("GIVEN_order_placed_and_taken_WHEN_stream_events_from_start_THEN_both_events_should_be_streamed", func(t *testing.T) {
+
+		placeOrderResponose := &rest.PlaceOrderReponse{}
+		placeOrder(placeOrderResponose, client)
+		takeOrder(placeOrderResponose.ID, &rest.TakeOrderResponse{}, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/orders/events", getBaseUrl()), nil)
+		req.Header.Set("X-API-Key", getAPIKey("ADMIN_API_KEY", "admin-key"))
+		req.Header.Set("X-Tenant-ID", "brand-a")
+		req.Header.Set("Last-Event-ID", "0")
+		resp, err := http.DefaultClient.Do(req)
+		assert.Equal(t, nil, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		data := fmt.Sprintf(`data:{"id":%d,`, placeOrderResponose.ID)
+		seen := map[string]bool{}
+		event := ""
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() && len(seen) < 2 {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				event = strings.TrimPrefix(line, "event:")
+			case strings.HasPrefix(line, data):
+				seen[event] = true
+			}
+		}
+
+		assert.Equal(t, true, seen[order.EventOrderCreated])
+		assert.Equal(t, true, seen[order.EventOrderStatusChanged])
+	})
+}
+
+func Test_Dispatch(t *testing.T) {
+
+	client := resty.New()
+
+	t.Run("GIVEN_courier_connected_WHEN_order_placed_and_offer_accepted_THEN_order_should_be_taken", func(t *testing.T) {
+
+		header := http.Header{}
+		header.Set("X-API-Key", getAPIKey("COURIER_API_KEY", "courier-key"))
+		dispatchUrl := "ws" + strings.TrimPrefix(getBaseUrl(), "http") + "/orders/dispatch"
+		conn, _, err := websocket.DefaultDialer.Dial(dispatchUrl, header)
+		if err != nil {
+			t.Fatalf("fail to dial dispatch channel: %v", err)
+		}
+		defer conn.Close()
+
+		placeOrderResponose := &rest.PlaceOrderReponse{}
+		placeOrder(placeOrderResponose, client)
+
+		_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		offer := ws.Message{}
+		for offer.OrderID != int64(placeOrderResponose.ID) {
+			offer = ws.Message{}
+			if err = conn.ReadJSON(&offer); err != nil {
+				t.Fatalf("fail to read offer: %v", err)
+			}
+			assert.Equal(t, ws.MessageOffer, offer.Type)
+		}
+
+		_ = conn.WriteJSON(ws.Message{Type: ws.MessageAccept, OrderID: offer.OrderID})
+		reply := ws.Message{}
+		for reply.Type == "" || reply.Type == ws.MessageOffer {
+			reply = ws.Message{}
+			if err = conn.ReadJSON(&reply); err != nil {
+				t.Fatalf("fail to read reply: %v", err)
+			}
+		}
+		assert.Equal(t, ws.MessageAccepted, reply.Type)
+
+		resp := takeOrder(placeOrderResponose.ID, &rest.TakeOrderResponse{}, client)
+		assert.Equal(t, 409, resp.StatusCode())
+	})
+}
+
+func Test_Webhooks(t *testing.T) {
+
+	// the app must be able to reach this process, e.g. http://integration-tests:8081 in docker
+	receiverUrl, isFound := os.LookupEnv("WEBHOOK_RECEIVER_URL")
+	if !isFound {
+		t.Skip("WEBHOOK_RECEIVER_URL not set")
+	}
+
+	client := resty.New()
+
+	t.Run("GIVEN_subscription_WHEN_order_placed_THEN_signed_event_should_be_delivered_and_logged", func(t *testing.T) {
+
+		received := make(chan []byte, 10)
+		secret := "integration-secret"
+		u, _ := url.Parse(receiverUrl)
+		listener, err := net.Listen("tcp", ":"+u.Port())
+		if err != nil {
+			t.Fatalf("fail to listen for webhooks: %v", err)
+		}
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+			if !webhook.Verify(secret, timestamp, body, r.Header.Get(webhook.HeaderSignature)) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			received <- body
+		})}
+		go server.Serve(listener)
+		defer server.Close()
+
+		sub := webhook.Subscription{}
+		resp, _ := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetBody(fmt.Sprintf(`{"url": "%s", "events": ["order.created"], "secret": "%s"}`, receiverUrl, secret)).
+			SetResult(&sub).
+			Post(fmt.Sprintf("%s/webhooks", getBaseUrl()))
+		assert.Equal(t, 201, resp.StatusCode())
+
+		placeOrderResponose := &rest.PlaceOrderReponse{}
+		placeOrder(placeOrderResponose, client)
+
+		var payload struct {
+			Type  string      `json:"type"`
+			Order order.Order `json:"order"`
+		}
+		timeout := time.After(10 * time.Second)
+		for payload.Order.ID != int64(placeOrderResponose.ID) {
+			select {
+			case body := <-received:
+				_ = json.Unmarshal(body, &payload)
+			case <-timeout:
+				t.Fatalf("order %d not delivered", placeOrderResponose.ID)
+			}
+		}
+		assert.Equal(t, order.EventOrderCreated, payload.Type)
+
+		deliveries := []webhook.Delivery{}
+		resp, _ = client.R().
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			SetResult(&deliveries).
+			Get(fmt.Sprintf("%s/webhooks/%d/deliveries?page=1&limit=10", getBaseUrl(), sub.ID))
+		assert.Equal(t, 200, resp.StatusCode())
+		assert.Equal(t, true, len(deliveries) > 0)
+
+		resp, _ = client.R().
+			SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+			Delete(fmt.Sprintf("%s/webhooks/%d", getBaseUrl(), sub.ID))
+		assert.Equal(t, 204, resp.StatusCode())
+	})
+}
+
 func listOrders(page int, limit int, client *resty.Client) (resp *resty.Response) {
 	resp, _ = client.R().
 		SetHeader("Content-Type", "application/json").
+		SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
 		Get(fmt.Sprintf("%s/orders?page=%d&limit=%d", getBaseUrl(), page, limit))
 
 	return
@@ -116,6 +631,7 @@ func listOrders(page int, limit int, client *resty.Client) (resp *resty.Response
 func placeOrder(placeOrderResponose *rest.PlaceOrderReponse, client *resty.Client) (resp *resty.Response) {
 	resp, _ = client.R().
 		SetHeader("Content-Type", "application/json").
+		SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
 		SetBody(`{"origin": ["0.00", "0.00"], "destination": ["1.00", "0.00"]}`).
 		SetResult(placeOrderResponose).
 		Post(fmt.Sprintf("%s/orders", getBaseUrl()))
@@ -123,9 +639,22 @@ func placeOrder(placeOrderResponose *rest.PlaceOrderReponse, client *resty.Clien
 	return
 }
 
+func placeScheduledOrder(scheduledFor time.Time, placeOrderResponose *rest.PlaceOrderReponse, client *resty.Client) (resp *resty.Response) {
+	resp, _ = client.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-API-Key", getAPIKey("MERCHANT_API_KEY", "merchant-key")).
+		SetBody(fmt.Sprintf(`{"origin": ["0.00", "0.00"], "destination": ["1.00", "0.00"], "scheduled_for": "%s"}`,
+			scheduledFor.UTC().Format(time.RFC3339))).
+		SetResult(placeOrderResponose).
+		Post(fmt.Sprintf("%s/orders", getBaseUrl()))
+
+	return
+}
+
 func takeOrder(orderId int, takeOrderResponse *rest.TakeOrderResponse, client *resty.Client) (resp *resty.Response) {
 	resp, _ = client.R().
 		SetHeader("Content-Type", "application/json").
+		SetHeader("X-API-Key", getAPIKey("COURIER_API_KEY", "courier-key")).
 		SetBody(`{"status":"TAKEN"}`).
 		SetResult(takeOrderResponse).
 		Patch(fmt.Sprintf("%s/orders/%d", getBaseUrl(), orderId))
@@ -133,6 +662,25 @@ func takeOrder(orderId int, takeOrderResponse *rest.TakeOrderResponse, client *r
 	return
 }
 
+func completeStop(orderId int, index int, stop *order.Stop, client *resty.Client) (resp *resty.Response) {
+	resp, _ = client.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-API-Key", getAPIKey("COURIER_API_KEY", "courier-key")).
+		SetBody(`{"status":"COMPLETED"}`).
+		SetResult(stop).
+		Patch(fmt.Sprintf("%s/orders/%d/stops/%d", getBaseUrl(), orderId, index))
+
+	return
+}
+
+func getAPIKey(envKey string, defaultKey string) string {
+	if key, isFound := os.LookupEnv(envKey); isFound {
+		return key
+	}
+
+	return defaultKey
+}
+
 func getBaseUrl() string {
 	appUrl := "http://localhost:8080"
 
@@ -1,14 +1,107 @@
 package logger
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
+
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	FormatJSON    string = "json"
+	FormatConsole string = "console"
+)
+
+var (
+	Logger *zap.Logger
+
+	// Level is the runtime adjustable level of Logger
+	Level = zap.NewAtomicLevelAt(zap.InfoLevel)
 )
 
-var Logger *zap.Logger
+// Config represents the settings used to build Logger
+type Config struct {
+	Level              string
+	Format             string
+	Outputs            []string
+	DisableSampling    bool
+	SamplingInitial    int
+	SamplingThereafter int
+}
+
+// Init builds Logger from cfg, empty fields fall back to zap production defaults
+func Init(cfg Config) error {
+	zapCfg, err := buildZapConfig(cfg)
+	if err != nil {
+		return err
+	}
 
-func Init() {
-	zapLogger, _ := zap.NewProduction()
-	defer zapLogger.Sync() // flushes buffer, if any
+	zapLogger, err := zapCfg.Build()
+	if err != nil {
+		return fmt.Errorf("fail to build logger: %w", err)
+	}
 
 	Logger = zapLogger
+	return nil
+}
+
+// Sync flushes buffered log entries, if any
+func Sync() {
+	if Logger != nil {
+		_ = Logger.Sync()
+	}
+}
+
+// LevelHandler returns a http.Handler reporting the current level on GET and
+// changing it on PUT with a body like {"level":"debug"}
+func LevelHandler() http.Handler {
+	return Level
+}
+
+func buildZapConfig(cfg Config) (zap.Config, error) {
+	zapCfg := zap.NewProductionConfig()
+
+	if cfg.Level != "" {
+		var lvl zapcore.Level
+		if err := lvl.UnmarshalText([]byte(strings.ToLower(cfg.Level))); err != nil {
+			return zapCfg, fmt.Errorf("invalid log level %q", cfg.Level)
+		}
+		Level.SetLevel(lvl)
+	} else {
+		Level.SetLevel(zapCfg.Level.Level())
+	}
+	zapCfg.Level = Level
+
+	switch strings.ToLower(cfg.Format) {
+	case "":
+	case FormatJSON:
+		zapCfg.Encoding = FormatJSON
+		zapCfg.EncoderConfig = zap.NewProductionEncoderConfig()
+	case FormatConsole:
+		zapCfg.Encoding = FormatConsole
+		zapCfg.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+	default:
+		return zapCfg, fmt.Errorf("invalid log format %q", cfg.Format)
+	}
+
+	if len(cfg.Outputs) > 0 {
+		zapCfg.OutputPaths = cfg.Outputs
+	}
+
+	if cfg.SamplingInitial < 0 || cfg.SamplingThereafter < 0 {
+		return zapCfg, fmt.Errorf("invalid log sampling %d/%d", cfg.SamplingInitial, cfg.SamplingThereafter)
+	}
+	if cfg.SamplingInitial > 0 {
+		zapCfg.Sampling.Initial = cfg.SamplingInitial
+	}
+	if cfg.SamplingThereafter > 0 {
+		zapCfg.Sampling.Thereafter = cfg.SamplingThereafter
+	}
+	if cfg.DisableSampling {
+		zapCfg.Sampling = nil
+	}
+
+	return zapCfg, nil
 }
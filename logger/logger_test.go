@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"go.uber.org/zap"
+)
+
+func TestInit(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		err := Init(Config{})
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, zap.InfoLevel, Level.Level())
+	})
+
+	t.Run("level-and-format", func(t *testing.T) {
+		err := Init(Config{Level: "DEBUG", Format: FormatConsole, DisableSampling: true})
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, zap.DebugLevel, Level.Level())
+	})
+
+	t.Run("invalid-level", func(t *testing.T) {
+		err := Init(Config{Level: "loud"})
+
+		assert.Equal(t, false, err == nil)
+	})
+
+	t.Run("invalid-format", func(t *testing.T) {
+		err := Init(Config{Format: "xml"})
+
+		assert.Equal(t, false, err == nil)
+	})
+
+	t.Run("invalid-sampling", func(t *testing.T) {
+		err := Init(Config{SamplingInitial: -1})
+
+		assert.Equal(t, false, err == nil)
+	})
+}
+
+func TestLevelHandler(t *testing.T) {
+	_ = Init(Config{Level: "info"})
+
+	req, _ := http.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"warn"}`))
+	w := httptest.NewRecorder()
+	LevelHandler().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, zap.WarnLevel, Level.Level())
+	assert.Equal(t, false, Logger.Core().Enabled(zap.InfoLevel))
+}
@@ -1,24 +1,210 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
 
+	"github.com/imylam/delivery-test/common/auth"
 	"github.com/imylam/delivery-test/configs"
 	"github.com/imylam/delivery-test/db"
+	"github.com/imylam/delivery-test/grpcserver"
 	"github.com/imylam/delivery-test/httpserver"
 	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/order/infrastructure/googlemap"
+	_orderRepo "github.com/imylam/delivery-test/order/infrastructure/mysql"
+	"github.com/imylam/delivery-test/order/scheduler"
+	_orderUsecase "github.com/imylam/delivery-test/order/usecase"
+	_outboxRepo "github.com/imylam/delivery-test/outbox/infrastructure/mysql"
+	"github.com/imylam/delivery-test/outbox/relay"
+	"github.com/imylam/delivery-test/outbox/sink"
+	"github.com/imylam/delivery-test/pricing"
+	"github.com/imylam/delivery-test/rules"
+	"github.com/imylam/delivery-test/servicearea"
+	_serviceAreaRepo "github.com/imylam/delivery-test/servicearea/infrastructure/mysql"
+	_serviceAreaUsecase "github.com/imylam/delivery-test/servicearea/usecase"
+	"github.com/imylam/delivery-test/webhook"
+	_webhookRepo "github.com/imylam/delivery-test/webhook/infrastructure/mysql"
+	_webhookUsecase "github.com/imylam/delivery-test/webhook/usecase"
+	"github.com/imylam/delivery-test/webhook/worker"
 
 	"github.com/asaskevich/govalidator"
+	"go.uber.org/zap"
 )
 
+// integrationTestDistance and integrationTestDuration are the distance and
+// travel time of every order placed during integration tests
+const (
+	integrationTestDistance int           = 10
+	integrationTestDuration time.Duration = 2 * time.Minute
+)
+
+const usage string = `Usage:
+  app [flags]               start the server
+  app config print [flags]  print the effective config with secrets redacted
+
+Run "app -h" to list the available flags.`
+
 func main() {
-	logger.Init()
-	db.InitDBConn()
+	args := os.Args[1:]
+	printConfig := false
+	if len(args) > 0 && args[0] == "config" {
+		if len(args) < 2 || args[1] != "print" {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		printConfig = true
+		args = args[2:]
+	}
+
+	cfg, err := configs.Load(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if printConfig {
+		if err := configs.Print(os.Stdout, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := logger.Init(getLoggerConfig(cfg.Log)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	db.InitDBConn(cfg.MySQL)
 	govalidator.SetFieldsRequiredByDefault(true)
 
-	router := httpserver.InitRoutes()
+	authenticator, err := auth.NewAuthenticator(cfg.Auth)
+	if err != nil {
+		logger.Logger.Fatal("Error creating authenticator", zap.String("error", err.Error()))
+	}
+	orderUC := newOrderUsecase(cfg)
+
+	if cfg.GRPC.Port != 0 {
+		go serveGRPC(cfg, authenticator, orderUC)
+	}
 
-	port := configs.Get(configs.KeyAppPort)
+	webhookUC := newWebhookUsecase(cfg)
+
+	if cfg.Outbox.PollInterval > 0 {
+		go newOutboxRelay(cfg).Run(context.Background())
+	}
+
+	if cfg.Scheduler.PollInterval > 0 {
+		go newScheduler(cfg).Run(context.Background())
+	}
+
+	serviceAreaUC := newServiceAreaUsecase()
+
+	router := httpserver.InitRoutes(cfg, authenticator, orderUC, webhookUC, serviceAreaUC)
+
+	port := strconv.Itoa(cfg.App.Port)
 	logger.Logger.Info(fmt.Sprintf("Starting server on port %s...", port))
 	router.Run(":" + port)
 }
+
+func newOrderUsecase(cfg *configs.Config) order.OrderUsecase {
+	var mapClient googlemap.MapClient
+	var geocoder googlemap.Geocoder
+	if cfg.IsIntegrationTest() {
+		mapClient = googlemap.NewStaticMapClient(integrationTestDistance, integrationTestDuration)
+		fixtures, err := os.ReadFile(cfg.GoogleMap.GeocodeFixtures)
+		if err == nil {
+			geocoder, err = googlemap.NewFixtureGeocoder(fixtures)
+		}
+		if err != nil {
+			logger.Logger.Fatal("Error loading geocode fixtures", zap.String("error", err.Error()))
+		}
+	} else {
+		mapClient = googlemap.NewMapClient(cfg.GoogleMap)
+		geocoder = googlemap.NewGeocoder(cfg.GoogleMap)
+	}
+
+	pricer, err := pricing.New(cfg.Pricing)
+	if err != nil {
+		logger.Logger.Fatal("Error creating pricer", zap.String("error", err.Error()))
+	}
+
+	validator, err := rules.New(cfg.Rules)
+	if err != nil {
+		logger.Logger.Fatal("Error creating business rules", zap.String("error", err.Error()))
+	}
+
+	mysqlConn := db.GetDBConnection()
+	orderRepo := _orderRepo.NewOrderRepositoryMysql(mysqlConn)
+	orderEventRepo := _orderRepo.NewOrderEventRepositoryMysql(mysqlConn)
+	quoteRepo := _orderRepo.NewQuoteRepositoryMysql(mysqlConn, cfg.Pricing.QuoteTTL)
+	serviceAreaRepo := _serviceAreaRepo.NewServiceAreaRepositoryMysql(mysqlConn)
+	return _orderUsecase.NewOrderUsecase(orderRepo, orderEventRepo, quoteRepo, serviceAreaRepo,
+		mapClient, geocoder, pricer, validator, cfg.Orders.MaxBatchSize)
+}
+
+func newServiceAreaUsecase() servicearea.ServiceAreaUsecase {
+	serviceAreaRepo := _serviceAreaRepo.NewServiceAreaRepositoryMysql(db.GetDBConnection())
+	return _serviceAreaUsecase.NewServiceAreaUsecase(serviceAreaRepo)
+}
+
+// newWebhookUsecase builds the webhook usecase and, unless disabled, starts
+// the worker delivering order events to the subscriptions
+func newWebhookUsecase(cfg *configs.Config) webhook.WebhookUsecase {
+	mysqlConn := db.GetDBConnection()
+	subscriptionRepo := _webhookRepo.NewSubscriptionRepositoryMysql(mysqlConn)
+	deliveryRepo := _webhookRepo.NewDeliveryRepositoryMysql(mysqlConn)
+
+	if cfg.Webhook.PollInterval > 0 {
+		orderEventRepo := _orderRepo.NewOrderEventRepositoryMysql(mysqlConn)
+		go worker.NewWorker(subscriptionRepo, deliveryRepo, orderEventRepo, cfg.Webhook).Run(context.Background())
+	}
+
+	return _webhookUsecase.NewWebhookUsecase(subscriptionRepo, deliveryRepo, cfg.Webhook)
+}
+
+func newOutboxRelay(cfg *configs.Config) *relay.Relay {
+	outboxSink, err := sink.New(cfg.Outbox, cfg.Events)
+	if err != nil {
+		logger.Logger.Fatal("Error creating outbox sink", zap.String("error", err.Error()))
+	}
+
+	outboxRepo := _outboxRepo.NewOutboxRepositoryMysql(db.GetDBConnection())
+	return relay.NewRelay(outboxRepo, outboxSink, cfg.Outbox)
+}
+
+// newScheduler builds the scheduler releasing the scheduled orders to couriers
+func newScheduler(cfg *configs.Config) *scheduler.Scheduler {
+	scheduleRepo := _orderRepo.NewScheduleRepositoryMysql(db.GetDBConnection())
+	return scheduler.NewScheduler(scheduleRepo, cfg.Scheduler)
+}
+
+func serveGRPC(cfg *configs.Config, authenticator auth.Authenticator, orderUC order.OrderUsecase) {
+	port := strconv.Itoa(cfg.GRPC.Port)
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		logger.Logger.Fatal("Error listening for gRPC", zap.String("error", err.Error()))
+	}
+
+	logger.Logger.Info(fmt.Sprintf("Starting gRPC server on port %s...", port))
+	if err := grpcserver.InitServer(cfg.GRPC, authenticator, orderUC).Serve(listener); err != nil {
+		logger.Logger.Fatal("Error serving gRPC", zap.String("error", err.Error()))
+	}
+}
+
+func getLoggerConfig(cfg configs.LogConfig) logger.Config {
+	return logger.Config{
+		Level:              cfg.Level,
+		Format:             cfg.Format,
+		Outputs:            cfg.Outputs,
+		DisableSampling:    !cfg.Sampling,
+		SamplingInitial:    cfg.SamplingInitial,
+		SamplingThereafter: cfg.SamplingThereafter,
+	}
+}
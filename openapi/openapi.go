@@ -0,0 +1,50 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	//go:embed openapi.yaml
+	specYAML []byte
+
+	//go:embed docs.html
+	docsHTML []byte
+)
+
+// Load parses the embedded OpenAPI document and checks it is well formed
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(specYAML)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load OpenAPI spec: %w", err)
+	}
+	if err = doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	return doc, nil
+}
+
+// SpecHandler serves doc as JSON
+func SpecHandler(doc *openapi3.T) (gin.HandlerFunc, error) {
+	specJSON, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", specJSON)
+	}, nil
+}
+
+// DocsHandler serves a Swagger UI page rendering /openapi.json
+func DocsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsHTML)
+}
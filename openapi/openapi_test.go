@@ -0,0 +1,117 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load()
+
+	assert.Equal(t, true, err == nil)
+	assert.Equal(t, true, doc.Paths.Find("/orders") != nil)
+	assert.Equal(t, true, doc.Paths.Find("/orders/{id}") != nil)
+}
+
+func TestSpecHandler(t *testing.T) {
+	doc, _ := Load()
+	handler, err := SpecHandler(doc)
+	if err != nil {
+		t.Fatalf("unexpected error '%s' when encoding spec", err.Error())
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/openapi.json", handler)
+
+	req, _ := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var body map[string]interface{}
+	err = json.Unmarshal(w.Body.Bytes(), &body)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, true, err == nil)
+	assert.Equal(t, "3.0.3", body["openapi"])
+}
+
+func TestFindOperation(t *testing.T) {
+	doc, _ := Load()
+	v := NewValidator(doc, true)
+
+	t.Run("documented", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPatch, "/orders/1", nil)
+
+		op, found := v.FindOperation(req, "/orders/:id", map[string]string{"id": "1"})
+
+		assert.Equal(t, true, found)
+		assert.Equal(t, "takeOrder", op.input.Route.Operation.OperationID)
+	})
+
+	t.Run("undocumented-method", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/orders/1", nil)
+
+		_, found := v.FindOperation(req, "/orders/:id", map[string]string{"id": "1"})
+
+		assert.Equal(t, false, found)
+	})
+
+	t.Run("undocumented-path", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/admin/log-level", nil)
+
+		_, found := v.FindOperation(req, "/admin/log-level", nil)
+
+		assert.Equal(t, false, found)
+	})
+}
+
+func TestStreams(t *testing.T) {
+	doc, _ := Load()
+	v := NewValidator(doc, true)
+
+	req, _ := http.NewRequest(http.MethodGet, "/orders/events", nil)
+	op, found := v.FindOperation(req, "/orders/events", nil)
+	assert.Equal(t, true, found)
+	assert.Equal(t, true, v.Streams(op))
+
+	req, _ = http.NewRequest(http.MethodGet, "/orders/1", nil)
+	op, _ = v.FindOperation(req, "/orders/:id", map[string]string{"id": "1"})
+	assert.Equal(t, false, v.Streams(op))
+}
+
+func TestValidateResponse(t *testing.T) {
+	doc, _ := Load()
+	v := NewValidator(doc, true)
+	req, _ := http.NewRequest(http.MethodGet, "/orders/1", nil)
+	op, _ := v.FindOperation(req, "/orders/:id", map[string]string{"id": "1"})
+	header := http.Header{"Content-Type": []string{"application/json"}}
+
+	t.Run("match", func(t *testing.T) {
+		body := []byte(`{"id":1,"distance":100,"status":"UNASSIGNED","merchant_id":"merchant-1"}`)
+
+		err := v.ValidateResponse(req.Context(), op, http.StatusOK, header, body)
+
+		assert.Equal(t, true, err == nil)
+	})
+
+	t.Run("missing-field", func(t *testing.T) {
+		body := []byte(`{"id":1,"status":"UNASSIGNED","merchant_id":"merchant-1"}`)
+
+		err := v.ValidateResponse(req.Context(), op, http.StatusOK, header, body)
+
+		assert.Equal(t, false, err == nil)
+	})
+
+	t.Run("undocumented-status", func(t *testing.T) {
+		err := v.ValidateResponse(req.Context(), op, http.StatusTeapot, header, []byte(`{"error":"teapot"}`))
+
+		assert.Equal(t, false, err == nil)
+	})
+}
@@ -0,0 +1,115 @@
+package openapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// Validator checks requests, and optionally responses, against an OpenAPI document
+type Validator struct {
+	doc               *openapi3.T
+	validateResponses bool
+	options           *openapi3filter.Options
+}
+
+// NewValidator creates a Validator for doc. Credentials are left to the
+// authentication middleware, only their presence in the spec is documented
+func NewValidator(doc *openapi3.T, validateResponses bool) *Validator {
+	return &Validator{
+		doc:               doc,
+		validateResponses: validateResponses,
+		options: &openapi3filter.Options{
+			AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+			IncludeResponseStatus: true,
+		},
+	}
+}
+
+// ValidatesResponses tells whether responses are checked too
+func (v *Validator) ValidatesResponses() bool {
+	return v.validateResponses
+}
+
+// Streams tells whether the operation answers with Server-Sent Events, which
+// cannot be held back until the response is complete to validate it
+func (v *Validator) Streams(op *Operation) bool {
+	for _, ref := range op.input.Route.Operation.Responses {
+		if ref.Value != nil && ref.Value.Content.Get("text/event-stream") != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Operation represents a request matched to an operation of the spec
+type Operation struct {
+	input *openapi3filter.RequestValidationInput
+}
+
+// FindOperation matches a request to the operation documented for method and
+// the gin route pattern, e.g. /orders/:id. It returns false for routes the
+// spec does not document
+func (v *Validator) FindOperation(req *http.Request, pattern string, params map[string]string) (*Operation, bool) {
+	path := toSpecPath(pattern)
+
+	pathItem := v.doc.Paths.Find(path)
+	if pathItem == nil {
+		return nil, false
+	}
+	op := pathItem.GetOperation(req.Method)
+	if op == nil {
+		return nil, false
+	}
+
+	route := &routers.Route{
+		Spec:      v.doc,
+		Path:      path,
+		PathItem:  pathItem,
+		Method:    req.Method,
+		Operation: op,
+	}
+
+	return &Operation{input: &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: params,
+		Route:      route,
+		Options:    v.options,
+	}}, true
+}
+
+// ValidateRequest checks the parameters and body of the request
+func (v *Validator) ValidateRequest(ctx context.Context, op *Operation) error {
+	return openapi3filter.ValidateRequest(ctx, op.input)
+}
+
+// ValidateResponse checks the status, headers and body of a response to the request
+func (v *Validator) ValidateResponse(ctx context.Context, op *Operation, status int, header http.Header, body []byte) error {
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: op.input,
+		Status:                 status,
+		Header:                 header,
+		Options:                v.options,
+	}
+	input.SetBodyBytes(body)
+
+	return openapi3filter.ValidateResponse(ctx, input)
+}
+
+// toSpecPath turns a gin route pattern into an OpenAPI path, /orders/:id
+// becomes /orders/{id}
+func toSpecPath(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
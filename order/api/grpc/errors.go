@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/order/usecase"
+	"go.uber.org/zap"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	errUnauthorized   string = "unauthorized"
+	errForbidden      string = "forbidden"
+	errTenantRequired string = "tenant required"
+	errOrderNotFound  string = "order not found"
+	errInternalServer string = "internal server error"
+)
+
+// toStatus converts an error returned by the usecase into a gRPC status, with
+// the same meaning as the status code the REST handlers answer with
+func toStatus(err error, logMsg string) error {
+	switch {
+	case errors.Is(err, auth.ErrNoIdentity):
+		return status.Error(codes.Unauthenticated, errUnauthorized)
+	case errors.Is(err, auth.ErrForbidden):
+		return status.Error(codes.PermissionDenied, errForbidden)
+	case errors.Is(err, auth.ErrNoTenant):
+		return status.Error(codes.InvalidArgument, errTenantRequired)
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, errOrderNotFound)
+	case errors.Is(err, order.ErrOutsideServiceArea), errors.As(err, new(*order.ValidationError)),
+		errors.Is(err, order.ErrAddressNotFound), errors.Is(err, order.ErrAmbiguousAddress):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, order.ErrQuoteNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, order.ErrNoRoute), errors.Is(err, order.ErrQuoteExpired), errors.Is(err, order.ErrQuoteUsed):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case err.Error() == usecase.ErrorOrderTaken:
+		return status.Error(codes.FailedPrecondition, usecase.ErrorOrderTaken)
+	default:
+		logger.Logger.Error(logMsg, zap.String("error", err.Error()))
+		return status.Error(codes.Internal, errInternalServer)
+	}
+}
@@ -0,0 +1,311 @@
+package grpc
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/order/api/grpc/orderpb"
+
+	googlegrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	errInvalidCoordinates    string = "invalid coordinates"
+	errCoordinatesOrAddress  string = "either coordinates or an address, not both"
+	errInvalidAddress        string = "invalid address"
+	errInvalidTravelMode     string = "invalid travel mode"
+	errQuoteOrRoute          string = "either a quote or the ends of the order, not both"
+	errStopsOrEnds           string = "either stops or the ends of the order, not both"
+	errStopCount             string = "an order has 2 to 10 stops"
+	errInvalidStopType       string = "invalid stop type"
+	errStopSequence          string = "the first stop must be a pickup and the last a dropoff"
+	errScheduleInPast        string = "scheduled_for must be in the future"
+	errInvalidResquestParams string = "invalid request params"
+)
+
+// maxAddressLength is the longest address an order can be placed with, in characters
+const maxAddressLength int = 255
+
+// maxStops is the most stops a multi-stop order can have
+const maxStops int = 10
+
+// orderServer represents the gRPC OrderService, backed by the same usecase as the REST handlers
+type orderServer struct {
+	orderpb.UnimplementedOrderServiceServer
+	orderUC order.OrderUsecase
+}
+
+// NewOrderServer will register the OrderService on s
+func NewOrderServer(s googlegrpc.ServiceRegistrar, orderUC order.OrderUsecase) {
+	orderpb.RegisterOrderServiceServer(s, &orderServer{orderUC: orderUC})
+}
+
+func (s *orderServer) PlaceOrder(ctx context.Context, req *orderpb.PlaceOrderRequest) (*orderpb.Order, error) {
+	placement, errMsg := toPlacement(req)
+	if errMsg != "" {
+		return nil, status.Error(codes.InvalidArgument, errMsg)
+	}
+
+	newOrder, err := s.orderUC.PlaceOrder(ctx, placement)
+	if err != nil {
+		return nil, toStatus(err, "fail to place order")
+	}
+
+	return toProtoOrder(newOrder), nil
+}
+
+func (s *orderServer) TakeOrder(ctx context.Context, req *orderpb.TakeOrderRequest) (*orderpb.TakeOrderResponse, error) {
+	if req.GetId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, errInvalidResquestParams)
+	}
+
+	result, err := s.orderUC.TakeOrder(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err, "fail to take order")
+	}
+
+	return &orderpb.TakeOrderResponse{Status: result}, nil
+}
+
+func (s *orderServer) ListOrders(ctx context.Context, req *orderpb.ListOrdersRequest) (*orderpb.ListOrdersResponse, error) {
+	if req.GetPage() < 1 || req.GetLimit() < 1 {
+		return nil, status.Error(codes.InvalidArgument, errInvalidResquestParams)
+	}
+
+	orders, err := s.orderUC.ListOrders(ctx, int(req.GetPage()), int(req.GetLimit()))
+	if err != nil {
+		return nil, toStatus(err, "fail to list orders")
+	}
+
+	resp := &orderpb.ListOrdersResponse{Orders: make([]*orderpb.Order, 0, len(*orders))}
+	for i := range *orders {
+		resp.Orders = append(resp.Orders, toProtoOrder(&(*orders)[i]))
+	}
+
+	return resp, nil
+}
+
+func (s *orderServer) GetOrder(ctx context.Context, req *orderpb.GetOrderRequest) (*orderpb.Order, error) {
+	if req.GetId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, errInvalidResquestParams)
+	}
+
+	orderFound, err := s.orderUC.GetOrder(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err, "fail to get order")
+	}
+
+	return toProtoOrder(orderFound), nil
+}
+
+// toPlacement converts a place order request into a placement, checked as by
+// the REST API. The message returned tells why the request is invalid, it is
+// empty for a valid one
+func toPlacement(req *orderpb.PlaceOrderRequest) (order.Placement, string) {
+	var p order.Placement
+
+	if ts := req.GetScheduledFor(); ts != nil {
+		at := ts.AsTime()
+		if !ts.IsValid() || !at.After(time.Now()) {
+			return p, errScheduleInPast
+		}
+		p.ScheduledFor = &at
+	}
+
+	hasEnds := req.GetOrigin() != nil || req.GetOriginAddress() != "" || req.GetDestination() != nil || req.GetDestinationAddress() != ""
+	hasOptions := req.GetTravelMode() != orderpb.TravelMode_TRAVEL_MODE_UNSPECIFIED ||
+		req.GetAvoidTolls() || req.GetAvoidHighways() || req.GetAvoidFerries()
+	if req.GetQuoteId() != "" {
+		if hasEnds || len(req.GetStops()) > 0 || hasOptions {
+			return p, errQuoteOrRoute
+		}
+		p.QuoteID = req.GetQuoteId()
+		return p, ""
+	}
+
+	var errMsg string
+	if stops := req.GetStops(); len(stops) > 0 {
+		if hasEnds {
+			return p, errStopsOrEnds
+		}
+		if p.Stops, errMsg = toStops(stops); errMsg != "" {
+			return p, errMsg
+		}
+	} else {
+		if p.Origin, p.OriginAddress, errMsg = toEnd(req.GetOrigin(), req.GetOriginAddress()); errMsg != "" {
+			return p, errMsg
+		}
+		if p.Destination, p.DestinationAddress, errMsg = toEnd(req.GetDestination(), req.GetDestinationAddress()); errMsg != "" {
+			return p, errMsg
+		}
+	}
+
+	p.Options = order.RouteOptions{
+		AvoidTolls:    req.GetAvoidTolls(),
+		AvoidHighways: req.GetAvoidHighways(),
+		AvoidFerries:  req.GetAvoidFerries(),
+	}
+	switch req.GetTravelMode() {
+	case orderpb.TravelMode_TRAVEL_MODE_UNSPECIFIED:
+	case orderpb.TravelMode_TRAVEL_MODE_DRIVING:
+		p.Options.Mode = order.TravelModeDriving
+	case orderpb.TravelMode_TRAVEL_MODE_BICYCLING:
+		p.Options.Mode = order.TravelModeBicycling
+	case orderpb.TravelMode_TRAVEL_MODE_WALKING:
+		p.Options.Mode = order.TravelModeWalking
+	case orderpb.TravelMode_TRAVEL_MODE_TWO_WHEELER:
+		p.Options.Mode = order.TravelModeTwoWheeler
+	default:
+		return p, errInvalidTravelMode
+	}
+
+	return p, ""
+}
+
+// toStops converts the stops of a multi-stop order, which starts with a
+// pickup, ends with a dropoff and gives each of its stops like an end
+func toStops(stops []*orderpb.PlaceOrderStop) ([]order.PlacementStop, string) {
+	if len(stops) < 2 || len(stops) > maxStops {
+		return nil, errStopCount
+	}
+
+	placed := make([]order.PlacementStop, len(stops))
+	for i, s := range stops {
+		switch s.GetType() {
+		case orderpb.StopType_STOP_TYPE_PICKUP:
+			placed[i].Type = order.StopPickup
+		case orderpb.StopType_STOP_TYPE_DROPOFF:
+			placed[i].Type = order.StopDropoff
+		default:
+			return nil, errInvalidStopType
+		}
+
+		var errMsg string
+		if placed[i].Coordinates, placed[i].Address, errMsg = toEnd(s.GetCoordinates(), s.GetAddress()); errMsg != "" {
+			return nil, errMsg
+		}
+	}
+	if placed[0].Type != order.StopPickup || placed[len(placed)-1].Type != order.StopDropoff {
+		return nil, errStopSequence
+	}
+
+	return placed, ""
+}
+
+// toEnd converts an end of an order given by either its coordinates or its
+// address, the message returned tells why it is invalid
+func toEnd(ll *orderpb.LatLng, address string) ([]string, string, string) {
+	switch {
+	case address != "" && ll != nil:
+		return nil, "", errCoordinatesOrAddress
+	case address != "":
+		if strings.TrimSpace(address) == "" || utf8.RuneCountInString(address) > maxAddressLength {
+			return nil, "", errInvalidAddress
+		}
+		return nil, address, ""
+	}
+
+	coords, ok := toCoordinates(ll)
+	if !ok {
+		return nil, "", errInvalidCoordinates
+	}
+	return coords, "", ""
+}
+
+// toCoordinates converts a LatLng to the latitude, longitude strings the usecase expects
+func toCoordinates(ll *orderpb.LatLng) ([]string, bool) {
+	if ll == nil {
+		return nil, false
+	}
+	lat, lng := ll.GetLatitude(), ll.GetLongitude()
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil, false
+	}
+
+	return []string{strconv.FormatFloat(lat, 'f', -1, 64), strconv.FormatFloat(lng, 'f', -1, 64)}, true
+}
+
+func toProtoOrder(o *order.Order) *orderpb.Order {
+	resp := &orderpb.Order{
+		Id:                  o.ID,
+		Distance:            int32(o.Distance),
+		Status:              toProtoStatus(o.Status),
+		MerchantId:          o.MerchantID,
+		CourierId:           o.CourierID,
+		TravelMode:          toProtoTravelMode(o.TravelMode),
+		EstimatedDuration:   int32(o.EstimatedDuration),
+		EstimatedDeliveryAt: toProtoTime(o.EstimatedDeliveryAt),
+		Price:               o.Price,
+		Currency:            o.Currency,
+		TariffVersion:       o.TariffVersion,
+		QuoteId:             o.QuoteID,
+		OriginAddress:       o.OriginAddress,
+		DestinationAddress:  o.DestinationAddress,
+		ScheduledFor:        toProtoTime(o.ScheduledFor),
+	}
+	for _, s := range o.Stops {
+		resp.Stops = append(resp.Stops, &orderpb.Stop{
+			Index:       int32(s.Index),
+			Type:        toProtoStopType(s.Type),
+			Address:     s.Address,
+			Distance:    int32(s.Distance),
+			CompletedAt: toProtoTime(s.CompletedAt),
+		})
+	}
+
+	return resp
+}
+
+// toProtoTime converts an optional time, nil when unset
+func toProtoTime(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func toProtoTravelMode(mode string) orderpb.TravelMode {
+	switch mode {
+	case order.TravelModeDriving:
+		return orderpb.TravelMode_TRAVEL_MODE_DRIVING
+	case order.TravelModeBicycling:
+		return orderpb.TravelMode_TRAVEL_MODE_BICYCLING
+	case order.TravelModeWalking:
+		return orderpb.TravelMode_TRAVEL_MODE_WALKING
+	case order.TravelModeTwoWheeler:
+		return orderpb.TravelMode_TRAVEL_MODE_TWO_WHEELER
+	default:
+		return orderpb.TravelMode_TRAVEL_MODE_UNSPECIFIED
+	}
+}
+
+func toProtoStopType(t string) orderpb.StopType {
+	switch t {
+	case order.StopPickup:
+		return orderpb.StopType_STOP_TYPE_PICKUP
+	case order.StopDropoff:
+		return orderpb.StopType_STOP_TYPE_DROPOFF
+	default:
+		return orderpb.StopType_STOP_TYPE_UNSPECIFIED
+	}
+}
+
+func toProtoStatus(s string) orderpb.OrderStatus {
+	switch s {
+	case order.StatusUnassigned:
+		return orderpb.OrderStatus_ORDER_STATUS_UNASSIGNED
+	case order.StatusTaken:
+		return orderpb.OrderStatus_ORDER_STATUS_TAKEN
+	case order.StatusScheduled:
+		return orderpb.OrderStatus_ORDER_STATUS_SCHEDULED
+	default:
+		return orderpb.OrderStatus_ORDER_STATUS_UNSPECIFIED
+	}
+}
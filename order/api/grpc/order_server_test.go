@@ -0,0 +1,389 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/order/api/grpc/orderpb"
+	"github.com/imylam/delivery-test/order/mocks"
+	"github.com/imylam/delivery-test/order/usecase"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPlaceOrder(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("invalid-latitude", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		server := &orderServer{orderUC: mockOrderUC}
+
+		_, err := server.PlaceOrder(context.Background(), &orderpb.PlaceOrderRequest{
+			Origin:      &orderpb.LatLng{Latitude: 92.300789, Longitude: 114.167815},
+			Destination: createValidDestination(),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		mockOrderUC.AssertNotCalled(t, "PlaceOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("missing-destination", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		server := &orderServer{orderUC: mockOrderUC}
+
+		_, err := server.PlaceOrder(context.Background(), &orderpb.PlaceOrderRequest{
+			Origin: createValidOrigin(),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockOrder := order.Order{ID: 1, Distance: 10, Status: order.StatusUnassigned, MerchantID: "merchant-1"}
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, order.Placement{Origin: []string{"22.300789", "114.167815"}, Destination: []string{"22.33", "114.19"}}).
+			Return(&mockOrder, nil).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		resp, err := server.PlaceOrder(context.Background(), &orderpb.PlaceOrderRequest{
+			Origin:      createValidOrigin(),
+			Destination: createValidDestination(),
+		})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(1), resp.GetId())
+		assert.Equal(t, int32(10), resp.GetDistance())
+		assert.Equal(t, orderpb.OrderStatus_ORDER_STATUS_UNASSIGNED, resp.GetStatus())
+		assert.Equal(t, "merchant-1", resp.GetMerchantId())
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("address-and-route-options", func(t *testing.T) {
+		mockOrder := order.Order{ID: 1, Distance: 10, TravelMode: order.TravelModeBicycling, Status: order.StatusUnassigned,
+			OriginAddress: "1 Austin Rd W, Tsim Sha Tsui, Hong Kong"}
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, order.Placement{
+			OriginAddress: "1 Austin Road West",
+			Destination:   []string{"22.33", "114.19"},
+			Options:       order.RouteOptions{Mode: order.TravelModeBicycling, AvoidFerries: true},
+		}).Return(&mockOrder, nil).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		resp, err := server.PlaceOrder(context.Background(), &orderpb.PlaceOrderRequest{
+			OriginAddress: "1 Austin Road West",
+			Destination:   createValidDestination(),
+			TravelMode:    orderpb.TravelMode_TRAVEL_MODE_BICYCLING,
+			AvoidFerries:  true,
+		})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, orderpb.TravelMode_TRAVEL_MODE_BICYCLING, resp.GetTravelMode())
+		assert.Equal(t, "1 Austin Rd W, Tsim Sha Tsui, Hong Kong", resp.GetOriginAddress())
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("stops", func(t *testing.T) {
+		mockOrder := order.Order{ID: 1, Distance: 30, Status: order.StatusUnassigned, Stops: []order.Stop{
+			{Index: 0, Type: order.StopPickup},
+			{Index: 1, Type: order.StopDropoff, Address: "1 Austin Rd W, Tsim Sha Tsui, Hong Kong", Distance: 30},
+		}}
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, order.Placement{Stops: []order.PlacementStop{
+			{Type: order.StopPickup, Coordinates: []string{"22.300789", "114.167815"}},
+			{Type: order.StopDropoff, Address: "1 Austin Road West"},
+		}}).Return(&mockOrder, nil).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		resp, err := server.PlaceOrder(context.Background(), &orderpb.PlaceOrderRequest{Stops: []*orderpb.PlaceOrderStop{
+			{Type: orderpb.StopType_STOP_TYPE_PICKUP, Coordinates: createValidOrigin()},
+			{Type: orderpb.StopType_STOP_TYPE_DROPOFF, Address: "1 Austin Road West"},
+		}})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, len(resp.GetStops()))
+		assert.Equal(t, orderpb.StopType_STOP_TYPE_DROPOFF, resp.GetStops()[1].GetType())
+		assert.Equal(t, int32(30), resp.GetStops()[1].GetDistance())
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("from-quote", func(t *testing.T) {
+		scheduledFor := time.Now().Add(time.Hour).UTC()
+		deliveryAt := scheduledFor.Add(5 * time.Minute)
+		mockOrder := order.Order{ID: 1, Distance: 1200, EstimatedDuration: 300, EstimatedDeliveryAt: &deliveryAt, Price: 1600,
+			Currency: "HKD", TariffVersion: "v1", QuoteID: "q-1", Status: order.StatusScheduled, ScheduledFor: &scheduledFor}
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, mock.MatchedBy(func(p order.Placement) bool {
+			return p.QuoteID == "q-1" && p.ScheduledFor != nil && p.ScheduledFor.Equal(scheduledFor)
+		})).Return(&mockOrder, nil).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		resp, err := server.PlaceOrder(context.Background(), &orderpb.PlaceOrderRequest{
+			QuoteId:      "q-1",
+			ScheduledFor: timestamppb.New(scheduledFor),
+		})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int32(300), resp.GetEstimatedDuration())
+		assert.Equal(t, deliveryAt, resp.GetEstimatedDeliveryAt().AsTime())
+		assert.Equal(t, int64(1600), resp.GetPrice())
+		assert.Equal(t, "HKD", resp.GetCurrency())
+		assert.Equal(t, "v1", resp.GetTariffVersion())
+		assert.Equal(t, "q-1", resp.GetQuoteId())
+		assert.Equal(t, scheduledFor, resp.GetScheduledFor().AsTime())
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			req    *orderpb.PlaceOrderRequest
+			errMsg string
+		}{
+			{"quote-with-route", &orderpb.PlaceOrderRequest{QuoteId: "q-1", TravelMode: orderpb.TravelMode_TRAVEL_MODE_WALKING}, errQuoteOrRoute},
+			{"coordinates-and-address", &orderpb.PlaceOrderRequest{Origin: createValidOrigin(), OriginAddress: "1 Austin Road West",
+				Destination: createValidDestination()}, errCoordinatesOrAddress},
+			{"blank-address", &orderpb.PlaceOrderRequest{OriginAddress: " ", Destination: createValidDestination()}, errInvalidAddress},
+			{"stops-and-ends", &orderpb.PlaceOrderRequest{Origin: createValidOrigin(), Stops: []*orderpb.PlaceOrderStop{
+				{Type: orderpb.StopType_STOP_TYPE_PICKUP, Coordinates: createValidOrigin()},
+				{Type: orderpb.StopType_STOP_TYPE_DROPOFF, Coordinates: createValidDestination()},
+			}}, errStopsOrEnds},
+			{"one-stop", &orderpb.PlaceOrderRequest{Stops: []*orderpb.PlaceOrderStop{
+				{Type: orderpb.StopType_STOP_TYPE_PICKUP, Coordinates: createValidOrigin()},
+			}}, errStopCount},
+			{"dropoff-first", &orderpb.PlaceOrderRequest{Stops: []*orderpb.PlaceOrderStop{
+				{Type: orderpb.StopType_STOP_TYPE_DROPOFF, Coordinates: createValidOrigin()},
+				{Type: orderpb.StopType_STOP_TYPE_DROPOFF, Coordinates: createValidDestination()},
+			}}, errStopSequence},
+			{"unknown-travel-mode", &orderpb.PlaceOrderRequest{Origin: createValidOrigin(), Destination: createValidDestination(),
+				TravelMode: orderpb.TravelMode(9)}, errInvalidTravelMode},
+			{"scheduled-in-past", &orderpb.PlaceOrderRequest{Origin: createValidOrigin(), Destination: createValidDestination(),
+				ScheduledFor: timestamppb.New(time.Now().Add(-time.Minute))}, errScheduleInPast},
+		}
+		for _, tt := range tests {
+			mockOrderUC := new(mocks.OrderUsecase)
+			server := &orderServer{orderUC: mockOrderUC}
+
+			_, err := server.PlaceOrder(context.Background(), tt.req)
+
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			assert.Equal(t, tt.errMsg, status.Convert(err).Message())
+			mockOrderUC.AssertNotCalled(t, "PlaceOrder", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, mock.Anything).
+			Return(nil, auth.ErrForbidden).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		_, err := server.PlaceOrder(context.Background(), &orderpb.PlaceOrderRequest{
+			Origin:      createValidOrigin(),
+			Destination: createValidDestination(),
+		})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("outside-service-area", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, mock.Anything).
+			Return(nil, fmt.Errorf("origin: %w", order.ErrOutsideServiceArea)).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		_, err := server.PlaceOrder(context.Background(), &orderpb.PlaceOrderRequest{
+			Origin:      createValidOrigin(),
+			Destination: createValidDestination(),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, "origin: outside the service area", status.Convert(err).Message())
+	})
+
+	t.Run("unprocessable", func(t *testing.T) {
+		tests := []struct {
+			err  error
+			code codes.Code
+		}{
+			{fmt.Errorf("destination: %w", order.ErrAddressNotFound), codes.InvalidArgument},
+			{order.ErrQuoteNotFound, codes.NotFound},
+			{order.ErrNoRoute, codes.FailedPrecondition},
+			{order.ErrQuoteExpired, codes.FailedPrecondition},
+		}
+		for _, tt := range tests {
+			mockOrderUC := new(mocks.OrderUsecase)
+			mockOrderUC.On("PlaceOrder", mock.Anything, mock.Anything).Return(nil, tt.err).Once()
+			server := &orderServer{orderUC: mockOrderUC}
+
+			_, err := server.PlaceOrder(context.Background(), &orderpb.PlaceOrderRequest{
+				Origin:      createValidOrigin(),
+				Destination: createValidDestination(),
+			})
+
+			assert.Equal(t, tt.code, status.Code(err))
+			assert.Equal(t, tt.err.Error(), status.Convert(err).Message())
+		}
+	})
+
+	t.Run("breaks-rules", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, mock.Anything).
+			Return(nil, &order.ValidationError{Violations: []order.Violation{
+				{Rule: order.RuleDistinctEnds, Message: "origin and destination are the same"},
+			}}).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		_, err := server.PlaceOrder(context.Background(), &orderpb.PlaceOrderRequest{
+			Origin:      createValidOrigin(),
+			Destination: createValidDestination(),
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, "order breaks business rules: origin and destination are the same", status.Convert(err).Message())
+	})
+
+	t.Run("internal-error", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, mock.Anything).
+			Return(nil, errors.New("map api down")).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		_, err := server.PlaceOrder(context.Background(), &orderpb.PlaceOrderRequest{
+			Origin:      createValidOrigin(),
+			Destination: createValidDestination(),
+		})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Equal(t, "internal server error", status.Convert(err).Message())
+	})
+}
+
+func TestTakeOrder(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("invalid-id", func(t *testing.T) {
+		server := &orderServer{orderUC: new(mocks.OrderUsecase)}
+
+		_, err := server.TakeOrder(context.Background(), &orderpb.TakeOrderRequest{Id: 0})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("TakeOrder", mock.Anything, int64(1)).Return("SUCCESS", nil).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		resp, err := server.TakeOrder(context.Background(), &orderpb.TakeOrderRequest{Id: 1})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "SUCCESS", resp.GetStatus())
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("TakeOrder", mock.Anything, int64(1)).Return("", sql.ErrNoRows).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		_, err := server.TakeOrder(context.Background(), &orderpb.TakeOrderRequest{Id: 1})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("already-taken", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("TakeOrder", mock.Anything, int64(1)).Return("", errors.New(usecase.ErrorOrderTaken)).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		_, err := server.TakeOrder(context.Background(), &orderpb.TakeOrderRequest{Id: 1})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+func TestListOrders(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("invalid-page", func(t *testing.T) {
+		server := &orderServer{orderUC: new(mocks.OrderUsecase)}
+
+		_, err := server.ListOrders(context.Background(), &orderpb.ListOrdersRequest{Page: 0, Limit: 10})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockOrders := []order.Order{
+			{ID: 1, Distance: 10, Status: order.StatusUnassigned},
+			{ID: 2, Distance: 20, Status: order.StatusTaken, CourierID: "courier-1"},
+		}
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("ListOrders", mock.Anything, 1, 10).Return(&mockOrders, nil).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		resp, err := server.ListOrders(context.Background(), &orderpb.ListOrdersRequest{Page: 1, Limit: 10})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, len(resp.GetOrders()))
+		assert.Equal(t, orderpb.OrderStatus_ORDER_STATUS_TAKEN, resp.GetOrders()[1].GetStatus())
+		assert.Equal(t, "courier-1", resp.GetOrders()[1].GetCourierId())
+	})
+}
+
+func TestGetOrder(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("GetOrder", mock.Anything, int64(1)).Return(nil, auth.ErrNoTenant).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		_, err := server.GetOrder(context.Background(), &orderpb.GetOrderRequest{Id: 1})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockOrder := order.Order{ID: 1, Distance: 10, Status: order.StatusUnassigned}
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("GetOrder", mock.Anything, int64(1)).Return(&mockOrder, nil).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		resp, err := server.GetOrder(context.Background(), &orderpb.GetOrderRequest{Id: 1})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(1), resp.GetId())
+	})
+
+	t.Run("scheduled", func(t *testing.T) {
+		mockOrder := order.Order{ID: 2, Distance: 10, Status: order.StatusScheduled}
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("GetOrder", mock.Anything, int64(2)).Return(&mockOrder, nil).Once()
+		server := &orderServer{orderUC: mockOrderUC}
+
+		resp, err := server.GetOrder(context.Background(), &orderpb.GetOrderRequest{Id: 2})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, orderpb.OrderStatus_ORDER_STATUS_SCHEDULED, resp.GetStatus())
+		assert.Equal(t, "ORDER_STATUS_SCHEDULED", resp.GetStatus().String())
+	})
+}
+
+func createValidOrigin() *orderpb.LatLng {
+	return &orderpb.LatLng{Latitude: 22.300789, Longitude: 114.167815}
+}
+
+func createValidDestination() *orderpb.LatLng {
+	return &orderpb.LatLng{Latitude: 22.33, Longitude: 114.19}
+}
@@ -0,0 +1,5 @@
+// Package orderpb holds the protobuf definition of the order gRPC API and the
+// code generated from it
+package orderpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative order.proto
@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	resterrors "github.com/imylam/delivery-test/common/rest_errors"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	"go.uber.org/zap"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerLastEventID string = "Last-Event-ID"
+	errInvalidArea    string = "invalid area"
+
+	// eventBatchSize is the number of events read from the history at once
+	eventBatchSize int = 100
+)
+
+var (
+	// eventPollInterval is how often streams look for new events in the history
+	eventPollInterval = time.Second
+	// eventHeartbeatInterval is how often idle streams get a comment, so proxies keep them open
+	eventHeartbeatInterval = 15 * time.Second
+)
+
+// streamEvents streams order events as Server-Sent Events. Streams start after
+// the latest event, or after the one given by Last-Event-ID when clients reconnect
+func (h *orderHandler) streamEvents(c *gin.Context) {
+	var req StreamEventsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	filter := order.EventFilter{Status: req.Status}
+	switch filter.Status {
+	case "", order.StatusScheduled, order.StatusUnassigned, order.StatusTaken:
+	default:
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+	if req.Area != "" {
+		area, ok := parseArea(req.Area)
+		if !ok {
+			c.Error(resterrors.NewBadRequestError(errInvalidArea))
+			return
+		}
+		filter.Area = area
+	}
+
+	ctx := c.Request.Context()
+
+	lastEventID := c.GetHeader(headerLastEventID)
+	if lastEventID == "" {
+		lastEventID = req.LastEventID
+	}
+
+	// event ids of the stream are the positions of the events
+	var lastPosition int64
+	var err error
+	if lastEventID != "" {
+		lastPosition, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastPosition < 0 {
+			c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+			return
+		}
+	} else {
+		lastPosition, err = h.orderUC.LatestEventPosition(ctx)
+	}
+
+	// errors are only reported before the stream starts, the first page is read for that
+	var events []order.OrderEvent
+	if err == nil {
+		events, err = h.orderUC.ListEvents(ctx, filter, lastPosition, eventBatchSize)
+	}
+	if restErr := callerError(err); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+	if err != nil {
+		logger.Logger.Error("fail to list order events", zap.String("error", err.Error()))
+		c.Error(resterrors.NewInternalServerError(errInternalServer))
+		return
+	}
+
+	// the headers are sent right away so clients know the stream is open
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Header("HTTP", "200")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+
+	poll := time.NewTicker(eventPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		for _, e := range events {
+			c.Render(-1, sse.Event{Id: strconv.FormatInt(e.Position, 10), Event: e.Type, Data: e.Order()})
+			lastPosition = e.Position
+		}
+		c.Writer.Flush()
+
+		if len(events) < eventBatchSize {
+			events = nil
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-heartbeat.C:
+				_, _ = c.Writer.WriteString(":\n\n")
+				continue
+			case <-poll.C:
+			}
+		}
+
+		events, err = h.orderUC.ListEvents(ctx, filter, lastPosition, eventBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Logger.Error("fail to list order events", zap.String("error", err.Error()))
+			}
+			return
+		}
+	}
+}
+
+// parseArea parses a bounding box given as south,west,north,east
+func parseArea(s string) (*order.Area, bool) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, false
+	}
+
+	var bounds [4]float64
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, false
+		}
+		bounds[i] = v
+	}
+
+	area := &order.Area{South: bounds[0], West: bounds[1], North: bounds[2], East: bounds[3]}
+	if area.South < -90 || area.North > 90 || area.South > area.North {
+		return nil, false
+	}
+	if area.West < -180 || area.East > 180 || area.West > area.East {
+		return nil, false
+	}
+
+	return area, true
+}
@@ -0,0 +1,130 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/order/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStreamEvents(t *testing.T) {
+	logger.Init(logger.Config{})
+	eventPollInterval = time.Millisecond
+
+	httpMethod := "GET"
+	httpPath := "/orders/events"
+	mockEvents := []order.OrderEvent{
+		{ID: 16, Type: order.EventOrderCreated, OrderID: 1, Distance: 100, TravelMode: order.TravelModeDriving, Status: order.StatusUnassigned, MerchantID: "merchant-1", Position: 6},
+		{ID: 15, Type: order.EventOrderStatusChanged, OrderID: 1, Distance: 100, TravelMode: order.TravelModeDriving, Status: order.StatusTaken, MerchantID: "merchant-1", CourierID: "courier-1", Position: 7},
+	}
+
+	t.Run("resume-from-last-event-id", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("ListEvents", mock.Anything, order.EventFilter{Status: order.StatusTaken}, int64(5), eventBatchSize).
+			Return(mockEvents, nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, order.EventFilter{Status: order.StatusTaken}, int64(7), eventBatchSize).
+			Run(func(mock.Arguments) { cancel() }).
+			Return([]order.OrderEvent{}, nil).Once()
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequestWithContext(ctx, httpMethod, httpPath+"?status=TAKEN", nil)
+		req.Header.Set(headerLastEventID, "5")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		assert.Equal(t, true, strings.Contains(w.Body.String(),
+			"id:6\nevent:order.created\ndata:{\"id\":1,\"distance\":100,\"travel_mode\":\"driving\",\"status\":\"UNASSIGNED\",\"merchant_id\":\"merchant-1\"}\n\n"))
+		assert.Equal(t, true, strings.Contains(w.Body.String(), "id:7\nevent:order.status_changed\n"))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("start-after-latest-event", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		mockArea := &order.Area{South: 22.2, West: 114.1, North: 22.4, East: 114.3}
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("LatestEventPosition", mock.Anything).Return(int64(42), nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, order.EventFilter{Area: mockArea}, int64(42), eventBatchSize).
+			Run(func(mock.Arguments) { cancel() }).
+			Return([]order.OrderEvent{}, nil).Once()
+		router := createGinRouterAs(auth.RoleCourier)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequestWithContext(ctx, httpMethod, httpPath+"?area=22.2,114.1,22.4,114.3", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "", w.Body.String())
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("invalid-area", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath+"?area=22.4,114.1,22.2,114.3", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"error":"invalid area"}`, w.Body.String())
+	})
+
+	t.Run("invalid-status", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath+"?status=LOST", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("LatestEventPosition", mock.Anything).Return(int64(0), auth.ErrForbidden).Once()
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("internal-error", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("ListEvents", mock.Anything, order.EventFilter{}, int64(5), eventBatchSize).
+			Return(nil, errors.New("db down")).Once()
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath+"?last_event_id=5", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -1,8 +1,16 @@
 package rest
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/common/middleware"
 	resterrors "github.com/imylam/delivery-test/common/rest_errors"
 	"github.com/imylam/delivery-test/logger"
 	"github.com/imylam/delivery-test/order"
@@ -15,24 +23,59 @@ import (
 
 const (
 	errInvalidCoordinates    string = "invalid coordinates"
+	errCoordinatesOrAddress  string = "either coordinates or an address, not both"
+	errInvalidAddress        string = "invalid address"
+	errInvalidTravelMode     string = "invalid travel mode"
+	errInvalidAvoid          string = "invalid avoid"
+	errQuoteOrRoute          string = "either a quote or the ends of the order, not both"
+	errQuoteNotAllowed       string = "quote_id is only accepted when placing an order"
+	errStopsOrEnds           string = "either stops or the ends of the order, not both"
+	errStopCount             string = "an order has 2 to 10 stops"
+	errInvalidStopType       string = "invalid stop type"
+	errStopSequence          string = "the first stop must be a pickup and the last a dropoff"
+	errStopsNotQuotable      string = "multi-stop orders cannot be quoted"
+	errScheduleNotAllowed    string = "scheduled_for is only accepted when placing an order"
+	errScheduleInPast        string = "scheduled_for must be in the future"
+	errBatchTooLarge         string = "a batch has at most %d orders"
 	errInvalidResquestParams string = "invalid request params"
 	errInternalServer        string = "internal server error"
+	errUnauthorized          string = "unauthorized"
+	errForbidden             string = "forbidden"
+	errTenantRequired        string = "tenant required"
+	errOrderNotFound         string = "order not found"
 )
 
+// maxAddressLength is the longest address an order can be placed with, in characters
+const maxAddressLength int = 255
+
+// maxStops is the most stops a multi-stop order can have
+const maxStops int = 10
+
 // orderHandler represents the httphandler for handling requests relating to Orders
 type orderHandler struct {
 	orderUC order.OrderUsecase
+	// maxBatchSize is the most orders a batch places
+	maxBatchSize int
 }
 
 // NewOrderHandler will initialize the Order endpoints
-func NewOrderHandler(g *gin.Engine, orderUC order.OrderUsecase) {
+func NewOrderHandler(g *gin.Engine, orderUC order.OrderUsecase, maxBatchSize int) {
 	handler := &orderHandler{
-		orderUC: orderUC,
+		orderUC:      orderUC,
+		maxBatchSize: maxBatchSize,
 	}
 
-	g.POST("/orders", handler.placeOrder)
-	g.PATCH("/orders/:id", handler.takeOrder)
-	g.GET("/orders", handler.listOrder)
+	orders := g.Group("/orders", middleware.ResolveTenant())
+	orders.POST("", middleware.RequirePermission(auth.PermissionOrderPlace), handler.placeOrder)
+	orders.POST("/batch", middleware.RequirePermission(auth.PermissionOrderPlace), handler.placeOrders)
+	orders.PATCH("/:id", middleware.RequirePermission(auth.PermissionOrderTake), handler.takeOrder)
+	orders.PATCH("/:id/stops/:index", middleware.RequirePermission(auth.PermissionOrderTake), handler.completeStop)
+	orders.GET("", middleware.RequirePermission(auth.PermissionOrderList), handler.listOrder)
+	orders.GET("/events", middleware.RequirePermission(auth.PermissionOrderList), handler.streamEvents)
+	orders.GET("/:id", middleware.RequirePermission(auth.PermissionOrderView), handler.getOrder)
+
+	quotes := g.Group("/quotes", middleware.ResolveTenant())
+	quotes.POST("", middleware.RequirePermission(auth.PermissionOrderPlace), handler.quoteOrder)
 }
 
 func (h *orderHandler) placeOrder(c *gin.Context) {
@@ -48,7 +91,23 @@ func (h *orderHandler) placeOrder(c *gin.Context) {
 		return
 	}
 
-	order, err := h.orderUC.PlaceOrder(req.Origin, req.Destination)
+	order, err := h.orderUC.PlaceOrder(c.Request.Context(), toPlacement(req))
+	if restErr := callerError(err); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+	if restErr := ruleError(err); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+	if isQuoteNotFound(err) {
+		c.Error(resterrors.NewNotFoundError(err.Error()))
+		return
+	}
+	if isLocationError(err) || isQuoteError(err) {
+		c.Error(resterrors.NewUnprocessableEntityError(err.Error()))
+		return
+	}
 	if err != nil {
 		logger.Logger.Error("fail to place order", zap.String("error", err.Error()))
 
@@ -60,6 +119,131 @@ func (h *orderHandler) placeOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
+func (h *orderHandler) placeOrders(c *gin.Context) {
+	var req PlaceOrdersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+	if len(req.Orders) > h.maxBatchSize {
+		c.Error(resterrors.NewBadRequestError(fmt.Sprintf(errBatchTooLarge, h.maxBatchSize)))
+		return
+	}
+
+	// invalid placements fail on their own, the usecase gets the others
+	results := make([]PlaceOrderResult, len(req.Orders))
+	var placements []order.Placement
+	var indexes []int
+	for i, item := range req.Orders {
+		results[i].Index = i
+		if isValid, errMsg := validatePlaceOrder(item); !isValid {
+			results[i].Error = errMsg
+			continue
+		}
+
+		placements = append(placements, toPlacement(item))
+		indexes = append(indexes, i)
+	}
+
+	if len(placements) > 0 {
+		placed, err := h.orderUC.PlaceOrders(c.Request.Context(), placements)
+		if restErr := callerError(err); restErr != nil {
+			c.Error(restErr)
+			return
+		}
+		if errors.Is(err, order.ErrBatchTooLarge) {
+			c.Error(resterrors.NewBadRequestError(fmt.Sprintf(errBatchTooLarge, h.maxBatchSize)))
+			return
+		}
+		if err != nil {
+			logger.Logger.Error("fail to place orders", zap.String("error", err.Error()))
+
+			c.Error(resterrors.NewInternalServerError(errInternalServer))
+			return
+		}
+
+		for k, r := range placed {
+			i := indexes[k]
+			var ruleErr *order.ValidationError
+			switch {
+			case errors.As(r.Err, &ruleErr):
+				results[i].Error = ruleErr.Error()
+				results[i].Violations = ruleErr.Violations
+			case isLocationError(r.Err), isQuoteError(r.Err):
+				results[i].Error = r.Err.Error()
+			case r.Err != nil:
+				logger.Logger.Error("fail to place order", zap.Int("index", i), zap.String("error", r.Err.Error()))
+				results[i].Error = errInternalServer
+			default:
+				results[i].Order = r.Order
+			}
+		}
+	}
+
+	resp := PlaceOrdersResponse{Results: results}
+	for _, r := range results {
+		if r.Order != nil {
+			resp.Placed++
+		} else {
+			resp.Failed++
+		}
+	}
+
+	c.Header("HTTP", "200")
+	c.JSON(http.StatusOK, resp)
+}
+
+// quoteOrder prices the order of the request without placing it
+func (h *orderHandler) quoteOrder(c *gin.Context) {
+	var req PlaceOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	if req.QuoteID != "" {
+		c.Error(resterrors.NewBadRequestError(errQuoteNotAllowed))
+		return
+	}
+	// quotes keep the ends of the order only
+	if len(req.Stops) > 0 {
+		c.Error(resterrors.NewBadRequestError(errStopsNotQuotable))
+		return
+	}
+	if req.ScheduledFor != nil {
+		c.Error(resterrors.NewBadRequestError(errScheduleNotAllowed))
+		return
+	}
+	isValid, errMsg := validatePlaceOrder(req)
+	if !isValid {
+		c.Error(resterrors.NewBadRequestError(errMsg))
+		return
+	}
+
+	quote, err := h.orderUC.QuoteOrder(c.Request.Context(), toPlacement(req))
+	if restErr := callerError(err); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+	if restErr := ruleError(err); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+	if isLocationError(err) || errors.Is(err, order.ErrNoTariff) {
+		c.Error(resterrors.NewUnprocessableEntityError(err.Error()))
+		return
+	}
+	if err != nil {
+		logger.Logger.Error("fail to quote order", zap.String("error", err.Error()))
+
+		c.Error(resterrors.NewInternalServerError(errInternalServer))
+		return
+	}
+
+	c.Header("HTTP", "200")
+	c.JSON(http.StatusOK, quote)
+}
+
 func (h *orderHandler) takeOrder(c *gin.Context) {
 	var req TakeOrderRequest
 	if err := c.ShouldBindUri(&req); err != nil {
@@ -82,7 +266,15 @@ func (h *orderHandler) takeOrder(c *gin.Context) {
 		return
 	}
 
-	status, err := h.orderUC.TakeOrder(req.ID)
+	status, err := h.orderUC.TakeOrder(c.Request.Context(), req.ID)
+	if restErr := callerError(err); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.Error(resterrors.NewNotFoundError(errOrderNotFound))
+		return
+	}
 	if err != nil {
 		if err.Error() != usecase.ErrorOrderTaken {
 			logger.Logger.Error("fail to take order", zap.String("error", err.Error()))
@@ -100,6 +292,53 @@ func (h *orderHandler) takeOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": status})
 }
 
+// completeStop marks a stop of a multi-stop order completed by the courier who took it
+func (h *orderHandler) completeStop(c *gin.Context) {
+	var req CompleteStopRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	if req.Status != "COMPLETED" {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	stop, err := h.orderUC.CompleteStop(c.Request.Context(), req.ID, req.Index)
+	if restErr := callerError(err); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+	switch {
+	case err == sql.ErrNoRows:
+		c.Error(resterrors.NewNotFoundError(errOrderNotFound))
+		return
+	case err == order.ErrStopNotFound:
+		c.Error(resterrors.NewNotFoundError(err.Error()))
+		return
+	case err == order.ErrNotTakenByCourier:
+		c.Error(resterrors.NewForbiddenError(err.Error()))
+		return
+	case err == order.ErrStopCompleted:
+		c.Header("HTTP", "409")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		logger.Logger.Error("fail to complete stop", zap.String("error", err.Error()))
+
+		c.Error(resterrors.NewInternalServerError(errInternalServer))
+		return
+	}
+
+	c.Header("HTTP", "200")
+	c.JSON(http.StatusOK, stop)
+}
+
 func (h *orderHandler) listOrder(c *gin.Context) {
 	var req ListOrderRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -113,7 +352,11 @@ func (h *orderHandler) listOrder(c *gin.Context) {
 		return
 	}
 
-	orders, err := h.orderUC.ListOrders(req.Page, req.Limit)
+	orders, err := h.orderUC.ListOrders(c.Request.Context(), req.Page, req.Limit)
+	if restErr := callerError(err); restErr != nil {
+		c.Error(restErr)
+		return
+	}
 	if err != nil {
 		logger.Logger.Error("fail to list orders", zap.String("error", err.Error()))
 		c.Error(resterrors.NewInternalServerError(errInternalServer))
@@ -130,16 +373,161 @@ func (h *orderHandler) listOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, orders)
 }
 
-// validatePlaceOrder checks where coodinates are string that can be converted to float64
+func (h *orderHandler) getOrder(c *gin.Context) {
+	var req GetOrderRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	order, err := h.orderUC.GetOrder(c.Request.Context(), req.ID)
+	if restErr := callerError(err); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.Error(resterrors.NewNotFoundError(errOrderNotFound))
+		return
+	}
+	if err != nil {
+		logger.Logger.Error("fail to get order", zap.String("error", err.Error()))
+		c.Error(resterrors.NewInternalServerError(errInternalServer))
+		return
+	}
+
+	c.Header("HTTP", "200")
+	c.JSON(http.StatusOK, order)
+}
+
+// callerError converts errors about the caller returned by the usecase into
+// rest errors, it returns nil for any other error
+func callerError(err error) resterrors.RestError {
+	switch err {
+	case auth.ErrNoIdentity:
+		return resterrors.NewUnauthorizedError(errUnauthorized)
+	case auth.ErrForbidden:
+		return resterrors.NewForbiddenError(errForbidden)
+	case auth.ErrNoTenant:
+		return resterrors.NewBadRequestError(errTenantRequired)
+	default:
+		return nil
+	}
+}
+
+// ruleError converts the business rules an order breaks into a rest error
+// listing them, it returns nil for any other error
+func ruleError(err error) resterrors.RestError {
+	var ruleErr *order.ValidationError
+	if !errors.As(err, &ruleErr) {
+		return nil
+	}
+
+	return resterrors.NewValidationError(ruleErr.Error(), ruleErr.Violations)
+}
+
+// isLocationError tells whether err is about an address the caller gave, an
+// end outside the service areas of the tenant or ends no route joins
+func isLocationError(err error) bool {
+	return errors.Is(err, order.ErrAddressNotFound) || errors.Is(err, order.ErrAmbiguousAddress) ||
+		errors.Is(err, order.ErrOutsideServiceArea) || errors.Is(err, order.ErrNoRoute)
+}
+
+// isQuoteNotFound tells whether err is about a quote that does not exist or
+// belongs to another merchant
+func isQuoteNotFound(err error) bool {
+	return errors.Is(err, order.ErrQuoteNotFound)
+}
+
+// isQuoteError tells whether err is about a quote the caller gave
+func isQuoteError(err error) bool {
+	return err == order.ErrQuoteNotFound || err == order.ErrQuoteExpired || err == order.ErrQuoteUsed
+}
+
+// validatePlaceOrder checks every end or stop is either coordinates, strings
+// that can be converted to float64, or an address and the travel mode and
+// avoidances are known. An order placed from a quote gives nothing else
 func validatePlaceOrder(req PlaceOrderRequest) (bool, string) {
-	originInterface := make([]interface{}, len(req.Origin))
-	for i, v := range req.Origin {
-		originInterface[i] = v
+	hasEnds := len(req.Origin) > 0 || req.OriginAddress != "" || len(req.Destination) > 0 || req.DestinationAddress != ""
+
+	if req.ScheduledFor != nil && !req.ScheduledFor.After(time.Now()) {
+		return false, errScheduleInPast
+	}
+
+	if req.QuoteID != "" {
+		if hasEnds || len(req.Stops) > 0 || req.TravelMode != "" || len(req.Avoid) > 0 {
+			return false, errQuoteOrRoute
+		}
+		return true, ""
+	}
+
+	if len(req.Stops) > 0 {
+		if hasEnds {
+			return false, errStopsOrEnds
+		}
+		if isValid, errMsg := validateStops(req.Stops); !isValid {
+			return false, errMsg
+		}
+	} else {
+		if isValid, errMsg := validateEnd(req.Origin, req.OriginAddress); !isValid {
+			return false, errMsg
+		}
+		if isValid, errMsg := validateEnd(req.Destination, req.DestinationAddress); !isValid {
+			return false, errMsg
+		}
 	}
 
-	destInterface := make([]interface{}, len(req.Destination))
-	for i, v := range req.Destination {
-		destInterface[i] = v
+	switch req.TravelMode {
+	case "", order.TravelModeDriving, order.TravelModeBicycling, order.TravelModeWalking, order.TravelModeTwoWheeler:
+	default:
+		return false, errInvalidTravelMode
+	}
+	for _, avoid := range req.Avoid {
+		switch avoid {
+		case order.AvoidTolls, order.AvoidHighways, order.AvoidFerries:
+		default:
+			return false, errInvalidAvoid
+		}
+	}
+
+	return true, ""
+}
+
+// validateStops checks a multi-stop order starts with a pickup, ends with a
+// dropoff and each of its stops is given like an end
+func validateStops(stops []PlaceOrderStop) (bool, string) {
+	if len(stops) < 2 || len(stops) > maxStops {
+		return false, errStopCount
+	}
+	for _, s := range stops {
+		if s.Type != order.StopPickup && s.Type != order.StopDropoff {
+			return false, errInvalidStopType
+		}
+		if isValid, errMsg := validateEnd(s.Coordinates, s.Address); !isValid {
+			return false, errMsg
+		}
+	}
+	if stops[0].Type != order.StopPickup || stops[len(stops)-1].Type != order.StopDropoff {
+		return false, errStopSequence
+	}
+
+	return true, ""
+}
+
+// validateEnd checks an end of an order is given by either its coordinates or its address
+func validateEnd(coords []string, address string) (bool, string) {
+	switch {
+	case address != "" && len(coords) > 0:
+		return false, errCoordinatesOrAddress
+	case address != "":
+		if strings.TrimSpace(address) == "" || utf8.RuneCountInString(address) > maxAddressLength {
+			return false, errInvalidAddress
+		}
+		return true, ""
+	}
+
+	coordsInterface := make([]interface{}, len(coords))
+	for i, v := range coords {
+		coordsInterface[i] = v
 	}
 
 	var fn govalidator.ConditionIterator = func(value interface{}, index int) bool {
@@ -156,19 +544,40 @@ func validatePlaceOrder(req PlaceOrderRequest) (bool, string) {
 		return govalidator.IsLongitude(s)
 	}
 
-	if govalidator.Count(originInterface, fn) != 2 {
+	if govalidator.Count(coordsInterface, fn) != 2 {
 		return false, errInvalidCoordinates
 	}
-	if !govalidator.ValidateArray(originInterface, fn) {
+	if !govalidator.ValidateArray(coordsInterface, fn) {
 		return false, errInvalidCoordinates
 	}
 
-	if govalidator.Count(destInterface, fn) != 2 {
-		return false, errInvalidCoordinates
+	return true, ""
+}
+
+// toPlacement converts a validated place order request into a placement
+func toPlacement(req PlaceOrderRequest) order.Placement {
+	p := order.Placement{
+		QuoteID:            req.QuoteID,
+		Origin:             req.Origin,
+		OriginAddress:      req.OriginAddress,
+		Destination:        req.Destination,
+		DestinationAddress: req.DestinationAddress,
+		Options:            order.RouteOptions{Mode: req.TravelMode},
+		ScheduledFor:       req.ScheduledFor,
 	}
-	if !govalidator.ValidateArray(destInterface, fn) {
-		return false, errInvalidCoordinates
+	for _, s := range req.Stops {
+		p.Stops = append(p.Stops, order.PlacementStop{Type: s.Type, Coordinates: s.Coordinates, Address: s.Address})
+	}
+	for _, avoid := range req.Avoid {
+		switch avoid {
+		case order.AvoidTolls:
+			p.Options.AvoidTolls = true
+		case order.AvoidHighways:
+			p.Options.AvoidHighways = true
+		case order.AvoidFerries:
+			p.Options.AvoidFerries = true
+		}
 	}
 
-	return true, ""
+	return p
 }
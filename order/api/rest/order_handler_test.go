@@ -2,24 +2,30 @@ package rest
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/assert/v2"
 	"github.com/go-sql-driver/mysql"
+	"github.com/imylam/delivery-test/common/auth"
 	"github.com/imylam/delivery-test/common/middleware"
 	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/openapi"
 	"github.com/imylam/delivery-test/order"
 	"github.com/imylam/delivery-test/order/mocks"
 	"github.com/stretchr/testify/mock"
 )
 
 func TestPlaceOrder(t *testing.T) {
-	logger.Init()
+	logger.Init(logger.Config{})
 
 	httpMethod := "POST"
 	httpPath := "/orders"
@@ -30,7 +36,7 @@ func TestPlaceOrder(t *testing.T) {
 
 		mockOrderUC := new(mocks.OrderUsecase)
 		router := createGinRouter()
-		NewOrderHandler(router, mockOrderUC)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
 
 		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
 		w := httptest.NewRecorder()
@@ -46,7 +52,7 @@ func TestPlaceOrder(t *testing.T) {
 
 		mockOrderUC := new(mocks.OrderUsecase)
 		router := createGinRouter()
-		NewOrderHandler(router, mockOrderUC)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
 
 		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
 		w := httptest.NewRecorder()
@@ -63,7 +69,7 @@ func TestPlaceOrder(t *testing.T) {
 
 		mockOrderUC := new(mocks.OrderUsecase)
 		router := createGinRouter()
-		NewOrderHandler(router, mockOrderUC)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
 
 		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
 		w := httptest.NewRecorder()
@@ -79,7 +85,7 @@ func TestPlaceOrder(t *testing.T) {
 
 		mockOrderUC := new(mocks.OrderUsecase)
 		router := createGinRouter()
-		NewOrderHandler(router, mockOrderUC)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
 
 		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
 		w := httptest.NewRecorder()
@@ -89,15 +95,519 @@ func TestPlaceOrder(t *testing.T) {
 		assert.Equal(t, "400", w.Header().Get("HTTP"))
 	})
 
+	t.Run("travel-mode", func(t *testing.T) {
+		placeOrderReq := createValidPlaceOrderRequest()
+		placeOrderReq.TravelMode = order.TravelModeTwoWheeler
+		placeOrderReq.Avoid = []string{order.AvoidTolls, order.AvoidFerries}
+		jsonBytes, _ := json.Marshal(placeOrderReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, order.Placement{
+			Origin:      createValidOrigin(),
+			Destination: createValidDestination(),
+			Options:     order.RouteOptions{Mode: order.TravelModeTwoWheeler, AvoidTolls: true, AvoidFerries: true},
+		}).Return(&order.Order{ID: 1, Distance: 1200, TravelMode: order.TravelModeTwoWheeler, Status: order.StatusUnassigned}, nil)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), `"travel_mode":"two_wheeler"`))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("scheduled", func(t *testing.T) {
+		scheduledFor := time.Date(2100, 10, 1, 12, 0, 0, 0, time.UTC)
+		placeOrderReq := createValidPlaceOrderRequest()
+		placeOrderReq.ScheduledFor = &scheduledFor
+		jsonBytes, _ := json.Marshal(placeOrderReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, order.Placement{
+			Origin:       createValidOrigin(),
+			Destination:  createValidDestination(),
+			ScheduledFor: &scheduledFor,
+		}).Return(&order.Order{ID: 1, Distance: 1200, Status: order.StatusScheduled, ScheduledFor: &scheduledFor}, nil)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), `"status":"SCHEDULED"`))
+		assert.Equal(t, true, strings.Contains(w.Body.String(), `"scheduled_for":"2100-10-01T12:00:00Z"`))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("scheduled-in-past", func(t *testing.T) {
+		scheduledFor := time.Now().Add(-time.Minute)
+		placeOrderReq := createValidPlaceOrderRequest()
+		placeOrderReq.ScheduledFor = &scheduledFor
+		jsonBytes, _ := json.Marshal(placeOrderReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), errScheduleInPast))
+		mockOrderUC.AssertNotCalled(t, "PlaceOrder")
+	})
+
+	t.Run("invalid-travel-mode", func(t *testing.T) {
+		placeOrderReq := createValidPlaceOrderRequest()
+		placeOrderReq.TravelMode = "flying"
+		jsonBytes, _ := json.Marshal(placeOrderReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockOrderUC.AssertNotCalled(t, "PlaceOrder")
+	})
+
+	t.Run("invalid-avoid", func(t *testing.T) {
+		placeOrderReq := createValidPlaceOrderRequest()
+		placeOrderReq.Avoid = []string{"indoor"}
+		jsonBytes, _ := json.Marshal(placeOrderReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockOrderUC.AssertNotCalled(t, "PlaceOrder")
+	})
+
+	t.Run("by-address", func(t *testing.T) {
+		placeOrderReq := PlaceOrderRequest{OriginAddress: "1 Austin Road West", Destination: createValidDestination()}
+		jsonBytes, _ := json.Marshal(placeOrderReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, order.Placement{OriginAddress: "1 Austin Road West", Destination: createValidDestination()}).
+			Return(&order.Order{ID: 1, Distance: 1200, Status: order.StatusUnassigned, OriginAddress: "1 Austin Rd W, Tsim Sha Tsui, Hong Kong",
+				Price: 1600, Currency: "HKD", TariffVersion: "v1"}, nil)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), `"origin_address":"1 Austin Rd W, Tsim Sha Tsui, Hong Kong"`))
+		assert.Equal(t, true, strings.Contains(w.Body.String(), `"price":1600,"currency":"HKD","tariff_version":"v1"`))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("multi-stop", func(t *testing.T) {
+		placeOrderReq := PlaceOrderRequest{Stops: []PlaceOrderStop{
+			{Type: order.StopPickup, Coordinates: createValidOrigin()},
+			{Type: order.StopDropoff, Address: "2 Finance Street"},
+			{Type: order.StopDropoff, Coordinates: createValidDestination()},
+		}}
+		jsonBytes, _ := json.Marshal(placeOrderReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, order.Placement{Stops: []order.PlacementStop{
+			{Type: order.StopPickup, Coordinates: createValidOrigin()},
+			{Type: order.StopDropoff, Address: "2 Finance Street"},
+			{Type: order.StopDropoff, Coordinates: createValidDestination()},
+		}}).Return(&order.Order{ID: 1, Distance: 5200, Status: order.StatusUnassigned, Stops: []order.Stop{
+			{Index: 0, Type: order.StopPickup},
+			{Index: 1, Type: order.StopDropoff, Address: "2 Finance St, Central, Hong Kong", Distance: 2000},
+			{Index: 2, Type: order.StopDropoff, Distance: 3200},
+		}}, nil)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), `{"index":1,"type":"dropoff","address":"2 Finance St, Central, Hong Kong","distance":2000}`))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("coordinates-and-address", func(t *testing.T) {
+		placeOrderReq := createValidPlaceOrderRequest()
+		placeOrderReq.DestinationAddress = "2 Finance Street"
+		jsonBytes, _ := json.Marshal(placeOrderReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), errCoordinatesOrAddress))
+		mockOrderUC.AssertNotCalled(t, "PlaceOrder")
+	})
+
+	t.Run("address-not-found", func(t *testing.T) {
+		placeOrderReq := PlaceOrderRequest{Origin: createValidOrigin(), DestinationAddress: "Atlantis"}
+		jsonBytes, _ := json.Marshal(placeOrderReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, mock.AnythingOfType("order.Placement")).
+			Return(nil, fmt.Errorf("destination: %w", order.ErrAddressNotFound))
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "422", w.Header().Get("HTTP"))
+		assert.Equal(t, true, strings.Contains(w.Body.String(), "destination: address not found"))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("no-route", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(createValidPlaceOrderRequest())
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, mock.AnythingOfType("order.Placement")).
+			Return(nil, order.ErrNoRoute)
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), order.ErrNoRoute.Error()))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("outside-service-area", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(createValidPlaceOrderRequest())
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, mock.AnythingOfType("order.Placement")).
+			Return(nil, fmt.Errorf("origin: %w", order.ErrOutsideServiceArea))
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), "origin: outside the service area"))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("breaks-rules", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(createValidPlaceOrderRequest())
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, mock.AnythingOfType("order.Placement")).
+			Return(nil, &order.ValidationError{Violations: []order.Violation{
+				{Rule: order.RuleMaxDistance, Message: "distance of 25000 m is over the maximum of 20000 m"},
+				{Rule: order.RuleOperatingHours, Message: "placed at 03:00, outside the operating hours from 08:00 to 02:00"},
+			}})
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp struct {
+			Error      string            `json:"error"`
+			Violations []order.Violation `json:"violations"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "422", w.Header().Get("HTTP"))
+		assert.Equal(t, 2, len(resp.Violations))
+		assert.Equal(t, order.RuleOperatingHours, resp.Violations[1].Rule)
+		assert.Equal(t, true, strings.HasPrefix(resp.Error, "order breaks business rules: "))
+		mockOrderUC.AssertExpectations(t)
+	})
+
 	t.Run("db-error", func(t *testing.T) {
 		tempMockRequest := createValidPlaceOrderRequest()
 		jsonBytes, _ := json.Marshal(tempMockRequest)
 
 		mockOrderUC := new(mocks.OrderUsecase)
-		mockOrderUC.On("PlaceOrder", mock.AnythingOfType("[]string"),
-			mock.AnythingOfType("[]string")).Return(nil, &mysql.MySQLError{})
+		mockOrderUC.On("PlaceOrder", mock.Anything, mock.AnythingOfType("order.Placement")).Return(nil, &mysql.MySQLError{})
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "500", w.Header().Get("HTTP"))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("forbidden-role", func(t *testing.T) {
+		tempMockRequest := createValidPlaceOrderRequest()
+		jsonBytes, _ := json.Marshal(tempMockRequest)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleCourier)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "403", w.Header().Get("HTTP"))
+		mockOrderUC.AssertNotCalled(t, "PlaceOrder")
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		tempMockRequest := createValidPlaceOrderRequest()
+		jsonBytes, _ := json.Marshal(tempMockRequest)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, mock.AnythingOfType("order.Placement")).Return(nil, auth.ErrNoIdentity)
 		router := createGinRouter()
-		NewOrderHandler(router, mockOrderUC)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "401", w.Header().Get("HTTP"))
+		mockOrderUC.AssertExpectations(t)
+	})
+}
+
+func TestPlaceOrderFromQuote(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	httpMethod := "POST"
+	httpPath := "/orders"
+
+	t.Run("success", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(PlaceOrderRequest{QuoteID: "4f1c"})
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrder", mock.Anything, order.Placement{QuoteID: "4f1c"}).
+			Return(&order.Order{ID: 1, Distance: 4200, Status: order.StatusUnassigned, Price: 3100, Currency: "HKD", TariffVersion: "v1", QuoteID: "4f1c"}, nil)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), `"quote_id":"4f1c"`))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("quote-and-ends", func(t *testing.T) {
+		placeOrderReq := createValidPlaceOrderRequest()
+		placeOrderReq.QuoteID = "4f1c"
+		jsonBytes, _ := json.Marshal(placeOrderReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), errQuoteOrRoute))
+		mockOrderUC.AssertNotCalled(t, "PlaceOrder")
+	})
+
+	quoteErrs := []struct {
+		err  error
+		code int
+	}{
+		{order.ErrQuoteNotFound, http.StatusNotFound},
+		{order.ErrQuoteExpired, http.StatusUnprocessableEntity},
+		{order.ErrQuoteUsed, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range quoteErrs {
+		quoteErr := tt.err
+		t.Run(strings.ReplaceAll(quoteErr.Error(), " ", "-"), func(t *testing.T) {
+			jsonBytes, _ := json.Marshal(PlaceOrderRequest{QuoteID: "4f1c"})
+
+			mockOrderUC := new(mocks.OrderUsecase)
+			mockOrderUC.On("PlaceOrder", mock.Anything, order.Placement{QuoteID: "4f1c"}).Return(nil, quoteErr)
+			router := createGinRouterAs(auth.RoleMerchant)
+			NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+			req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, true, strings.Contains(w.Body.String(), quoteErr.Error()))
+		})
+	}
+
+	t.Run("batch-quote-used-meanwhile", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(PlaceOrdersRequest{Orders: []PlaceOrderRequest{{QuoteID: "4f1c"}}})
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrders", mock.Anything, []order.Placement{{QuoteID: "4f1c"}}).
+			Return([]order.PlacementResult{{Err: order.ErrQuoteUsed}}, nil)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath+"/batch", bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp PlaceOrdersResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, resp.Failed)
+		assert.Equal(t, order.ErrQuoteUsed.Error(), resp.Results[0].Error)
+		mockOrderUC.AssertExpectations(t)
+	})
+}
+
+func TestPlaceOrders(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	httpMethod := "POST"
+	httpPath := "/orders/batch"
+
+	t.Run("too-large", func(t *testing.T) {
+		var placeOrdersReq PlaceOrdersRequest
+		for i := 0; i <= mockMaxBatchSize; i++ {
+			placeOrdersReq.Orders = append(placeOrdersReq.Orders, createValidPlaceOrderRequest())
+		}
+		jsonBytes, _ := json.Marshal(placeOrdersReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), "a batch has at most 10 orders"))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("partial-failure", func(t *testing.T) {
+		placeOrdersReq := PlaceOrdersRequest{Orders: []PlaceOrderRequest{
+			createValidPlaceOrderRequest(),
+			createMockPlaceOrderRequest([]string{"92.300789", "114.167815"}, createValidDestination()),
+			createMockPlaceOrderRequest(createValidOrigin(), []string{"22.28", "114.15"}),
+			createMockPlaceOrderRequest(createValidOrigin(), []string{"22.3", "114.17"}),
+		}}
+		jsonBytes, _ := json.Marshal(placeOrdersReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrders", mock.Anything, []order.Placement{
+			{Origin: createValidOrigin(), Destination: createValidDestination()},
+			{Origin: createValidOrigin(), Destination: []string{"22.28", "114.15"}},
+			{Origin: createValidOrigin(), Destination: []string{"22.3", "114.17"}},
+		}).Return([]order.PlacementResult{
+			{Order: &order.Order{ID: 1, Distance: 1200, Status: order.StatusUnassigned, MerchantID: "merchant-1"}},
+			{Err: order.ErrNoRoute},
+			{Err: &order.ValidationError{Violations: []order.Violation{
+				{Rule: order.RuleMinDistance, Message: "distance of 100 m is under the minimum of 500 m"},
+			}}},
+		}, nil)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp PlaceOrdersResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "200", w.Header().Get("HTTP"))
+		assert.Equal(t, 1, resp.Placed)
+		assert.Equal(t, 3, resp.Failed)
+		assert.Equal(t, int64(1), resp.Results[0].Order.ID)
+		assert.Equal(t, errInvalidCoordinates, resp.Results[1].Error)
+		assert.Equal(t, 2, resp.Results[2].Index)
+		assert.Equal(t, order.ErrNoRoute.Error(), resp.Results[2].Error)
+		assert.Equal(t, "order breaks business rules: distance of 100 m is under the minimum of 500 m", resp.Results[3].Error)
+		assert.Equal(t, order.RuleMinDistance, resp.Results[3].Violations[0].Rule)
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("all-invalid", func(t *testing.T) {
+		placeOrdersReq := PlaceOrdersRequest{Orders: []PlaceOrderRequest{
+			createMockPlaceOrderRequest([]string{"22.300789", "abc"}, createValidDestination()),
+		}}
+		jsonBytes, _ := json.Marshal(placeOrdersReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), `"failed":1`))
+		mockOrderUC.AssertNotCalled(t, "PlaceOrders")
+	})
+
+	t.Run("empty-batch", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(PlaceOrdersRequest{Orders: []PlaceOrderRequest{}})
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("db-error", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(PlaceOrdersRequest{Orders: []PlaceOrderRequest{createValidPlaceOrderRequest()}})
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("PlaceOrders", mock.Anything, mock.AnythingOfType("[]order.Placement")).
+			Return(nil, &mysql.MySQLError{})
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
 
 		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
 		w := httptest.NewRecorder()
@@ -107,6 +617,183 @@ func TestPlaceOrder(t *testing.T) {
 		assert.Equal(t, "500", w.Header().Get("HTTP"))
 		mockOrderUC.AssertExpectations(t)
 	})
+
+	t.Run("forbidden-role", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(PlaceOrdersRequest{Orders: []PlaceOrderRequest{createValidPlaceOrderRequest()}})
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleCourier)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockOrderUC.AssertNotCalled(t, "PlaceOrders")
+	})
+}
+
+func TestQuoteOrder(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	httpMethod := "POST"
+	httpPath := "/quotes"
+
+	t.Run("success", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(createValidPlaceOrderRequest())
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("QuoteOrder", mock.Anything, order.Placement{Origin: createValidOrigin(), Destination: createValidDestination()}).
+			Return(&order.Quote{ID: "4f1c", TenantID: "brand-a", MerchantID: "merchant-1", Distance: 4200, TravelMode: order.TravelModeDriving,
+				Price: 3100, Currency: "HKD", TariffVersion: "v1", ExpiresAt: time.Date(2022, 10, 1, 12, 5, 0, 0, time.UTC)}, nil)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"id":"4f1c","distance":4200,"travel_mode":"driving","price":3100,"currency":"HKD","tariff_version":"v1",`+
+			`"expires_at":"2022-10-01T12:05:00Z"}`, w.Body.String())
+		mockOrderUC.AssertExpectations(t)
+		mockOrderUC.AssertNotCalled(t, "PlaceOrder")
+	})
+
+	t.Run("invalid-coordinates", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(createMockPlaceOrderRequest([]string{"22.300789"}, createValidDestination()))
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockOrderUC.AssertNotCalled(t, "QuoteOrder")
+	})
+
+	t.Run("quote-id", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(PlaceOrderRequest{QuoteID: "4f1c"})
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), errQuoteNotAllowed))
+		mockOrderUC.AssertNotCalled(t, "QuoteOrder")
+	})
+
+	t.Run("scheduled-for", func(t *testing.T) {
+		scheduledFor := time.Now().Add(2 * time.Hour)
+		placeOrderReq := createValidPlaceOrderRequest()
+		placeOrderReq.ScheduledFor = &scheduledFor
+		jsonBytes, _ := json.Marshal(placeOrderReq)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), errScheduleNotAllowed))
+		mockOrderUC.AssertNotCalled(t, "QuoteOrder")
+	})
+
+	t.Run("stops", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(PlaceOrderRequest{Stops: []PlaceOrderStop{
+			{Type: order.StopPickup, Coordinates: createValidOrigin()},
+			{Type: order.StopDropoff, Coordinates: createValidDestination()},
+		}})
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), errStopsNotQuotable))
+		mockOrderUC.AssertNotCalled(t, "QuoteOrder")
+	})
+
+	t.Run("no-tariff", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(createValidPlaceOrderRequest())
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("QuoteOrder", mock.Anything, mock.AnythingOfType("order.Placement")).Return(nil, order.ErrNoTariff)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), order.ErrNoTariff.Error()))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("ambiguous-address", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(PlaceOrderRequest{OriginAddress: "Nathan Road", Destination: createValidDestination()})
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("QuoteOrder", mock.Anything, mock.AnythingOfType("order.Placement")).
+			Return(nil, fmt.Errorf("origin: %w", order.ErrAmbiguousAddress))
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), "origin: address is ambiguous"))
+	})
+
+	t.Run("forbidden-role", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(createValidPlaceOrderRequest())
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleCourier)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockOrderUC.AssertNotCalled(t, "QuoteOrder")
+	})
+
+	t.Run("map-api-error", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(createValidPlaceOrderRequest())
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("QuoteOrder", mock.Anything, mock.AnythingOfType("order.Placement")).Return(nil, fmt.Errorf("service unavailable"))
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockOrderUC.AssertExpectations(t)
+	})
 }
 
 func TestTakeOrder(t *testing.T) {
@@ -121,7 +808,7 @@ func TestTakeOrder(t *testing.T) {
 
 		mockOrderUC := new(mocks.OrderUsecase)
 		router := createGinRouter()
-		NewOrderHandler(router, mockOrderUC)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
 
 		req, _ := http.NewRequest(httpMethod, tempHTTPPath, bytes.NewReader(jsonBytes))
 		w := httptest.NewRecorder()
@@ -138,7 +825,7 @@ func TestTakeOrder(t *testing.T) {
 
 		mockOrderUC := new(mocks.OrderUsecase)
 		router := createGinRouter()
-		NewOrderHandler(router, mockOrderUC)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
 
 		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
 		w := httptest.NewRecorder()
@@ -148,14 +835,31 @@ func TestTakeOrder(t *testing.T) {
 		assert.Equal(t, "400", w.Header().Get("HTTP"))
 	})
 
+	t.Run("not-found", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(createMockTakeOrderRequest())
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("TakeOrder", mock.Anything, mock.AnythingOfType("int64")).Return("", sql.ErrNoRows)
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "404", w.Header().Get("HTTP"))
+		mockOrderUC.AssertExpectations(t)
+	})
+
 	t.Run("db-error", func(t *testing.T) {
 		mockRequest := createMockTakeOrderRequest()
 		jsonBytes, _ := json.Marshal(mockRequest)
 
 		mockOrderUC := new(mocks.OrderUsecase)
-		mockOrderUC.On("TakeOrder", mock.AnythingOfType("int64")).Return("", &mysql.MySQLError{})
+		mockOrderUC.On("TakeOrder", mock.Anything, mock.AnythingOfType("int64")).Return("", &mysql.MySQLError{})
 		router := createGinRouter()
-		NewOrderHandler(router, mockOrderUC)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
 
 		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
 		w := httptest.NewRecorder()
@@ -167,6 +871,86 @@ func TestTakeOrder(t *testing.T) {
 	})
 }
 
+func TestCompleteStop(t *testing.T) {
+	httpMethod := "PATCH"
+	httpPath := "/orders/1/stops/1"
+	jsonBytes, _ := json.Marshal(CompleteStopRequest{Status: "COMPLETED"})
+
+	t.Run("success", func(t *testing.T) {
+		completedAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("CompleteStop", mock.Anything, int64(1), 1).
+			Return(&order.Stop{Index: 1, Type: order.StopDropoff, Distance: 2000, CompletedAt: &completedAt}, nil)
+		router := createGinRouterAs(auth.RoleCourier)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"index":1,"type":"dropoff","distance":2000,"completed_at":"2022-10-01T12:00:00Z"}`, w.Body.String())
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("invalid-status", func(t *testing.T) {
+		invalidJSONBytes, _ := json.Marshal(CompleteStopRequest{Status: "TAKEN"})
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleCourier)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(invalidJSONBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockOrderUC.AssertNotCalled(t, "CompleteStop")
+	})
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"order-not-found", sql.ErrNoRows, http.StatusNotFound},
+		{"stop-not-found", order.ErrStopNotFound, http.StatusNotFound},
+		{"not-taken-by-courier", order.ErrNotTakenByCourier, http.StatusForbidden},
+		{"already-completed", order.ErrStopCompleted, http.StatusConflict},
+		{"db-error", &mysql.MySQLError{}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOrderUC := new(mocks.OrderUsecase)
+			mockOrderUC.On("CompleteStop", mock.Anything, int64(1), 1).Return(nil, tt.err)
+			router := createGinRouterAs(auth.RoleCourier)
+			NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+			req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, fmt.Sprint(tt.status), w.Header().Get("HTTP"))
+			mockOrderUC.AssertExpectations(t)
+		})
+	}
+
+	t.Run("forbidden-role", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockOrderUC.AssertNotCalled(t, "CompleteStop")
+	})
+}
+
 func TestListOrders(t *testing.T) {
 	httpMethod := "GET"
 	httpPath := "/orders"
@@ -177,10 +961,10 @@ func TestListOrders(t *testing.T) {
 		expJSONRespBytes, _ := json.Marshal([]string{})
 
 		mockOrderUC := new(mocks.OrderUsecase)
-		mockOrderUC.On("ListOrders", mock.AnythingOfType("int"),
+		mockOrderUC.On("ListOrders", mock.Anything, mock.AnythingOfType("int"),
 			mock.AnythingOfType("int")).Return(&[]order.Order{}, nil)
 		router := createGinRouter()
-		NewOrderHandler(router, mockOrderUC)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
 
 		req, _ := http.NewRequest(httpMethod, httpPath+qParams, nil)
 		w := httptest.NewRecorder()
@@ -192,6 +976,27 @@ func TestListOrders(t *testing.T) {
 		mockOrderUC.AssertExpectations(t)
 	})
 
+	t.Run("estimated-delivery", func(t *testing.T) {
+		qParams := buildListOrderQueryParams(1, 4)
+		deliveryAt := time.Date(2022, 10, 1, 12, 7, 0, 0, time.UTC)
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("ListOrders", mock.Anything, mock.AnythingOfType("int"),
+			mock.AnythingOfType("int")).Return(&[]order.Order{
+			{ID: 1, Distance: 1200, EstimatedDuration: 420, EstimatedDeliveryAt: &deliveryAt, Status: order.StatusUnassigned, MerchantID: "merchant-1"},
+		}, nil)
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath+qParams, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, strings.Contains(w.Body.String(), `"estimated_duration":420,"estimated_delivery_at":"2022-10-01T12:07:00Z"`))
+		mockOrderUC.AssertExpectations(t)
+	})
+
 	t.Run("invalid-qparams", func(t *testing.T) {
 		mockPage := "aaa"
 		mockLimit := 4
@@ -199,7 +1004,7 @@ func TestListOrders(t *testing.T) {
 
 		mockOrderUC := new(mocks.OrderUsecase)
 		router := createGinRouter()
-		NewOrderHandler(router, mockOrderUC)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
 
 		req, _ := http.NewRequest(httpMethod, httpPath+qParams, nil)
 		w := httptest.NewRecorder()
@@ -213,10 +1018,10 @@ func TestListOrders(t *testing.T) {
 		qParams := buildListOrderQueryParams(1, 4)
 
 		mockOrderUC := new(mocks.OrderUsecase)
-		mockOrderUC.On("ListOrders", mock.AnythingOfType("int"),
+		mockOrderUC.On("ListOrders", mock.Anything, mock.AnythingOfType("int"),
 			mock.AnythingOfType("int")).Return(nil, &mysql.MySQLError{})
 		router := createGinRouter()
-		NewOrderHandler(router, mockOrderUC)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
 
 		req, _ := http.NewRequest(httpMethod, httpPath+qParams, nil)
 		w := httptest.NewRecorder()
@@ -228,6 +1033,58 @@ func TestListOrders(t *testing.T) {
 	})
 }
 
+func TestGetOrder(t *testing.T) {
+	httpMethod := "GET"
+	httpPath := "/orders/1"
+
+	t.Run("success", func(t *testing.T) {
+		mockOrder := &order.Order{ID: 1, Distance: 100, Status: order.StatusUnassigned, MerchantID: "merchant-1"}
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("GetOrder", mock.Anything, int64(1)).Return(mockOrder, nil)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "200", w.Header().Get("HTTP"))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("other-merchant-order", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("GetOrder", mock.Anything, int64(1)).Return(nil, auth.ErrForbidden)
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "403", w.Header().Get("HTTP"))
+		mockOrderUC.AssertExpectations(t)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("GetOrder", mock.Anything, int64(1)).Return(nil, sql.ErrNoRows)
+		router := createGinRouter()
+		NewOrderHandler(router, mockOrderUC, mockMaxBatchSize)
+
+		req, _ := http.NewRequest(httpMethod, httpPath, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "404", w.Header().Get("HTTP"))
+		mockOrderUC.AssertExpectations(t)
+	})
+}
+
 func TestValidatePlaceOrder(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockRequest := PlaceOrderRequest{
@@ -240,6 +1097,70 @@ func TestValidatePlaceOrder(t *testing.T) {
 		assert.Equal(t, true, isValid)
 	})
 
+	t.Run("quote", func(t *testing.T) {
+		isValid, _ := validatePlaceOrder(PlaceOrderRequest{QuoteID: "4f1c"})
+
+		assert.Equal(t, true, isValid)
+	})
+
+	t.Run("scheduled", func(t *testing.T) {
+		later := time.Now().Add(2 * time.Hour)
+		earlier := time.Now().Add(-time.Minute)
+
+		tests := []struct {
+			name   string
+			req    PlaceOrderRequest
+			errMsg string
+		}{
+			{"future", PlaceOrderRequest{Origin: createValidOrigin(), Destination: createValidDestination(), ScheduledFor: &later}, ""},
+			{"from-quote", PlaceOrderRequest{QuoteID: "4f1c", ScheduledFor: &later}, ""},
+			{"past", PlaceOrderRequest{Origin: createValidOrigin(), Destination: createValidDestination(), ScheduledFor: &earlier}, errScheduleInPast},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				isValid, s := validatePlaceOrder(tt.req)
+
+				assert.Equal(t, tt.errMsg == "", isValid)
+				assert.Equal(t, tt.errMsg, s)
+			})
+		}
+	})
+
+	t.Run("quote-with-travel-mode", func(t *testing.T) {
+		isValid, s := validatePlaceOrder(PlaceOrderRequest{QuoteID: "4f1c", TravelMode: order.TravelModeWalking})
+
+		assert.Equal(t, false, isValid)
+		assert.Equal(t, errQuoteOrRoute, s)
+	})
+
+	t.Run("stops", func(t *testing.T) {
+		stops := []PlaceOrderStop{
+			{Type: order.StopPickup, Coordinates: createValidOrigin()},
+			{Type: order.StopDropoff, Address: "2 Finance Street"},
+		}
+
+		tests := []struct {
+			name   string
+			req    PlaceOrderRequest
+			errMsg string
+		}{
+			{"valid", PlaceOrderRequest{Stops: stops}, ""},
+			{"and-ends", PlaceOrderRequest{Stops: stops, Origin: createValidOrigin()}, errStopsOrEnds},
+			{"one-stop", PlaceOrderRequest{Stops: stops[:1]}, errStopCount},
+			{"invalid-type", PlaceOrderRequest{Stops: []PlaceOrderStop{stops[0], {Type: "detour", Address: "2 Finance Street"}}}, errInvalidStopType},
+			{"ends-with-pickup", PlaceOrderRequest{Stops: []PlaceOrderStop{stops[0], stops[0]}}, errStopSequence},
+			{"invalid-coordinates", PlaceOrderRequest{Stops: []PlaceOrderStop{{Type: order.StopPickup, Coordinates: []string{"aa", "bb"}}, stops[1]}}, errInvalidCoordinates},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				isValid, s := validatePlaceOrder(tt.req)
+
+				assert.Equal(t, tt.errMsg == "", isValid)
+				assert.Equal(t, tt.errMsg, s)
+			})
+		}
+	})
+
 	t.Run("coordinate-not-two", func(t *testing.T) {
 		mockRequest := PlaceOrderRequest{
 			Origin:      []string{"22.300789", "114.167815", "114.167815"},
@@ -289,11 +1210,35 @@ func TestValidatePlaceOrder(t *testing.T) {
 	})
 }
 
+var spec = loadSpec()
+
+// mockMaxBatchSize is the most orders a batch of the tests places
+const mockMaxBatchSize int = 10
+
+func loadSpec() *openapi3.T {
+	doc, err := openapi.Load()
+	if err != nil {
+		panic(err)
+	}
+	return doc
+}
+
 func createGinRouter() *gin.Engine {
+	return createGinRouterAs(auth.RoleAdmin)
+}
+
+// createGinRouterAs creates a router whose requests are all made by a caller holding role
+func createGinRouterAs(role string) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 
 	router := gin.New()
 	router.Use(middleware.HandleRestError)
+	router.Use(func(c *gin.Context) {
+		identity := &auth.Identity{Subject: role + "-1", Roles: []string{role}, Tenant: "brand-a"}
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+	})
+	// every request and response of the handler tests must match the spec
+	router.Use(middleware.ValidateOpenAPI(openapi.NewValidator(spec, true)))
 
 	return router
 }
@@ -1,9 +1,33 @@
 package rest
 
-// PlaceOrderRequest represents the object of place order request params
+import "time"
+
+// PlaceOrderRequest represents the object of place order request params, each
+// end is either coordinates or an address. A multi-stop order gives its Stops
+// instead of its ends. Orders are driven unless TravelMode says otherwise. An
+// order placed from a quote only gives QuoteID, and ScheduledFor if any
 type PlaceOrderRequest struct {
-	Origin      []string `json:"origin"`
-	Destination []string `json:"destination"`
+	QuoteID            string           `json:"quote_id,omitempty"`
+	Origin             []string         `json:"origin,omitempty"`
+	OriginAddress      string           `json:"origin_address,omitempty"`
+	Destination        []string         `json:"destination,omitempty"`
+	DestinationAddress string           `json:"destination_address,omitempty"`
+	Stops              []PlaceOrderStop `json:"stops,omitempty"`
+	TravelMode         string           `json:"travel_mode,omitempty"`
+	Avoid              []string         `json:"avoid,omitempty"`
+	ScheduledFor       *time.Time       `json:"scheduled_for,omitempty"`
+}
+
+// PlaceOrderStop represents a stop of a multi-stop order, either coordinates or an address
+type PlaceOrderStop struct {
+	Type        string   `json:"type"`
+	Coordinates []string `json:"coordinates,omitempty"`
+	Address     string   `json:"address,omitempty"`
+}
+
+// PlaceOrdersRequest represents the object of place orders in batch request params
+type PlaceOrdersRequest struct {
+	Orders []PlaceOrderRequest `json:"orders"`
 }
 
 // TakeOrderRequest represents the object of take order request params
@@ -12,8 +36,27 @@ type TakeOrderRequest struct {
 	Status string `json:"status" valid:"-"`
 }
 
+// CompleteStopRequest represents the object of complete stop request params
+type CompleteStopRequest struct {
+	ID     int64  `uri:"id" json:"-" binding:"required"`
+	Index  int    `uri:"index" json:"-" binding:"min=0"`
+	Status string `json:"status"`
+}
+
+// GetOrderRequest represents the object of get order request params
+type GetOrderRequest struct {
+	ID int64 `uri:"id" binding:"required"`
+}
+
 // ListOrderRequest represents the object of list order request params
 type ListOrderRequest struct {
 	Page  int `form:"page" valid:"int"`
 	Limit int `form:"limit" valid:"int"`
 }
+
+// StreamEventsRequest represents the object of stream order events request params
+type StreamEventsRequest struct {
+	Status      string `form:"status"`
+	Area        string `form:"area"`
+	LastEventID string `form:"last_event_id"`
+}
@@ -1,10 +1,44 @@
 package rest
 
+import (
+	"time"
+
+	"github.com/imylam/delivery-test/order"
+)
+
 // PlaceOrderReponse represents the place order reponse body
 type PlaceOrderReponse struct {
-	ID       int    `json:"id"`
-	Distance int    `json:"distance"`
-	Status   string `json:"status"`
+	ID                  int          `json:"id"`
+	Distance            int          `json:"distance"`
+	TravelMode          string       `json:"travel_mode"`
+	OriginAddress       string       `json:"origin_address"`
+	DestinationAddress  string       `json:"destination_address"`
+	EstimatedDuration   int          `json:"estimated_duration"`
+	EstimatedDeliveryAt *time.Time   `json:"estimated_delivery_at"`
+	Price               int64        `json:"price"`
+	Currency            string       `json:"currency"`
+	TariffVersion       string       `json:"tariff_version"`
+	QuoteID             string       `json:"quote_id"`
+	ScheduledFor        *time.Time   `json:"scheduled_for"`
+	Status              string       `json:"status"`
+	Stops               []order.Stop `json:"stops"`
+}
+
+// PlaceOrdersResponse represents the place orders in batch response body,
+// Results follow the order of the request
+type PlaceOrdersResponse struct {
+	Placed  int                `json:"placed"`
+	Failed  int                `json:"failed"`
+	Results []PlaceOrderResult `json:"results"`
+}
+
+// PlaceOrderResult represents the outcome of placing one order of a batch,
+// either Order or Error is set. Violations lists the business rules behind Error
+type PlaceOrderResult struct {
+	Index      int               `json:"index"`
+	Order      *order.Order      `json:"order,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Violations []order.Violation `json:"violations,omitempty"`
 }
 
 // TakeOrderResponse rrepresents the take order reponse body
@@ -0,0 +1,304 @@
+package ws
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/common/middleware"
+	resterrors "github.com/imylam/delivery-test/common/rest_errors"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/order/usecase"
+	"go.uber.org/zap"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	errInternalServer  string = "internal server error"
+	errUnauthorized    string = "unauthorized"
+	errForbidden       string = "forbidden"
+	errTenantRequired  string = "tenant required"
+	errOrderNotFound   string = "order not found"
+	errOrderNotOffered string = "order not offered"
+	errInvalidMessage  string = "invalid message"
+	errUnknownMessage  string = "unknown message type"
+	offerBatchSize     int    = 100
+	maxMessageSize     int64  = 4096
+	outgoingBufferSize int    = 16
+)
+
+var (
+	// offerPollInterval is how often the event history is checked for new orders to offer
+	offerPollInterval = time.Second
+	// maxOffers is how many orders a courier has on offer at most, the oldest
+	// offers are withdrawn past it
+	maxOffers = 1000
+	// pingInterval is how often couriers are pinged, they are dropped without a pong within pongWait
+	pingInterval = 30 * time.Second
+	pongWait     = 60 * time.Second
+	writeWait    = 10 * time.Second
+)
+
+// dispatchHandler represents the WebSocket handler offering orders to couriers
+type dispatchHandler struct {
+	orderUC  order.OrderUsecase
+	upgrader websocket.Upgrader
+}
+
+// NewDispatchHandler will initialize the courier dispatch endpoint
+func NewDispatchHandler(g *gin.Engine, orderUC order.OrderUsecase) {
+	handler := &dispatchHandler{
+		orderUC: orderUC,
+		upgrader: websocket.Upgrader{
+			// callers authenticate with headers rather than cookies, so other
+			// sites cannot open channels on their behalf
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+	}
+
+	g.GET("/orders/dispatch", middleware.ResolveTenant(), middleware.RequirePermission(auth.PermissionOrderTake), handler.dispatch)
+}
+
+// dispatch upgrades the request to a WebSocket, offers the orders placed from
+// then on to the courier and takes the ones they accept
+func (h *dispatchHandler) dispatch(c *gin.Context) {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	// read before upgrading so caller errors are still plain HTTP responses
+	lastPosition, err := h.orderUC.LatestEventPosition(ctx)
+	if err != nil {
+		c.Error(toRestError(err))
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader already answered with an error
+		logger.Logger.Debug("fail to upgrade dispatch channel", zap.String("error", err.Error()))
+		return
+	}
+	defer conn.Close()
+
+	s := &session{
+		conn:    conn,
+		orderUC: h.orderUC,
+		out:     make(chan Message, outgoingBufferSize),
+		offered: map[int64]int64{},
+	}
+
+	go s.read(ctx, cancel)
+	go s.offer(ctx, cancel, lastPosition)
+	s.write(ctx)
+}
+
+// session represents the dispatch channel of a courier. Only write touches the
+// connection for writing, the other goroutines go through out. offered holds
+// the position of the event each order on offer was offered with
+type session struct {
+	conn    *websocket.Conn
+	orderUC order.OrderUsecase
+	out     chan Message
+
+	mu      sync.Mutex
+	offered map[int64]int64
+}
+
+// write sends outgoing messages and pings until ctx is done or the connection fails
+func (s *session) write(ctx context.Context) {
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			_ = s.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeWait))
+			return
+		case msg := <-s.out:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := s.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// read handles the messages of the courier until the connection is closed
+func (s *session) read(ctx context.Context, cancel context.CancelFunc) {
+	defer cancel()
+
+	s.conn.SetReadLimit(maxMessageSize)
+	_ = s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil || msg.OrderID < 0 {
+			s.send(ctx, Message{Type: MessageError, Error: errInvalidMessage})
+			continue
+		}
+
+		switch msg.Type {
+		case MessageAccept:
+			s.accept(ctx, msg.OrderID)
+		case MessageDecline:
+			s.withdraw(msg.OrderID)
+		default:
+			s.send(ctx, Message{Type: MessageError, OrderID: msg.OrderID, Error: errUnknownMessage})
+		}
+	}
+}
+
+// accept takes an offered order through the same path as PATCH /orders/:id, so
+// only one courier ever gets it
+func (s *session) accept(ctx context.Context, id int64) {
+	if !s.withdraw(id) {
+		s.send(ctx, Message{Type: MessageError, OrderID: id, Error: errOrderNotOffered})
+		return
+	}
+
+	_, err := s.orderUC.TakeOrder(ctx, id)
+	switch {
+	case err == nil:
+		s.send(ctx, Message{Type: MessageAccepted, OrderID: id})
+	case err.Error() == usecase.ErrorOrderTaken:
+		s.send(ctx, Message{Type: MessageTaken, OrderID: id, Error: usecase.ErrorOrderTaken})
+	case err == sql.ErrNoRows:
+		s.send(ctx, Message{Type: MessageError, OrderID: id, Error: errOrderNotFound})
+	default:
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Logger.Error("fail to take order", zap.String("error", err.Error()))
+		s.send(ctx, Message{Type: MessageError, OrderID: id, Error: errInternalServer})
+	}
+}
+
+// offer offers the orders that become unassigned after the event at
+// lastPosition and withdraws the offers of the orders other couriers take
+func (s *session) offer(ctx context.Context, cancel context.CancelFunc, lastPosition int64) {
+	defer cancel()
+
+	filter := order.EventFilter{Withdrawals: true}
+	poll := time.NewTicker(offerPollInterval)
+	defer poll.Stop()
+
+	for {
+		events, err := s.orderUC.ListEvents(ctx, filter, lastPosition, offerBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Logger.Error("fail to list order events", zap.String("error", err.Error()))
+			}
+			return
+		}
+
+		for _, e := range events {
+			lastPosition = e.Position
+
+			if e.Status != order.StatusUnassigned {
+				// the courier withdrew the offer themselves when they took it
+				if s.withdraw(e.OrderID) {
+					s.send(ctx, Message{Type: MessageWithdrawn, OrderID: e.OrderID})
+				}
+				continue
+			}
+
+			isNew, dropped := s.add(e.OrderID, e.Position)
+			if dropped != 0 {
+				s.send(ctx, Message{Type: MessageWithdrawn, OrderID: dropped})
+			}
+			if isNew {
+				o := e.Order()
+				s.send(ctx, Message{Type: MessageOffer, OrderID: o.ID, Order: &o})
+			}
+		}
+
+		if len(events) == offerBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+// add puts an order on offer, it tells whether it was not already. Past
+// maxOffers the oldest offer is dropped for it, dropped is its order or 0
+func (s *session) add(id, position int64) (isNew bool, dropped int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.offered[id]; ok {
+		return false, 0
+	}
+	if len(s.offered) >= maxOffers {
+		oldest := position
+		for offeredID, offeredAt := range s.offered {
+			if offeredAt < oldest {
+				dropped, oldest = offeredID, offeredAt
+			}
+		}
+		delete(s.offered, dropped)
+	}
+	s.offered[id] = position
+
+	return true, dropped
+}
+
+// withdraw forgets an offer, it tells whether the order was on offer
+func (s *session) withdraw(id int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.offered[id]; !ok {
+		return false
+	}
+	delete(s.offered, id)
+
+	return true
+}
+
+// send queues msg for write, it gives up once ctx is done
+func (s *session) send(ctx context.Context, msg Message) {
+	select {
+	case s.out <- msg:
+	case <-ctx.Done():
+	}
+}
+
+// toRestError converts errors returned by the usecase into rest errors
+func toRestError(err error) resterrors.RestError {
+	switch err {
+	case auth.ErrNoIdentity:
+		return resterrors.NewUnauthorizedError(errUnauthorized)
+	case auth.ErrForbidden:
+		return resterrors.NewForbiddenError(errForbidden)
+	case auth.ErrNoTenant:
+		return resterrors.NewBadRequestError(errTenantRequired)
+	default:
+		logger.Logger.Error("fail to open dispatch channel", zap.String("error", err.Error()))
+		return resterrors.NewInternalServerError(errInternalServer)
+	}
+}
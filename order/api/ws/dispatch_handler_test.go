@@ -0,0 +1,191 @@
+package ws
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/gorilla/websocket"
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/common/middleware"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/order/mocks"
+	"github.com/imylam/delivery-test/order/usecase"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDispatch(t *testing.T) {
+	logger.Init(logger.Config{})
+	offerPollInterval = time.Millisecond
+
+	withdrawalsFilter := order.EventFilter{Withdrawals: true}
+	mockEvents := []order.OrderEvent{
+		{ID: 16, Type: order.EventOrderCreated, OrderID: 1, Distance: 100, Status: order.StatusUnassigned, MerchantID: "merchant-1", Position: 6},
+		{ID: 17, Type: order.EventOrderCreated, OrderID: 2, Distance: 200, Status: order.StatusUnassigned, MerchantID: "merchant-1", Position: 7},
+	}
+
+	t.Run("accept-offer", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("LatestEventPosition", mock.Anything).Return(int64(5), nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, withdrawalsFilter, int64(5), offerBatchSize).Return(mockEvents, nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, withdrawalsFilter, int64(7), offerBatchSize).Return([]order.OrderEvent{}, nil)
+		mockOrderUC.On("TakeOrder", mock.Anything, int64(1)).Return("SUCCESS", nil).Once()
+		conn := dialDispatch(t, auth.RoleCourier, mockOrderUC)
+
+		offer := readMessage(t, conn)
+		assert.Equal(t, MessageOffer, offer.Type)
+		assert.Equal(t, int64(1), offer.OrderID)
+		assert.Equal(t, 100, offer.Order.Distance)
+		assert.Equal(t, int64(2), readMessage(t, conn).OrderID)
+
+		_ = conn.WriteJSON(Message{Type: MessageAccept, OrderID: 1})
+		reply := readMessage(t, conn)
+
+		assert.Equal(t, MessageAccepted, reply.Type)
+		assert.Equal(t, int64(1), reply.OrderID)
+	})
+
+	t.Run("accept-offer-taken-by-other-courier", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("LatestEventPosition", mock.Anything).Return(int64(5), nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, withdrawalsFilter, int64(5), offerBatchSize).Return(mockEvents[:1], nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, withdrawalsFilter, int64(6), offerBatchSize).Return([]order.OrderEvent{}, nil)
+		mockOrderUC.On("TakeOrder", mock.Anything, int64(1)).Return("", errors.New(usecase.ErrorOrderTaken)).Once()
+		conn := dialDispatch(t, auth.RoleCourier, mockOrderUC)
+
+		readMessage(t, conn)
+		_ = conn.WriteJSON(Message{Type: MessageAccept, OrderID: 1})
+		reply := readMessage(t, conn)
+
+		assert.Equal(t, MessageTaken, reply.Type)
+		assert.Equal(t, usecase.ErrorOrderTaken, reply.Error)
+	})
+
+	t.Run("accept-declined-offer", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("LatestEventPosition", mock.Anything).Return(int64(5), nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, withdrawalsFilter, int64(5), offerBatchSize).Return(mockEvents[:1], nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, withdrawalsFilter, int64(6), offerBatchSize).Return([]order.OrderEvent{}, nil)
+		conn := dialDispatch(t, auth.RoleCourier, mockOrderUC)
+
+		readMessage(t, conn)
+		_ = conn.WriteJSON(Message{Type: MessageDecline, OrderID: 1})
+		_ = conn.WriteJSON(Message{Type: MessageAccept, OrderID: 1})
+		reply := readMessage(t, conn)
+
+		assert.Equal(t, MessageError, reply.Type)
+		assert.Equal(t, errOrderNotOffered, reply.Error)
+		mockOrderUC.AssertNotCalled(t, "TakeOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("offer-withdrawn-when-taken", func(t *testing.T) {
+		taken := order.OrderEvent{ID: 18, Type: order.EventOrderStatusChanged, OrderID: 1, Status: order.StatusTaken, Position: 8}
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("LatestEventPosition", mock.Anything).Return(int64(5), nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, withdrawalsFilter, int64(5), offerBatchSize).Return(mockEvents, nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, withdrawalsFilter, int64(7), offerBatchSize).Return([]order.OrderEvent{taken}, nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, withdrawalsFilter, int64(8), offerBatchSize).Return([]order.OrderEvent{}, nil)
+		conn := dialDispatch(t, auth.RoleCourier, mockOrderUC)
+
+		readMessage(t, conn)
+		readMessage(t, conn)
+		withdrawn := readMessage(t, conn)
+
+		assert.Equal(t, MessageWithdrawn, withdrawn.Type)
+		assert.Equal(t, int64(1), withdrawn.OrderID)
+
+		_ = conn.WriteJSON(Message{Type: MessageAccept, OrderID: 1})
+		assert.Equal(t, errOrderNotOffered, readMessage(t, conn).Error)
+		mockOrderUC.AssertNotCalled(t, "TakeOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("oldest-offer-withdrawn-past-max", func(t *testing.T) {
+		defer func(max int) { maxOffers = max }(maxOffers)
+		maxOffers = 1
+
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("LatestEventPosition", mock.Anything).Return(int64(5), nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, withdrawalsFilter, int64(5), offerBatchSize).Return(mockEvents, nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, withdrawalsFilter, int64(7), offerBatchSize).Return([]order.OrderEvent{}, nil)
+		conn := dialDispatch(t, auth.RoleCourier, mockOrderUC)
+
+		assert.Equal(t, int64(1), readMessage(t, conn).OrderID)
+		withdrawn := readMessage(t, conn)
+		offer := readMessage(t, conn)
+
+		assert.Equal(t, MessageWithdrawn, withdrawn.Type)
+		assert.Equal(t, int64(1), withdrawn.OrderID)
+		assert.Equal(t, MessageOffer, offer.Type)
+		assert.Equal(t, int64(2), offer.OrderID)
+	})
+
+	t.Run("invalid-message", func(t *testing.T) {
+		mockOrderUC := new(mocks.OrderUsecase)
+		mockOrderUC.On("LatestEventPosition", mock.Anything).Return(int64(0), nil).Once()
+		mockOrderUC.On("ListEvents", mock.Anything, withdrawalsFilter, int64(0), offerBatchSize).Return([]order.OrderEvent{}, nil)
+		conn := dialDispatch(t, auth.RoleCourier, mockOrderUC)
+
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("take it"))
+		assert.Equal(t, errInvalidMessage, readMessage(t, conn).Error)
+
+		_ = conn.WriteJSON(Message{Type: "steal", OrderID: 1})
+		assert.Equal(t, errUnknownMessage, readMessage(t, conn).Error)
+	})
+
+	t.Run("merchant-forbidden", func(t *testing.T) {
+		server := newDispatchServer(auth.RoleMerchant, new(mocks.OrderUsecase))
+		defer server.Close()
+
+		_, resp, err := websocket.DefaultDialer.Dial(toWebSocketURL(server.URL), nil)
+
+		assert.Equal(t, websocket.ErrBadHandshake, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
+
+func newDispatchServer(role string, orderUC order.OrderUsecase) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(middleware.HandleRestError)
+	router.Use(func(c *gin.Context) {
+		identity := &auth.Identity{Subject: role + "-1", Roles: []string{role}, Tenant: "brand-a"}
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+	})
+	NewDispatchHandler(router, orderUC)
+
+	return httptest.NewServer(router)
+}
+
+func dialDispatch(t *testing.T, role string, orderUC order.OrderUsecase) *websocket.Conn {
+	server := newDispatchServer(role, orderUC)
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial(toWebSocketURL(server.URL), nil)
+	if err != nil {
+		t.Fatalf("fail to dial dispatch channel: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) Message {
+	var msg Message
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("fail to read message: %v", err)
+	}
+
+	return msg
+}
+
+func toWebSocketURL(serverURL string) string {
+	return "ws" + strings.TrimPrefix(serverURL, "http") + "/orders/dispatch"
+}
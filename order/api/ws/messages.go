@@ -0,0 +1,25 @@
+package ws
+
+import "github.com/imylam/delivery-test/order"
+
+// Types of the messages exchanged over the dispatch channel. Couriers are sent
+// offers and answer with accept or decline, accepts are answered with accepted
+// or taken, and anything the server cannot act on with error. Offers of orders
+// taken meanwhile, or dropped for newer ones, are withdrawn
+const (
+	MessageOffer     string = "offer"
+	MessageAccept    string = "accept"
+	MessageDecline   string = "decline"
+	MessageAccepted  string = "accepted"
+	MessageTaken     string = "taken"
+	MessageWithdrawn string = "withdrawn"
+	MessageError     string = "error"
+)
+
+// Message represents a JSON message exchanged over the dispatch channel
+type Message struct {
+	Type    string       `json:"type"`
+	OrderID int64        `json:"order_id,omitempty"`
+	Order   *order.Order `json:"order,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
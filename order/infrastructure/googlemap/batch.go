@@ -0,0 +1,68 @@
+package googlemap
+
+// Batch gathers origin-destination pairs whose distances are looked up with a
+// single Distance Matrix request, origins and destinations shared by several
+// pairs are only asked once
+type Batch struct {
+	Origins      []string
+	Destinations []string
+
+	originIdx map[string]int
+	destIdx   map[string]int
+	// pairs are the indexes of the origin and the destination of every pair
+	pairs [][2]int
+}
+
+// NewBatch returns an empty batch
+func NewBatch() *Batch {
+	return &Batch{
+		originIdx: map[string]int{},
+		destIdx:   map[string]int{},
+	}
+}
+
+// Len returns the number of pairs in the batch
+func (b *Batch) Len() int {
+	return len(b.pairs)
+}
+
+// Fits tells whether a pair from origin to dest can join the batch without
+// exceeding the limits of a Distance Matrix request
+func (b *Batch) Fits(origin, dest string) bool {
+	origins, dests := len(b.Origins), len(b.Destinations)
+	if _, ok := b.originIdx[origin]; !ok {
+		origins++
+	}
+	if _, ok := b.destIdx[dest]; !ok {
+		dests++
+	}
+
+	return origins <= MaxMatrixOrigins && dests <= MaxMatrixDestinations &&
+		origins*dests <= MaxMatrixElements
+}
+
+// Add adds a pair from origin to dest and returns its index in the batch
+func (b *Batch) Add(origin, dest string) int {
+	o, ok := b.originIdx[origin]
+	if !ok {
+		o = len(b.Origins)
+		b.originIdx[origin] = o
+		b.Origins = append(b.Origins, origin)
+	}
+
+	d, ok := b.destIdx[dest]
+	if !ok {
+		d = len(b.Destinations)
+		b.destIdx[dest] = d
+		b.Destinations = append(b.Destinations, dest)
+	}
+
+	b.pairs = append(b.pairs, [2]int{o, d})
+	return len(b.pairs) - 1
+}
+
+// Route returns the route of the pair at index i out of the routes returned
+// by GetDistances for the origins and destinations of the batch
+func (b *Batch) Route(routes [][]Route, i int) Route {
+	return routes[b.pairs[i][0]][b.pairs[i][1]]
+}
@@ -0,0 +1,80 @@
+package googlemap
+
+import (
+	"sync"
+	"time"
+
+	"github.com/imylam/delivery-test/order"
+)
+
+// coalescer gathers the lookups with the same options made within window
+// into batches sent with a single call to getDistances
+type coalescer struct {
+	window       time.Duration
+	getDistances func([]string, []string, order.RouteOptions) ([][]Route, error)
+
+	mu      sync.Mutex
+	pending map[order.RouteOptions]*pendingBatch
+}
+
+// pendingBatch is a batch waiting for its window to end, waiters[i] receives
+// the route of the pair i
+type pendingBatch struct {
+	batch   *Batch
+	opts    order.RouteOptions
+	waiters []chan Route
+}
+
+func newCoalescer(window time.Duration, getDistances func([]string, []string, order.RouteOptions) ([][]Route, error)) *coalescer {
+	return &coalescer{
+		window:       window,
+		getDistances: getDistances,
+		pending:      map[order.RouteOptions]*pendingBatch{},
+	}
+}
+
+// get returns the route from origin to dest, once the batch it joined is sent
+func (c *coalescer) get(origin, dest string, opts order.RouteOptions) Route {
+	waiter := make(chan Route, 1)
+	// unset and explicit driving are the same options
+	opts.Mode = opts.TravelMode()
+
+	c.mu.Lock()
+	p := c.pending[opts]
+	if p != nil && !p.batch.Fits(origin, dest) {
+		// a full batch is sent right away, the lookup starts the next one
+		go c.send(p)
+		p = nil
+	}
+	if p == nil {
+		p = &pendingBatch{batch: NewBatch(), opts: opts}
+		c.pending[opts] = p
+		time.AfterFunc(c.window, func() {
+			c.mu.Lock()
+			if c.pending[opts] != p {
+				c.mu.Unlock()
+				return
+			}
+			delete(c.pending, opts)
+			c.mu.Unlock()
+
+			c.send(p)
+		})
+	}
+	p.batch.Add(origin, dest)
+	p.waiters = append(p.waiters, waiter)
+	c.mu.Unlock()
+
+	return <-waiter
+}
+
+func (c *coalescer) send(p *pendingBatch) {
+	routes, err := c.getDistances(p.batch.Origins, p.batch.Destinations, p.opts)
+	for i, waiter := range p.waiters {
+		if err != nil {
+			waiter <- Route{Err: err}
+			continue
+		}
+		waiter <- p.batch.Route(routes, i)
+	}
+}
@@ -0,0 +1,47 @@
+package googlemap
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/imylam/delivery-test/order"
+)
+
+type fixtureGeocoder struct {
+	places map[string][]Place
+}
+
+// NewFixtureGeocoder creates a Geocoder that answers from fixtures without
+// calling Google, for use in tests. fixtures is a JSON object from addresses
+// to the places they match: none for an address not found, several for an
+// ambiguous one. Addresses match regardless of case and spacing
+func NewFixtureGeocoder(fixtures []byte) (Geocoder, error) {
+	var places map[string][]Place
+	if err := json.Unmarshal(fixtures, &places); err != nil {
+		return nil, err
+	}
+
+	g := &fixtureGeocoder{places: make(map[string][]Place, len(places))}
+	for address, p := range places {
+		g.places[normalizeAddress(address)] = p
+	}
+
+	return g, nil
+}
+
+// Geocode returns the places of address in the fixtures, order.ErrAddressNotFound when missing
+func (g *fixtureGeocoder) Geocode(address string) (Place, error) {
+	places := g.places[normalizeAddress(address)]
+	switch len(places) {
+	case 0:
+		return Place{}, order.ErrAddressNotFound
+	case 1:
+		return places[0], nil
+	default:
+		return Place{}, order.ErrAmbiguousAddress
+	}
+}
+
+func normalizeAddress(address string) string {
+	return strings.ToLower(strings.Join(strings.Fields(address), " "))
+}
@@ -0,0 +1,72 @@
+package googlemap
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	"go.uber.org/zap"
+
+	"googlemaps.github.io/maps"
+)
+
+// Place is where an address is, FormattedAddress is the address as the geocoder writes it
+type Place struct {
+	FormattedAddress string  `json:"formatted_address"`
+	Lat              float64 `json:"lat"`
+	Lng              float64 `json:"lng"`
+}
+
+// Coordinates returns the latitude and the longitude of the place, as orders are placed with
+func (p Place) Coordinates() []string {
+	return []string{strconv.FormatFloat(p.Lat, 'f', -1, 64), strconv.FormatFloat(p.Lng, 'f', -1, 64)}
+}
+
+// Geocoder interface
+type Geocoder interface {
+	// Geocode returns the place of address, order.ErrAddressNotFound when
+	// there is none and order.ErrAmbiguousAddress when there are several
+	Geocode(string) (Place, error)
+}
+
+type geocoder struct {
+	client *maps.Client
+}
+
+// NewGeocoder creates new a geocoder object representation of Geocoder interface
+func NewGeocoder(cfg configs.GoogleMapConfig, options ...maps.ClientOption) Geocoder {
+	c, err := maps.NewClient(append([]maps.ClientOption{maps.WithAPIKey(cfg.APIKey)}, options...)...)
+	if err != nil {
+		logger.Logger.Error("fail to create google map geocoding client", zap.String("error", err.Error()))
+	}
+
+	return &geocoder{client: c}
+}
+
+// Geocode calls Google Map Geocoding API
+func (g *geocoder) Geocode(address string) (Place, error) {
+	results, err := g.client.Geocode(context.Background(), &maps.GeocodingRequest{Address: address})
+	if err != nil {
+		return Place{}, err
+	}
+
+	return onePlace(results)
+}
+
+// onePlace returns the only place of results
+func onePlace(results []maps.GeocodingResult) (Place, error) {
+	switch len(results) {
+	case 0:
+		return Place{}, order.ErrAddressNotFound
+	case 1:
+		return Place{
+			FormattedAddress: results[0].FormattedAddress,
+			Lat:              results[0].Geometry.Location.Lat,
+			Lng:              results[0].Geometry.Location.Lng,
+		}, nil
+	default:
+		return Place{}, order.ErrAmbiguousAddress
+	}
+}
@@ -0,0 +1,75 @@
+package googlemap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/order"
+	"googlemaps.github.io/maps"
+)
+
+func TestGeocode(t *testing.T) {
+	result := func(address string, lat, lng string) string {
+		return `{"formatted_address":"` + address + `","geometry":{"location":{"lat":` + lat + `,"lng":` + lng + `}}}`
+	}
+	responses := map[string]string{
+		"1 Austin Road West": `{"status":"OK","results":[` + result("1 Austin Rd W, Tsim Sha Tsui, Hong Kong", "22.3038", "114.1602") + `]}`,
+		"Nathan Road": `{"status":"OK","results":[` + result("Nathan Rd, Tsim Sha Tsui, Hong Kong", "22.2988", "114.1722") +
+			`,` + result("Nathan Rd, Mong Kok, Hong Kong", "22.3193", "114.1694") + `]}`,
+		"Atlantis": `{"status":"ZERO_RESULTS","results":[]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(responses[r.URL.Query().Get("address")]))
+	}))
+	defer server.Close()
+
+	g := NewGeocoder(configs.GoogleMapConfig{APIKey: "AIza-key"}, maps.WithBaseURL(server.URL))
+
+	t.Run("found", func(t *testing.T) {
+		place, err := g.Geocode("1 Austin Road West")
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, Place{FormattedAddress: "1 Austin Rd W, Tsim Sha Tsui, Hong Kong", Lat: 22.3038, Lng: 114.1602}, place)
+		assert.Equal(t, []string{"22.3038", "114.1602"}, place.Coordinates())
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		_, err := g.Geocode("Nathan Road")
+
+		assert.Equal(t, order.ErrAmbiguousAddress, err)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		_, err := g.Geocode("Atlantis")
+
+		assert.Equal(t, order.ErrAddressNotFound, err)
+	})
+}
+
+func TestFixtureGeocoder(t *testing.T) {
+	g, err := NewFixtureGeocoder([]byte(`{
+		"1 Austin Road West": [{"formatted_address": "1 Austin Rd W, Tsim Sha Tsui, Hong Kong", "lat": 22.3038, "lng": 114.1602}],
+		"Nathan Road": [
+			{"formatted_address": "Nathan Rd, Tsim Sha Tsui, Hong Kong", "lat": 22.2988, "lng": 114.1722},
+			{"formatted_address": "Nathan Rd, Mong Kok, Hong Kong", "lat": 22.3193, "lng": 114.1694}
+		]
+	}`))
+	assert.Equal(t, nil, err)
+
+	place, err := g.Geocode(" 1 AUSTIN road   West")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 22.3038, place.Lat)
+
+	_, err = g.Geocode("Nathan Road")
+	assert.Equal(t, order.ErrAmbiguousAddress, err)
+
+	_, err = g.Geocode("Atlantis")
+	assert.Equal(t, order.ErrAddressNotFound, err)
+
+	_, err = NewFixtureGeocoder([]byte(`[]`))
+	assert.NotEqual(t, nil, err)
+}
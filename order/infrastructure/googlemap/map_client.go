@@ -2,55 +2,201 @@ package googlemap
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/imylam/delivery-test/configs"
 	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
 	"go.uber.org/zap"
 
 	"googlemaps.github.io/maps"
 )
 
+// Limits of a single Distance Matrix request
+const (
+	MaxMatrixOrigins      int = 25
+	MaxMatrixDestinations int = 25
+	MaxMatrixElements     int = 100
+)
+
+// departureNow makes Google take the current traffic into account
+const departureNow string = "now"
+
+// Route is the distance in meters and the travel time from an origin to a
+// destination, Err is order.ErrNoRoute when there is no route between them
+type Route struct {
+	Distance int
+	Duration time.Duration
+	Err      error
+}
+
 // MapClient interface
 type MapClient interface {
-	GetDistance(string, string) (int, error)
+	GetDistance(string, string, order.RouteOptions) (Route, error)
+	// GetDistances returns the route from every origin, as rows, to every destination
+	GetDistances([]string, []string, order.RouteOptions) ([][]Route, error)
 }
 
 type mapClient struct {
-	client *maps.Client
+	client       *maps.Client
+	trafficAware bool
+	coalescer    *coalescer
 }
 
 // NewMapClient creates new a mapClient object representation of MapClient interface
-func NewMapClient() MapClient {
-	c, err := maps.NewClient(maps.WithAPIKey(configs.Get(configs.KeyGoogleMapAPIKey)))
+func NewMapClient(cfg configs.GoogleMapConfig, options ...maps.ClientOption) MapClient {
+	c, err := maps.NewClient(append([]maps.ClientOption{maps.WithAPIKey(cfg.APIKey)}, options...)...)
 	if err != nil {
 		logger.Logger.Error("fail to get distance from google map", zap.String("error", err.Error()))
 	}
 
-	return &mapClient{client: c}
+	mc := &mapClient{client: c, trafficAware: cfg.TrafficAware}
+	if cfg.CoalesceWindow > 0 {
+		mc.coalescer = newCoalescer(cfg.CoalesceWindow, mc.GetDistances)
+	}
+
+	return mc
 }
 
-// GetDistance calls Google Map Distance Matrix API and returns the distance between origin and destination
-func (mc *mapClient) GetDistance(origin string, destination string) (distance int, err error) {
+// GetDistance returns the route between origin and destination. Lookups with
+// the same options made within the coalesce window are sent together in one request
+func (mc *mapClient) GetDistance(origin string, destination string, opts order.RouteOptions) (Route, error) {
+	if mc.coalescer != nil {
+		route := mc.coalescer.get(origin, destination, opts)
+		return route, route.Err
+	}
+
+	routes, err := mc.GetDistances([]string{origin}, []string{destination}, opts)
+	if err != nil {
+		return Route{}, err
+	}
+
+	return routes[0][0], routes[0][0].Err
+}
+
+// GetDistances calls Google Map Distance Matrix API for every origin and
+// destination, in as many requests as the API limits require
+func (mc *mapClient) GetDistances(origins, destinations []string, opts order.RouteOptions) ([][]Route, error) {
+	routes := make([][]Route, len(origins))
+	for i := range routes {
+		routes[i] = make([]Route, len(destinations))
+	}
+	if len(origins) == 0 || len(destinations) == 0 {
+		return routes, nil
+	}
+
+	destStep := len(destinations)
+	if destStep > MaxMatrixDestinations {
+		destStep = MaxMatrixDestinations
+	}
+	originStep := MaxMatrixElements / destStep
+	if originStep > MaxMatrixOrigins {
+		originStep = MaxMatrixOrigins
+	}
+
+	for o := 0; o < len(origins); o += originStep {
+		oEnd := minInt(o+originStep, len(origins))
+		for d := 0; d < len(destinations); d += destStep {
+			dEnd := minInt(d+destStep, len(destinations))
+
+			chunk, err := mc.getMatrix(origins[o:oEnd], destinations[d:dEnd], opts)
+			if err != nil {
+				return nil, err
+			}
+			for i, row := range chunk {
+				copy(routes[o+i][d:dEnd], row)
+			}
+		}
+	}
+
+	return routes, nil
+}
+
+// getMatrix sends a single Distance Matrix request, within the API limits
+func (mc *mapClient) getMatrix(origins, destinations []string, opts order.RouteOptions) ([][]Route, error) {
+	mode, avoid := travelMode(opts)
 	r := &maps.DistanceMatrixRequest{
-		Origins:      []string{origin},
-		Destinations: []string{destination},
+		Origins:      origins,
+		Destinations: destinations,
+		Mode:         mode,
+		Avoid:        avoid,
 		Units:        maps.UnitsMetric,
 	}
+	// traffic only slows down vehicles on the road
+	if mc.trafficAware && mode == maps.TravelModeDriving {
+		r.DepartureTime = departureNow
+	}
 
 	resp, err := mc.client.DistanceMatrix(context.Background(), r)
 	if err != nil {
-		return
+		return nil, err
+	}
+	if len(resp.Rows) != len(origins) {
+		return nil, fmt.Errorf("Google Map API error: %d rows for %d origins", len(resp.Rows), len(origins))
+	}
+
+	routes := make([][]Route, len(origins))
+	for i, row := range resp.Rows {
+		if len(row.Elements) != len(destinations) {
+			return nil, fmt.Errorf("Google Map API error: %d elements for %d destinations", len(row.Elements), len(destinations))
+		}
+
+		routes[i] = make([]Route, len(destinations))
+		for j, element := range row.Elements {
+			switch element.Status {
+			case "OK":
+				routes[i][j].Distance = element.Distance.Meters
+				routes[i][j].Duration = element.Duration
+				// only given when departing at a time, traffic included
+				if element.DurationInTraffic > 0 {
+					routes[i][j].Duration = element.DurationInTraffic
+				}
+			case "NOT_FOUND", "ZERO_RESULTS":
+				routes[i][j].Err = order.ErrNoRoute
+			default:
+				routes[i][j].Err = fmt.Errorf("Google Map API error: element status %s", element.Status)
+			}
+		}
 	}
 
-	respStatus := resp.Rows[0].Elements[0].Status
+	return routes, nil
+}
 
-	if respStatus != "OK" {
-		err = errors.New("Google Map API error: cannot get distance from coordinates")
-		return
+// travelMode returns the Distance Matrix mode and avoidances of opts. The
+// Distance Matrix API has no two-wheeler mode, two-wheelers drive off highways
+func travelMode(opts order.RouteOptions) (maps.Mode, maps.Avoid) {
+	var mode maps.Mode
+	var avoid []string
+	switch opts.TravelMode() {
+	case order.TravelModeBicycling:
+		mode = maps.TravelModeBicycling
+	case order.TravelModeWalking:
+		mode = maps.TravelModeWalking
+	case order.TravelModeTwoWheeler:
+		mode = maps.TravelModeDriving
+		opts.AvoidHighways = true
+	default:
+		mode = maps.TravelModeDriving
 	}
 
-	distance = resp.Rows[0].Elements[0].Distance.Meters
+	if opts.AvoidTolls {
+		avoid = append(avoid, string(maps.AvoidTolls))
+	}
+	if opts.AvoidHighways {
+		avoid = append(avoid, string(maps.AvoidHighways))
+	}
+	if opts.AvoidFerries {
+		avoid = append(avoid, string(maps.AvoidFerries))
+	}
+
+	return mode, maps.Avoid(strings.Join(avoid, "|"))
+}
 
-	return
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
 }
@@ -0,0 +1,276 @@
+package googlemap
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/order"
+	"googlemaps.github.io/maps"
+)
+
+// newMatrixServer answers Distance Matrix requests for origins "o<i>" and
+// destinations "d<j>" with a distance of i*100+j, or with the status given
+// for the destination in statuses
+func newMatrixServer(t *testing.T, requests *int32, statuses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		origins := strings.Split(r.URL.Query().Get("origins"), "|")
+		destinations := strings.Split(r.URL.Query().Get("destinations"), "|")
+
+		type element map[string]interface{}
+		var rows []map[string][]element
+		for _, o := range origins {
+			i, _ := strconv.Atoi(strings.TrimPrefix(o, "o"))
+			var elements []element
+			for _, d := range destinations {
+				j, _ := strconv.Atoi(strings.TrimPrefix(d, "d"))
+				if status, ok := statuses[d]; ok {
+					elements = append(elements, element{"status": status})
+					continue
+				}
+				elements = append(elements, element{"status": "OK", "distance": map[string]interface{}{"value": i*100 + j}})
+			}
+			rows = append(rows, map[string][]element{"elements": elements})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{"status": "OK", "rows": rows}); err != nil {
+			t.Errorf("fail to encode response: %s", err.Error())
+		}
+	}))
+}
+
+func names(prefix string, n int) []string {
+	s := make([]string, n)
+	for i := range s {
+		s[i] = prefix + strconv.Itoa(i)
+	}
+	return s
+}
+
+func TestGetDistances(t *testing.T) {
+	t.Run("chunked-to-api-limits", func(t *testing.T) {
+		var requests int32
+		server := newMatrixServer(t, &requests, nil)
+		defer server.Close()
+
+		mc := NewMapClient(configs.GoogleMapConfig{APIKey: "AIza-key"}, maps.WithBaseURL(server.URL))
+		routes, err := mc.GetDistances(names("o", 30), names("d", 5), order.RouteOptions{})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int32(2), requests)
+		assert.Equal(t, 30, len(routes))
+		assert.Equal(t, 0, routes[0][0].Distance)
+		assert.Equal(t, 2104, routes[21][4].Distance)
+	})
+
+	t.Run("chunked-destinations", func(t *testing.T) {
+		var requests int32
+		server := newMatrixServer(t, &requests, nil)
+		defer server.Close()
+
+		mc := NewMapClient(configs.GoogleMapConfig{APIKey: "AIza-key"}, maps.WithBaseURL(server.URL))
+		routes, err := mc.GetDistances(names("o", 2), names("d", 30), order.RouteOptions{})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int32(2), requests)
+		assert.Equal(t, 129, routes[1][29].Distance)
+	})
+
+	t.Run("element-statuses", func(t *testing.T) {
+		var requests int32
+		server := newMatrixServer(t, &requests, map[string]string{"d1": "ZERO_RESULTS", "d2": "MAX_ROUTE_LENGTH_EXCEEDED"})
+		defer server.Close()
+
+		mc := NewMapClient(configs.GoogleMapConfig{APIKey: "AIza-key"}, maps.WithBaseURL(server.URL))
+		routes, err := mc.GetDistances(names("o", 1), names("d", 3), order.RouteOptions{})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, routes[0][0].Err)
+		assert.Equal(t, order.ErrNoRoute, routes[0][1].Err)
+		assert.Equal(t, "Google Map API error: element status MAX_ROUTE_LENGTH_EXCEEDED", routes[0][2].Err.Error())
+	})
+}
+
+func TestGetDistancesDuration(t *testing.T) {
+	newServer := func(departureTime *string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*departureTime = r.URL.Query().Get("departure_time")
+			element := `{"status":"OK","distance":{"value":1200},"duration":{"value":300}`
+			if *departureTime != "" {
+				element += `,"duration_in_traffic":{"value":420}`
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"status":"OK","rows":[{"elements":[` + element + `}]}]}`))
+		}))
+	}
+
+	t.Run("without-traffic", func(t *testing.T) {
+		var departureTime string
+		server := newServer(&departureTime)
+		defer server.Close()
+
+		mc := NewMapClient(configs.GoogleMapConfig{APIKey: "AIza-key"}, maps.WithBaseURL(server.URL))
+		route, err := mc.GetDistance("o0", "d0", order.RouteOptions{})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "", departureTime)
+		assert.Equal(t, 5*time.Minute, route.Duration)
+	})
+
+	t.Run("traffic-aware", func(t *testing.T) {
+		var departureTime string
+		server := newServer(&departureTime)
+		defer server.Close()
+
+		mc := NewMapClient(configs.GoogleMapConfig{APIKey: "AIza-key", TrafficAware: true}, maps.WithBaseURL(server.URL))
+		route, err := mc.GetDistance("o0", "d0", order.RouteOptions{})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "now", departureTime)
+		assert.Equal(t, 7*time.Minute, route.Duration)
+	})
+}
+
+func TestGetDistancesTravelMode(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"OK","rows":[{"elements":[{"status":"OK","distance":{"value":1200}}]}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		opts  order.RouteOptions
+		mode  string
+		avoid string
+	}{
+		{"default", order.RouteOptions{}, "driving", ""},
+		{"bicycling", order.RouteOptions{Mode: order.TravelModeBicycling, AvoidFerries: true}, "bicycling", "ferries"},
+		{"walking", order.RouteOptions{Mode: order.TravelModeWalking}, "walking", ""},
+		{"two-wheeler", order.RouteOptions{Mode: order.TravelModeTwoWheeler, AvoidTolls: true}, "driving", "tolls|highways"},
+	}
+
+	mc := NewMapClient(configs.GoogleMapConfig{APIKey: "AIza-key", TrafficAware: true}, maps.WithBaseURL(server.URL))
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := mc.GetDistance("o0", "d0", test.opts)
+
+			assert.Equal(t, nil, err)
+			assert.Equal(t, test.mode, query.Get("mode"))
+			assert.Equal(t, test.avoid, query.Get("avoid"))
+			// traffic is only asked for vehicles on the road
+			assert.Equal(t, test.mode == "driving", query.Get("departure_time") == "now")
+		})
+	}
+}
+
+func TestGetDistance(t *testing.T) {
+	t.Run("coalesced", func(t *testing.T) {
+		var requests int32
+		server := newMatrixServer(t, &requests, nil)
+		defer server.Close()
+
+		mc := NewMapClient(configs.GoogleMapConfig{APIKey: "AIza-key", CoalesceWindow: 50 * time.Millisecond},
+			maps.WithBaseURL(server.URL))
+
+		distances := make([]int, 3)
+		var wg sync.WaitGroup
+		for i := range distances {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				route, _ := mc.GetDistance("o1", "d"+strconv.Itoa(i), order.RouteOptions{})
+				distances[i] = route.Distance
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), requests)
+		assert.Equal(t, []int{100, 101, 102}, distances)
+	})
+
+	t.Run("coalesced-by-options", func(t *testing.T) {
+		var requests int32
+		server := newMatrixServer(t, &requests, nil)
+		defer server.Close()
+
+		mc := NewMapClient(configs.GoogleMapConfig{APIKey: "AIza-key", CoalesceWindow: 50 * time.Millisecond},
+			maps.WithBaseURL(server.URL))
+
+		modes := []string{order.TravelModeDriving, order.TravelModeBicycling, order.TravelModeDriving}
+		var wg sync.WaitGroup
+		for i, mode := range modes {
+			wg.Add(1)
+			go func(i int, mode string) {
+				defer wg.Done()
+				_, _ = mc.GetDistance("o1", "d"+strconv.Itoa(i), order.RouteOptions{Mode: mode})
+			}(i, mode)
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(2), requests)
+	})
+
+	t.Run("not-coalesced", func(t *testing.T) {
+		var requests int32
+		server := newMatrixServer(t, &requests, map[string]string{"d2": "NOT_FOUND"})
+		defer server.Close()
+
+		mc := NewMapClient(configs.GoogleMapConfig{APIKey: "AIza-key"}, maps.WithBaseURL(server.URL))
+		route, err := mc.GetDistance("o3", "d1", order.RouteOptions{})
+		_, noRouteErr := mc.GetDistance("o3", "d2", order.RouteOptions{})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 301, route.Distance)
+		assert.Equal(t, order.ErrNoRoute, noRouteErr)
+		assert.Equal(t, int32(2), requests)
+	})
+}
+
+func TestCoalescerSendsFullBatch(t *testing.T) {
+	var calls int32
+	c := newCoalescer(time.Hour, func(origins, destinations []string, opts order.RouteOptions) ([][]Route, error) {
+		atomic.AddInt32(&calls, 1)
+		routes := make([][]Route, len(origins))
+		for i := range routes {
+			routes[i] = make([]Route, len(destinations))
+		}
+		return routes, nil
+	})
+
+	// 11 distinct pairs exceed a 10x10 matrix, the first batch is sent once full
+	var wg sync.WaitGroup
+	for i := 0; i < 11; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.get("o"+strconv.Itoa(i), "d"+strconv.Itoa(i), order.RouteOptions{})
+		}(i)
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	c.mu.Lock()
+	pending := c.pending[order.RouteOptions{Mode: order.TravelModeDriving}]
+	delete(c.pending, order.RouteOptions{Mode: order.TravelModeDriving})
+	c.mu.Unlock()
+	c.send(pending)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
@@ -1,6 +1,7 @@
 package googlemap
 
 import (
+	"github.com/imylam/delivery-test/order"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -9,24 +10,45 @@ type MockMapClient struct {
 	mock.Mock
 }
 
-// GetDistance provides a mock function with given fields: origin, destination
-func (_m *MockMapClient) GetDistance(origin string, destination string) (distance int, err error) {
-	ret := _m.Called(origin, destination)
+// GetDistance provides a mock function with given fields: origin, destination, opts
+func (_m *MockMapClient) GetDistance(origin string, destination string, opts order.RouteOptions) (Route, error) {
+	ret := _m.Called(origin, destination, opts)
 
-	var r0 int
-	if rf, ok := ret.Get(0).(func(string, string) int); ok {
-		r0 = rf(origin, destination)
+	var r0 Route
+	if rf, ok := ret.Get(0).(func(string, string, order.RouteOptions) Route); ok {
+		r0 = rf(origin, destination, opts)
 	} else {
-		if _, ok := ret.Get(0).(int); ok {
-			r0 = ret.Get(0).(int)
-		} else {
-			r0 = 0
+		if _, ok := ret.Get(0).(Route); ok {
+			r0 = ret.Get(0).(Route)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(origin, destination)
+	if rf, ok := ret.Get(1).(func(string, string, order.RouteOptions) error); ok {
+		r1 = rf(origin, destination, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDistances provides a mock function with given fields: origins, destinations, opts
+func (_m *MockMapClient) GetDistances(origins []string, destinations []string, opts order.RouteOptions) ([][]Route, error) {
+	ret := _m.Called(origins, destinations, opts)
+
+	var r0 [][]Route
+	if rf, ok := ret.Get(0).(func([]string, []string, order.RouteOptions) [][]Route); ok {
+		r0 = rf(origins, destinations, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([][]Route)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string, []string, order.RouteOptions) error); ok {
+		r1 = rf(origins, destinations, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,35 @@
+package googlemap
+
+import (
+	"time"
+
+	"github.com/imylam/delivery-test/order"
+)
+
+type staticMapClient struct {
+	route Route
+}
+
+// NewStaticMapClient creates a MapClient that answers every lookup with the
+// given distance and duration without calling Google, for use in integration tests
+func NewStaticMapClient(distance int, duration time.Duration) MapClient {
+	return &staticMapClient{route: Route{Distance: distance, Duration: duration}}
+}
+
+// GetDistance returns the configured route regardless of origin, destination and options
+func (mc *staticMapClient) GetDistance(origin string, destination string, opts order.RouteOptions) (Route, error) {
+	return mc.route, nil
+}
+
+// GetDistances returns the configured route for every origin and destination, whatever the options
+func (mc *staticMapClient) GetDistances(origins, destinations []string, opts order.RouteOptions) ([][]Route, error) {
+	routes := make([][]Route, len(origins))
+	for i := range routes {
+		routes[i] = make([]Route, len(destinations))
+		for j := range routes[i] {
+			routes[i][j] = mc.route
+		}
+	}
+
+	return routes, nil
+}
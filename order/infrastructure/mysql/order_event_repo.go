@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"strings"
+
+	"github.com/imylam/delivery-test/order"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type orderEventRepoMysql struct {
+	MysqlConn *sqlx.DB
+}
+
+// NewOrderEventRepositoryMysql will create an object that represent the order.OrderEventRepository interface
+func NewOrderEventRepositoryMysql(mysqlConn *sqlx.DB) order.OrderEventRepository {
+	return &orderEventRepoMysql{mysqlConn}
+}
+
+// FindAfter returns the events of the tenant positioned after afterPosition,
+// in the order of their positions
+func (repo *orderEventRepoMysql) FindAfter(tenantID string, filter order.EventFilter, afterPosition int64, limit int) ([]order.OrderEvent, error) {
+	if tenantID == "" {
+		return nil, errNoTenant
+	}
+
+	conds := []string{"tenant_id=?", "position>?"}
+	args := []interface{}{tenantID, afterPosition}
+	if filter.Status != "" {
+		conds = append(conds, "status=?")
+		args = append(args, filter.Status)
+	}
+	if filter.MerchantID != "" {
+		conds = append(conds, "merchant_id=?")
+		args = append(args, filter.MerchantID)
+	}
+	switch {
+	case filter.Courier != "" && filter.Withdrawals:
+		conds = append(conds, "(status=? OR courier_id=? OR (type=? AND status=?))")
+		args = append(args, order.StatusUnassigned, filter.Courier, order.EventOrderStatusChanged, order.StatusTaken)
+	case filter.Courier != "":
+		conds = append(conds, "(status=? OR courier_id=?)")
+		args = append(args, order.StatusUnassigned, filter.Courier)
+	}
+	if a := filter.Area; a != nil {
+		conds = append(conds, "origin_lat BETWEEN ? AND ?", "origin_lng BETWEEN ? AND ?")
+		args = append(args, a.South, a.North, a.West, a.East)
+	}
+
+	q := "SELECT * FROM order_events WHERE " + strings.Join(conds, " AND ") + " ORDER BY position LIMIT ?"
+	args = append(args, limit)
+
+	events := []order.OrderEvent{}
+	err := repo.MysqlConn.Select(&events, q, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (repo *orderEventRepoMysql) LatestPosition(tenantID string) (int64, error) {
+	q := "SELECT COALESCE(MAX(position), 0) FROM order_events WHERE tenant_id=?"
+
+	if tenantID == "" {
+		return 0, errNoTenant
+	}
+
+	var position int64
+	err := repo.MysqlConn.Get(&position, q, tenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	return position, nil
+}
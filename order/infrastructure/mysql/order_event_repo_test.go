@@ -0,0 +1,121 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/go-sql-driver/mysql"
+	"github.com/imylam/delivery-test/order"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestFindAfter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q := "SELECT (.+) FROM order_events"
+	columns := []string{"id", "tenant_id", "type", "order_id", "distance", "travel_mode", "status", "merchant_id", "courier_id", "origin_lat", "origin_lng", "created_at", "position"}
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(7, mockTenantID, order.EventOrderCreated, 1, 100, order.TravelModeDriving, order.StatusUnassigned, "merchant-1", "", 22.3, 114.2, time.Now(), 6).
+			AddRow(6, mockTenantID, order.EventOrderStatusChanged, 1, 100, order.TravelModeDriving, order.StatusTaken, "merchant-1", "courier-1", 22.3, 114.2, time.Now(), 7)
+		mock.ExpectQuery("SELECT (.+) FROM order_events WHERE tenant_id=\\? AND position>\\? ORDER BY position LIMIT").
+			WithArgs(mockTenantID, int64(5), 100).WillReturnRows(rows)
+
+		repo := NewOrderEventRepositoryMysql(sqlxDB)
+		events, err := repo.FindAfter(mockTenantID, order.EventFilter{}, 5, 100)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, 2, len(events))
+		assert.Equal(t, int64(7), events[1].Position)
+		assert.Equal(t, "courier-1", events[1].Order().CourierID)
+	})
+
+	t.Run("success-with-filter", func(t *testing.T) {
+		filter := order.EventFilter{
+			Status:  order.StatusUnassigned,
+			Courier: "courier-1",
+			Area:    &order.Area{South: 22.2, West: 114.1, North: 22.4, East: 114.3},
+		}
+
+		mock.ExpectQuery("SELECT (.+) FROM order_events WHERE tenant_id=\\? AND position>\\? AND status=\\? "+
+			"AND \\(status=\\? OR courier_id=\\?\\) AND origin_lat BETWEEN \\? AND \\? AND origin_lng BETWEEN \\? AND \\? ORDER BY position LIMIT").
+			WithArgs(mockTenantID, int64(0), order.StatusUnassigned, order.StatusUnassigned, "courier-1", 22.2, 22.4, 114.1, 114.3, 100).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		repo := NewOrderEventRepositoryMysql(sqlxDB)
+		events, err := repo.FindAfter(mockTenantID, filter, 0, 100)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, 0, len(events))
+	})
+
+	t.Run("success-with-withdrawals", func(t *testing.T) {
+		filter := order.EventFilter{Courier: "courier-1", Withdrawals: true}
+
+		mock.ExpectQuery("SELECT (.+) FROM order_events WHERE tenant_id=\\? AND position>\\? "+
+			"AND \\(status=\\? OR courier_id=\\? OR \\(type=\\? AND status=\\?\\)\\) ORDER BY position LIMIT").
+			WithArgs(mockTenantID, int64(0), order.StatusUnassigned, "courier-1", order.EventOrderStatusChanged, order.StatusTaken, 100).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		repo := NewOrderEventRepositoryMysql(sqlxDB)
+		events, err := repo.FindAfter(mockTenantID, filter, 0, 100)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, 0, len(events))
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewOrderEventRepositoryMysql(sqlxDB)
+		_, err := repo.FindAfter("", order.EventFilter{}, 0, 100)
+
+		assert.Equal(t, errNoTenant, err)
+	})
+
+	t.Run("select-error", func(t *testing.T) {
+		mock.ExpectQuery(q).WithArgs(mockTenantID, int64(0), 100).WillReturnError(&mysql.MySQLError{})
+
+		repo := NewOrderEventRepositoryMysql(sqlxDB)
+		_, err := repo.FindAfter(mockTenantID, order.EventFilter{}, 0, 100)
+
+		_, isMysqlError := err.(*mysql.MySQLError)
+		assert.Equal(t, true, isMysqlError)
+	})
+}
+
+func TestLatestPosition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COALESCE\\(MAX\\(position\\), 0\\) FROM order_events WHERE tenant_id=\\?").
+			WithArgs(mockTenantID).
+			WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(42))
+
+		repo := NewOrderEventRepositoryMysql(sqlxDB)
+		position, err := repo.LatestPosition(mockTenantID)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, int64(42), position)
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewOrderEventRepositoryMysql(sqlxDB)
+		_, err := repo.LatestPosition("")
+
+		assert.Equal(t, errNoTenant, err)
+	})
+}
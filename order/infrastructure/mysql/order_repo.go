@@ -2,12 +2,21 @@ package mysql
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
 
 	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/outbox"
+	_outboxRepo "github.com/imylam/delivery-test/outbox/infrastructure/mysql"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// errNoTenant guards against queries escaping the tenant scope
+var errNoTenant = errors.New("order repository: tenant required")
+
 type orderRepoMysql struct {
 	MysqlConn *sqlx.DB
 }
@@ -17,42 +26,155 @@ func NewOrderRepositoryMysql(mysqlConn *sqlx.DB) order.OrderRepository {
 	return &orderRepoMysql{mysqlConn}
 }
 
-func (repo *orderRepoMysql) Create(order *order.Order) error {
-	q1 := "INSERT INTO orders (distance, status, created_at, updated_at) VALUES (?,?,now(),now())"
-	q2 := "SELECT * FROM orders WHERE id=?"
+func (repo *orderRepoMysql) Create(newOrder *order.Order) error {
+	if newOrder.TenantID == "" {
+		return errNoTenant
+	}
 
-	insertStmt, err := repo.MysqlConn.Prepare(q1)
+	tx, err := repo.MysqlConn.Beginx()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	result, err := insertStmt.Exec(order.Distance, order.Status)
+	eventID, err := insertOrder(tx, newOrder)
 	if err != nil {
 		return err
 	}
 
-	order.ID, err = result.LastInsertId()
+	err = positionEvents(tx, newOrder.TenantID, []int64{eventID})
 	if err != nil {
 		return err
 	}
 
-	err = repo.MysqlConn.QueryRowx(q2, order.ID).StructScan(order)
+	return tx.Commit()
+}
+
+// CreateBatch creates every order in a single transaction, none is created if
+// one fails and the error names it
+func (repo *orderRepoMysql) CreateBatch(newOrders []*order.Order) error {
+	for _, o := range newOrders {
+		if o.TenantID == "" {
+			return errNoTenant
+		}
+	}
+
+	tx, err := repo.MysqlConn.Beginx()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	eventIDs := make(map[string][]int64)
+	for i, o := range newOrders {
+		eventID, err := insertOrder(tx, o)
+		if err != nil {
+			return &order.BatchError{Index: i, Err: err}
+		}
+		eventIDs[o.TenantID] = append(eventIDs[o.TenantID], eventID)
+	}
+
+	// tenants are positioned in the same order by every batch so they cannot deadlock
+	tenants := make([]string, 0, len(eventIDs))
+	for tenantID := range eventIDs {
+		tenants = append(tenants, tenantID)
+	}
+	sort.Strings(tenants)
+	for _, tenantID := range tenants {
+		err = positionEvents(tx, tenantID, eventIDs[tenantID])
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertOrder inserts newOrder along with its stops, its event and outbox
+// message, then reads it back to fill its ID and timestamps, and returns the
+// id of the event. The quote it is placed from, if any, is marked used
+func insertOrder(tx *sqlx.Tx, newOrder *order.Order) (int64, error) {
+	q1 := "INSERT INTO orders (tenant_id, distance, travel_mode, estimated_duration, price, currency, tariff_version, quote_id, status, merchant_id, " +
+		"origin_address, origin_lat, origin_lng, destination_address, destination_lat, destination_lng, scheduled_for, created_at, updated_at) " +
+		"VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,now(),now())"
+	q2 := "SELECT * FROM orders WHERE tenant_id=? AND id=?"
+
+	result, err := tx.Exec(q1, newOrder.TenantID, newOrder.Distance, newOrder.TravelMode, newOrder.EstimatedDuration,
+		newOrder.Price, newOrder.Currency, newOrder.TariffVersion, newOrder.QuoteID, newOrder.Status, newOrder.MerchantID, newOrder.OriginAddress, newOrder.OriginLat, newOrder.OriginLng,
+		newOrder.DestinationAddress, newOrder.DestinationLat, newOrder.DestinationLng, newOrder.ScheduledFor)
+	if err != nil {
+		return 0, err
+	}
+
+	newOrder.ID, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if newOrder.QuoteID != "" {
+		err = claimQuote(tx, newOrder)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(newOrder.Stops) > 0 {
+		err = insertStops(tx, newOrder)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	eventID, err := insertEvent(tx, order.EventOrderCreated, newOrder.TenantID, newOrder.ID)
+	if err != nil {
+		return 0, err
+	}
 
-	return nil
+	err = tx.QueryRowx(q2, newOrder.TenantID, newOrder.ID).StructScan(newOrder)
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertOutboxMessage(tx, order.EventOrderCreated, newOrder)
+	if err != nil {
+		return 0, err
+	}
+
+	return eventID, nil
+}
+
+// insertStops inserts the stops of newOrder in a single statement
+func insertStops(tx *sqlx.Tx, newOrder *order.Order) error {
+	q := "INSERT INTO order_stops (tenant_id, order_id, position, type, address, lat, lng, distance) VALUES " +
+		strings.TrimSuffix(strings.Repeat("(?,?,?,?,?,?,?,?),", len(newOrder.Stops)), ",")
+
+	args := make([]interface{}, 0, 8*len(newOrder.Stops))
+	for i := range newOrder.Stops {
+		stop := &newOrder.Stops[i]
+		stop.TenantID = newOrder.TenantID
+		stop.OrderID = newOrder.ID
+		args = append(args, stop.TenantID, stop.OrderID, stop.Index, stop.Type, stop.Address, stop.Lat, stop.Lng, stop.Distance)
+	}
+
+	_, err := tx.Exec(q, args...)
+	return err
 }
 
-func (repo *orderRepoMysql) UpdateStatusByID(id int64) error {
-	q := "UPDATE orders SET status=? WHERE id=? AND status=?"
+func (repo *orderRepoMysql) UpdateStatusByID(tenantID string, id int64, courierID string) error {
+	q1 := "UPDATE orders SET status=?, courier_id=? WHERE tenant_id=? AND id=? AND status=?"
+	q2 := "SELECT * FROM orders WHERE tenant_id=? AND id=?"
+
+	if tenantID == "" {
+		return errNoTenant
+	}
 
-	updateStmt, err := repo.MysqlConn.Prepare(q)
+	tx, err := repo.MysqlConn.Beginx()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	result, err := updateStmt.Exec(order.StatusTaken, id, order.StatusUnassigned)
+	result, err := tx.Exec(q1, order.StatusTaken, courierID, tenantID, id, order.StatusUnassigned)
 	if err != nil {
 		return err
 	}
@@ -65,25 +187,134 @@ func (repo *orderRepoMysql) UpdateStatusByID(id int64) error {
 		return sql.ErrNoRows
 	}
 
+	eventID, err := insertEvent(tx, order.EventOrderStatusChanged, tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	var updated order.Order
+	err = tx.QueryRowx(q2, tenantID, id).StructScan(&updated)
+	if err != nil {
+		return err
+	}
+
+	err = insertOutboxMessage(tx, order.EventOrderStatusChanged, &updated)
+	if err != nil {
+		return err
+	}
+
+	err = positionEvents(tx, tenantID, []int64{eventID})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertEvent records an event of eventType with a snapshot of the order as tx
+// sees it and returns its id. The event has no position until positionEvents
+func insertEvent(tx *sqlx.Tx, eventType, tenantID string, orderID int64) (int64, error) {
+	q := "INSERT INTO order_events (tenant_id, type, order_id, distance, travel_mode, status, merchant_id, courier_id, origin_lat, origin_lng, created_at) " +
+		"SELECT tenant_id, ?, id, distance, travel_mode, status, merchant_id, courier_id, origin_lat, origin_lng, now(6) FROM orders WHERE tenant_id=? AND id=?"
+
+	result, err := tx.Exec(q, eventType, tenantID, orderID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// positionEvents gives the events ids of a tenant, written by tx, the next
+// positions of the tenant in their order. It must come right before tx
+// commits: the position row of the tenant stays locked until then, so the
+// transaction taking the next positions waits for tx to commit and events
+// become visible in the order of their positions. Cursors on positions then
+// never skip an event, however long its transaction takes
+func positionEvents(tx *sqlx.Tx, tenantID string, ids []int64) error {
+	q1 := "INSERT INTO order_event_positions (tenant_id, position) VALUES (?,?) ON DUPLICATE KEY UPDATE position=position+VALUES(position)"
+	q2 := "SELECT position FROM order_event_positions WHERE tenant_id=?"
+
+	_, err := tx.Exec(q1, tenantID, len(ids))
+	if err != nil {
+		return err
+	}
+
+	var last int64
+	err = tx.Get(&last, q2, tenantID)
+	if err != nil {
+		return err
+	}
+
+	// FIELD numbers the ids from 1 in the order given
+	q3, args, err := sqlx.In("UPDATE order_events SET position=?+FIELD(id, ?) WHERE id IN (?)", last-int64(len(ids)), ids, ids)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(tx.Rebind(q3), args...)
 	return err
 }
 
-func (repo *orderRepoMysql) FindByID(id int64) (*order.Order, error) {
-	q := "SELECT * FROM orders WHERE id=?"
+// insertOutboxMessage records an event of eventType carrying o for the outbox
+// relay, it is only published if tx is committed
+func insertOutboxMessage(tx *sqlx.Tx, eventType string, o *order.Order) error {
+	payload, err := json.Marshal(order.NewOutboxPayload(o))
+	if err != nil {
+		return err
+	}
+
+	return _outboxRepo.Insert(tx, &outbox.Message{
+		TenantID:      o.TenantID,
+		AggregateType: outbox.AggregateOrder,
+		AggregateID:   o.ID,
+		EventType:     eventType,
+		Payload:       payload,
+	})
+}
+
+func (repo *orderRepoMysql) FindByID(tenantID string, id int64) (*order.Order, error) {
+	q := "SELECT * FROM orders WHERE tenant_id=? AND id=?"
+
+	if tenantID == "" {
+		return nil, errNoTenant
+	}
 
 	var order order.Order
-	err := repo.MysqlConn.QueryRowx(q, id).StructScan(&order)
+	err := repo.MysqlConn.QueryRowx(q, tenantID, id).StructScan(&order)
+	if err != nil {
+		return nil, err
+	}
+
+	stops, err := repo.findStops(tenantID, []int64{id})
 	if err != nil {
 		return nil, err
 	}
+	order.Stops = stops[id]
 
 	return &order, err
 }
 
-func (repo *orderRepoMysql) FindRange(limit, offset int) (*[]order.Order, error) {
-	q := "SELECT * FROM orders LIMIT ? OFFSET ?"
+func (repo *orderRepoMysql) FindRange(tenantID string, filter order.OrderFilter, limit, offset int) (*[]order.Order, error) {
+	if tenantID == "" {
+		return nil, errNoTenant
+	}
+
+	conds := []string{"tenant_id=?"}
+	args := []interface{}{tenantID}
+	if filter.MerchantID != "" {
+		conds = append(conds, "merchant_id=?")
+		args = append(args, filter.MerchantID)
+	}
+	if filter.Status != "" {
+		conds = append(conds, "status=?")
+		args = append(args, filter.Status)
+	}
+
+	q := "SELECT * FROM orders WHERE " + strings.Join(conds, " AND ") + " LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
 
-	rows, err := repo.MysqlConn.Queryx(q, limit, offset)
+	rows, err := repo.MysqlConn.Queryx(q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +329,76 @@ func (repo *orderRepoMysql) FindRange(limit, offset int) (*[]order.Order, error)
 		}
 		orders = append(orders, order)
 	}
+	if len(orders) == 0 {
+		return &orders, nil
+	}
+
+	ids := make([]int64, len(orders))
+	for i := range orders {
+		ids[i] = orders[i].ID
+	}
+	stops, err := repo.findStops(tenantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		orders[i].Stops = stops[orders[i].ID]
+	}
 
 	return &orders, nil
 }
+
+// CompleteStop marks the stop at index of an order completed, as long as the
+// order is taken by courierID and the stop is not completed yet, and returns
+// it. It fails with sql.ErrNoRows otherwise
+func (repo *orderRepoMysql) CompleteStop(tenantID string, id int64, index int, courierID string) (*order.Stop, error) {
+	q1 := "UPDATE order_stops s JOIN orders o ON o.tenant_id=s.tenant_id AND o.id=s.order_id SET s.completed_at=now(6) " +
+		"WHERE s.tenant_id=? AND o.id=? AND o.status=? AND o.courier_id=? AND s.position=? AND s.completed_at IS NULL"
+	q2 := "SELECT * FROM order_stops WHERE tenant_id=? AND order_id=? AND position=?"
+
+	if tenantID == "" {
+		return nil, errNoTenant
+	}
+
+	result, err := repo.MysqlConn.Exec(q1, tenantID, id, order.StatusTaken, courierID, index)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	var stop order.Stop
+	err = repo.MysqlConn.QueryRowx(q2, tenantID, id, index).StructScan(&stop)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stop, nil
+}
+
+// findStops returns the stops of the orders ids of the tenant, by order and in their order
+func (repo *orderRepoMysql) findStops(tenantID string, ids []int64) (map[int64][]order.Stop, error) {
+	q, args, err := sqlx.In("SELECT * FROM order_stops WHERE tenant_id=? AND order_id IN (?) ORDER BY order_id, position", tenantID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var stops []order.Stop
+	err = repo.MysqlConn.Select(&stops, repo.MysqlConn.Rebind(q), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	byOrder := make(map[int64][]order.Stop)
+	for _, stop := range stops {
+		byOrder[stop.OrderID] = append(byOrder[stop.OrderID], stop)
+	}
+
+	return byOrder, nil
+}
@@ -3,6 +3,7 @@ package mysql
 import (
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"testing"
 	"time"
 
@@ -14,6 +15,10 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+const mockTenantID string = "brand-a"
+
+var stopColumns = []string{"tenant_id", "order_id", "position", "type", "address", "lat", "lng", "distance", "completed_at"}
+
 type AnyInt struct{}
 
 // Match satisfies sqlmock.Argument interface
@@ -22,6 +27,25 @@ func (a AnyInt) Match(v driver.Value) bool {
 	return ok
 }
 
+// expectPositionEvents expects the events ids of a transaction of the mock
+// tenant to be given the positions up to last
+func expectPositionEvents(mock sqlmock.Sqlmock, ids []int64, last int64) {
+	mock.ExpectExec("INSERT INTO order_event_positions \\(tenant_id, position\\) VALUES \\(\\?,\\?\\) "+
+		"ON DUPLICATE KEY UPDATE position=position\\+VALUES\\(position\\)").
+		WithArgs(mockTenantID, len(ids)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT position FROM order_event_positions WHERE tenant_id=\\?").WithArgs(mockTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(last))
+
+	args := []driver.Value{last - int64(len(ids))}
+	for i := 0; i < 2; i++ {
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	mock.ExpectExec("UPDATE order_events SET position=\\?\\+FIELD\\(id, (.+)\\) WHERE id IN \\((.+)\\)").
+		WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, int64(len(ids))))
+}
+
 type AnyTime struct{}
 
 // Match satisfies sqlmock.Argument interface
@@ -39,38 +63,174 @@ func TestCreate(t *testing.T) {
 	defer db.Close()
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 	qInsert := "INSERT INTO orders"
+	qInsertEvent := "INSERT INTO order_events (.+) SELECT (.+) FROM orders"
 	qSelect := "SELECT (.+) FROM orders"
+	qInsertOutbox := "INSERT INTO outbox_messages"
 
 	mockOrder := order.Order{
-		Distance: 1000,
-		Status:   order.StatusUnassigned,
+		TenantID:          mockTenantID,
+		Distance:          1000,
+		TravelMode:        order.TravelModeBicycling,
+		EstimatedDuration: 300,
+		Price:             1444,
+		Currency:          "HKD",
+		TariffVersion:     "v1",
+		Status:            order.StatusUnassigned,
+		MerchantID:        "merchant-1",
+		OriginAddress:     "1 Austin Rd W, Tsim Sha Tsui, Hong Kong",
+		OriginLat:         22.300789,
+		OriginLng:         114.167815,
+		DestinationLat:    22.3354,
+		DestinationLng:    114.176155,
 	}
 
 	t.Run("success", func(t *testing.T) {
 		tempOrder := mockOrder
 		mockOrderID := int64(8)
 
-		prepInsert := mock.ExpectPrepare(qInsert)
-		prepInsert.ExpectExec().WithArgs(tempOrder.Distance, tempOrder.Status).
+		mock.ExpectBegin()
+		mock.ExpectExec(qInsert).
+			WithArgs(tempOrder.TenantID, tempOrder.Distance, tempOrder.TravelMode, tempOrder.EstimatedDuration,
+				tempOrder.Price, tempOrder.Currency, tempOrder.TariffVersion, tempOrder.QuoteID, tempOrder.Status, tempOrder.MerchantID,
+				tempOrder.OriginAddress, tempOrder.OriginLat, tempOrder.OriginLng,
+				tempOrder.DestinationAddress, tempOrder.DestinationLat, tempOrder.DestinationLng, tempOrder.ScheduledFor).
 			WillReturnResult(sqlmock.NewResult(mockOrderID, 1))
+		mock.ExpectExec(qInsertEvent).WithArgs(order.EventOrderCreated, mockTenantID, mockOrderID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		rows := sqlmock.NewRows([]string{"id", "distance", "status", "created_at", "updated_at"}).
 			AddRow(mockOrderID, tempOrder.Distance, tempOrder.Status, time.Now(), time.Now())
-		mock.ExpectQuery(qSelect).WithArgs(mockOrderID).WillReturnRows(rows)
+		mock.ExpectQuery(qSelect).WithArgs(mockTenantID, mockOrderID).WillReturnRows(rows)
+		mock.ExpectExec(qInsertOutbox).
+			WithArgs(mockTenantID, "order", mockOrderID, order.EventOrderCreated,
+				[]byte(`{"id":8,"distance":1000,"travel_mode":"bicycling","estimated_duration":300,"price":1444,"currency":"HKD","tariff_version":"v1","status":"UNASSIGNED","merchant_id":"merchant-1","origin_address":"1 Austin Rd W, Tsim Sha Tsui, Hong Kong",`+
+					`"origin":{"lat":22.300789,"lng":114.167815}}`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectPositionEvents(mock, []int64{1}, 3)
+		mock.ExpectCommit()
 
 		repo := NewOrderRepositoryMysql(sqlxDB)
 		err = repo.Create(&tempOrder)
 
 		assert.Equal(t, true, err == nil)
 		assert.Equal(t, mockOrderID, tempOrder.ID)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("insert-outbox-error", func(t *testing.T) {
+		tempOrder := mockOrder
+		mockOrderID := int64(11)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(qInsert).WillReturnResult(sqlmock.NewResult(mockOrderID, 1))
+		mock.ExpectExec(qInsertEvent).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(qSelect).WithArgs(mockTenantID, mockOrderID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(mockOrderID))
+		mock.ExpectExec(qInsertOutbox).WillReturnError(&mysql.MySQLError{})
+		mock.ExpectRollback()
+
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		err = repo.Create(&tempOrder)
+
+		_, isMysqlError := err.(*mysql.MySQLError)
+		assert.Equal(t, true, isMysqlError)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("from-quote", func(t *testing.T) {
+		tempOrder := mockOrder
+		tempOrder.QuoteID = "4f1c"
+		mockOrderID := int64(12)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(qInsert).WillReturnResult(sqlmock.NewResult(mockOrderID, 1))
+		mock.ExpectExec("UPDATE quotes SET order_id=\\? WHERE tenant_id=\\? AND id=\\? AND order_id=0 AND expires_at>now\\(6\\)").
+			WithArgs(mockOrderID, mockTenantID, "4f1c").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(qInsertEvent).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(qSelect).WithArgs(mockTenantID, mockOrderID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(mockOrderID))
+		mock.ExpectExec(qInsertOutbox).WillReturnResult(sqlmock.NewResult(1, 1))
+		expectPositionEvents(mock, []int64{1}, 4)
+		mock.ExpectCommit()
+
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		err = repo.Create(&tempOrder)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("multi-stop", func(t *testing.T) {
+		tempOrder := mockOrder
+		tempOrder.Stops = []order.Stop{
+			{Index: 0, Type: order.StopPickup, Lat: 22.300789, Lng: 114.167815},
+			{Index: 1, Type: order.StopPickup, Address: "1 Austin Rd W, Tsim Sha Tsui, Hong Kong", Lat: 22.3038, Lng: 114.1602, Distance: 400},
+			{Index: 2, Type: order.StopDropoff, Lat: 22.3354, Lng: 114.176155, Distance: 600},
+		}
+		mockOrderID := int64(14)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(qInsert).WillReturnResult(sqlmock.NewResult(mockOrderID, 1))
+		mock.ExpectExec("INSERT INTO order_stops \\(tenant_id, order_id, position, type, address, lat, lng, distance\\) VALUES "+
+			"\\(\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?\\),\\(\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?\\),\\(\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?\\)$").
+			WithArgs(mockTenantID, mockOrderID, 0, order.StopPickup, "", 22.300789, 114.167815, 0,
+				mockTenantID, mockOrderID, 1, order.StopPickup, "1 Austin Rd W, Tsim Sha Tsui, Hong Kong", 22.3038, 114.1602, 400,
+				mockTenantID, mockOrderID, 2, order.StopDropoff, "", 22.3354, 114.176155, 600).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(qInsertEvent).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(qSelect).WithArgs(mockTenantID, mockOrderID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(mockOrderID))
+		mock.ExpectExec(qInsertOutbox).WillReturnResult(sqlmock.NewResult(1, 1))
+		expectPositionEvents(mock, []int64{1}, 5)
+		mock.ExpectCommit()
+
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		err = repo.Create(&tempOrder)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, mockOrderID, tempOrder.Stops[2].OrderID)
+		assert.Equal(t, 3, len(tempOrder.Stops))
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("quote-used", func(t *testing.T) {
+		tempOrder := mockOrder
+		tempOrder.QuoteID = "4f1c"
+		mockOrderID := int64(13)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(qInsert).WillReturnResult(sqlmock.NewResult(mockOrderID, 1))
+		mock.ExpectExec("UPDATE quotes").WithArgs(mockOrderID, mockTenantID, "4f1c").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		err = repo.Create(&tempOrder)
+
+		assert.Equal(t, order.ErrQuoteUsed, err)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		tempOrder := mockOrder
+		tempOrder.TenantID = ""
+
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		err = repo.Create(&tempOrder)
+
+		assert.Equal(t, errNoTenant, err)
 	})
 
 	t.Run("insert-error", func(t *testing.T) {
 		tempOrder := mockOrder
 
-		prepInsert := mock.ExpectPrepare(qInsert)
-		prepInsert.ExpectExec().WithArgs(tempOrder.Distance, tempOrder.Status).
+		mock.ExpectBegin()
+		mock.ExpectExec(qInsert).
+			WithArgs(tempOrder.TenantID, tempOrder.Distance, tempOrder.TravelMode, tempOrder.EstimatedDuration,
+				tempOrder.Price, tempOrder.Currency, tempOrder.TariffVersion, tempOrder.QuoteID, tempOrder.Status, tempOrder.MerchantID,
+				tempOrder.OriginAddress, tempOrder.OriginLat, tempOrder.OriginLng,
+				tempOrder.DestinationAddress, tempOrder.DestinationLat, tempOrder.DestinationLng, tempOrder.ScheduledFor).
 			WillReturnError(&mysql.MySQLError{})
+		mock.ExpectRollback()
 
 		repo := NewOrderRepositoryMysql(sqlxDB)
 		err = repo.Create(&tempOrder)
@@ -79,17 +239,47 @@ func TestCreate(t *testing.T) {
 
 		_, isMysqlError := err.(*mysql.MySQLError)
 		assert.Equal(t, true, isMysqlError)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("insert-event-error", func(t *testing.T) {
+		tempOrder := mockOrder
+		mockOrderID := int64(9)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(qInsert).
+			WithArgs(tempOrder.TenantID, tempOrder.Distance, tempOrder.TravelMode, tempOrder.EstimatedDuration,
+				tempOrder.Price, tempOrder.Currency, tempOrder.TariffVersion, tempOrder.QuoteID, tempOrder.Status, tempOrder.MerchantID,
+				tempOrder.OriginAddress, tempOrder.OriginLat, tempOrder.OriginLng,
+				tempOrder.DestinationAddress, tempOrder.DestinationLat, tempOrder.DestinationLng, tempOrder.ScheduledFor).
+			WillReturnResult(sqlmock.NewResult(mockOrderID, 1))
+		mock.ExpectExec(qInsertEvent).WithArgs(order.EventOrderCreated, mockTenantID, mockOrderID).
+			WillReturnError(&mysql.MySQLError{})
+		mock.ExpectRollback()
+
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		err = repo.Create(&tempOrder)
+
+		_, isMysqlError := err.(*mysql.MySQLError)
+		assert.Equal(t, true, isMysqlError)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
 	})
 
 	t.Run("select-error", func(t *testing.T) {
 		tempOrder := mockOrder
 		mockOrderID := int64(10)
 
-		prepInsert := mock.ExpectPrepare(qInsert)
-		prepInsert.ExpectExec().WithArgs(tempOrder.Distance, tempOrder.Status).
+		mock.ExpectBegin()
+		mock.ExpectExec(qInsert).
+			WithArgs(tempOrder.TenantID, tempOrder.Distance, tempOrder.TravelMode, tempOrder.EstimatedDuration,
+				tempOrder.Price, tempOrder.Currency, tempOrder.TariffVersion, tempOrder.QuoteID, tempOrder.Status, tempOrder.MerchantID,
+				tempOrder.OriginAddress, tempOrder.OriginLat, tempOrder.OriginLng,
+				tempOrder.DestinationAddress, tempOrder.DestinationLat, tempOrder.DestinationLng, tempOrder.ScheduledFor).
 			WillReturnResult(sqlmock.NewResult(mockOrderID, 1))
-
-		mock.ExpectQuery(qSelect).WithArgs(mockOrderID).WillReturnError(&mysql.MySQLError{})
+		mock.ExpectExec(qInsertEvent).WithArgs(order.EventOrderCreated, mockTenantID, mockOrderID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(qSelect).WithArgs(mockTenantID, mockOrderID).WillReturnError(&mysql.MySQLError{})
+		mock.ExpectRollback()
 
 		repo := NewOrderRepositoryMysql(sqlxDB)
 		err = repo.Create(&tempOrder)
@@ -98,6 +288,85 @@ func TestCreate(t *testing.T) {
 
 		_, isMysqlError := err.(*mysql.MySQLError)
 		assert.Equal(t, true, isMysqlError)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCreateBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	qInsert := "INSERT INTO orders"
+	qInsertEvent := "INSERT INTO order_events (.+) SELECT (.+) FROM orders"
+	qSelect := "SELECT (.+) FROM orders"
+	qInsertOutbox := "INSERT INTO outbox_messages"
+
+	mockOrder := order.Order{
+		TenantID:   mockTenantID,
+		Distance:   1000,
+		Status:     order.StatusUnassigned,
+		MerchantID: "merchant-1",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		first, second := mockOrder, mockOrder
+
+		mock.ExpectBegin()
+		for _, id := range []int64{8, 9} {
+			mock.ExpectExec(qInsert).WillReturnResult(sqlmock.NewResult(id, 1))
+			mock.ExpectExec(qInsertEvent).WithArgs(order.EventOrderCreated, mockTenantID, id).
+				WillReturnResult(sqlmock.NewResult(id+10, 1))
+			mock.ExpectQuery(qSelect).WithArgs(mockTenantID, id).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+			mock.ExpectExec(qInsertOutbox).WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+		expectPositionEvents(mock, []int64{18, 19}, 7)
+		mock.ExpectCommit()
+
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		err = repo.CreateBatch([]*order.Order{&first, &second})
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, int64(8), first.ID)
+		assert.Equal(t, int64(9), second.ID)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		first, second := mockOrder, mockOrder
+		second.TenantID = ""
+
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		err = repo.CreateBatch([]*order.Order{&first, &second})
+
+		assert.Equal(t, errNoTenant, err)
+	})
+
+	t.Run("insert-error-rolls-back", func(t *testing.T) {
+		first, second := mockOrder, mockOrder
+
+		mock.ExpectBegin()
+		mock.ExpectExec(qInsert).WillReturnResult(sqlmock.NewResult(10, 1))
+		mock.ExpectExec(qInsertEvent).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(qSelect).WithArgs(mockTenantID, int64(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+		mock.ExpectExec(qInsertOutbox).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(qInsert).WillReturnError(&mysql.MySQLError{})
+		mock.ExpectRollback()
+
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		err = repo.CreateBatch([]*order.Order{&first, &second})
+
+		var batchErr *order.BatchError
+		assert.Equal(t, true, errors.As(err, &batchErr))
+		assert.Equal(t, 1, batchErr.Index)
+		_, isMysqlError := batchErr.Err.(*mysql.MySQLError)
+		assert.Equal(t, true, isMysqlError)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
 	})
 }
 
@@ -110,48 +379,123 @@ func TestUpdateStatusByID(t *testing.T) {
 	defer db.Close()
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 	q := "UPDATE orders SET"
+	qInsertEvent := "INSERT INTO order_events (.+) SELECT (.+) FROM orders"
+	qSelect := "SELECT (.+) FROM orders"
+	qInsertOutbox := "INSERT INTO outbox_messages"
+	mockCourierID := "courier-1"
 
 	t.Run("success", func(t *testing.T) {
 		mockOrderID := int64(8)
 
-		prepUpdate := mock.ExpectPrepare(q)
-		prepUpdate.ExpectExec().WithArgs(order.StatusTaken, mockOrderID, order.StatusUnassigned).
+		mock.ExpectBegin()
+		mock.ExpectExec(q).WithArgs(order.StatusTaken, mockCourierID, mockTenantID, mockOrderID, order.StatusUnassigned).
 			WillReturnResult(sqlmock.NewResult(mockOrderID, 1))
+		mock.ExpectExec(qInsertEvent).WithArgs(order.EventOrderStatusChanged, mockTenantID, mockOrderID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		rows := sqlmock.NewRows([]string{"id", "tenant_id", "distance", "travel_mode", "status", "merchant_id", "courier_id", "origin_lat", "origin_lng"}).
+			AddRow(mockOrderID, mockTenantID, 1000, order.TravelModeDriving, order.StatusTaken, "merchant-1", mockCourierID, 22.300789, 114.167815)
+		mock.ExpectQuery(qSelect).WithArgs(mockTenantID, mockOrderID).WillReturnRows(rows)
+		mock.ExpectExec(qInsertOutbox).
+			WithArgs(mockTenantID, "order", mockOrderID, order.EventOrderStatusChanged,
+				[]byte(`{"id":8,"distance":1000,"travel_mode":"driving","status":"TAKEN","merchant_id":"merchant-1","courier_id":"courier-1",`+
+					`"origin":{"lat":22.300789,"lng":114.167815}}`)).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		expectPositionEvents(mock, []int64{1}, 9)
+		mock.ExpectCommit()
 
 		repo := NewOrderRepositoryMysql(sqlxDB)
-		err = repo.UpdateStatusByID(mockOrderID)
+		err = repo.UpdateStatusByID(mockTenantID, mockOrderID, mockCourierID)
 
 		assert.Equal(t, true, err == nil)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
 	})
 
 	t.Run("no-update", func(t *testing.T) {
 		mockOrderID := int64(8)
 
-		prepUpdate := mock.ExpectPrepare(q)
-		prepUpdate.ExpectExec().WithArgs(order.StatusTaken, mockOrderID, order.StatusUnassigned).
+		mock.ExpectBegin()
+		mock.ExpectExec(q).WithArgs(order.StatusTaken, mockCourierID, mockTenantID, mockOrderID, order.StatusUnassigned).
 			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
 
 		repo := NewOrderRepositoryMysql(sqlxDB)
-		err = repo.UpdateStatusByID(mockOrderID)
+		err = repo.UpdateStatusByID(mockTenantID, mockOrderID, mockCourierID)
 
 		assert.Equal(t, false, err == nil)
 		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		err = repo.UpdateStatusByID("", 8, mockCourierID)
+
+		assert.Equal(t, errNoTenant, err)
 	})
 
 	t.Run("update-error", func(t *testing.T) {
 		mockOrderID := int64(8)
 
-		prepUpdate := mock.ExpectPrepare(q)
-		prepUpdate.ExpectExec().WithArgs(order.StatusTaken, mockOrderID, order.StatusUnassigned).
+		mock.ExpectBegin()
+		mock.ExpectExec(q).WithArgs(order.StatusTaken, mockCourierID, mockTenantID, mockOrderID, order.StatusUnassigned).
 			WillReturnError(&mysql.MySQLError{})
+		mock.ExpectRollback()
 
 		repo := NewOrderRepositoryMysql(sqlxDB)
-		err = repo.UpdateStatusByID(mockOrderID)
+		err = repo.UpdateStatusByID(mockTenantID, mockOrderID, mockCourierID)
 
 		assert.Equal(t, false, err == nil)
 
 		_, isMysqlError := err.(*mysql.MySQLError)
 		assert.Equal(t, true, isMysqlError)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCompleteStop(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q := "UPDATE order_stops s JOIN orders o ON o.tenant_id=s.tenant_id AND o.id=s.order_id SET s.completed_at=now\\(6\\) " +
+		"WHERE s.tenant_id=\\? AND o.id=\\? AND o.status=\\? AND o.courier_id=\\? AND s.position=\\? AND s.completed_at IS NULL"
+	mockOrderID := int64(8)
+
+	t.Run("success", func(t *testing.T) {
+		completedAt := time.Now()
+
+		mock.ExpectExec(q).WithArgs(mockTenantID, mockOrderID, order.StatusTaken, "courier-1", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT \\* FROM order_stops WHERE tenant_id=\\? AND order_id=\\? AND position=\\?").WithArgs(mockTenantID, mockOrderID, 1).
+			WillReturnRows(sqlmock.NewRows(stopColumns).AddRow(mockTenantID, mockOrderID, 1, order.StopDropoff, "", 22.33, 114.17, 888, completedAt))
+
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		stop, err := repo.CompleteStop(mockTenantID, mockOrderID, 1, "courier-1")
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, stop.Index)
+		assert.Equal(t, completedAt, *stop.CompletedAt)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no-update", func(t *testing.T) {
+		mock.ExpectExec(q).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		_, err := repo.CompleteStop(mockTenantID, mockOrderID, 1, "courier-2")
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		_, err := repo.CompleteStop("", mockOrderID, 1, "courier-1")
+
+		assert.Equal(t, errNoTenant, err)
 	})
 }
 
@@ -172,22 +516,39 @@ func TestFindByID(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "distance", "status", "created_at", "updated_at"}).
 			AddRow(mockOrderID, mockDistance, mockStatus, time.Now(), time.Now())
-		mock.ExpectQuery(q).WithArgs(mockOrderID).WillReturnRows(rows)
+		mock.ExpectQuery(q).WithArgs(mockTenantID, mockOrderID).WillReturnRows(rows)
+		mock.ExpectQuery("SELECT \\* FROM order_stops WHERE tenant_id=\\? AND order_id IN \\(\\?\\) ORDER BY order_id, position").
+			WithArgs(mockTenantID, mockOrderID).
+			WillReturnRows(sqlmock.NewRows(stopColumns).
+				AddRow(mockTenantID, mockOrderID, 0, order.StopPickup, "", 22.3, 114.16, 0, nil).
+				AddRow(mockTenantID, mockOrderID, 1, order.StopDropoff, "", 22.33, 114.17, 888, time.Now()))
 
 		repo := NewOrderRepositoryMysql(sqlxDB)
-		order, err := repo.FindByID(mockOrderID)
+		found, err := repo.FindByID(mockTenantID, mockOrderID)
 
 		assert.Equal(t, true, err == nil)
-		assert.Equal(t, mockOrderID, order.ID)
+		assert.Equal(t, mockOrderID, found.ID)
+		assert.Equal(t, 2, len(found.Stops))
+		assert.Equal(t, order.StopDropoff, found.Stops[1].Type)
+		assert.Equal(t, true, found.Stops[0].CompletedAt == nil)
+		assert.Equal(t, false, found.Stops[1].CompletedAt == nil)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		_, err := repo.FindByID("", 8)
+
+		assert.Equal(t, errNoTenant, err)
 	})
 
 	t.Run("select-error", func(t *testing.T) {
 		mockOrderID := int64(99)
 
-		mock.ExpectQuery(q).WithArgs(mockOrderID).WillReturnError(&mysql.MySQLError{})
+		mock.ExpectQuery(q).WithArgs(mockTenantID, mockOrderID).WillReturnError(&mysql.MySQLError{})
 
 		repo := NewOrderRepositoryMysql(sqlxDB)
-		_, err := repo.FindByID(mockOrderID)
+		_, err := repo.FindByID(mockTenantID, mockOrderID)
 
 		assert.Equal(t, false, err == nil)
 
@@ -214,23 +575,58 @@ func TestFindRange(t *testing.T) {
 			AddRow(1, 100, order.StatusTaken, time.Now(), time.Now()).
 			AddRow(2, 200, order.StatusUnassigned, time.Now(), time.Now()).
 			AddRow(3, 300, order.StatusUnassigned, time.Now(), time.Now())
-		mock.ExpectQuery(q).WithArgs(mockLimit, mockPage).WillReturnRows(rows)
+		mock.ExpectQuery(q).WithArgs(mockTenantID, mockLimit, mockPage).WillReturnRows(rows)
+		// the stops of the whole page are found at once
+		mock.ExpectQuery("SELECT \\* FROM order_stops WHERE tenant_id=\\? AND order_id IN \\(\\?, \\?, \\?\\)").
+			WithArgs(mockTenantID, int64(1), int64(2), int64(3)).
+			WillReturnRows(sqlmock.NewRows(stopColumns).
+				AddRow(mockTenantID, 2, 0, order.StopPickup, "", 22.3, 114.16, 0, nil).
+				AddRow(mockTenantID, 2, 1, order.StopDropoff, "", 22.33, 114.17, 200, nil))
 
 		repo := NewOrderRepositoryMysql(sqlxDB)
-		orders, err := repo.FindRange(mockLimit, mockPage)
+		orders, err := repo.FindRange(mockTenantID, order.OrderFilter{}, mockLimit, mockPage)
 
 		assert.Equal(t, true, err == nil)
 		assert.Equal(t, 3, len(*orders))
+		assert.Equal(t, 0, len((*orders)[0].Stops))
+		assert.Equal(t, 2, len((*orders)[1].Stops))
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("success-with-filter", func(t *testing.T) {
+		mockLimit := 3
+		mockPage := 1
+		mockFilter := order.OrderFilter{MerchantID: "merchant-1", Status: order.StatusUnassigned}
+
+		rows := sqlmock.NewRows([]string{"id", "distance", "status", "merchant_id", "created_at", "updated_at"}).
+			AddRow(2, 200, order.StatusUnassigned, "merchant-1", time.Now(), time.Now())
+		mock.ExpectQuery("SELECT (.+) FROM orders WHERE tenant_id=\\? AND merchant_id=\\? AND status=\\? LIMIT").
+			WithArgs(mockTenantID, mockFilter.MerchantID, mockFilter.Status, mockLimit, mockPage).WillReturnRows(rows)
+		mock.ExpectQuery("SELECT \\* FROM order_stops").WithArgs(mockTenantID, int64(2)).WillReturnRows(sqlmock.NewRows(stopColumns))
+
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		orders, err := repo.FindRange(mockTenantID, mockFilter, mockLimit, mockPage)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, 1, len(*orders))
+		assert.Equal(t, "merchant-1", (*orders)[0].MerchantID)
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewOrderRepositoryMysql(sqlxDB)
+		_, err := repo.FindRange("", order.OrderFilter{}, 3, 1)
+
+		assert.Equal(t, errNoTenant, err)
 	})
 
 	t.Run("select-error", func(t *testing.T) {
 		mockLimit := 4
 		mockPage := 2
 
-		mock.ExpectQuery(q).WithArgs(mockLimit, mockPage).WillReturnError(&mysql.MySQLError{})
+		mock.ExpectQuery(q).WithArgs(mockTenantID, mockLimit, mockPage).WillReturnError(&mysql.MySQLError{})
 
 		repo := NewOrderRepositoryMysql(sqlxDB)
-		_, err := repo.FindRange(mockLimit, mockPage)
+		_, err := repo.FindRange(mockTenantID, order.OrderFilter{}, mockLimit, mockPage)
 
 		assert.Equal(t, false, err == nil)
 
@@ -0,0 +1,95 @@
+package mysql
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/imylam/delivery-test/order"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type quoteRepoMysql struct {
+	MysqlConn *sqlx.DB
+	ttl       time.Duration
+}
+
+// NewQuoteRepositoryMysql will create an object that represent the
+// order.QuoteRepository interface, quotes expire ttl after they are created
+func NewQuoteRepositoryMysql(mysqlConn *sqlx.DB, ttl time.Duration) order.QuoteRepository {
+	return &quoteRepoMysql{MysqlConn: mysqlConn, ttl: ttl}
+}
+
+// Create inserts q with a new random ID, then reads it back to fill its timestamps
+func (repo *quoteRepoMysql) Create(q *order.Quote) error {
+	q1 := "INSERT INTO quotes (id, tenant_id, merchant_id, distance, travel_mode, estimated_duration, " +
+		"origin_address, origin_lat, origin_lng, destination_address, destination_lat, destination_lng, " +
+		"price, currency, tariff_version, expires_at, created_at) " +
+		"VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,now(6) + INTERVAL ? MICROSECOND,now(6))"
+	q2 := "SELECT * FROM quotes WHERE tenant_id=? AND id=?"
+
+	if q.TenantID == "" {
+		return errNoTenant
+	}
+
+	id, err := newQuoteID()
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.MysqlConn.Exec(q1, id, q.TenantID, q.MerchantID, q.Distance, q.TravelMode, q.EstimatedDuration,
+		q.OriginAddress, q.OriginLat, q.OriginLng, q.DestinationAddress, q.DestinationLat, q.DestinationLng,
+		q.Price, q.Currency, q.TariffVersion, repo.ttl.Microseconds())
+	if err != nil {
+		return err
+	}
+
+	return repo.MysqlConn.QueryRowx(q2, q.TenantID, id).StructScan(q)
+}
+
+func (repo *quoteRepoMysql) FindByID(tenantID string, id string) (*order.Quote, error) {
+	q := "SELECT * FROM quotes WHERE tenant_id=? AND id=?"
+
+	if tenantID == "" {
+		return nil, errNoTenant
+	}
+
+	var quote order.Quote
+	err := repo.MysqlConn.QueryRowx(q, tenantID, id).StructScan(&quote)
+	if err != nil {
+		return nil, err
+	}
+
+	return &quote, nil
+}
+
+// claimQuote marks the quote of o used by it, in the transaction creating o.
+// It fails with order.ErrQuoteUsed when the quote was used or expired since it was checked
+func claimQuote(tx *sqlx.Tx, o *order.Order) error {
+	q := "UPDATE quotes SET order_id=? WHERE tenant_id=? AND id=? AND order_id=0 AND expires_at>now(6)"
+
+	result, err := tx.Exec(q, o.ID, o.TenantID, o.QuoteID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return order.ErrQuoteUsed
+	}
+
+	return nil
+}
+
+func newQuoteID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,122 @@
+package mysql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/go-sql-driver/mysql"
+	"github.com/imylam/delivery-test/order"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestCreateQuote(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockQuote := order.Quote{
+		TenantID:       mockTenantID,
+		MerchantID:     "merchant-1",
+		Distance:       4200,
+		TravelMode:     order.TravelModeDriving,
+		OriginLat:      22.300789,
+		OriginLng:      114.167815,
+		DestinationLat: 22.3354,
+		DestinationLng: 114.176155,
+		Price:          3100,
+		Currency:       "HKD",
+		TariffVersion:  "v1",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		tempQuote := mockQuote
+		expiresAt := time.Date(2022, 10, 1, 12, 5, 0, 0, time.UTC)
+
+		mock.ExpectExec("INSERT INTO quotes (.+) VALUES (.+)now\\(6\\) \\+ INTERVAL \\? MICROSECOND").
+			WithArgs(sqlmock.AnyArg(), mockTenantID, "merchant-1", 4200, order.TravelModeDriving, 0,
+				"", 22.300789, 114.167815, "", 22.3354, 114.176155, int64(3100), "HKD", "v1", int64(300000000)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT (.+) FROM quotes WHERE tenant_id=\\? AND id=\\?").
+			WithArgs(mockTenantID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "expires_at"}).AddRow("4f1c", expiresAt))
+
+		repo := NewQuoteRepositoryMysql(sqlxDB, 5*time.Minute)
+		err := repo.Create(&tempQuote)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "4f1c", tempQuote.ID)
+		assert.Equal(t, expiresAt, tempQuote.ExpiresAt)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("insert-error", func(t *testing.T) {
+		tempQuote := mockQuote
+
+		mock.ExpectExec("INSERT INTO quotes").WillReturnError(&mysql.MySQLError{})
+
+		repo := NewQuoteRepositoryMysql(sqlxDB, 5*time.Minute)
+		err := repo.Create(&tempQuote)
+
+		_, isMysqlError := err.(*mysql.MySQLError)
+		assert.Equal(t, true, isMysqlError)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		tempQuote := mockQuote
+		tempQuote.TenantID = ""
+
+		repo := NewQuoteRepositoryMysql(sqlxDB, 5*time.Minute)
+		err := repo.Create(&tempQuote)
+
+		assert.Equal(t, errNoTenant, err)
+	})
+}
+
+func TestFindQuoteByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q := "SELECT (.+) FROM quotes WHERE tenant_id=\\? AND id=\\?"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "tenant_id", "merchant_id", "distance", "price", "order_id"}).
+			AddRow("4f1c", mockTenantID, "merchant-1", 4200, 3100, 0)
+		mock.ExpectQuery(q).WithArgs(mockTenantID, "4f1c").WillReturnRows(rows)
+
+		repo := NewQuoteRepositoryMysql(sqlxDB, 5*time.Minute)
+		quote, err := repo.FindByID(mockTenantID, "4f1c")
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "merchant-1", quote.MerchantID)
+		assert.Equal(t, int64(3100), quote.Price)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		mock.ExpectQuery(q).WithArgs(mockTenantID, "4f1c").WillReturnError(sql.ErrNoRows)
+
+		repo := NewQuoteRepositoryMysql(sqlxDB, 5*time.Minute)
+		_, err := repo.FindByID(mockTenantID, "4f1c")
+
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewQuoteRepositoryMysql(sqlxDB, 5*time.Minute)
+		_, err := repo.FindByID("", "4f1c")
+
+		assert.Equal(t, errNoTenant, err)
+	})
+}
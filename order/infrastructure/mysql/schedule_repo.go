@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	_lease "github.com/imylam/delivery-test/common/lease"
+	"github.com/imylam/delivery-test/order"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type scheduleRepoMysql struct {
+	MysqlConn *sqlx.DB
+}
+
+// NewScheduleRepositoryMysql will create an object that represent the order.ScheduleRepository interface
+func NewScheduleRepositoryMysql(mysqlConn *sqlx.DB) order.ScheduleRepository {
+	return &scheduleRepoMysql{mysqlConn}
+}
+
+// AcquireLease takes or renews the lease called name for holder, it returns
+// false while another holder has an unexpired lease
+func (repo *scheduleRepoMysql) AcquireLease(name, holder string, lease time.Duration) (bool, error) {
+	return _lease.AcquireMysql(repo.MysqlConn, name, holder, lease)
+}
+
+func (repo *scheduleRepoMysql) FindDue(before time.Time, limit int) ([]order.Order, error) {
+	q := "SELECT * FROM orders WHERE status=? AND scheduled_for<=? ORDER BY scheduled_for, id LIMIT ?"
+
+	orders := []order.Order{}
+	err := repo.MysqlConn.Select(&orders, q, order.StatusScheduled, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// Release makes the order unassigned if it is still scheduled, so that a
+// single scheduler releases it even if several race for it
+func (repo *scheduleRepoMysql) Release(tenantID string, id int64) (*order.Order, error) {
+	q1 := "UPDATE orders SET status=? WHERE tenant_id=? AND id=? AND status=?"
+	q2 := "SELECT * FROM orders WHERE tenant_id=? AND id=?"
+
+	if tenantID == "" {
+		return nil, errNoTenant
+	}
+
+	tx, err := repo.MysqlConn.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(q1, order.StatusUnassigned, tenantID, id, order.StatusScheduled)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	eventID, err := insertEvent(tx, order.EventOrderStatusChanged, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var released order.Order
+	err = tx.QueryRowx(q2, tenantID, id).StructScan(&released)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertOutboxMessage(tx, order.EventOrderStatusChanged, &released)
+	if err != nil {
+		return nil, err
+	}
+
+	err = positionEvents(tx, tenantID, []int64{eventID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &released, tx.Commit()
+}
@@ -0,0 +1,117 @@
+package mysql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/go-sql-driver/mysql"
+	"github.com/imylam/delivery-test/order"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestFindDue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q := "SELECT (.+) FROM orders WHERE status=\\? AND scheduled_for<=\\? ORDER BY scheduled_for, id LIMIT \\?"
+	before := time.Date(2022, 10, 1, 12, 30, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		scheduledFor := time.Date(2022, 10, 1, 12, 15, 0, 0, time.UTC)
+		rows := sqlmock.NewRows([]string{"id", "tenant_id", "status", "scheduled_for"}).
+			AddRow(8, mockTenantID, order.StatusScheduled, scheduledFor).
+			AddRow(9, "brand-b", order.StatusScheduled, scheduledFor)
+		mock.ExpectQuery(q).WithArgs(order.StatusScheduled, before, 100).WillReturnRows(rows)
+
+		repo := NewScheduleRepositoryMysql(sqlxDB)
+		orders, err := repo.FindDue(before, 100)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, len(orders))
+		assert.Equal(t, "brand-b", orders[1].TenantID)
+		assert.Equal(t, scheduledFor, *orders[0].ScheduledFor)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("db-error", func(t *testing.T) {
+		mock.ExpectQuery(q).WillReturnError(&mysql.MySQLError{})
+
+		repo := NewScheduleRepositoryMysql(sqlxDB)
+		orders, err := repo.FindDue(before, 100)
+
+		assert.Equal(t, false, err == nil)
+		assert.Equal(t, true, orders == nil)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRelease(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q := "UPDATE orders SET status=\\? WHERE tenant_id=\\? AND id=\\? AND status=\\?"
+	qInsertEvent := "INSERT INTO order_events (.+) SELECT (.+) FROM orders"
+	qSelect := "SELECT (.+) FROM orders"
+	qInsertOutbox := "INSERT INTO outbox_messages"
+	mockOrderID := int64(8)
+
+	t.Run("success", func(t *testing.T) {
+		scheduledFor := time.Date(2022, 10, 1, 12, 15, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(q).WithArgs(order.StatusUnassigned, mockTenantID, mockOrderID, order.StatusScheduled).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(qInsertEvent).WithArgs(order.EventOrderStatusChanged, mockTenantID, mockOrderID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		rows := sqlmock.NewRows([]string{"id", "tenant_id", "distance", "status", "merchant_id", "scheduled_for"}).
+			AddRow(mockOrderID, mockTenantID, 1000, order.StatusUnassigned, "merchant-1", scheduledFor)
+		mock.ExpectQuery(qSelect).WithArgs(mockTenantID, mockOrderID).WillReturnRows(rows)
+		mock.ExpectExec(qInsertOutbox).
+			WithArgs(mockTenantID, "order", mockOrderID, order.EventOrderStatusChanged,
+				[]byte(`{"id":8,"distance":1000,"status":"UNASSIGNED","merchant_id":"merchant-1","scheduled_for":"2022-10-01T12:15:00Z",`+
+					`"origin":{"lat":0,"lng":0}}`)).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		expectPositionEvents(mock, []int64{1}, 3)
+		mock.ExpectCommit()
+
+		repo := NewScheduleRepositoryMysql(sqlxDB)
+		released, err := repo.Release(mockTenantID, mockOrderID)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, order.StatusUnassigned, released.Status)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not-scheduled", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(q).WithArgs(order.StatusUnassigned, mockTenantID, mockOrderID, order.StatusScheduled).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		repo := NewScheduleRepositoryMysql(sqlxDB)
+		released, err := repo.Release(mockTenantID, mockOrderID)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Equal(t, true, released == nil)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewScheduleRepositoryMysql(sqlxDB)
+		_, err := repo.Release("", mockOrderID)
+
+		assert.Equal(t, errNoTenant, err)
+	})
+}
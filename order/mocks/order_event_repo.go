@@ -0,0 +1,57 @@
+package mocks
+
+import (
+	"github.com/imylam/delivery-test/order"
+	"github.com/stretchr/testify/mock"
+)
+
+// OrderEventRepository is a mock type for the OrderEventRepository type
+type OrderEventRepository struct {
+	mock.Mock
+}
+
+// FindAfter provides a mock function with given fields: tenantID, filter, afterPosition, limit
+func (_m *OrderEventRepository) FindAfter(tenantID string, filter order.EventFilter, afterPosition int64, limit int) ([]order.OrderEvent, error) {
+	ret := _m.Called(tenantID, filter, afterPosition, limit)
+
+	var r0 []order.OrderEvent
+	if rf, ok := ret.Get(0).(func(string, order.EventFilter, int64, int) []order.OrderEvent); ok {
+		r0 = rf(tenantID, filter, afterPosition, limit)
+	} else {
+		if _, ok := ret.Get(0).([]order.OrderEvent); ok {
+			r0 = ret.Get(0).([]order.OrderEvent)
+		} else {
+			r0 = nil
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, order.EventFilter, int64, int) error); ok {
+		r1 = rf(tenantID, filter, afterPosition, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LatestPosition provides a mock function with given fields: tenantID
+func (_m *OrderEventRepository) LatestPosition(tenantID string) (int64, error) {
+	ret := _m.Called(tenantID)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(string) int64); ok {
+		r0 = rf(tenantID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -24,13 +24,13 @@ func (_m *OrderRepository) Create(newOrder *order.Order) error {
 	return r0
 }
 
-// UpdateStatusByID provides a mock function with given fields: id
-func (_m *OrderRepository) UpdateStatusByID(id int64) error {
-	ret := _m.Called(id)
+// CreateBatch provides a mock function with given fields: newOrders
+func (_m *OrderRepository) CreateBatch(newOrders []*order.Order) error {
+	ret := _m.Called(newOrders)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func([]*order.Order) error); ok {
+		r0 = rf(newOrders)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -38,13 +38,52 @@ func (_m *OrderRepository) UpdateStatusByID(id int64) error {
 	return r0
 }
 
-// FindByID provides a mock function with given fields: id
-func (_m *OrderRepository) FindByID(id int64) (*order.Order, error) {
-	ret := _m.Called(id)
+// UpdateStatusByID provides a mock function with given fields: tenantID, id, courierID
+func (_m *OrderRepository) UpdateStatusByID(tenantID string, id int64, courierID string) error {
+	ret := _m.Called(tenantID, id, courierID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int64, string) error); ok {
+		r0 = rf(tenantID, id, courierID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CompleteStop provides a mock function with given fields: tenantID, id, index, courierID
+func (_m *OrderRepository) CompleteStop(tenantID string, id int64, index int, courierID string) (*order.Stop, error) {
+	ret := _m.Called(tenantID, id, index, courierID)
+
+	var r0 *order.Stop
+	if rf, ok := ret.Get(0).(func(string, int64, int, string) *order.Stop); ok {
+		r0 = rf(tenantID, id, index, courierID)
+	} else {
+		if _, ok := ret.Get(0).(*order.Stop); ok {
+			r0 = ret.Get(0).(*order.Stop)
+		} else {
+			r0 = nil
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int64, int, string) error); ok {
+		r1 = rf(tenantID, id, index, courierID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByID provides a mock function with given fields: tenantID, id
+func (_m *OrderRepository) FindByID(tenantID string, id int64) (*order.Order, error) {
+	ret := _m.Called(tenantID, id)
 
 	var r0 *order.Order
-	if rf, ok := ret.Get(0).(func(int64) *order.Order); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(string, int64) *order.Order); ok {
+		r0 = rf(tenantID, id)
 	} else {
 		if _, ok := ret.Get(0).(*order.Order); ok {
 			r0 = ret.Get(0).(*order.Order)
@@ -54,8 +93,8 @@ func (_m *OrderRepository) FindByID(id int64) (*order.Order, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(string, int64) error); ok {
+		r1 = rf(tenantID, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -63,13 +102,13 @@ func (_m *OrderRepository) FindByID(id int64) (*order.Order, error) {
 	return r0, r1
 }
 
-// FindRange provides a mock function with given fields: limit, offset
-func (_m *OrderRepository) FindRange(limit, offset int) (*[]order.Order, error) {
-	ret := _m.Called(limit, offset)
+// FindRange provides a mock function with given fields: tenantID, filter, limit, offset
+func (_m *OrderRepository) FindRange(tenantID string, filter order.OrderFilter, limit, offset int) (*[]order.Order, error) {
+	ret := _m.Called(tenantID, filter, limit, offset)
 
 	var r0 *[]order.Order
-	if rf, ok := ret.Get(0).(func(int, int) *[]order.Order); ok {
-		r0 = rf(limit, offset)
+	if rf, ok := ret.Get(0).(func(string, order.OrderFilter, int, int) *[]order.Order); ok {
+		r0 = rf(tenantID, filter, limit, offset)
 	} else {
 		if _, ok := ret.Get(0).(*[]order.Order); ok {
 			r0 = ret.Get(0).(*[]order.Order)
@@ -79,8 +118,8 @@ func (_m *OrderRepository) FindRange(limit, offset int) (*[]order.Order, error)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(limit, offset)
+	if rf, ok := ret.Get(1).(func(string, order.OrderFilter, int, int) error); ok {
+		r1 = rf(tenantID, filter, limit, offset)
 	} else {
 		r1 = ret.Error(1)
 	}
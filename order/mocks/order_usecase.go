@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"context"
+
 	"github.com/imylam/delivery-test/order"
 	"github.com/stretchr/testify/mock"
 )
@@ -10,13 +12,13 @@ type OrderUsecase struct {
 	mock.Mock
 }
 
-// PlaceOrder provides a mock function with given fields: origins, destinations
-func (_m *OrderUsecase) PlaceOrder(origins, destinations []string) (*order.Order, error) {
-	ret := _m.Called(origins, destinations)
+// PlaceOrder provides a mock function with given fields: ctx, placement
+func (_m *OrderUsecase) PlaceOrder(ctx context.Context, placement order.Placement) (*order.Order, error) {
+	ret := _m.Called(ctx, placement)
 
 	var r0 *order.Order
-	if rf, ok := ret.Get(0).(func([]string, []string) *order.Order); ok {
-		r0 = rf(origins, destinations)
+	if rf, ok := ret.Get(0).(func(context.Context, order.Placement) *order.Order); ok {
+		r0 = rf(ctx, placement)
 	} else {
 		if _, ok := ret.Get(0).(*order.Order); ok {
 			r0 = ret.Get(0).(*order.Order)
@@ -26,8 +28,8 @@ func (_m *OrderUsecase) PlaceOrder(origins, destinations []string) (*order.Order
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]string, []string) error); ok {
-		r1 = rf(origins, destinations)
+	if rf, ok := ret.Get(1).(func(context.Context, order.Placement) error); ok {
+		r1 = rf(ctx, placement)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -35,13 +37,61 @@ func (_m *OrderUsecase) PlaceOrder(origins, destinations []string) (*order.Order
 	return r0, r1
 }
 
-// TakeOrder provides a mock function with given fields: id
-func (_m *OrderUsecase) TakeOrder(id int64) (string, error) {
-	ret := _m.Called(id)
+// PlaceOrders provides a mock function with given fields: ctx, placements
+func (_m *OrderUsecase) PlaceOrders(ctx context.Context, placements []order.Placement) ([]order.PlacementResult, error) {
+	ret := _m.Called(ctx, placements)
+
+	var r0 []order.PlacementResult
+	if rf, ok := ret.Get(0).(func(context.Context, []order.Placement) []order.PlacementResult); ok {
+		r0 = rf(ctx, placements)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]order.PlacementResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []order.Placement) error); ok {
+		r1 = rf(ctx, placements)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QuoteOrder provides a mock function with given fields: ctx, placement
+func (_m *OrderUsecase) QuoteOrder(ctx context.Context, placement order.Placement) (*order.Quote, error) {
+	ret := _m.Called(ctx, placement)
+
+	var r0 *order.Quote
+	if rf, ok := ret.Get(0).(func(context.Context, order.Placement) *order.Quote); ok {
+		r0 = rf(ctx, placement)
+	} else {
+		if _, ok := ret.Get(0).(*order.Quote); ok {
+			r0 = ret.Get(0).(*order.Quote)
+		} else {
+			r0 = nil
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, order.Placement) error); ok {
+		r1 = rf(ctx, placement)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TakeOrder provides a mock function with given fields: ctx, id
+func (_m *OrderUsecase) TakeOrder(ctx context.Context, id int64) (string, error) {
+	ret := _m.Called(ctx, id)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(int64) string); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) string); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if _, ok := ret.Get(0).(string); ok {
 			r0 = ret.Get(0).(string)
@@ -51,8 +101,33 @@ func (_m *OrderUsecase) TakeOrder(id int64) (string, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompleteStop provides a mock function with given fields: ctx, id, index
+func (_m *OrderUsecase) CompleteStop(ctx context.Context, id int64, index int) (*order.Stop, error) {
+	ret := _m.Called(ctx, id, index)
+
+	var r0 *order.Stop
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int) *order.Stop); ok {
+		r0 = rf(ctx, id, index)
+	} else {
+		if _, ok := ret.Get(0).(*order.Stop); ok {
+			r0 = ret.Get(0).(*order.Stop)
+		} else {
+			r0 = nil
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int) error); ok {
+		r1 = rf(ctx, id, index)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -60,13 +135,13 @@ func (_m *OrderUsecase) TakeOrder(id int64) (string, error) {
 	return r0, r1
 }
 
-// ListOrders provides a mock function with given fields: page, limit
-func (_m *OrderUsecase) ListOrders(page, limit int) (*[]order.Order, error) {
-	ret := _m.Called(page, limit)
+// ListOrders provides a mock function with given fields: ctx, page, limit
+func (_m *OrderUsecase) ListOrders(ctx context.Context, page, limit int) (*[]order.Order, error) {
+	ret := _m.Called(ctx, page, limit)
 
 	var r0 *[]order.Order
-	if rf, ok := ret.Get(0).(func(int, int) *[]order.Order); ok {
-		r0 = rf(page, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) *[]order.Order); ok {
+		r0 = rf(ctx, page, limit)
 	} else {
 		if _, ok := ret.Get(0).(*[]order.Order); ok {
 			r0 = ret.Get(0).(*[]order.Order)
@@ -76,8 +151,79 @@ func (_m *OrderUsecase) ListOrders(page, limit int) (*[]order.Order, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(page, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, page, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrder provides a mock function with given fields: ctx, id
+func (_m *OrderUsecase) GetOrder(ctx context.Context, id int64) (*order.Order, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *order.Order
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *order.Order); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if _, ok := ret.Get(0).(*order.Order); ok {
+			r0 = ret.Get(0).(*order.Order)
+		} else {
+			r0 = nil
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListEvents provides a mock function with given fields: ctx, filter, afterID, limit
+func (_m *OrderUsecase) ListEvents(ctx context.Context, filter order.EventFilter, afterID int64, limit int) ([]order.OrderEvent, error) {
+	ret := _m.Called(ctx, filter, afterID, limit)
+
+	var r0 []order.OrderEvent
+	if rf, ok := ret.Get(0).(func(context.Context, order.EventFilter, int64, int) []order.OrderEvent); ok {
+		r0 = rf(ctx, filter, afterID, limit)
+	} else {
+		if _, ok := ret.Get(0).([]order.OrderEvent); ok {
+			r0 = ret.Get(0).([]order.OrderEvent)
+		} else {
+			r0 = nil
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, order.EventFilter, int64, int) error); ok {
+		r1 = rf(ctx, filter, afterID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LatestEventPosition provides a mock function with given fields: ctx
+func (_m *OrderUsecase) LatestEventPosition(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,50 @@
+package mocks
+
+import (
+	"github.com/imylam/delivery-test/order"
+	"github.com/stretchr/testify/mock"
+)
+
+// QuoteRepository is a mock type for the QuoteRepository type
+type QuoteRepository struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: quote
+func (_m *QuoteRepository) Create(quote *order.Quote) error {
+	ret := _m.Called(quote)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*order.Quote) error); ok {
+		r0 = rf(quote)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindByID provides a mock function with given fields: tenantID, id
+func (_m *QuoteRepository) FindByID(tenantID string, id string) (*order.Quote, error) {
+	ret := _m.Called(tenantID, id)
+
+	var r0 *order.Quote
+	if rf, ok := ret.Get(0).(func(string, string) *order.Quote); ok {
+		r0 = rf(tenantID, id)
+	} else {
+		if _, ok := ret.Get(0).(*order.Quote); ok {
+			r0 = ret.Get(0).(*order.Quote)
+		} else {
+			r0 = nil
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(tenantID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,80 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/imylam/delivery-test/order"
+	"github.com/stretchr/testify/mock"
+)
+
+// ScheduleRepository is a mock type for the ScheduleRepository type
+type ScheduleRepository struct {
+	mock.Mock
+}
+
+// AcquireLease provides a mock function with given fields: name, holder, lease
+func (_m *ScheduleRepository) AcquireLease(name string, holder string, lease time.Duration) (bool, error) {
+	ret := _m.Called(name, holder, lease)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string, time.Duration) bool); ok {
+		r0 = rf(name, holder, lease)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, time.Duration) error); ok {
+		r1 = rf(name, holder, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindDue provides a mock function with given fields: before, limit
+func (_m *ScheduleRepository) FindDue(before time.Time, limit int) ([]order.Order, error) {
+	ret := _m.Called(before, limit)
+
+	var r0 []order.Order
+	if rf, ok := ret.Get(0).(func(time.Time, int) []order.Order); ok {
+		r0 = rf(before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]order.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time, int) error); ok {
+		r1 = rf(before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Release provides a mock function with given fields: tenantID, id
+func (_m *ScheduleRepository) Release(tenantID string, id int64) (*order.Order, error) {
+	ret := _m.Called(tenantID, id)
+
+	var r0 *order.Order
+	if rf, ok := ret.Get(0).(func(string, int64) *order.Order); ok {
+		r0 = rf(tenantID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*order.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int64) error); ok {
+		r1 = rf(tenantID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -1,32 +1,432 @@
 package order
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 const (
+	StatusScheduled  string = "SCHEDULED"
 	StatusUnassigned string = "UNASSIGNED"
 	StatusTaken      string = "TAKEN"
 )
 
-// Order struct to represents an Order
+const (
+	EventOrderCreated       string = "order.created"
+	EventOrderStatusChanged string = "order.status_changed"
+)
+
+// Types of the stops of a multi-stop order
+const (
+	StopPickup  string = "pickup"
+	StopDropoff string = "dropoff"
+)
+
+// Travel modes of the couriers, two-wheelers are motorbikes and scooters
+const (
+	TravelModeDriving    string = "driving"
+	TravelModeBicycling  string = "bicycling"
+	TravelModeWalking    string = "walking"
+	TravelModeTwoWheeler string = "two_wheeler"
+)
+
+// Features of the road a route can avoid
+const (
+	AvoidTolls    string = "tolls"
+	AvoidHighways string = "highways"
+	AvoidFerries  string = "ferries"
+)
+
+var (
+	// ErrNoRoute is returned when no route links the origin of an order to its destination
+	ErrNoRoute = errors.New("no route between origin and destination")
+	// ErrAddressNotFound is returned when an address matches no place
+	ErrAddressNotFound = errors.New("address not found")
+	// ErrAmbiguousAddress is returned when an address matches several places
+	ErrAmbiguousAddress = errors.New("address is ambiguous")
+	// ErrOutsideServiceArea is returned when an end of an order is outside every service area of its tenant
+	ErrOutsideServiceArea = errors.New("outside the service area")
+	// ErrNoTariff is returned when the tenant of an order has no tariff to price it with
+	ErrNoTariff = errors.New("no tariff to price the order with")
+	// ErrQuoteNotFound is returned when a quote does not exist or belongs to another merchant
+	ErrQuoteNotFound = errors.New("quote not found")
+	// ErrQuoteExpired is returned when a quote is placed after it expired
+	ErrQuoteExpired = errors.New("quote expired")
+	// ErrQuoteUsed is returned when a quote is placed a second time
+	ErrQuoteUsed = errors.New("quote already used")
+	// ErrBatchTooLarge is returned when a batch holds more orders than are placed at once
+	ErrBatchTooLarge = errors.New("too many orders in the batch")
+	// ErrStopNotFound is returned when an order has no stop at an index
+	ErrStopNotFound = errors.New("stop not found")
+	// ErrStopCompleted is returned when a stop is completed a second time
+	ErrStopCompleted = errors.New("stop already completed")
+	// ErrNotTakenByCourier is returned when a courier acts on an order they did not take
+	ErrNotTakenByCourier = errors.New("order not taken by the courier")
+)
+
+// Order struct to represents an Order. EstimatedDuration is the travel time
+// in seconds from the origin to the destination, 0 when unknown, and
+// EstimatedDeliveryAt is only set by EstimateDelivery. The addresses are only
+// set for the ends placed by address. Price is in the minor unit of Currency,
+// quoted with the TariffVersion of the tenant, and 0 when the order is not
+// priced. QuoteID is the quote the order was placed from, if any. A
+// multi-stop order lists its Stops, the origin and the destination being its
+// first and last stops, Distance and EstimatedDuration covering every leg. A
+// scheduled order is picked up at ScheduledFor, it stays SCHEDULED and hidden
+// from couriers until it is released shortly before
 type Order struct {
-	ID        int64     `json:"id" db:"id"`
-	Distance  int       `json:"distance" db:"distance"`
-	Status    string    `json:"status" db:"status"`
-	CreatedAt time.Time `json:"-" db:"created_at"`
-	UpdatedAt time.Time `json:"-" db:"updated_at"`
+	ID                  int64      `json:"id" db:"id"`
+	TenantID            string     `json:"-" db:"tenant_id"`
+	Distance            int        `json:"distance" db:"distance"`
+	TravelMode          string     `json:"travel_mode,omitempty" db:"travel_mode"`
+	EstimatedDuration   int        `json:"estimated_duration,omitempty" db:"estimated_duration"`
+	EstimatedDeliveryAt *time.Time `json:"estimated_delivery_at,omitempty" db:"-"`
+	Price               int64      `json:"price,omitempty" db:"price"`
+	Currency            string     `json:"currency,omitempty" db:"currency"`
+	TariffVersion       string     `json:"tariff_version,omitempty" db:"tariff_version"`
+	QuoteID             string     `json:"quote_id,omitempty" db:"quote_id"`
+	Status              string     `json:"status" db:"status"`
+	MerchantID          string     `json:"merchant_id" db:"merchant_id"`
+	CourierID           string     `json:"courier_id,omitempty" db:"courier_id"`
+	OriginAddress       string     `json:"origin_address,omitempty" db:"origin_address"`
+	OriginLat           float64    `json:"-" db:"origin_lat"`
+	OriginLng           float64    `json:"-" db:"origin_lng"`
+	DestinationAddress  string     `json:"destination_address,omitempty" db:"destination_address"`
+	DestinationLat      float64    `json:"-" db:"destination_lat"`
+	DestinationLng      float64    `json:"-" db:"destination_lng"`
+	Stops               []Stop     `json:"stops,omitempty" db:"-"`
+	ScheduledFor        *time.Time `json:"scheduled_for,omitempty" db:"scheduled_for"`
+	CreatedAt           time.Time  `json:"-" db:"created_at"`
+	UpdatedAt           time.Time  `json:"-" db:"updated_at"`
+}
+
+// Stop is a pickup or a dropoff of a multi-stop order, Index is its position
+// in the order, from 0. Distance is the length in meters of the leg from the
+// previous stop, 0 for the first. The address is only set for stops placed by
+// address. CompletedAt is when the courier completed it, nil until then
+type Stop struct {
+	TenantID    string     `json:"-" db:"tenant_id"`
+	OrderID     int64      `json:"-" db:"order_id"`
+	Index       int        `json:"index" db:"position"`
+	Type        string     `json:"type" db:"type"`
+	Address     string     `json:"address,omitempty" db:"address"`
+	Lat         float64    `json:"-" db:"lat"`
+	Lng         float64    `json:"-" db:"lng"`
+	Distance    int        `json:"distance" db:"distance"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// OutboxPayload is the payload of the outbox messages of an order: the order
+// as the API shows it, plus the coordinates of its origin for the consumers
+// such as the message broker that locate orders
+type OutboxPayload struct {
+	*Order
+	Origin LatLng `json:"origin"`
+}
+
+// LatLng is a point on the map
+type LatLng struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// NewOutboxPayload returns the payload of the outbox messages carrying o
+func NewOutboxPayload(o *Order) *OutboxPayload {
+	return &OutboxPayload{Order: o, Origin: LatLng{Lat: o.OriginLat, Lng: o.OriginLng}}
+}
+
+// EstimateDelivery sets EstimatedDeliveryAt to when the order arrives if it
+// travels as soon as it is placed, or picked up for a scheduled order. It is
+// left unset when the duration is unknown
+func (o *Order) EstimateDelivery() {
+	if o.EstimatedDuration == 0 || o.CreatedAt.IsZero() {
+		return
+	}
+
+	at := o.PickupAt(o.CreatedAt).Add(time.Duration(o.EstimatedDuration) * time.Second)
+	o.EstimatedDeliveryAt = &at
+}
+
+// Schedule holds o back until it is released for a pickup at t, a nil t
+// leaves it up for grabs right away
+func (o *Order) Schedule(t *time.Time) {
+	if t == nil {
+		return
+	}
+
+	o.ScheduledFor = t
+	o.Status = StatusScheduled
+}
+
+// PickupAt returns when o is picked up: when it is scheduled for, or now
+func (o *Order) PickupAt(now time.Time) time.Time {
+	if o.ScheduledFor != nil {
+		return *o.ScheduledFor
+	}
+	return now
+}
+
+// OrderEvent represents an order being created or changing status, along with
+// a snapshot of the order right after it. Events are numbered in the order
+// they happened by ID, and in the order they were committed by Position
+type OrderEvent struct {
+	ID         int64     `db:"id"`
+	TenantID   string    `db:"tenant_id"`
+	Type       string    `db:"type"`
+	OrderID    int64     `db:"order_id"`
+	Distance   int       `db:"distance"`
+	TravelMode string    `db:"travel_mode"`
+	Status     string    `db:"status"`
+	MerchantID string    `db:"merchant_id"`
+	CourierID  string    `db:"courier_id"`
+	OriginLat  float64   `db:"origin_lat"`
+	OriginLng  float64   `db:"origin_lng"`
+	CreatedAt  time.Time `db:"created_at"`
+	Position   int64     `db:"position"`
+}
+
+// Order returns the snapshot of the order carried by the event
+func (e *OrderEvent) Order() Order {
+	return Order{
+		ID:         e.OrderID,
+		TenantID:   e.TenantID,
+		Distance:   e.Distance,
+		TravelMode: e.TravelMode,
+		Status:     e.Status,
+		MerchantID: e.MerchantID,
+		CourierID:  e.CourierID,
+		OriginLat:  e.OriginLat,
+		OriginLng:  e.OriginLng,
+	}
 }
 
-// OrderUsecase represents Order Usecase
+// Area is a bounding box the origin of an order falls in
+type Area struct {
+	South float64
+	West  float64
+	North float64
+	East  float64
+}
+
+// RouteOptions tells how the courier travels from the origin to the
+// destination, an empty Mode is driving. It is comparable so that lookups
+// sharing their options can be sent together
+type RouteOptions struct {
+	Mode          string
+	AvoidTolls    bool
+	AvoidHighways bool
+	AvoidFerries  bool
+}
+
+// TravelMode returns the mode of the options, driving when unset
+func (o RouteOptions) TravelMode() string {
+	if o.Mode == "" {
+		return TravelModeDriving
+	}
+	return o.Mode
+}
+
+// Placement is an order to place, coordinates are a latitude and a longitude.
+// Each end is given either as coordinates or as an address to geocode, unless
+// the order is placed from the quote QuoteID. A multi-stop order gives its
+// Stops instead of its ends. A placement with ScheduledFor is picked up then
+// rather than right away
+type Placement struct {
+	QuoteID            string
+	Origin             []string
+	OriginAddress      string
+	Destination        []string
+	DestinationAddress string
+	Stops              []PlacementStop
+	Options            RouteOptions
+	ScheduledFor       *time.Time
+}
+
+// PlacementStop is a stop of a multi-stop order to place, given either as
+// coordinates or as an address to geocode like the ends of a Placement
+type PlacementStop struct {
+	Type        string
+	Coordinates []string
+	Address     string
+}
+
+// Quote is what an order would cost if placed now, fields are as on Order.
+// Its merchant can place the order from it once, until ExpiresAt, without
+// looking up the route again. OrderID is the order placed from it, 0 until then
+type Quote struct {
+	ID                 string    `json:"id" db:"id"`
+	TenantID           string    `json:"-" db:"tenant_id"`
+	MerchantID         string    `json:"-" db:"merchant_id"`
+	Distance           int       `json:"distance" db:"distance"`
+	TravelMode         string    `json:"travel_mode" db:"travel_mode"`
+	EstimatedDuration  int       `json:"estimated_duration,omitempty" db:"estimated_duration"`
+	OriginAddress      string    `json:"origin_address,omitempty" db:"origin_address"`
+	OriginLat          float64   `json:"-" db:"origin_lat"`
+	OriginLng          float64   `json:"-" db:"origin_lng"`
+	DestinationAddress string    `json:"destination_address,omitempty" db:"destination_address"`
+	DestinationLat     float64   `json:"-" db:"destination_lat"`
+	DestinationLng     float64   `json:"-" db:"destination_lng"`
+	Price              int64     `json:"price" db:"price"`
+	Currency           string    `json:"currency" db:"currency"`
+	TariffVersion      string    `json:"tariff_version" db:"tariff_version"`
+	OrderID            int64     `json:"-" db:"order_id"`
+	ExpiresAt          time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt          time.Time `json:"-" db:"created_at"`
+}
+
+// Order returns the order placed from the quote
+func (q *Quote) Order() *Order {
+	return &Order{
+		TenantID:           q.TenantID,
+		Distance:           q.Distance,
+		TravelMode:         q.TravelMode,
+		EstimatedDuration:  q.EstimatedDuration,
+		Price:              q.Price,
+		Currency:           q.Currency,
+		TariffVersion:      q.TariffVersion,
+		QuoteID:            q.ID,
+		Status:             StatusUnassigned,
+		MerchantID:         q.MerchantID,
+		OriginAddress:      q.OriginAddress,
+		OriginLat:          q.OriginLat,
+		OriginLng:          q.OriginLng,
+		DestinationAddress: q.DestinationAddress,
+		DestinationLat:     q.DestinationLat,
+		DestinationLng:     q.DestinationLng,
+	}
+}
+
+// PlacementResult is the outcome of one placement of a batch, either Order or Err is set
+type PlacementResult struct {
+	Order *Order
+	Err   error
+}
+
+// Rules orders can break, as named in violations
+const (
+	RuleMinDistance    string = "min_distance"
+	RuleMaxDistance    string = "max_distance"
+	RuleDistinctEnds   string = "distinct_ends"
+	RuleBlockedZone    string = "blocked_zone"
+	RuleOperatingHours string = "operating_hours"
+)
+
+// Rule is a business rule orders are checked against before they are placed
+type Rule interface {
+	// Check returns how o placed at breaks the rule, nil when it does not
+	Check(o *Order, at time.Time) *Violation
+}
+
+// Violation is a business rule an order breaks
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when an order breaks business rules of its
+// tenant, it lists every rule broken
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Message
+	}
+
+	return "order breaks business rules: " + strings.Join(msgs, "; ")
+}
+
+// BatchError is returned when the order at Index of a batch fails to be
+// created, none of the batch is created then
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("order %d of the batch: %s", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// OrderFilter narrows down the orders returned by FindRange, empty fields match any order
+type OrderFilter struct {
+	MerchantID string
+	Status     string
+}
+
+// EventFilter narrows down the events returned by FindAfter, empty fields match any event
+type EventFilter struct {
+	Status     string
+	Area       *Area
+	MerchantID string
+	// Courier matches the events of unassigned orders and of orders taken by Courier
+	Courier string
+	// Withdrawals also matches for Courier the events of orders other couriers
+	// took, so that offers of them can be withdrawn
+	Withdrawals bool
+}
+
+// OrderUsecase represents Order Usecase, the caller is read from the context
 type OrderUsecase interface {
-	PlaceOrder([]string, []string) (*Order, error)
-	TakeOrder(int64) (string, error)
-	ListOrders(int, int) (*[]Order, error)
+	PlaceOrder(context.Context, Placement) (*Order, error)
+	PlaceOrders(context.Context, []Placement) ([]PlacementResult, error)
+	QuoteOrder(context.Context, Placement) (*Quote, error)
+	TakeOrder(context.Context, int64) (string, error)
+	CompleteStop(context.Context, int64, int) (*Stop, error)
+	ListOrders(context.Context, int, int) (*[]Order, error)
+	GetOrder(context.Context, int64) (*Order, error)
+	ListEvents(context.Context, EventFilter, int64, int) ([]OrderEvent, error)
+	LatestEventPosition(context.Context) (int64, error)
 }
 
-// OrderRepository represents Order Repository
+// OrderRepository represents Order Repository, every method is scoped to a
+// tenant: Create and CreateBatch to the TenantID of the orders, the others to
+// their first argument. Creating an order placed from a quote marks the quote
+// used, failing with ErrQuoteUsed if it was used or expired meanwhile.
+// CreateBatch fails with a *BatchError naming the order at fault. The stops of
+// an order are created and found along with it
 type OrderRepository interface {
 	Create(*Order) error
-	UpdateStatusByID(int64) error
-	FindByID(int64) (*Order, error)
-	FindRange(int, int) (*[]Order, error)
+	CreateBatch([]*Order) error
+	UpdateStatusByID(string, int64, string) error
+	CompleteStop(string, int64, int, string) (*Stop, error)
+	FindByID(string, int64) (*Order, error)
+	FindRange(string, OrderFilter, int, int) (*[]Order, error)
+}
+
+// ScheduleRepository represents the scheduled orders waiting to be released.
+// It spans every tenant since a single scheduler releases the orders of all of
+// them. FindDue lists the orders scheduled for up to a time, the earliest
+// first. Release makes a scheduled order unassigned along with its event and
+// fails with sql.ErrNoRows once it is not scheduled anymore
+type ScheduleRepository interface {
+	AcquireLease(string, string, time.Duration) (bool, error)
+	FindDue(time.Time, int) ([]Order, error)
+	Release(string, int64) (*Order, error)
+}
+
+// QuoteRepository represents the quotes orders are placed from. Create is
+// scoped to the TenantID of the quote, FindByID to its first argument. A quote
+// is marked used by the OrderRepository creating the order placed from it
+type QuoteRepository interface {
+	Create(*Quote) error
+	FindByID(string, string) (*Quote, error)
+}
+
+// OrderEventRepository represents the history of order events, written by the
+// OrderRepository along with the changes. Every method is scoped to the tenant
+// given as first argument. Events are numbered per tenant by their Position,
+// taken as their transaction commits, so a cursor on positions never skips an
+// event committed after a later one was read
+type OrderEventRepository interface {
+	FindAfter(string, EventFilter, int64, int) ([]OrderEvent, error)
+	LatestPosition(string) (int64, error)
 }
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/imylam/delivery-test/common/lease"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	"go.uber.org/zap"
+)
+
+// leaseName is the lease schedulers on every instance compete for, only its
+// holder releases orders
+const leaseName string = "order-scheduler"
+
+// Scheduler releases the scheduled orders to couriers the lead time of its
+// config before they are picked up. Each order is released by a conditional
+// update, so an order is released once even if schedulers on several
+// instances hold the lease in turn
+type Scheduler struct {
+	repo   order.ScheduleRepository
+	cfg    configs.SchedulerConfig
+	holder string
+	now    func() time.Time
+
+	leaseRenewAt time.Time
+}
+
+// NewScheduler creates a Scheduler with the settings of cfg
+func NewScheduler(repo order.ScheduleRepository, cfg configs.SchedulerConfig) *Scheduler {
+	return &Scheduler{
+		repo:   repo,
+		cfg:    cfg,
+		holder: lease.NewHolder(),
+		now:    time.Now,
+	}
+}
+
+// Run releases orders every poll interval until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Release(ctx); err != nil {
+			logger.Logger.Error("fail to release scheduled orders", zap.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Release releases a batch of the orders due within the lead time if this
+// scheduler holds the lease, the earliest first
+func (s *Scheduler) Release(ctx context.Context) error {
+	held, err := s.renewLease()
+	if err != nil || !held {
+		return err
+	}
+
+	orders, err := s.repo.FindDue(s.now().Add(s.cfg.Lead), s.cfg.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range orders {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		_, err := s.repo.Release(o.TenantID, o.ID)
+		if err == sql.ErrNoRows {
+			// released meanwhile by a previous holder of the lease
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renewLease takes or renews the lease once half of it has gone by
+func (s *Scheduler) renewLease() (bool, error) {
+	if s.now().Before(s.leaseRenewAt) {
+		return true, nil
+	}
+
+	held, err := s.repo.AcquireLease(leaseName, s.holder, s.cfg.Lease)
+	if err != nil || !held {
+		s.leaseRenewAt = time.Time{}
+		return false, err
+	}
+
+	s.leaseRenewAt = s.now().Add(s.cfg.Lease / 2)
+	return true, nil
+}
@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/order/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+var mockNow = time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func mockConfig() configs.SchedulerConfig {
+	return configs.SchedulerConfig{
+		PollInterval: 10 * time.Second,
+		BatchSize:    100,
+		Lease:        30 * time.Second,
+		Lead:         30 * time.Minute,
+	}
+}
+
+func newMockScheduler(repo *mocks.ScheduleRepository) *Scheduler {
+	s := NewScheduler(repo, mockConfig())
+	s.holder = "scheduler-1"
+	s.now = func() time.Time { return mockNow }
+	return s
+}
+
+func mockScheduledOrder(id int64) order.Order {
+	scheduledFor := mockNow.Add(20 * time.Minute)
+	return order.Order{ID: id, TenantID: "brand-a", Status: order.StatusScheduled, MerchantID: "merchant-1", ScheduledFor: &scheduledFor}
+}
+
+func mockReleasedOrder(id int64) *order.Order {
+	o := mockScheduledOrder(id)
+	o.Status = order.StatusUnassigned
+	return &o
+}
+
+func TestRelease(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.ScheduleRepository)
+		mockRepo.On("AcquireLease", leaseName, "scheduler-1", 30*time.Second).Return(true, nil).Once()
+		mockRepo.On("FindDue", mockNow.Add(30*time.Minute), 100).
+			Return([]order.Order{mockScheduledOrder(5), mockScheduledOrder(6)}, nil).Once()
+		mockRepo.On("Release", "brand-a", int64(5)).Return(mockReleasedOrder(5), nil).Once()
+		mockRepo.On("Release", "brand-a", int64(6)).Return(mockReleasedOrder(6), nil).Once()
+
+		err := newMockScheduler(mockRepo).Release(context.Background())
+
+		assert.Equal(t, nil, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("lease-held-elsewhere", func(t *testing.T) {
+		mockRepo := new(mocks.ScheduleRepository)
+		mockRepo.On("AcquireLease", leaseName, "scheduler-1", 30*time.Second).Return(false, nil).Once()
+
+		err := newMockScheduler(mockRepo).Release(context.Background())
+
+		assert.Equal(t, nil, err)
+		mockRepo.AssertNotCalled(t, "FindDue", mock.Anything, mock.Anything)
+	})
+
+	t.Run("released-meanwhile", func(t *testing.T) {
+		mockRepo := new(mocks.ScheduleRepository)
+		mockRepo.On("AcquireLease", leaseName, "scheduler-1", 30*time.Second).Return(true, nil).Once()
+		mockRepo.On("FindDue", mockNow.Add(30*time.Minute), 100).
+			Return([]order.Order{mockScheduledOrder(5), mockScheduledOrder(6)}, nil).Once()
+		mockRepo.On("Release", "brand-a", int64(5)).Return(nil, sql.ErrNoRows).Once()
+		mockRepo.On("Release", "brand-a", int64(6)).Return(mockReleasedOrder(6), nil).Once()
+
+		err := newMockScheduler(mockRepo).Release(context.Background())
+
+		assert.Equal(t, nil, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("db-error", func(t *testing.T) {
+		mockRepo := new(mocks.ScheduleRepository)
+		mockRepo.On("AcquireLease", leaseName, "scheduler-1", 30*time.Second).Return(true, nil).Once()
+		mockRepo.On("FindDue", mockNow.Add(30*time.Minute), 100).Return([]order.Order{mockScheduledOrder(5), mockScheduledOrder(6)}, nil).Once()
+		mockRepo.On("Release", "brand-a", int64(5)).Return(nil, errors.New("db down")).Once()
+
+		err := newMockScheduler(mockRepo).Release(context.Background())
+
+		assert.Equal(t, errors.New("db down"), err)
+		mockRepo.AssertNotCalled(t, "Release", "brand-a", int64(6))
+	})
+}
+
+func TestRenewLease(t *testing.T) {
+	mockRepo := new(mocks.ScheduleRepository)
+	mockRepo.On("AcquireLease", leaseName, "scheduler-1", 30*time.Second).Return(true, nil).Once()
+	mockRepo.On("FindDue", mock.Anything, 100).Return([]order.Order{}, nil).Twice()
+	s := newMockScheduler(mockRepo)
+
+	// the lease is only renewed once half of it has gone by
+	assert.Equal(t, nil, s.Release(context.Background()))
+	assert.Equal(t, nil, s.Release(context.Background()))
+
+	mockRepo.AssertExpectations(t)
+}
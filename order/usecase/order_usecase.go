@@ -1,13 +1,21 @@
 package usecase
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/common/auth"
 	"github.com/imylam/delivery-test/order"
 	"github.com/imylam/delivery-test/order/infrastructure/googlemap"
+	"github.com/imylam/delivery-test/pricing"
+	"github.com/imylam/delivery-test/rules"
+	"github.com/imylam/delivery-test/servicearea"
 )
 
 const (
@@ -15,50 +23,285 @@ const (
 	statusUpdateOrderStatusSuccess string = "SUCCESS"
 )
 
+// geocodeConcurrency is how many addresses of a batch are geocoded at once
+const geocodeConcurrency int = 8
+
 type orderUsecase struct {
 	orderRepo order.OrderRepository
+	eventRepo order.OrderEventRepository
+	quoteRepo order.QuoteRepository
+	areaRepo  servicearea.ServiceAreaRepository
 	mapClient googlemap.MapClient
+	geocoder  googlemap.Geocoder
+	pricer    pricing.Pricer
+	validator rules.Validator
+	// maxBatchSize is the most orders PlaceOrders places at once
+	maxBatchSize int
 }
 
 // NewOrderUsecase will create new a orderUsecase object representation of order.OrderUsecase interface
-func NewOrderUsecase(userRepo order.OrderRepository,
-	mapClient googlemap.MapClient) order.OrderUsecase {
+func NewOrderUsecase(userRepo order.OrderRepository, eventRepo order.OrderEventRepository, quoteRepo order.QuoteRepository,
+	areaRepo servicearea.ServiceAreaRepository, mapClient googlemap.MapClient, geocoder googlemap.Geocoder, pricer pricing.Pricer,
+	validator rules.Validator, maxBatchSize int) order.OrderUsecase {
 
 	return &orderUsecase{
-		orderRepo: userRepo,
-		mapClient: mapClient,
+		orderRepo:    userRepo,
+		eventRepo:    eventRepo,
+		quoteRepo:    quoteRepo,
+		areaRepo:     areaRepo,
+		mapClient:    mapClient,
+		geocoder:     geocoder,
+		pricer:       pricer,
+		validator:    validator,
+		maxBatchSize: maxBatchSize,
 	}
 }
 
-func (uc *orderUsecase) PlaceOrder(origins, destinations []string) (newOrder *order.Order, err error) {
-	origin := strings.Join(origins, ",")
-	dest := strings.Join(destinations, ",")
-
-	dist, err := getDistance(origin, dest, uc.mapClient)
+// PlaceOrder places an order, priced with the tariff of the tenant if it has
+// one. An order placed from a quote keeps the route and the price of the
+// quote, the service areas were checked when it was quoted. A scheduled order
+// is priced and checked against the business rules for when it is picked up.
+// It fails with an *order.ValidationError when the order breaks business rules of the tenant
+func (uc *orderUsecase) PlaceOrder(ctx context.Context, p order.Placement) (newOrder *order.Order, err error) {
+	merchant, err := authorize(ctx, auth.PermissionOrderPlace)
 	if err != nil {
 		return
 	}
 
-	newOrder = &order.Order{Distance: dist, Status: order.StatusUnassigned}
+	if p.QuoteID != "" {
+		newOrder, err = uc.quotedOrder(merchant, p.QuoteID, p.ScheduledFor)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		newOrder, err = uc.routedOrder(merchant, p)
+		if err != nil {
+			return nil, err
+		}
+		err = uc.price(newOrder, newOrder.PickupAt(time.Now()))
+		if err != nil && !errors.Is(err, order.ErrNoTariff) {
+			return nil, err
+		}
+	}
+
 	err = uc.orderRepo.Create(newOrder)
 	if err != nil {
 		return
 	}
+	newOrder.EstimateDelivery()
 
 	return
 }
 
-func (uc *orderUsecase) TakeOrder(id int64) (status string, err error) {
-	orderFound, err := uc.orderRepo.FindByID(id)
+// PlaceOrders places a batch of orders, priced, checked against the service
+// areas and the business rules and from quotes as by PlaceOrder. The
+// distances are looked up with as few Distance Matrix requests as the API
+// limits and the different route options of the placements allow and the
+// orders are created in a single transaction. A placement that cannot be
+// placed gets its error in its result without failing the others, the error
+// returned fails them all. A batch of more placements than the maximum fails
+// with order.ErrBatchTooLarge
+func (uc *orderUsecase) PlaceOrders(ctx context.Context, placements []order.Placement) ([]order.PlacementResult, error) {
+	merchant, err := authorize(ctx, auth.PermissionOrderPlace)
+	if err != nil {
+		return nil, err
+	}
+	if len(placements) > uc.maxBatchSize {
+		return nil, order.ErrBatchTooLarge
+	}
+
+	areas, err := uc.areaRepo.FindAll(merchant.Tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]order.PlacementResult, len(placements))
+	orders := make([]*order.Order, len(placements))
+	// geocoded on a copy, the placements of the caller are left as they were
+	placements = append([]order.Placement(nil), placements...)
+	uc.locateAll(placements, results)
+
+	// batches[k] holds the placements items[k], in the order of its pairs, all
+	// with the options of the placement items[k][0]. open is the batch a
+	// placement with the same options joins if it fits
+	var batches []*googlemap.Batch
+	var items [][]int
+	open := map[order.RouteOptions]int{}
+	quoted := map[string]bool{}
+	for i, p := range placements {
+		if results[i].Err != nil {
+			continue
+		}
+		if p.QuoteID != "" {
+			if quoted[p.QuoteID] {
+				results[i].Err = order.ErrQuoteUsed
+				continue
+			}
+			quoted[p.QuoteID] = true
+			orders[i], results[i].Err = uc.quotedOrder(merchant, p.QuoteID, p.ScheduledFor)
+			continue
+		}
+
+		orders[i], err = newPlacedOrder(merchant, p)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := inServiceArea(areas, orders[i]); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if len(orders[i].Stops) > 0 {
+			// the legs do not fit the pairs of the batches, they are looked up on their own
+			results[i].Err = uc.routeStops(orders[i], p.Options)
+			continue
+		}
+
+		origin, dest := strings.Join(p.Origin, ","), strings.Join(p.Destination, ",")
+		k, ok := open[p.Options]
+		if !ok || !batches[k].Fits(origin, dest) {
+			k = len(batches)
+			open[p.Options] = k
+			batches = append(batches, googlemap.NewBatch())
+			items = append(items, nil)
+		}
+		batches[k].Add(origin, dest)
+		items[k] = append(items[k], i)
+	}
+
+	for k, b := range batches {
+		routes, err := uc.mapClient.GetDistances(b.Origins, b.Destinations, placements[items[k][0]].Options)
+		for pair, i := range items[k] {
+			switch {
+			case err != nil:
+				results[i].Err = err
+			case b.Route(routes, pair).Err != nil:
+				results[i].Err = b.Route(routes, pair).Err
+			default:
+				orders[i].Distance = b.Route(routes, pair).Distance
+				orders[i].EstimatedDuration = durationSeconds(b.Route(routes, pair).Duration)
+			}
+		}
+	}
+
+	// placed[j] is the placement of newOrders[j]
+	var newOrders []*order.Order
+	var placed []int
+	now := time.Now()
+	for i, o := range orders {
+		if o == nil || results[i].Err != nil {
+			continue
+		}
+		if o.QuoteID == "" {
+			if err := uc.validator.Validate(o, o.PickupAt(now)); err != nil {
+				results[i].Err = err
+				continue
+			}
+			if err := uc.price(o, o.PickupAt(now)); err != nil && !errors.Is(err, order.ErrNoTariff) {
+				results[i].Err = err
+				continue
+			}
+		}
+		newOrders = append(newOrders, o)
+		placed = append(placed, i)
+	}
+	if len(newOrders) == 0 {
+		return results, nil
+	}
+
+	// a quote used meanwhile only fails its own placement, the batch is
+	// created again without it
+	for {
+		err = uc.orderRepo.CreateBatch(newOrders)
+		var batchErr *order.BatchError
+		if !errors.As(err, &batchErr) || !errors.Is(batchErr.Err, order.ErrQuoteUsed) {
+			break
+		}
+
+		results[placed[batchErr.Index]].Err = order.ErrQuoteUsed
+		newOrders = append(newOrders[:batchErr.Index], newOrders[batchErr.Index+1:]...)
+		placed = append(placed[:batchErr.Index], placed[batchErr.Index+1:]...)
+		if len(newOrders) == 0 {
+			return results, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, o := range orders {
+		if o != nil && results[i].Err == nil {
+			o.EstimateDelivery()
+			results[i].Order = o
+		}
+	}
+
+	return results, nil
+}
+
+// QuoteOrder returns what the order of p would cost if placed now, without
+// placing it. The quote is kept for the merchant to place the order from it.
+// It fails with order.ErrNoTariff when the tenant has no tariff
+func (uc *orderUsecase) QuoteOrder(ctx context.Context, p order.Placement) (*order.Quote, error) {
+	merchant, err := authorize(ctx, auth.PermissionOrderPlace)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := uc.routedOrder(merchant, p)
+	if err != nil {
+		return nil, err
+	}
+	err = uc.price(o, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &order.Quote{
+		TenantID:           o.TenantID,
+		MerchantID:         o.MerchantID,
+		Distance:           o.Distance,
+		TravelMode:         o.TravelMode,
+		EstimatedDuration:  o.EstimatedDuration,
+		OriginAddress:      o.OriginAddress,
+		OriginLat:          o.OriginLat,
+		OriginLng:          o.OriginLng,
+		DestinationAddress: o.DestinationAddress,
+		DestinationLat:     o.DestinationLat,
+		DestinationLng:     o.DestinationLng,
+		Price:              o.Price,
+		Currency:           o.Currency,
+		TariffVersion:      o.TariffVersion,
+	}
+	err = uc.quoteRepo.Create(quote)
+	if err != nil {
+		return nil, err
+	}
+
+	return quote, nil
+}
+
+func (uc *orderUsecase) TakeOrder(ctx context.Context, id int64) (status string, err error) {
+	courier, err := authorize(ctx, auth.PermissionOrderTake)
+	if err != nil {
+		return
+	}
+
+	orderFound, err := uc.orderRepo.FindByID(courier.Tenant, id)
 	if err != nil {
 		return
 	}
+	if orderFound.Status == order.StatusScheduled {
+		// hidden from couriers until it is released, as if it did not exist
+		err = sql.ErrNoRows
+		return
+	}
 	if orderFound.Status == order.StatusTaken {
 		err = errors.New(ErrorOrderTaken)
 		return
 	}
 
-	err = uc.orderRepo.UpdateStatusByID(id)
+	err = uc.orderRepo.UpdateStatusByID(courier.Tenant, id, courier.Subject)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			err = errors.New(ErrorOrderTaken)
@@ -71,23 +314,460 @@ func (uc *orderUsecase) TakeOrder(id int64) (status string, err error) {
 	return
 }
 
-func (uc *orderUsecase) ListOrders(page, limit int) (orders *[]order.Order, err error) {
+// CompleteStop marks the stop at index of a multi-stop order completed, only
+// the courier who took the order may complete its stops
+func (uc *orderUsecase) CompleteStop(ctx context.Context, id int64, index int) (*order.Stop, error) {
+	courier, err := authorize(ctx, auth.PermissionOrderTake)
+	if err != nil {
+		return nil, err
+	}
+
+	orderFound, err := uc.orderRepo.FindByID(courier.Tenant, id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case orderFound.Status != order.StatusTaken || orderFound.CourierID != courier.Subject:
+		return nil, order.ErrNotTakenByCourier
+	case index < 0 || index >= len(orderFound.Stops):
+		return nil, order.ErrStopNotFound
+	case orderFound.Stops[index].CompletedAt != nil:
+		return nil, order.ErrStopCompleted
+	}
+
+	stop, err := uc.orderRepo.CompleteStop(courier.Tenant, id, index, courier.Subject)
+	if err == sql.ErrNoRows {
+		// completed meanwhile
+		return nil, order.ErrStopCompleted
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return stop, nil
+}
+
+// ListOrders lists every order to admins, their own orders to merchants and
+// the orders still up for grabs to couriers
+func (uc *orderUsecase) ListOrders(ctx context.Context, page, limit int) (orders *[]order.Order, err error) {
+	caller, err := authorize(ctx, auth.PermissionOrderList)
+	if err != nil {
+		return
+	}
+
+	var filter order.OrderFilter
+	switch {
+	case caller.HasRole(auth.RoleAdmin):
+	case caller.HasRole(auth.RoleMerchant):
+		filter.MerchantID = caller.Subject
+	default:
+		filter.Status = order.StatusUnassigned
+	}
+
 	offset := (page - 1) * limit
-	orders, err = uc.orderRepo.FindRange(limit, offset)
+	orders, err = uc.orderRepo.FindRange(caller.Tenant, filter, limit, offset)
+	if err != nil {
+		return
+	}
+
+	for i := range *orders {
+		(*orders)[i].EstimateDelivery()
+	}
 
 	return
 }
 
-func getDistance(origin, dest string, mapClient googlemap.MapClient) (int, error) {
+// GetOrder returns an order to admins, to the merchant who placed it and to
+// couriers while it is unassigned or after they took it
+func (uc *orderUsecase) GetOrder(ctx context.Context, id int64) (orderFound *order.Order, err error) {
+	caller, err := authorize(ctx, auth.PermissionOrderView)
+	if err != nil {
+		return
+	}
 
-	if configs.Get(configs.KeyAppEnv) == "integration-test" {
-		return 10, nil
+	orderFound, err = uc.orderRepo.FindByID(caller.Tenant, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canView(caller, orderFound) {
+		return nil, auth.ErrForbidden
+	}
+	orderFound.EstimateDelivery()
+
+	return
+}
+
+// ListEvents lists the events positioned after afterPosition the caller may
+// see, with the same rules as GetOrder: every event to admins, the events of
+// their own orders to merchants and to couriers the events of unassigned
+// orders or orders they took. Couriers asking for withdrawals also get the
+// events of orders other couriers took, without who took them
+func (uc *orderUsecase) ListEvents(ctx context.Context, filter order.EventFilter, afterPosition int64, limit int) ([]order.OrderEvent, error) {
+	caller, err := authorize(ctx, auth.PermissionOrderList)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.MerchantID, filter.Courier = "", ""
+	switch {
+	case caller.HasRole(auth.RoleAdmin):
+	case caller.HasRole(auth.RoleMerchant):
+		filter.MerchantID = caller.Subject
+	default:
+		filter.Courier = caller.Subject
+	}
+
+	events, err := uc.eventRepo.FindAfter(caller.Tenant, filter, afterPosition, limit)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Courier != "" {
+		for i := range events {
+			if events[i].CourierID != filter.Courier {
+				events[i].CourierID = ""
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// LatestEventPosition returns the position of the latest event of the caller tenant, 0 if there is none
+func (uc *orderUsecase) LatestEventPosition(ctx context.Context) (int64, error) {
+	caller, err := authorize(ctx, auth.PermissionOrderList)
+	if err != nil {
+		return 0, err
+	}
+
+	return uc.eventRepo.LatestPosition(caller.Tenant)
+}
+
+// locate geocodes the ends and the stops of p given as addresses, filling
+// their coordinates and replacing the addresses with the ones formatted by the geocoder
+func (uc *orderUsecase) locate(p *order.Placement) error {
+	if len(p.Stops) > 0 {
+		// located on a copy, the stops of the caller are left as they were
+		p.Stops = append([]order.PlacementStop(nil), p.Stops...)
+	}
+	for i := range p.Stops {
+		stop := &p.Stops[i]
+		if stop.Address == "" {
+			continue
+		}
+		place, err := uc.geocoder.Geocode(stop.Address)
+		if err != nil {
+			return fmt.Errorf("stop %d: %w", i, err)
+		}
+		stop.Coordinates, stop.Address = place.Coordinates(), place.FormattedAddress
+	}
+
+	if p.OriginAddress != "" {
+		place, err := uc.geocoder.Geocode(p.OriginAddress)
+		if err != nil {
+			return fmt.Errorf("origin: %w", err)
+		}
+		p.Origin, p.OriginAddress = place.Coordinates(), place.FormattedAddress
+	}
+	if p.DestinationAddress != "" {
+		place, err := uc.geocoder.Geocode(p.DestinationAddress)
+		if err != nil {
+			return fmt.Errorf("destination: %w", err)
+		}
+		p.Destination, p.DestinationAddress = place.Coordinates(), place.FormattedAddress
+	}
+
+	return nil
+}
+
+// routedOrder returns the order merchant places with p now, located, checked
+// against the service areas of the tenant, with its route looked up and
+// checked against the business rules of the tenant
+func (uc *orderUsecase) routedOrder(merchant *auth.Identity, p order.Placement) (*order.Order, error) {
+	err := uc.locate(&p)
+	if err != nil {
+		return nil, err
+	}
+	o, err := newPlacedOrder(merchant, p)
+	if err != nil {
+		return nil, err
+	}
+	areas, err := uc.areaRepo.FindAll(merchant.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	err = inServiceArea(areas, o)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(o.Stops) > 0 {
+		err = uc.routeStops(o, p.Options)
+		if err != nil {
+			return nil, err
+		}
 	} else {
-		dist, err := mapClient.GetDistance(origin, dest)
+		origin := strings.Join(p.Origin, ",")
+		dest := strings.Join(p.Destination, ",")
+
+		route, err := uc.mapClient.GetDistance(origin, dest, p.Options)
+		if err != nil {
+			return nil, err
+		}
+		o.Distance = route.Distance
+		o.EstimatedDuration = durationSeconds(route.Duration)
+	}
+
+	err = uc.validator.Validate(o, o.PickupAt(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// routeStops looks up the route of every leg of the multi-stop order o at once,
+// its distance and duration are the sums of those of its legs
+func (uc *orderUsecase) routeStops(o *order.Order, opts order.RouteOptions) error {
+	routes := make([]googlemap.Route, len(o.Stops))
+	errs := make([]error, len(o.Stops))
+	var wg sync.WaitGroup
+	for i := 1; i < len(o.Stops); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			from, to := o.Stops[i-1], o.Stops[i]
+			routes[i], errs[i] = uc.mapClient.GetDistance(formatCoordinates(from.Lat, from.Lng), formatCoordinates(to.Lat, to.Lng), opts)
+		}(i)
+	}
+	wg.Wait()
+
+	var duration time.Duration
+	o.Distance = 0
+	for i := 1; i < len(o.Stops); i++ {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		o.Stops[i].Distance = routes[i].Distance
+		o.Distance += routes[i].Distance
+		duration += routes[i].Duration
+	}
+	o.EstimatedDuration = durationSeconds(duration)
+
+	return nil
+}
+
+// quotedOrder returns the order merchant places now from its quote id,
+// scheduled for scheduledFor if not nil, checked again against the business
+// rules as the operating hours may have ended since it was quoted. The quote
+// is only marked used once the order is created
+func (uc *orderUsecase) quotedOrder(merchant *auth.Identity, id string, scheduledFor *time.Time) (*order.Order, error) {
+	quote, err := uc.quoteRepo.FindByID(merchant.Tenant, id)
+	if err == sql.ErrNoRows {
+		return nil, order.ErrQuoteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case quote.MerchantID != merchant.Subject:
+		return nil, order.ErrQuoteNotFound
+	case quote.OrderID != 0:
+		return nil, order.ErrQuoteUsed
+	case !time.Now().Before(quote.ExpiresAt):
+		return nil, order.ErrQuoteExpired
+	}
+
+	o := quote.Order()
+	o.Schedule(scheduledFor)
+	err = uc.validator.Validate(o, o.PickupAt(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// price prices o as placed at, with the tariff of its tenant
+func (uc *orderUsecase) price(o *order.Order, at time.Time) error {
+	p, err := uc.pricer.Price(o.TenantID, pricing.Trip{
+		Distance: o.Distance,
+		Duration: time.Duration(o.EstimatedDuration) * time.Second,
+		At:       at,
+	})
+	if err != nil {
+		return err
+	}
+	o.Price, o.Currency, o.TariffVersion = p.Amount, p.Currency, p.TariffVersion
+
+	return nil
+}
+
+// locateAll locates the placements concurrently, the error of the placement i goes to results[i]
+func (uc *orderUsecase) locateAll(placements []order.Placement, results []order.PlacementResult) {
+	sem := make(chan struct{}, geocodeConcurrency)
+	var wg sync.WaitGroup
+	for i := range placements {
+		if !hasAddress(placements[i]) {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			results[i].Err = uc.locate(&placements[i])
+			<-sem
+		}(i)
+	}
+	wg.Wait()
+}
+
+// newPlacedOrder returns the order merchant places with p, once located and
+// scheduled if p is, its route is yet to be looked up
+func newPlacedOrder(merchant *auth.Identity, p order.Placement) (*order.Order, error) {
+	if len(p.Stops) > 0 {
+		return newMultiStopOrder(merchant, p)
+	}
+
+	originLat, originLng, err := parseCoordinates(p.Origin)
+	if err != nil {
+		return nil, fmt.Errorf("origin: %w", err)
+	}
+	destLat, destLng, err := parseCoordinates(p.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("destination: %w", err)
+	}
+
+	o := &order.Order{
+		TenantID:           merchant.Tenant,
+		TravelMode:         p.Options.TravelMode(),
+		Status:             order.StatusUnassigned,
+		MerchantID:         merchant.Subject,
+		OriginAddress:      p.OriginAddress,
+		OriginLat:          originLat,
+		OriginLng:          originLng,
+		DestinationAddress: p.DestinationAddress,
+		DestinationLat:     destLat,
+		DestinationLng:     destLng,
+	}
+	o.Schedule(p.ScheduledFor)
+
+	return o, nil
+}
+
+// newMultiStopOrder is newPlacedOrder for the placements of multi-stop
+// orders, their first and last stops are the ends of the order
+func newMultiStopOrder(merchant *auth.Identity, p order.Placement) (*order.Order, error) {
+	stops := make([]order.Stop, len(p.Stops))
+	for i, s := range p.Stops {
+		lat, lng, err := parseCoordinates(s.Coordinates)
 		if err != nil {
-			return 0, err
+			return nil, fmt.Errorf("stop %d: %w", i, err)
+		}
+		stops[i] = order.Stop{Index: i, Type: s.Type, Address: s.Address, Lat: lat, Lng: lng}
+	}
+	origin, dest := stops[0], stops[len(stops)-1]
+
+	o := &order.Order{
+		TenantID:           merchant.Tenant,
+		TravelMode:         p.Options.TravelMode(),
+		Status:             order.StatusUnassigned,
+		MerchantID:         merchant.Subject,
+		OriginAddress:      origin.Address,
+		OriginLat:          origin.Lat,
+		OriginLng:          origin.Lng,
+		DestinationAddress: dest.Address,
+		DestinationLat:     dest.Lat,
+		DestinationLng:     dest.Lng,
+		Stops:              stops,
+	}
+	o.Schedule(p.ScheduledFor)
+
+	return o, nil
+}
+
+// hasAddress tells whether an end or a stop of p is given as an address
+func hasAddress(p order.Placement) bool {
+	if p.OriginAddress != "" || p.DestinationAddress != "" {
+		return true
+	}
+	for _, s := range p.Stops {
+		if s.Address != "" {
+			return true
 		}
+	}
+
+	return false
+}
+
+// inServiceArea fails with order.ErrOutsideServiceArea when an end or a stop
+// of o is outside areas, the service areas of its tenant
+func inServiceArea(areas []servicearea.ServiceArea, o *order.Order) error {
+	for _, s := range o.Stops {
+		if !servicearea.Covers(areas, s.Lat, s.Lng) {
+			return fmt.Errorf("stop %d: %w", s.Index, order.ErrOutsideServiceArea)
+		}
+	}
+	if !servicearea.Covers(areas, o.OriginLat, o.OriginLng) {
+		return fmt.Errorf("origin: %w", order.ErrOutsideServiceArea)
+	}
+	if !servicearea.Covers(areas, o.DestinationLat, o.DestinationLng) {
+		return fmt.Errorf("destination: %w", order.ErrOutsideServiceArea)
+	}
+
+	return nil
+}
+
+// formatCoordinates is the inverse of parseCoordinates, as sent to the map client
+func formatCoordinates(lat, lng float64) string {
+	return strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lng, 'f', -1, 64)
+}
+
+// durationSeconds rounds d to the second, the unit of estimated durations
+func durationSeconds(d time.Duration) int {
+	return int(d.Round(time.Second) / time.Second)
+}
+
+// parseCoordinates returns the latitude and longitude of coordinates
+func parseCoordinates(coords []string) (lat, lng float64, err error) {
+	if len(coords) != 2 {
+		err = errors.New("coordinates must be a latitude and a longitude")
+		return
+	}
+	lat, err = strconv.ParseFloat(coords[0], 64)
+	if err != nil {
+		return
+	}
+	lng, err = strconv.ParseFloat(coords[1], 64)
+
+	return
+}
+
+// authorize is auth.Authorize for callers acting on a tenant, the only
+// callers allowed to touch orders
+func authorize(ctx context.Context, perm auth.Permission) (*auth.Identity, error) {
+	caller, err := auth.Authorize(ctx, perm)
+	if err != nil {
+		return nil, err
+	}
+	if caller.Tenant == "" {
+		return nil, auth.ErrNoTenant
+	}
+
+	return caller, nil
+}
 
-		return dist, nil
+func canView(caller *auth.Identity, o *order.Order) bool {
+	switch {
+	case caller.HasRole(auth.RoleAdmin):
+		return true
+	case caller.HasRole(auth.RoleMerchant) && o.MerchantID == caller.Subject:
+		return true
+	case caller.HasRole(auth.RoleCourier) && (o.Status == order.StatusUnassigned || o.CourierID == caller.Subject):
+		return true
+	default:
+		return false
 	}
 }
@@ -1,21 +1,70 @@
 package usecase
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/go-playground/assert/v2"
 	"github.com/go-sql-driver/mysql"
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/configs"
 	"github.com/imylam/delivery-test/order"
 	"github.com/imylam/delivery-test/order/infrastructure/googlemap"
+	"github.com/imylam/delivery-test/pricing"
+	"github.com/imylam/delivery-test/rules"
+	"github.com/imylam/delivery-test/servicearea"
+	_serviceAreaMocks "github.com/imylam/delivery-test/servicearea/mocks"
 
 	"github.com/imylam/delivery-test/order/mocks"
 	"github.com/stretchr/testify/mock"
 )
 
+const mockTenantID string = "brand-a"
+
+// mockMaxBatchSize is the most orders a batch of the tests places
+const mockMaxBatchSize int = 100
+
+// mockGeocoder knows an address in Tsim Sha Tsui and an ambiguous Nathan Road
+var mockGeocoder, _ = googlemap.NewFixtureGeocoder([]byte(`{
+	"1 Austin Road West": [{"formatted_address": "1 Austin Rd W, Tsim Sha Tsui, Hong Kong", "lat": 22.3038, "lng": 114.1602}],
+	"Nathan Road": [
+		{"formatted_address": "Nathan Rd, Tsim Sha Tsui, Hong Kong", "lat": 22.2988, "lng": 114.1722},
+		{"formatted_address": "Nathan Rd, Mong Kok, Hong Kong", "lat": 22.3193, "lng": 114.1694}
+	]
+}`))
+
+// mockPricer prices the orders of mockTenantID at 10.00 plus 5.00 per km, those of other tenants are not priced
+var mockPricer, _ = pricing.New(configs.PricingConfig{Tariffs: map[string]configs.TariffConfig{
+	mockTenantID: {Version: "v1", Currency: "HKD", BaseFare: 1000, PerKM: 500},
+}})
+
+// mockValidator rejects the orders of mockTenantID travelling over 10 km, those of other tenants are not checked
+var mockValidator, _ = rules.New(configs.RulesConfig{Tenants: map[string]configs.RuleSetConfig{
+	mockTenantID: {MaxDistance: 10000},
+}})
+
+// mockKowloon is a service area around the orders placed by the tests
+var mockKowloon = servicearea.ServiceArea{ID: 1, TenantID: mockTenantID, Name: "Kowloon", Geometry: servicearea.Geometry{
+	Type: servicearea.TypePolygon,
+	Polygons: []servicearea.Polygon{
+		{{{114.14, 22.27}, {114.2, 22.27}, {114.2, 22.34}, {114.14, 22.34}, {114.14, 22.27}}},
+	},
+}}
+
+// mockServiceAreas returns a repository with areas as the service areas of
+// every tenant, none lets orders be placed anywhere
+func mockServiceAreas(areas ...servicearea.ServiceArea) *_serviceAreaMocks.ServiceAreaRepository {
+	repo := new(_serviceAreaMocks.ServiceAreaRepository)
+	repo.On("FindAll", mock.AnythingOfType("string")).Return(areas, nil)
+
+	return repo
+}
+
 type AnyTime struct{}
 
 // Match satisfies sqlmock.Argument interface
@@ -27,31 +76,141 @@ func (a AnyTime) Match(v driver.Value) bool {
 func TestPlaceOrder(t *testing.T) {
 	mockOrderRepo := new(mocks.OrderRepository)
 	mockMapClient := new(googlemap.MockMapClient)
+	placement := order.Placement{Origin: []string{"22.300789", "114.167815"}, Destination: []string{"22.33540", "114.176155"}}
 
 	t.Run("success", func(t *testing.T) {
 		distance := 888
+		createdAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
 
-		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
-			Return(distance, nil).Once()
-		mockOrderRepo.On("Create", mock.AnythingOfType("*order.Order")).Return(nil).Once()
+		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("order.RouteOptions")).
+			Return(googlemap.Route{Distance: distance, Duration: 7*time.Minute + 400*time.Millisecond}, nil).Once()
+		mockOrderRepo.On("Create", mock.AnythingOfType("*order.Order")).Return(nil).Once().
+			Run(func(args mock.Arguments) { args.Get(0).(*order.Order).CreatedAt = createdAt })
 
-		uc := NewOrderUsecase(mockOrderRepo, mockMapClient)
-		order, err := uc.PlaceOrder([]string{"22.300789", "114.167815"}, []string{"22.33540", "114.176155"})
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		order, err := uc.PlaceOrder(mockMerchantCtx(), placement)
 
 		assert.Equal(t, true, err == nil)
 		assert.Equal(t, distance, order.Distance)
+		assert.Equal(t, "driving", order.TravelMode)
+		assert.Equal(t, 420, order.EstimatedDuration)
+		assert.Equal(t, createdAt.Add(7*time.Minute), *order.EstimatedDeliveryAt)
+		assert.Equal(t, int64(1444), order.Price)
+		assert.Equal(t, "HKD", order.Currency)
+		assert.Equal(t, "v1", order.TariffVersion)
+		assert.Equal(t, "merchant-1", order.MerchantID)
+		assert.Equal(t, mockTenantID, order.TenantID)
+		assert.Equal(t, 22.300789, order.OriginLat)
+		assert.Equal(t, 114.167815, order.OriginLng)
 		mockMapClient.AssertExpectations(t)
 		mockOrderRepo.AssertExpectations(t)
 	})
 
+	t.Run("by-address", func(t *testing.T) {
+		mockMapClient.On("GetDistance", "22.3038,114.1602", "22.33540,114.176155", order.RouteOptions{}).
+			Return(googlemap.Route{Distance: 4200}, nil).Once()
+		mockOrderRepo.On("Create", mock.AnythingOfType("*order.Order")).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		order, err := uc.PlaceOrder(mockMerchantCtx(), order.Placement{OriginAddress: "1 austin road  west", Destination: placement.Destination})
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, "1 Austin Rd W, Tsim Sha Tsui, Hong Kong", order.OriginAddress)
+		assert.Equal(t, 22.3038, order.OriginLat)
+		assert.Equal(t, 114.1602, order.OriginLng)
+		assert.Equal(t, "", order.DestinationAddress)
+		assert.Equal(t, 22.3354, order.DestinationLat)
+		mockMapClient.AssertExpectations(t)
+	})
+
+	t.Run("address-not-found", func(t *testing.T) {
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(mockMerchantCtx(), order.Placement{Origin: placement.Origin, DestinationAddress: "Atlantis"})
+
+		assert.Equal(t, true, errors.Is(err, order.ErrAddressNotFound))
+		assert.Equal(t, "destination: address not found", err.Error())
+	})
+
+	t.Run("no-tariff", func(t *testing.T) {
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "merchant-1", Roles: []string{auth.RoleMerchant}, Tenant: "brand-b"})
+
+		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("order.RouteOptions")).
+			Return(googlemap.Route{Distance: 888}, nil).Once()
+		mockOrderRepo.On("Create", mock.AnythingOfType("*order.Order")).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		order, err := uc.PlaceOrder(ctx, placement)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, int64(0), order.Price)
+		assert.Equal(t, "", order.TariffVersion)
+	})
+
+	t.Run("in-service-area", func(t *testing.T) {
+		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("order.RouteOptions")).
+			Return(googlemap.Route{Distance: 888}, nil).Once()
+		mockOrderRepo.On("Create", mock.AnythingOfType("*order.Order")).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(mockKowloon), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(mockMerchantCtx(), placement)
+
+		assert.Equal(t, true, err == nil)
+	})
+
+	t.Run("outside-service-area", func(t *testing.T) {
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(mockKowloon), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+
+		_, err := uc.PlaceOrder(mockMerchantCtx(), order.Placement{Origin: placement.Origin, Destination: []string{"51.5007", "-0.1246"}})
+		assert.Equal(t, true, errors.Is(err, order.ErrOutsideServiceArea))
+		assert.Equal(t, "destination: outside the service area", err.Error())
+
+		_, err = uc.PlaceOrder(mockMerchantCtx(), order.Placement{Origin: []string{"22.4", "114.1"}, Destination: placement.Destination})
+		assert.Equal(t, "origin: outside the service area", err.Error())
+	})
+
+	t.Run("breaks-rules", func(t *testing.T) {
+		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("order.RouteOptions")).
+			Return(googlemap.Route{Distance: 12000}, nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(mockMerchantCtx(), placement)
+
+		var verr *order.ValidationError
+		assert.Equal(t, true, errors.As(err, &verr))
+		assert.Equal(t, []order.Violation{{Rule: order.RuleMaxDistance, Message: "distance of 12000 m is over the maximum of 10000 m"}}, verr.Violations)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(context.Background(), placement)
+
+		assert.Equal(t, auth.ErrNoIdentity, err)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(mockCourierCtx(), placement)
+
+		assert.Equal(t, auth.ErrForbidden, err)
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "merchant-1", Roles: []string{auth.RoleMerchant}})
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(ctx, placement)
+
+		assert.Equal(t, auth.ErrNoTenant, err)
+	})
+
 	t.Run("map-api-error", func(t *testing.T) {
 		mapErrMsg := "service unavailable"
 
-		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
-			Return(0, errors.New(mapErrMsg)).Once()
+		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("order.RouteOptions")).
+			Return(googlemap.Route{}, errors.New(mapErrMsg)).Once()
 
-		uc := NewOrderUsecase(mockOrderRepo, mockMapClient)
-		_, err := uc.PlaceOrder([]string{"22.300789", "114.167815"}, []string{"22.33540", "114.176155"})
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(mockMerchantCtx(), placement)
 
 		if err == nil {
 			t.Errorf("TestPlaceOrder() fails, expect an error, got none")
@@ -66,12 +225,12 @@ func TestPlaceOrder(t *testing.T) {
 	t.Run("db-error", func(t *testing.T) {
 		distance := 941
 
-		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
-			Return(distance, nil).Once()
+		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("order.RouteOptions")).
+			Return(googlemap.Route{Distance: distance}, nil).Once()
 		mockOrderRepo.On("Create", mock.AnythingOfType("*order.Order")).Return(&mysql.MySQLError{}).Once()
 
-		uc := NewOrderUsecase(mockOrderRepo, mockMapClient)
-		_, err := uc.PlaceOrder([]string{"22.300789", "114.167815"}, []string{"22.33540", "114.176155"})
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(mockMerchantCtx(), placement)
 
 		assert.Equal(t, false, err == nil)
 
@@ -82,6 +241,611 @@ func TestPlaceOrder(t *testing.T) {
 	})
 }
 
+func TestPlaceMultiStopOrder(t *testing.T) {
+	stops := []order.PlacementStop{
+		{Type: order.StopPickup, Coordinates: []string{"22.300789", "114.167815"}},
+		{Type: order.StopPickup, Address: "1 Austin Road West"},
+		{Type: order.StopDropoff, Coordinates: []string{"22.33540", "114.176155"}},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+
+		// every leg is looked up, from the previous stop
+		mockMapClient.On("GetDistance", "22.300789,114.167815", "22.3038,114.1602", order.RouteOptions{}).
+			Return(googlemap.Route{Distance: 900, Duration: 3 * time.Minute}, nil).Once()
+		mockMapClient.On("GetDistance", "22.3038,114.1602", "22.3354,114.176155", order.RouteOptions{}).
+			Return(googlemap.Route{Distance: 4100, Duration: 9 * time.Minute}, nil).Once()
+		mockOrderRepo.On("Create", mock.MatchedBy(func(o *order.Order) bool {
+			return len(o.Stops) == 3 && o.Stops[1].Address == "1 Austin Rd W, Tsim Sha Tsui, Hong Kong"
+		})).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(mockKowloon), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		o, err := uc.PlaceOrder(mockMerchantCtx(), order.Placement{Stops: stops})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 5000, o.Distance)
+		assert.Equal(t, 720, o.EstimatedDuration)
+		assert.Equal(t, int64(3500), o.Price)
+		assert.Equal(t, []int{0, 900, 4100}, []int{o.Stops[0].Distance, o.Stops[1].Distance, o.Stops[2].Distance})
+		assert.Equal(t, 22.300789, o.OriginLat)
+		assert.Equal(t, 22.3354, o.DestinationLat)
+		// the stops of the caller are left as they were
+		assert.Equal(t, "1 Austin Road West", stops[1].Address)
+		mockMapClient.AssertExpectations(t)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("stop-outside-service-area", func(t *testing.T) {
+		outside := append([]order.PlacementStop{stops[0], {Type: order.StopPickup, Coordinates: []string{"51.5007", "-0.1246"}}}, stops[2])
+
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(mockKowloon), new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(mockMerchantCtx(), order.Placement{Stops: outside})
+
+		assert.Equal(t, true, errors.Is(err, order.ErrOutsideServiceArea))
+		assert.Equal(t, "stop 1: outside the service area", err.Error())
+	})
+
+	t.Run("stop-address-not-found", func(t *testing.T) {
+		unknown := []order.PlacementStop{stops[0], {Type: order.StopDropoff, Address: "Atlantis"}}
+
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(mockMerchantCtx(), order.Placement{Stops: unknown})
+
+		assert.Equal(t, "stop 1: address not found", err.Error())
+	})
+
+	t.Run("no-route", func(t *testing.T) {
+		mockMapClient := new(googlemap.MockMapClient)
+		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), "22.3038,114.1602", order.RouteOptions{}).
+			Return(googlemap.Route{Distance: 900}, nil).Once()
+		mockMapClient.On("GetDistance", "22.3038,114.1602", mock.AnythingOfType("string"), order.RouteOptions{}).
+			Return(googlemap.Route{}, order.ErrNoRoute).Once()
+
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(mockMerchantCtx(), order.Placement{Stops: stops})
+
+		assert.Equal(t, order.ErrNoRoute, err)
+	})
+
+	t.Run("in-batch", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+
+		mockMapClient.On("GetDistances", []string{"22.300789,114.167815"}, []string{"22.33540,114.176155"}, order.RouteOptions{}).
+			Return([][]googlemap.Route{{{Distance: 1200}}}, nil).Once()
+		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string"), order.RouteOptions{}).
+			Return(googlemap.Route{Distance: 1000}, nil).Twice()
+		mockOrderRepo.On("CreateBatch", mock.MatchedBy(func(orders []*order.Order) bool {
+			return len(orders) == 2 && len(orders[0].Stops) == 0 && orders[1].Distance == 2000
+		})).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		results, err := uc.PlaceOrders(mockMerchantCtx(), []order.Placement{
+			{Origin: []string{"22.300789", "114.167815"}, Destination: []string{"22.33540", "114.176155"}},
+			{Stops: stops},
+		})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, results[1].Err)
+		assert.Equal(t, 3, len(results[1].Order.Stops))
+		mockMapClient.AssertExpectations(t)
+		mockOrderRepo.AssertExpectations(t)
+	})
+}
+
+func TestPlaceScheduledOrder(t *testing.T) {
+	placement := order.Placement{Origin: []string{"22.300789", "114.167815"}, Destination: []string{"22.33540", "114.176155"}}
+	createdAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	scheduledFor := createdAt.Add(24 * time.Hour)
+
+	t.Run("success", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("order.RouteOptions")).
+			Return(googlemap.Route{Distance: 888, Duration: 7 * time.Minute}, nil).Once()
+		mockOrderRepo.On("Create", mock.MatchedBy(func(o *order.Order) bool {
+			return o.Status == order.StatusScheduled && *o.ScheduledFor == scheduledFor
+		})).Return(nil).Once().
+			Run(func(args mock.Arguments) { args.Get(0).(*order.Order).CreatedAt = createdAt })
+
+		p := placement
+		p.ScheduledFor = &scheduledFor
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		o, err := uc.PlaceOrder(mockMerchantCtx(), p)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, order.StatusScheduled, o.Status)
+		// delivered once travelled from the pickup rather than from the placement
+		assert.Equal(t, scheduledFor.Add(7*time.Minute), *o.EstimatedDeliveryAt)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("rules-checked-at-pickup", func(t *testing.T) {
+		// open for a minute a day, placements now would be rejected as often as pickups
+		validator, _ := rules.New(configs.RulesConfig{Default: &configs.RuleSetConfig{
+			OperatingHours: &configs.OperatingHoursConfig{From: "11:00", To: "11:01", TimeZone: "UTC"},
+		}})
+		mockMapClient := new(googlemap.MockMapClient)
+		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("order.RouteOptions")).
+			Return(googlemap.Route{Distance: 888, Duration: 7 * time.Minute}, nil).Once()
+
+		p := placement
+		p.ScheduledFor = &scheduledFor
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, validator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(mockMerchantCtx(), p)
+
+		assert.Equal(t, &order.ValidationError{Violations: []order.Violation{
+			{Rule: order.RuleOperatingHours, Message: "placed at 12:00, outside the operating hours from 11:00 to 11:01"},
+		}}, err)
+	})
+
+	t.Run("in-batch", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+		mockMapClient.On("GetDistances", mock.Anything, mock.Anything, order.RouteOptions{}).
+			Return([][]googlemap.Route{{{Distance: 888, Duration: 7 * time.Minute}}}, nil).Once()
+		mockOrderRepo.On("CreateBatch", mock.MatchedBy(func(orders []*order.Order) bool {
+			return len(orders) == 2 && orders[0].Status == order.StatusScheduled && orders[1].Status == order.StatusUnassigned
+		})).Return(nil).Once()
+
+		scheduled := placement
+		scheduled.ScheduledFor = &scheduledFor
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		results, err := uc.PlaceOrders(mockMerchantCtx(), []order.Placement{scheduled, placement})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, scheduledFor, *results[0].Order.ScheduledFor)
+		assert.Equal(t, true, results[1].Order.ScheduledFor == nil)
+		mockOrderRepo.AssertExpectations(t)
+	})
+}
+
+func TestPlaceOrders(t *testing.T) {
+	origin := []string{"22.300789", "114.167815"}
+	dest1 := []string{"22.33540", "114.176155"}
+	dest2 := []string{"22.28", "114.15"}
+
+	t.Run("success", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+
+		// the shared origin is asked once
+		mockMapClient.On("GetDistances", []string{"22.300789,114.167815"}, []string{"22.33540,114.176155", "22.28,114.15"}, order.RouteOptions{}).
+			Return([][]googlemap.Route{{{Distance: 1200, Duration: 5 * time.Minute}, {Err: order.ErrNoRoute}}}, nil).Once()
+		mockOrderRepo.On("CreateBatch", mock.MatchedBy(func(orders []*order.Order) bool {
+			return len(orders) == 2 && orders[0].Distance == 1200 && orders[1].Distance == 1200
+		})).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		results, err := uc.PlaceOrders(mockMerchantCtx(), []order.Placement{
+			{Origin: origin, Destination: dest1},
+			{Origin: []string{"22.300789"}, Destination: dest1},
+			{Origin: origin, Destination: dest2},
+			{Origin: origin, Destination: dest1},
+		})
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, 4, len(results))
+		assert.Equal(t, "merchant-1", results[0].Order.MerchantID)
+		assert.Equal(t, mockTenantID, results[0].Order.TenantID)
+		assert.Equal(t, false, results[1].Err == nil)
+		assert.Equal(t, order.ErrNoRoute, results[2].Err)
+		assert.Equal(t, true, results[2].Order == nil)
+		assert.Equal(t, 1200, results[3].Order.Distance)
+		assert.Equal(t, 300, results[3].Order.EstimatedDuration)
+		assert.Equal(t, int64(1600), results[3].Order.Price)
+		mockMapClient.AssertExpectations(t)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("batch-too-large", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+
+		placements := make([]order.Placement, mockMaxBatchSize+1)
+		for i := range placements {
+			placements[i] = order.Placement{Origin: origin, Destination: dest1}
+		}
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		results, err := uc.PlaceOrders(mockMerchantCtx(), placements)
+
+		assert.Equal(t, order.ErrBatchTooLarge, err)
+		assert.Equal(t, true, results == nil)
+		mockMapClient.AssertExpectations(t)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("breaks-rules", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+
+		mockMapClient.On("GetDistances", []string{"22.300789,114.167815"}, []string{"22.33540,114.176155", "22.28,114.15"}, order.RouteOptions{}).
+			Return([][]googlemap.Route{{{Distance: 1200}, {Distance: 15000}}}, nil).Once()
+		mockOrderRepo.On("CreateBatch", mock.MatchedBy(func(orders []*order.Order) bool {
+			return len(orders) == 1 && orders[0].Distance == 1200
+		})).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		results, err := uc.PlaceOrders(mockMerchantCtx(), []order.Placement{
+			{Origin: origin, Destination: dest1},
+			{Origin: origin, Destination: dest2},
+		})
+
+		var verr *order.ValidationError
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, results[0].Err)
+		assert.Equal(t, true, errors.As(results[1].Err, &verr))
+		assert.Equal(t, order.RuleMaxDistance, verr.Violations[0].Rule)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("split-over-matrix-limits", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+
+		var placements []order.Placement
+		for i := 0; i < 11; i++ {
+			lat := strconv.FormatFloat(22.3+float64(i)/100, 'f', 2, 64)
+			placements = append(placements, order.Placement{Origin: []string{lat, "114.16"}, Destination: []string{lat, "114.17"}})
+		}
+
+		routes := func(origins, dests []string, opts order.RouteOptions) [][]googlemap.Route {
+			r := make([][]googlemap.Route, len(origins))
+			for i := range r {
+				r[i] = make([]googlemap.Route, len(dests))
+			}
+			return r
+		}
+		mockMapClient.On("GetDistances", mock.AnythingOfType("[]string"), mock.AnythingOfType("[]string"), mock.AnythingOfType("order.RouteOptions")).
+			Return(routes, nil).Twice()
+		mockOrderRepo.On("CreateBatch", mock.AnythingOfType("[]*order.Order")).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		results, err := uc.PlaceOrders(mockMerchantCtx(), placements)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, 11, len(results))
+		assert.Equal(t, 10, len(mockMapClient.Calls[0].Arguments.Get(0).([]string)))
+		assert.Equal(t, 1, len(mockMapClient.Calls[1].Arguments.Get(0).([]string)))
+		mockMapClient.AssertExpectations(t)
+	})
+
+	t.Run("by-address", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+
+		mockMapClient.On("GetDistances", []string{"22.3038,114.1602"}, []string{"22.33540,114.176155"}, order.RouteOptions{}).
+			Return([][]googlemap.Route{{{Distance: 4200}}}, nil).Once()
+		mockOrderRepo.On("CreateBatch", mock.AnythingOfType("[]*order.Order")).Return(nil).Once()
+
+		placements := []order.Placement{
+			{OriginAddress: "1 Austin Road West", Destination: dest1},
+			{OriginAddress: "Nathan Road", Destination: dest1},
+		}
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		results, err := uc.PlaceOrders(mockMerchantCtx(), placements)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, "1 Austin Rd W, Tsim Sha Tsui, Hong Kong", results[0].Order.OriginAddress)
+		assert.Equal(t, 4200, results[0].Order.Distance)
+		assert.Equal(t, true, errors.Is(results[1].Err, order.ErrAmbiguousAddress))
+		assert.Equal(t, "1 Austin Road West", placements[0].OriginAddress)
+		mockMapClient.AssertExpectations(t)
+	})
+
+	t.Run("batched-by-options", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+		bicycling := order.RouteOptions{Mode: order.TravelModeBicycling, AvoidFerries: true}
+
+		mockMapClient.On("GetDistances", []string{"22.300789,114.167815"}, []string{"22.33540,114.176155"}, order.RouteOptions{}).
+			Return([][]googlemap.Route{{{Distance: 1200}}}, nil).Once()
+		mockMapClient.On("GetDistances", []string{"22.300789,114.167815"}, []string{"22.33540,114.176155", "22.28,114.15"}, bicycling).
+			Return([][]googlemap.Route{{{Distance: 1000}, {Distance: 2000}}}, nil).Once()
+		mockOrderRepo.On("CreateBatch", mock.AnythingOfType("[]*order.Order")).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		results, err := uc.PlaceOrders(mockMerchantCtx(), []order.Placement{
+			{Origin: origin, Destination: dest1, Options: bicycling},
+			{Origin: origin, Destination: dest1},
+			{Origin: origin, Destination: dest2, Options: bicycling},
+		})
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, 1000, results[0].Order.Distance)
+		assert.Equal(t, "bicycling", results[0].Order.TravelMode)
+		assert.Equal(t, 1200, results[1].Order.Distance)
+		assert.Equal(t, "driving", results[1].Order.TravelMode)
+		assert.Equal(t, 2000, results[2].Order.Distance)
+		mockMapClient.AssertExpectations(t)
+	})
+
+	t.Run("outside-service-area", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+
+		mockMapClient.On("GetDistances", []string{"22.300789,114.167815"}, []string{"22.33540,114.176155"}, order.RouteOptions{}).
+			Return([][]googlemap.Route{{{Distance: 1200}}}, nil).Once()
+		mockOrderRepo.On("CreateBatch", mock.MatchedBy(func(orders []*order.Order) bool { return len(orders) == 1 })).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(mockKowloon), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		results, err := uc.PlaceOrders(mockMerchantCtx(), []order.Placement{
+			{Origin: origin, Destination: dest1},
+			{Origin: origin, Destination: []string{"51.5007", "-0.1246"}},
+		})
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, 1200, results[0].Order.Distance)
+		assert.Equal(t, true, errors.Is(results[1].Err, order.ErrOutsideServiceArea))
+		assert.Equal(t, true, results[1].Order == nil)
+		mockMapClient.AssertExpectations(t)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("service-area-error", func(t *testing.T) {
+		mockAreaRepo := new(_serviceAreaMocks.ServiceAreaRepository)
+		mockAreaRepo.On("FindAll", mockTenantID).Return(nil, &mysql.MySQLError{}).Once()
+
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockAreaRepo, new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrders(mockMerchantCtx(), []order.Placement{{Origin: origin, Destination: dest1}})
+
+		_, isMysqlError := err.(*mysql.MySQLError)
+		assert.Equal(t, true, isMysqlError)
+	})
+
+	t.Run("map-api-error", func(t *testing.T) {
+		mockMapClient := new(googlemap.MockMapClient)
+		mockMapClient.On("GetDistances", mock.AnythingOfType("[]string"), mock.AnythingOfType("[]string"), mock.AnythingOfType("order.RouteOptions")).
+			Return(nil, errors.New("service unavailable")).Once()
+
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		results, err := uc.PlaceOrders(mockMerchantCtx(), []order.Placement{{Origin: origin, Destination: dest1}})
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, "service unavailable", results[0].Err.Error())
+		mockMapClient.AssertExpectations(t)
+	})
+
+	t.Run("db-error", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+		mockMapClient.On("GetDistances", mock.AnythingOfType("[]string"), mock.AnythingOfType("[]string"), mock.AnythingOfType("order.RouteOptions")).
+			Return([][]googlemap.Route{{{Distance: 1200}}}, nil).Once()
+		mockOrderRepo.On("CreateBatch", mock.AnythingOfType("[]*order.Order")).Return(&mysql.MySQLError{}).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrders(mockMerchantCtx(), []order.Placement{{Origin: origin, Destination: dest1}})
+
+		_, isMysqlError := err.(*mysql.MySQLError)
+		assert.Equal(t, true, isMysqlError)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrders(mockCourierCtx(), []order.Placement{{Origin: origin, Destination: dest1}})
+
+		assert.Equal(t, auth.ErrForbidden, err)
+	})
+}
+
+func TestQuoteOrder(t *testing.T) {
+	placement := order.Placement{OriginAddress: "1 Austin Road West", Destination: []string{"22.33540", "114.176155"}}
+
+	t.Run("success", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockQuoteRepo := new(mocks.QuoteRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+		expiresAt := time.Now().Add(5 * time.Minute)
+		mockMapClient.On("GetDistance", "22.3038,114.1602", "22.33540,114.176155", order.RouteOptions{Mode: order.TravelModeWalking}).
+			Return(googlemap.Route{Distance: 4200, Duration: 50 * time.Minute}, nil).Once()
+		mockQuoteRepo.On("Create", mock.AnythingOfType("*order.Quote")).Return(nil).Once().
+			Run(func(args mock.Arguments) {
+				q := args.Get(0).(*order.Quote)
+				q.ID, q.ExpiresAt = "4f1c", expiresAt
+			})
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), mockQuoteRepo, mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		p := placement
+		p.Options.Mode = order.TravelModeWalking
+		quote, err := uc.QuoteOrder(mockMerchantCtx(), p)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, &order.Quote{
+			ID:                "4f1c",
+			TenantID:          mockTenantID,
+			MerchantID:        "merchant-1",
+			Distance:          4200,
+			TravelMode:        order.TravelModeWalking,
+			EstimatedDuration: 3000,
+			OriginAddress:     "1 Austin Rd W, Tsim Sha Tsui, Hong Kong",
+			OriginLat:         22.3038,
+			OriginLng:         114.1602,
+			DestinationLat:    22.3354,
+			DestinationLng:    114.176155,
+			Price:             3100,
+			Currency:          "HKD",
+			TariffVersion:     "v1",
+			ExpiresAt:         expiresAt,
+		}, quote)
+		mockMapClient.AssertExpectations(t)
+		mockQuoteRepo.AssertExpectations(t)
+		// nothing is placed
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("no-tariff", func(t *testing.T) {
+		mockMapClient := new(googlemap.MockMapClient)
+		mockMapClient.On("GetDistance", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("order.RouteOptions")).
+			Return(googlemap.Route{Distance: 4200}, nil).Once()
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "merchant-1", Roles: []string{auth.RoleMerchant}, Tenant: "brand-b"})
+
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.QuoteOrder(ctx, placement)
+
+		assert.Equal(t, order.ErrNoTariff, err)
+	})
+
+	t.Run("ambiguous-address", func(t *testing.T) {
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.QuoteOrder(mockMerchantCtx(), order.Placement{OriginAddress: "Nathan Road", Destination: placement.Destination})
+
+		assert.Equal(t, true, errors.Is(err, order.ErrAmbiguousAddress))
+	})
+
+	t.Run("outside-service-area", func(t *testing.T) {
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(mockKowloon), new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.QuoteOrder(mockMerchantCtx(), order.Placement{Origin: []string{"51.5007", "-0.1246"}, Destination: placement.Destination})
+
+		assert.Equal(t, true, errors.Is(err, order.ErrOutsideServiceArea))
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.QuoteOrder(mockCourierCtx(), placement)
+
+		assert.Equal(t, auth.ErrForbidden, err)
+	})
+}
+
+func TestPlaceOrderFromQuote(t *testing.T) {
+	mockQuote := func(expiresAt time.Time) *order.Quote {
+		return &order.Quote{ID: "4f1c", TenantID: mockTenantID, MerchantID: "merchant-1", Distance: 4200,
+			TravelMode: order.TravelModeDriving, Price: 3100, Currency: "HKD", TariffVersion: "v1",
+			OriginLat: 22.3038, OriginLng: 114.1602, DestinationLat: 22.3354, DestinationLng: 114.176155, ExpiresAt: expiresAt}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockQuoteRepo := new(mocks.QuoteRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+		mockQuoteRepo.On("FindByID", mockTenantID, "4f1c").Return(mockQuote(time.Now().Add(time.Minute)), nil).Once()
+		mockOrderRepo.On("Create", mock.AnythingOfType("*order.Order")).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), mockQuoteRepo, mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		o, err := uc.PlaceOrder(mockMerchantCtx(), order.Placement{QuoteID: "4f1c"})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "4f1c", o.QuoteID)
+		assert.Equal(t, 4200, o.Distance)
+		assert.Equal(t, int64(3100), o.Price)
+		assert.Equal(t, "merchant-1", o.MerchantID)
+		assert.Equal(t, order.StatusUnassigned, o.Status)
+		assert.Equal(t, 22.3354, o.DestinationLat)
+		// the route is not looked up again
+		mockMapClient.AssertNotCalled(t, "GetDistance")
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name    string
+		quote   *order.Quote
+		findErr error
+		want    error
+	}{
+		{"not-found", nil, sql.ErrNoRows, order.ErrQuoteNotFound},
+		{"other-merchant", func() *order.Quote {
+			q := mockQuote(time.Now().Add(time.Minute))
+			q.MerchantID = "merchant-2"
+			return q
+		}(), nil, order.ErrQuoteNotFound},
+		{"used", func() *order.Quote {
+			q := mockQuote(time.Now().Add(time.Minute))
+			q.OrderID = 8
+			return q
+		}(), nil, order.ErrQuoteUsed},
+		{"expired", mockQuote(time.Now().Add(-time.Second)), nil, order.ErrQuoteExpired},
+		// the rules are checked again as they may have changed since it was quoted
+		{"breaks-rules", func() *order.Quote {
+			q := mockQuote(time.Now().Add(time.Minute))
+			q.Distance = 12000
+			return q
+		}(), nil, &order.ValidationError{Violations: []order.Violation{
+			{Rule: order.RuleMaxDistance, Message: "distance of 12000 m is over the maximum of 10000 m"},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOrderRepo := new(mocks.OrderRepository)
+			mockQuoteRepo := new(mocks.QuoteRepository)
+			mockQuoteRepo.On("FindByID", mockTenantID, "4f1c").Return(tt.quote, tt.findErr).Once()
+
+			uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), mockQuoteRepo, mockServiceAreas(), new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+			_, err := uc.PlaceOrder(mockMerchantCtx(), order.Placement{QuoteID: "4f1c"})
+
+			assert.Equal(t, tt.want, err)
+			mockOrderRepo.AssertNotCalled(t, "Create")
+		})
+	}
+
+	t.Run("used-meanwhile", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockQuoteRepo := new(mocks.QuoteRepository)
+		mockQuoteRepo.On("FindByID", mockTenantID, "4f1c").Return(mockQuote(time.Now().Add(time.Minute)), nil).Once()
+		mockOrderRepo.On("Create", mock.AnythingOfType("*order.Order")).Return(order.ErrQuoteUsed).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), mockQuoteRepo, mockServiceAreas(), new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.PlaceOrder(mockMerchantCtx(), order.Placement{QuoteID: "4f1c"})
+
+		assert.Equal(t, order.ErrQuoteUsed, err)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockQuoteRepo := new(mocks.QuoteRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+		mockQuoteRepo.On("FindByID", mockTenantID, "4f1c").Return(mockQuote(time.Now().Add(time.Minute)), nil).Once()
+		mockMapClient.On("GetDistances", []string{"22.300789,114.167815"}, []string{"22.33540,114.176155"}, order.RouteOptions{}).
+			Return([][]googlemap.Route{{{Distance: 1200}}}, nil).Once()
+		mockOrderRepo.On("CreateBatch", mock.MatchedBy(func(orders []*order.Order) bool {
+			return len(orders) == 2 && orders[0].QuoteID == "4f1c" && orders[1].Price == 1600
+		})).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), mockQuoteRepo, mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		results, err := uc.PlaceOrders(mockMerchantCtx(), []order.Placement{
+			{QuoteID: "4f1c"},
+			{Origin: []string{"22.300789", "114.167815"}, Destination: []string{"22.33540", "114.176155"}},
+			{QuoteID: "4f1c"},
+		})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(3100), results[0].Order.Price)
+		assert.Equal(t, int64(1600), results[1].Order.Price)
+		assert.Equal(t, order.ErrQuoteUsed, results[2].Err)
+		mockQuoteRepo.AssertExpectations(t)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("batch-used-meanwhile", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockQuoteRepo := new(mocks.QuoteRepository)
+		mockMapClient := new(googlemap.MockMapClient)
+		mockQuoteRepo.On("FindByID", mockTenantID, "4f1c").Return(mockQuote(time.Now().Add(time.Minute)), nil).Once()
+		mockMapClient.On("GetDistances", []string{"22.300789,114.167815"}, []string{"22.33540,114.176155"}, order.RouteOptions{}).
+			Return([][]googlemap.Route{{{Distance: 1200}}}, nil).Once()
+		mockOrderRepo.On("CreateBatch", mock.MatchedBy(func(orders []*order.Order) bool { return len(orders) == 2 })).
+			Return(&order.BatchError{Index: 0, Err: order.ErrQuoteUsed}).Once()
+		mockOrderRepo.On("CreateBatch", mock.MatchedBy(func(orders []*order.Order) bool {
+			return len(orders) == 1 && orders[0].QuoteID == ""
+		})).Return(nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), mockQuoteRepo, mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		results, err := uc.PlaceOrders(mockMerchantCtx(), []order.Placement{
+			{QuoteID: "4f1c"},
+			{Origin: []string{"22.300789", "114.167815"}, Destination: []string{"22.33540", "114.176155"}},
+		})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, order.ErrQuoteUsed, results[0].Err)
+		assert.Equal(t, true, results[0].Order == nil)
+		assert.Equal(t, int64(1600), results[1].Order.Price)
+		mockOrderRepo.AssertExpectations(t)
+	})
+}
+
 func TestTakeOrder(t *testing.T) {
 	mockOrderRepo := new(mocks.OrderRepository)
 	mockMapClient := new(googlemap.MockMapClient)
@@ -92,41 +856,69 @@ func TestTakeOrder(t *testing.T) {
 		mockOrderID := int64(1)
 		tempOrder := mockOrder
 
-		mockOrderRepo.On("FindByID", mock.AnythingOfType("int64")).Return(&tempOrder, nil).Once()
-		mockOrderRepo.On("UpdateStatusByID", mock.AnythingOfType("int64")).Return(nil).Once()
+		mockOrderRepo.On("FindByID", mockTenantID, mock.AnythingOfType("int64")).Return(&tempOrder, nil).Once()
+		mockOrderRepo.On("UpdateStatusByID", mockTenantID, mockOrderID, "courier-1").Return(nil).Once()
 
-		uc := NewOrderUsecase(mockOrderRepo, mockMapClient)
-		status, err := uc.TakeOrder(mockOrderID)
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		status, err := uc.TakeOrder(mockCourierCtx(), mockOrderID)
 
 		assert.Equal(t, true, err == nil)
 		assert.Equal(t, statusUpdateOrderStatusSuccess, status)
 		mockOrderRepo.AssertExpectations(t)
 	})
 
+	t.Run("unauthenticated", func(t *testing.T) {
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.TakeOrder(context.Background(), int64(1))
+
+		assert.Equal(t, auth.ErrNoIdentity, err)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.TakeOrder(mockMerchantCtx(), int64(1))
+
+		assert.Equal(t, auth.ErrForbidden, err)
+	})
+
 	t.Run("order-taken", func(t *testing.T) {
 		mockOrderID := int64(1)
 		tempOrder := mockOrder
 		tempOrder.Status = order.StatusTaken
 
-		mockOrderRepo.On("FindByID", mock.AnythingOfType("int64")).Return(&tempOrder, nil).Once()
+		mockOrderRepo.On("FindByID", mockTenantID, mock.AnythingOfType("int64")).Return(&tempOrder, nil).Once()
 
-		uc := NewOrderUsecase(mockOrderRepo, mockMapClient)
-		_, err := uc.TakeOrder(mockOrderID)
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.TakeOrder(mockCourierCtx(), mockOrderID)
 
 		assert.Equal(t, false, err == nil)
 		assert.Equal(t, ErrorOrderTaken, err.Error())
 		mockOrderRepo.AssertExpectations(t)
 	})
 
+	t.Run("order-scheduled", func(t *testing.T) {
+		tempOrder := mockOrder
+		tempOrder.Status = order.StatusScheduled
+
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockOrderRepo.On("FindByID", mockTenantID, mock.AnythingOfType("int64")).Return(&tempOrder, nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.TakeOrder(mockCourierCtx(), int64(1))
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
 	t.Run("order-taken-when-update", func(t *testing.T) {
 		mockOrderID := int64(1)
 		tempOrder := mockOrder
 
-		mockOrderRepo.On("FindByID", mock.AnythingOfType("int64")).Return(&tempOrder, nil).Once()
-		mockOrderRepo.On("UpdateStatusByID", mock.AnythingOfType("int64")).Return(sql.ErrNoRows).Once()
+		mockOrderRepo.On("FindByID", mockTenantID, mock.AnythingOfType("int64")).Return(&tempOrder, nil).Once()
+		mockOrderRepo.On("UpdateStatusByID", mockTenantID, mock.AnythingOfType("int64"), mock.AnythingOfType("string")).Return(sql.ErrNoRows).Once()
 
-		uc := NewOrderUsecase(mockOrderRepo, mockMapClient)
-		_, err := uc.TakeOrder(mockOrderID)
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.TakeOrder(mockCourierCtx(), mockOrderID)
 
 		assert.Equal(t, false, err == nil)
 		assert.Equal(t, ErrorOrderTaken, err.Error())
@@ -136,10 +928,10 @@ func TestTakeOrder(t *testing.T) {
 	t.Run("no-such-order", func(t *testing.T) {
 		mockOrderID := int64(1)
 
-		mockOrderRepo.On("FindByID", mock.AnythingOfType("int64")).Return(nil, sql.ErrNoRows).Once()
+		mockOrderRepo.On("FindByID", mockTenantID, mock.AnythingOfType("int64")).Return(nil, sql.ErrNoRows).Once()
 
-		uc := NewOrderUsecase(mockOrderRepo, mockMapClient)
-		_, err := uc.TakeOrder(mockOrderID)
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.TakeOrder(mockCourierCtx(), mockOrderID)
 
 		assert.Equal(t, false, err == nil)
 		assert.Equal(t, sql.ErrNoRows, err)
@@ -150,11 +942,11 @@ func TestTakeOrder(t *testing.T) {
 		mockOrderID := int64(1)
 		tempOrder := mockOrder
 
-		mockOrderRepo.On("FindByID", mock.AnythingOfType("int64")).Return(&tempOrder, nil).Once()
-		mockOrderRepo.On("UpdateStatusByID", mock.AnythingOfType("int64")).Return(&mysql.MySQLError{}).Once()
+		mockOrderRepo.On("FindByID", mockTenantID, mock.AnythingOfType("int64")).Return(&tempOrder, nil).Once()
+		mockOrderRepo.On("UpdateStatusByID", mockTenantID, mock.AnythingOfType("int64"), mock.AnythingOfType("string")).Return(&mysql.MySQLError{}).Once()
 
-		uc := NewOrderUsecase(mockOrderRepo, mockMapClient)
-		_, err := uc.TakeOrder(mockOrderID)
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.TakeOrder(mockCourierCtx(), mockOrderID)
 
 		assert.Equal(t, false, err == nil)
 
@@ -164,14 +956,72 @@ func TestTakeOrder(t *testing.T) {
 	})
 }
 
+func TestCompleteStop(t *testing.T) {
+	completedAt := time.Now()
+	mockOrder := func() *order.Order {
+		return &order.Order{ID: 1, Status: order.StatusTaken, CourierID: "courier-1", Stops: []order.Stop{
+			{OrderID: 1, Index: 0, Type: order.StopPickup, CompletedAt: &completedAt},
+			{OrderID: 1, Index: 1, Type: order.StopDropoff, Distance: 888},
+		}}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockOrderRepo := new(mocks.OrderRepository)
+		mockOrderRepo.On("FindByID", mockTenantID, int64(1)).Return(mockOrder(), nil).Once()
+		mockOrderRepo.On("CompleteStop", mockTenantID, int64(1), 1, "courier-1").
+			Return(&order.Stop{OrderID: 1, Index: 1, Type: order.StopDropoff, CompletedAt: &completedAt}, nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		stop, err := uc.CompleteStop(mockCourierCtx(), 1, 1)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, &completedAt, stop.CompletedAt)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		index   int
+		want    error
+		repoErr error
+	}{
+		{"other-courier", mockCallerCtx("courier-2", auth.RoleCourier), 1, order.ErrNotTakenByCourier, nil},
+		{"stop-not-found", mockCourierCtx(), 2, order.ErrStopNotFound, nil},
+		{"negative-index", mockCourierCtx(), -1, order.ErrStopNotFound, nil},
+		{"already-completed", mockCourierCtx(), 0, order.ErrStopCompleted, nil},
+		{"completed-meanwhile", mockCourierCtx(), 1, order.ErrStopCompleted, sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOrderRepo := new(mocks.OrderRepository)
+			mockOrderRepo.On("FindByID", mockTenantID, int64(1)).Return(mockOrder(), nil).Once()
+			mockOrderRepo.On("CompleteStop", mockTenantID, int64(1), tt.index, mock.AnythingOfType("string")).Return(nil, tt.repoErr).Maybe()
+
+			uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+			_, err := uc.CompleteStop(tt.ctx, 1, tt.index)
+
+			assert.Equal(t, tt.want, err)
+		})
+	}
+
+	t.Run("forbidden", func(t *testing.T) {
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.CompleteStop(mockMerchantCtx(), 1, 1)
+
+		assert.Equal(t, auth.ErrForbidden, err)
+	})
+}
+
 func TestListOrders(t *testing.T) {
 	mockOrderRepo := new(mocks.OrderRepository)
 	mockMapClient := new(googlemap.MockMapClient)
 
 	mockPage := 1
 	mockLimit := 4
+	createdAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
 	mockOrders := []order.Order{
-		{ID: 1, Distance: 100, Status: order.StatusTaken},
+		{ID: 1, Distance: 100, EstimatedDuration: 60, Status: order.StatusTaken, CreatedAt: createdAt},
 		{ID: 2, Distance: 200, Status: order.StatusUnassigned},
 		{ID: 3, Distance: 300, Status: order.StatusUnassigned},
 		{ID: 4, Distance: 400, Status: order.StatusTaken},
@@ -180,23 +1030,58 @@ func TestListOrders(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		tempOrders := mockOrders
 
-		mockOrderRepo.On("FindRange", mock.AnythingOfType("int"),
+		mockOrderRepo.On("FindRange", mockTenantID, order.OrderFilter{}, mock.AnythingOfType("int"),
 			mock.AnythingOfType("int")).Return(&tempOrders, nil).Once()
 
-		uc := NewOrderUsecase(mockOrderRepo, mockMapClient)
-		orders, err := uc.ListOrders(mockPage, mockLimit)
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		orders, err := uc.ListOrders(mockAdminCtx(), mockPage, mockLimit)
 
 		assert.Equal(t, true, err == nil)
 		assert.Equal(t, len(tempOrders), len(*orders))
+		assert.Equal(t, createdAt.Add(time.Minute), *(*orders)[0].EstimatedDeliveryAt)
+		assert.Equal(t, true, (*orders)[1].EstimatedDeliveryAt == nil)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("merchant-sees-own-orders", func(t *testing.T) {
+		tempOrders := mockOrders
+
+		mockOrderRepo.On("FindRange", mockTenantID, order.OrderFilter{MerchantID: "merchant-1"}, mock.AnythingOfType("int"),
+			mock.AnythingOfType("int")).Return(&tempOrders, nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.ListOrders(mockMerchantCtx(), mockPage, mockLimit)
+
+		assert.Equal(t, true, err == nil)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("courier-sees-unassigned-orders", func(t *testing.T) {
+		tempOrders := mockOrders
+
+		mockOrderRepo.On("FindRange", mockTenantID, order.OrderFilter{Status: order.StatusUnassigned}, mock.AnythingOfType("int"),
+			mock.AnythingOfType("int")).Return(&tempOrders, nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.ListOrders(mockCourierCtx(), mockPage, mockLimit)
+
+		assert.Equal(t, true, err == nil)
 		mockOrderRepo.AssertExpectations(t)
 	})
 
+	t.Run("unauthenticated", func(t *testing.T) {
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.ListOrders(context.Background(), mockPage, mockLimit)
+
+		assert.Equal(t, auth.ErrNoIdentity, err)
+	})
+
 	t.Run("db-error", func(t *testing.T) {
-		mockOrderRepo.On("FindRange", mock.AnythingOfType("int"),
+		mockOrderRepo.On("FindRange", mockTenantID, mock.AnythingOfType("order.OrderFilter"), mock.AnythingOfType("int"),
 			mock.AnythingOfType("int")).Return(nil, &mysql.MySQLError{}).Once()
 
-		uc := NewOrderUsecase(mockOrderRepo, mockMapClient)
-		_, err := uc.ListOrders(mockPage, mockLimit)
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.ListOrders(mockAdminCtx(), mockPage, mockLimit)
 
 		assert.Equal(t, false, err == nil)
 
@@ -205,3 +1090,167 @@ func TestListOrders(t *testing.T) {
 		mockOrderRepo.AssertExpectations(t)
 	})
 }
+
+func mockMerchantCtx() context.Context {
+	return mockCallerCtx("merchant-1", auth.RoleMerchant)
+}
+
+func mockCourierCtx() context.Context {
+	return mockCallerCtx("courier-1", auth.RoleCourier)
+}
+
+func mockAdminCtx() context.Context {
+	return mockCallerCtx("admin-1", auth.RoleAdmin)
+}
+
+func mockCallerCtx(subject string, role string) context.Context {
+	return auth.WithIdentity(context.Background(), &auth.Identity{Subject: subject, Roles: []string{role}, Tenant: mockTenantID})
+}
+
+func TestGetOrder(t *testing.T) {
+	mockOrderRepo := new(mocks.OrderRepository)
+	mockMapClient := new(googlemap.MockMapClient)
+
+	mockOrderID := int64(1)
+	mockOrder := order.Order{ID: mockOrderID, Status: order.StatusTaken, MerchantID: "merchant-1", CourierID: "courier-1"}
+
+	t.Run("merchant-own-order", func(t *testing.T) {
+		tempOrder := mockOrder
+
+		mockOrderRepo.On("FindByID", mockTenantID, mockOrderID).Return(&tempOrder, nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		orderFound, err := uc.GetOrder(mockMerchantCtx(), mockOrderID)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, mockOrderID, orderFound.ID)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("merchant-other-order", func(t *testing.T) {
+		tempOrder := mockOrder
+		tempOrder.MerchantID = "merchant-2"
+
+		mockOrderRepo.On("FindByID", mockTenantID, mockOrderID).Return(&tempOrder, nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.GetOrder(mockMerchantCtx(), mockOrderID)
+
+		assert.Equal(t, auth.ErrForbidden, err)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("courier-order-taken-by-other", func(t *testing.T) {
+		tempOrder := mockOrder
+		tempOrder.CourierID = "courier-2"
+
+		mockOrderRepo.On("FindByID", mockTenantID, mockOrderID).Return(&tempOrder, nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.GetOrder(mockCourierCtx(), mockOrderID)
+
+		assert.Equal(t, auth.ErrForbidden, err)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		tempOrder := mockOrder
+
+		mockOrderRepo.On("FindByID", mockTenantID, mockOrderID).Return(&tempOrder, nil).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.GetOrder(mockAdminCtx(), mockOrderID)
+
+		assert.Equal(t, true, err == nil)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("no-such-order", func(t *testing.T) {
+		mockOrderRepo.On("FindByID", mockTenantID, mockOrderID).Return(nil, sql.ErrNoRows).Once()
+
+		uc := NewOrderUsecase(mockOrderRepo, new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.GetOrder(mockAdminCtx(), mockOrderID)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		mockOrderRepo.AssertExpectations(t)
+	})
+}
+
+func TestListEvents(t *testing.T) {
+	mockMapClient := new(googlemap.MockMapClient)
+	mockAfterID := int64(5)
+	mockLimit := 100
+	mockArea := &order.Area{South: 22.2, West: 114.1, North: 22.4, East: 114.3}
+	mockEvents := []order.OrderEvent{{ID: 6, Type: order.EventOrderCreated, OrderID: 1, Status: order.StatusUnassigned}}
+
+	t.Run("merchant-own-orders", func(t *testing.T) {
+		mockEventRepo := new(mocks.OrderEventRepository)
+		mockEventRepo.On("FindAfter", mockTenantID, order.EventFilter{Area: mockArea, MerchantID: "merchant-1"}, mockAfterID, mockLimit).
+			Return(mockEvents, nil).Once()
+
+		uc := NewOrderUsecase(new(mocks.OrderRepository), mockEventRepo, new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		events, err := uc.ListEvents(mockMerchantCtx(), order.EventFilter{Area: mockArea}, mockAfterID, mockLimit)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, 1, len(events))
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("courier-cannot-widen-filter", func(t *testing.T) {
+		mockEventRepo := new(mocks.OrderEventRepository)
+		mockEventRepo.On("FindAfter", mockTenantID, order.EventFilter{Status: order.StatusTaken, Courier: "courier-1"}, mockAfterID, mockLimit).
+			Return([]order.OrderEvent{}, nil).Once()
+
+		uc := NewOrderUsecase(new(mocks.OrderRepository), mockEventRepo, new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.ListEvents(mockCourierCtx(), order.EventFilter{Status: order.StatusTaken, MerchantID: "merchant-1"}, mockAfterID, mockLimit)
+
+		assert.Equal(t, true, err == nil)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("courier-withdrawals-hide-other-couriers", func(t *testing.T) {
+		mockEventRepo := new(mocks.OrderEventRepository)
+		mockEventRepo.On("FindAfter", mockTenantID, order.EventFilter{Courier: "courier-1", Withdrawals: true}, mockAfterID, mockLimit).
+			Return([]order.OrderEvent{
+				{ID: 7, Type: order.EventOrderStatusChanged, OrderID: 1, Status: order.StatusTaken, CourierID: "courier-2"},
+				{ID: 8, Type: order.EventOrderStatusChanged, OrderID: 2, Status: order.StatusTaken, CourierID: "courier-1"},
+			}, nil).Once()
+
+		uc := NewOrderUsecase(new(mocks.OrderRepository), mockEventRepo, new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		events, err := uc.ListEvents(mockCourierCtx(), order.EventFilter{Withdrawals: true}, mockAfterID, mockLimit)
+
+		assert.Equal(t, true, err == nil)
+		assert.Equal(t, "", events[0].CourierID)
+		assert.Equal(t, "courier-1", events[1].CourierID)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		mockEventRepo := new(mocks.OrderEventRepository)
+		mockEventRepo.On("FindAfter", mockTenantID, order.EventFilter{}, mockAfterID, mockLimit).Return(mockEvents, nil).Once()
+
+		uc := NewOrderUsecase(new(mocks.OrderRepository), mockEventRepo, new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.ListEvents(mockAdminCtx(), order.EventFilter{}, mockAfterID, mockLimit)
+
+		assert.Equal(t, true, err == nil)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("no-identity", func(t *testing.T) {
+		uc := NewOrderUsecase(new(mocks.OrderRepository), new(mocks.OrderEventRepository), new(mocks.QuoteRepository), mockServiceAreas(), mockMapClient, mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+		_, err := uc.ListEvents(context.Background(), order.EventFilter{}, mockAfterID, mockLimit)
+
+		assert.Equal(t, auth.ErrNoIdentity, err)
+	})
+}
+
+func TestLatestEventPosition(t *testing.T) {
+	mockEventRepo := new(mocks.OrderEventRepository)
+	mockEventRepo.On("LatestPosition", mockTenantID).Return(int64(42), nil).Once()
+
+	uc := NewOrderUsecase(new(mocks.OrderRepository), mockEventRepo, new(mocks.QuoteRepository), mockServiceAreas(), new(googlemap.MockMapClient), mockGeocoder, mockPricer, mockValidator, mockMaxBatchSize)
+	position, err := uc.LatestEventPosition(mockCourierCtx())
+
+	assert.Equal(t, true, err == nil)
+	assert.Equal(t, int64(42), position)
+}
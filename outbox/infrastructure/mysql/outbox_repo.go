@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"errors"
+	"time"
+
+	_lease "github.com/imylam/delivery-test/common/lease"
+	"github.com/imylam/delivery-test/outbox"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// errNoTenant guards against messages escaping the tenant scope
+var errNoTenant = errors.New("outbox repository: tenant required")
+
+const messageColumns string = "id, tenant_id, aggregate_type, aggregate_id, event_type, payload, attempts, last_error, " +
+	"next_attempt_at, created_at, published_at, buried_at"
+
+type outboxRepoMysql struct {
+	MysqlConn *sqlx.DB
+}
+
+// NewOutboxRepositoryMysql will create an object that represent the outbox.Repository interface
+func NewOutboxRepositoryMysql(mysqlConn *sqlx.DB) outbox.Repository {
+	return &outboxRepoMysql{mysqlConn}
+}
+
+// Insert records m in tx, it is called by the repositories of the aggregates
+// so the message is only recorded if their change is committed
+func Insert(tx *sqlx.Tx, m *outbox.Message) error {
+	q := "INSERT INTO outbox_messages (tenant_id, aggregate_type, aggregate_id, event_type, payload, next_attempt_at, created_at) " +
+		"VALUES (?,?,?,?,?,now(6),now(6))"
+
+	if m.TenantID == "" {
+		return errNoTenant
+	}
+
+	result, err := tx.Exec(q, m.TenantID, m.AggregateType, m.AggregateID, m.EventType, []byte(m.Payload))
+	if err != nil {
+		return err
+	}
+
+	m.ID, err = result.LastInsertId()
+	return err
+}
+
+// AcquireLease takes or renews the lease called name for holder, it returns
+// false while another holder has an unexpired lease
+func (repo *outboxRepoMysql) AcquireLease(name, holder string, lease time.Duration) (bool, error) {
+	return _lease.AcquireMysql(repo.MysqlConn, name, holder, lease)
+}
+
+// FindPending lists the oldest messages due to be published. Messages behind
+// one of their aggregate that is waiting for its next attempt are left out, so
+// the messages of an aggregate still leave in order. Buried messages are
+// neither listed nor hold back the later ones
+func (repo *outboxRepoMysql) FindPending(limit int) ([]outbox.Message, error) {
+	q := "SELECT " + messageColumns + " FROM outbox_messages m " +
+		"WHERE published_at IS NULL AND buried_at IS NULL AND next_attempt_at<=now(6) " +
+		"AND NOT EXISTS (SELECT 1 FROM outbox_messages w WHERE w.tenant_id=m.tenant_id " +
+		"AND w.aggregate_type=m.aggregate_type AND w.aggregate_id=m.aggregate_id AND w.id<m.id " +
+		"AND w.published_at IS NULL AND w.buried_at IS NULL AND w.next_attempt_at>now(6)) " +
+		"ORDER BY id LIMIT ?"
+
+	messages := []outbox.Message{}
+	err := repo.MysqlConn.Select(&messages, q, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (repo *outboxRepoMysql) MarkPublished(id int64) error {
+	q := "UPDATE outbox_messages SET attempts=attempts+1, last_error='', published_at=now(6) WHERE id=?"
+
+	_, err := repo.MysqlConn.Exec(q, id)
+	return err
+}
+
+// MarkFailed records a failed attempt, the message is tried again at next
+func (repo *outboxRepoMysql) MarkFailed(id int64, lastError string, next time.Time) error {
+	q := "UPDATE outbox_messages SET attempts=attempts+1, last_error=?, next_attempt_at=? WHERE id=?"
+
+	_, err := repo.MysqlConn.Exec(q, lastError, next, id)
+	return err
+}
+
+// Bury records the last failed attempt of a message and gives up on it, it is
+// kept for inspection and no longer holds back the later messages of its aggregate
+func (repo *outboxRepoMysql) Bury(id int64, lastError string) error {
+	q := "UPDATE outbox_messages SET attempts=attempts+1, last_error=?, buried_at=now(6) WHERE id=?"
+
+	_, err := repo.MysqlConn.Exec(q, lastError, id)
+	return err
+}
+
+// DeletePublished deletes up to limit messages published longer than retention ago
+func (repo *outboxRepoMysql) DeletePublished(retention time.Duration, limit int) (int64, error) {
+	q := "DELETE FROM outbox_messages WHERE published_at < now(6) - INTERVAL ? MICROSECOND ORDER BY id LIMIT ?"
+
+	result, err := repo.MysqlConn.Exec(q, retention.Microseconds(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,103 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/outbox"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+const mockTenantID string = "brand-a"
+
+func TestInsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	t.Run("success", func(t *testing.T) {
+		m := &outbox.Message{TenantID: mockTenantID, AggregateType: outbox.AggregateOrder, AggregateID: 5,
+			EventType: "order.created", Payload: []byte(`{"id":5}`)}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO outbox_messages").
+			WithArgs(mockTenantID, outbox.AggregateOrder, int64(5), "order.created", []byte(`{"id":5}`)).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+
+		tx, _ := sqlxDB.Beginx()
+		err := Insert(tx, m)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(3), m.ID)
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		err := Insert(nil, &outbox.Message{})
+
+		assert.Equal(t, errNoTenant, err)
+	})
+}
+
+func TestFindPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	columns := []string{"id", "tenant_id", "aggregate_type", "aggregate_id", "event_type", "payload", "attempts", "last_error",
+		"next_attempt_at", "created_at", "published_at", "buried_at"}
+
+	mock.ExpectQuery("SELECT (.+) FROM outbox_messages m WHERE published_at IS NULL AND buried_at IS NULL AND next_attempt_at<=now\\(6\\) " +
+		"AND NOT EXISTS \\(SELECT 1 FROM outbox_messages w WHERE (.+) AND w.id<m.id (.+) AND w.next_attempt_at>now\\(6\\)\\) " +
+		"ORDER BY id LIMIT \\?").
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(3, mockTenantID, outbox.AggregateOrder, 5, "order.created", []byte(`{"id":5}`), 0, "", time.Now(), time.Now(), nil, nil))
+
+	repo := NewOutboxRepositoryMysql(sqlxDB)
+	messages, err := repo.FindPending(100)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(messages))
+	assert.Equal(t, `{"id":5}`, string(messages[0].Payload))
+	assert.Equal(t, true, messages[0].PublishedAt == nil)
+}
+
+func TestMarkMessages(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	next := time.Now().Add(5 * time.Second)
+
+	mock.ExpectExec("UPDATE outbox_messages SET attempts=attempts\\+1, last_error='', published_at=now\\(6\\) WHERE id=\\?").
+		WithArgs(int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE outbox_messages SET attempts=attempts\\+1, last_error=\\?, next_attempt_at=\\? WHERE id=\\?").
+		WithArgs("unexpected status 503", next, int64(4)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE outbox_messages SET attempts=attempts\\+1, last_error=\\?, buried_at=now\\(6\\) WHERE id=\\?").
+		WithArgs("unexpected status 400", int64(5)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM outbox_messages WHERE published_at < now\\(6\\) - INTERVAL \\? MICROSECOND ORDER BY id LIMIT \\?").
+		WithArgs(int64(3600000000), 1000).WillReturnResult(sqlmock.NewResult(0, 12))
+
+	repo := NewOutboxRepositoryMysql(sqlxDB)
+	assert.Equal(t, nil, repo.MarkPublished(3))
+	assert.Equal(t, nil, repo.MarkFailed(4, "unexpected status 503", next))
+	assert.Equal(t, nil, repo.Bury(5, "unexpected status 400"))
+	deleted, err := repo.DeletePublished(time.Hour, 1000)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(12), deleted)
+	assert.Equal(t, nil, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,120 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/imylam/delivery-test/outbox"
+	"github.com/stretchr/testify/mock"
+)
+
+// Repository is a mock type for the Repository type
+type Repository struct {
+	mock.Mock
+}
+
+// AcquireLease provides a mock function with given fields: name, holder, lease
+func (_m *Repository) AcquireLease(name string, holder string, lease time.Duration) (bool, error) {
+	ret := _m.Called(name, holder, lease)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string, time.Duration) bool); ok {
+		r0 = rf(name, holder, lease)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, time.Duration) error); ok {
+		r1 = rf(name, holder, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindPending provides a mock function with given fields: limit
+func (_m *Repository) FindPending(limit int) ([]outbox.Message, error) {
+	ret := _m.Called(limit)
+
+	var r0 []outbox.Message
+	if rf, ok := ret.Get(0).(func(int) []outbox.Message); ok {
+		r0 = rf(limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]outbox.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkPublished provides a mock function with given fields: id
+func (_m *Repository) MarkPublished(id int64) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MarkFailed provides a mock function with given fields: id, lastError, next
+func (_m *Repository) MarkFailed(id int64, lastError string, next time.Time) error {
+	ret := _m.Called(id, lastError, next)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, string, time.Time) error); ok {
+		r0 = rf(id, lastError, next)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Bury provides a mock function with given fields: id, lastError
+func (_m *Repository) Bury(id int64, lastError string) error {
+	ret := _m.Called(id, lastError)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, string) error); ok {
+		r0 = rf(id, lastError)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeletePublished provides a mock function with given fields: retention, limit
+func (_m *Repository) DeletePublished(retention time.Duration, limit int) (int64, error) {
+	ret := _m.Called(retention, limit)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(time.Duration, int) int64); ok {
+		r0 = rf(retention, limit)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Duration, int) error); ok {
+		r1 = rf(retention, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/imylam/delivery-test/outbox"
+	"github.com/stretchr/testify/mock"
+)
+
+// Sink is a mock type for the Sink type
+type Sink struct {
+	mock.Mock
+}
+
+// Publish provides a mock function with given fields: ctx, m
+func (_m *Sink) Publish(ctx context.Context, m *outbox.Message) error {
+	ret := _m.Called(ctx, m)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *outbox.Message) error); ok {
+		r0 = rf(ctx, m)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,47 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const AggregateOrder string = "order"
+
+// Message represents an event recorded in the same transaction as the change
+// it describes, waiting to be published. Messages of an aggregate are
+// published in the order they were recorded. A message that keeps failing is
+// eventually buried: it is no longer tried and stays in the table until an
+// operator deals with it
+type Message struct {
+	ID            int64           `json:"id" db:"id"`
+	TenantID      string          `json:"tenant_id" db:"tenant_id"`
+	AggregateType string          `json:"aggregate_type" db:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id" db:"aggregate_id"`
+	EventType     string          `json:"type" db:"event_type"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	Attempts      int             `json:"-" db:"attempts"`
+	LastError     string          `json:"-" db:"last_error"`
+	NextAttemptAt time.Time       `json:"-" db:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	PublishedAt   *time.Time      `json:"-" db:"published_at"`
+	BuriedAt      *time.Time      `json:"-" db:"buried_at"`
+}
+
+// Sink represents where the relay publishes messages, e.g. a log, an HTTP
+// endpoint or a message broker. A message is published at least once, so
+// consumers should skip the IDs they have already seen
+type Sink interface {
+	Publish(context.Context, *Message) error
+}
+
+// Repository represents Outbox Repository. It spans every tenant since a
+// single relay publishes the messages of all of them
+type Repository interface {
+	AcquireLease(string, string, time.Duration) (bool, error)
+	FindPending(int) ([]Message, error)
+	MarkPublished(int64) error
+	MarkFailed(int64, string, time.Time) error
+	Bury(int64, string) error
+	DeletePublished(time.Duration, int) (int64, error)
+}
@@ -0,0 +1,154 @@
+package relay
+
+import (
+	"context"
+	"time"
+
+	"github.com/imylam/delivery-test/common/lease"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/outbox"
+	"go.uber.org/zap"
+)
+
+const (
+	// leaseName is the lease relays on every instance compete for, only its
+	// holder publishes so messages leave in the order they were recorded
+	leaseName string = "outbox-relay"
+
+	// purgeBatchSize bounds the published messages deleted per poll
+	purgeBatchSize int = 1000
+
+	// maxErrorLength bounds the error stored on a message
+	maxErrorLength int = 255
+)
+
+// Relay publishes the pending outbox messages to a sink. Messages are marked
+// published only after the sink accepted them, so a crash in between
+// publishes them again. A message that fails holds back the later messages of
+// its aggregate until it is published, or buried after the max attempts
+type Relay struct {
+	repo   outbox.Repository
+	sink   outbox.Sink
+	cfg    configs.OutboxConfig
+	holder string
+	now    func() time.Time
+
+	leaseRenewAt time.Time
+}
+
+// NewRelay creates a Relay with the settings of cfg
+func NewRelay(repo outbox.Repository, sink outbox.Sink, cfg configs.OutboxConfig) *Relay {
+	return &Relay{
+		repo:   repo,
+		sink:   sink,
+		cfg:    cfg,
+		holder: lease.NewHolder(),
+		now:    time.Now,
+	}
+}
+
+// Run publishes messages every poll interval until ctx is done
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Publish(ctx); err != nil {
+			logger.Logger.Error("fail to relay outbox messages", zap.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publish sends a batch of pending messages to the sink if this relay holds
+// the lease, then deletes the messages published past the retention
+func (r *Relay) Publish(ctx context.Context) error {
+	held, err := r.renewLease()
+	if err != nil || !held {
+		return err
+	}
+
+	messages, err := r.repo.FindPending(r.cfg.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	// aggregates with a message not published yet, their later messages wait
+	blocked := map[aggregate]bool{}
+	for i := range messages {
+		m := &messages[i]
+		key := aggregate{m.TenantID, m.AggregateType, m.AggregateID}
+		if blocked[key] {
+			continue
+		}
+
+		// the lease must still be ours when the message is marked published
+		held, err := r.renewLease()
+		if err != nil || !held {
+			return err
+		}
+
+		if err := r.sink.Publish(ctx, m); err != nil {
+			if m.Attempts+1 >= r.cfg.MaxAttempts {
+				logger.Logger.Error("outbox message buried",
+					zap.Int64("id", m.ID), zap.Int("attempts", m.Attempts+1), zap.String("error", err.Error()))
+
+				if err := r.repo.Bury(m.ID, truncate(err.Error())); err != nil {
+					return err
+				}
+				continue
+			}
+
+			blocked[key] = true
+			logger.Logger.Debug("outbox message not published",
+				zap.Int64("id", m.ID), zap.Int("attempts", m.Attempts+1), zap.String("error", err.Error()))
+
+			if err := r.repo.MarkFailed(m.ID, truncate(err.Error()), r.now().Add(r.cfg.RetryInterval)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := r.repo.MarkPublished(m.ID); err != nil {
+			return err
+		}
+	}
+
+	_, err = r.repo.DeletePublished(r.cfg.Retention, purgeBatchSize)
+	return err
+}
+
+type aggregate struct {
+	tenantID      string
+	aggregateType string
+	aggregateID   int64
+}
+
+// renewLease takes or renews the lease once half of it has gone by
+func (r *Relay) renewLease() (bool, error) {
+	if r.now().Before(r.leaseRenewAt) {
+		return true, nil
+	}
+
+	held, err := r.repo.AcquireLease(leaseName, r.holder, r.cfg.Lease)
+	if err != nil || !held {
+		r.leaseRenewAt = time.Time{}
+		return false, err
+	}
+
+	r.leaseRenewAt = r.now().Add(r.cfg.Lease / 2)
+	return true, nil
+}
+
+func truncate(s string) string {
+	if len(s) > maxErrorLength {
+		return s[:maxErrorLength]
+	}
+	return s
+}
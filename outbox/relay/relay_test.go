@@ -0,0 +1,157 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/outbox"
+	"github.com/imylam/delivery-test/outbox/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+var mockNow = time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func mockConfig() configs.OutboxConfig {
+	return configs.OutboxConfig{
+		PollInterval:  time.Second,
+		BatchSize:     100,
+		Lease:         30 * time.Second,
+		RetryInterval: 5 * time.Second,
+		MaxAttempts:   3,
+		Retention:     time.Hour,
+	}
+}
+
+func newMockRelay(repo *mocks.Repository, sink *mocks.Sink) *Relay {
+	r := NewRelay(repo, sink, mockConfig())
+	r.holder = "relay-1"
+	r.now = func() time.Time { return mockNow }
+	return r
+}
+
+func mockMessage(id, orderID int64) outbox.Message {
+	return outbox.Message{ID: id, TenantID: "brand-a", AggregateType: outbox.AggregateOrder, AggregateID: orderID,
+		EventType: "order.created", Payload: []byte(`{}`), NextAttemptAt: mockNow}
+}
+
+// publishedIDs returns the IDs of the messages handed to the sink, in order
+func publishedIDs(sink *mocks.Sink) []int64 {
+	var ids []int64
+	for _, call := range sink.Calls {
+		ids = append(ids, call.Arguments.Get(1).(*outbox.Message).ID)
+	}
+	return ids
+}
+
+func TestPublish(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.Repository)
+		mockRepo.On("AcquireLease", leaseName, "relay-1", 30*time.Second).Return(true, nil).Once()
+		mockRepo.On("FindPending", 100).Return([]outbox.Message{mockMessage(1, 5), mockMessage(2, 6)}, nil).Once()
+		mockRepo.On("MarkPublished", int64(1)).Return(nil).Once()
+		mockRepo.On("MarkPublished", int64(2)).Return(nil).Once()
+		mockRepo.On("DeletePublished", time.Hour, purgeBatchSize).Return(int64(0), nil).Once()
+		mockSink := new(mocks.Sink)
+		mockSink.On("Publish", mock.Anything, mock.AnythingOfType("*outbox.Message")).Return(nil)
+
+		err := newMockRelay(mockRepo, mockSink).Publish(context.Background())
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []int64{1, 2}, publishedIDs(mockSink))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("lease-held-elsewhere", func(t *testing.T) {
+		mockRepo := new(mocks.Repository)
+		mockRepo.On("AcquireLease", leaseName, "relay-1", 30*time.Second).Return(false, nil).Once()
+
+		err := newMockRelay(mockRepo, new(mocks.Sink)).Publish(context.Background())
+
+		assert.Equal(t, nil, err)
+		mockRepo.AssertNotCalled(t, "FindPending", mock.Anything)
+	})
+
+	t.Run("failure-holds-back-its-order-only", func(t *testing.T) {
+		mockRepo := new(mocks.Repository)
+		mockRepo.On("AcquireLease", leaseName, "relay-1", 30*time.Second).Return(true, nil).Once()
+		mockRepo.On("FindPending", 100).
+			Return([]outbox.Message{mockMessage(1, 5), mockMessage(2, 6), mockMessage(3, 5), mockMessage(4, 6)}, nil).Once()
+		mockRepo.On("MarkFailed", int64(1), "connection refused", mockNow.Add(5*time.Second)).Return(nil).Once()
+		mockRepo.On("MarkPublished", int64(2)).Return(nil).Once()
+		mockRepo.On("MarkPublished", int64(4)).Return(nil).Once()
+		mockRepo.On("DeletePublished", time.Hour, purgeBatchSize).Return(int64(0), nil).Once()
+		mockSink := new(mocks.Sink)
+		mockSink.On("Publish", mock.Anything, mock.MatchedBy(func(m *outbox.Message) bool { return m.ID == 1 })).
+			Return(errors.New("connection refused")).Once()
+		mockSink.On("Publish", mock.Anything, mock.AnythingOfType("*outbox.Message")).Return(nil)
+
+		err := newMockRelay(mockRepo, mockSink).Publish(context.Background())
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []int64{1, 2, 4}, publishedIDs(mockSink))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failure-after-max-attempts-buries-message", func(t *testing.T) {
+		failing := mockMessage(1, 5)
+		failing.Attempts = 2
+
+		mockRepo := new(mocks.Repository)
+		mockRepo.On("AcquireLease", leaseName, "relay-1", 30*time.Second).Return(true, nil).Once()
+		mockRepo.On("FindPending", 100).Return([]outbox.Message{failing, mockMessage(2, 5)}, nil).Once()
+		mockRepo.On("Bury", int64(1), "unexpected status 400").Return(nil).Once()
+		mockRepo.On("MarkPublished", int64(2)).Return(nil).Once()
+		mockRepo.On("DeletePublished", time.Hour, purgeBatchSize).Return(int64(0), nil).Once()
+		mockSink := new(mocks.Sink)
+		mockSink.On("Publish", mock.Anything, mock.MatchedBy(func(m *outbox.Message) bool { return m.ID == 1 })).
+			Return(errors.New("unexpected status 400")).Once()
+		mockSink.On("Publish", mock.Anything, mock.AnythingOfType("*outbox.Message")).Return(nil)
+
+		err := newMockRelay(mockRepo, mockSink).Publish(context.Background())
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []int64{1, 2}, publishedIDs(mockSink))
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "MarkFailed", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("lease-renewed-and-lost-mid-batch", func(t *testing.T) {
+		mockRepo := new(mocks.Repository)
+		mockRepo.On("AcquireLease", leaseName, "relay-1", 30*time.Second).Return(true, nil).Once()
+		mockRepo.On("AcquireLease", leaseName, "relay-1", 30*time.Second).Return(false, nil).Once()
+		mockRepo.On("FindPending", 100).Return([]outbox.Message{mockMessage(1, 5), mockMessage(2, 6)}, nil).Once()
+		mockRepo.On("MarkPublished", int64(1)).Return(nil).Once()
+
+		r := newMockRelay(mockRepo, nil)
+		mockSink := new(mocks.Sink)
+		// the first message takes longer than half the lease
+		mockSink.On("Publish", mock.Anything, mock.AnythingOfType("*outbox.Message")).
+			Run(func(mock.Arguments) { r.now = func() time.Time { return mockNow.Add(20 * time.Second) } }).
+			Return(nil)
+		r.sink = mockSink
+
+		err := r.Publish(context.Background())
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []int64{1}, publishedIDs(mockSink))
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "DeletePublished", mock.Anything, mock.Anything)
+	})
+
+	t.Run("find-error", func(t *testing.T) {
+		mockRepo := new(mocks.Repository)
+		mockRepo.On("AcquireLease", leaseName, "relay-1", 30*time.Second).Return(true, nil).Once()
+		mockRepo.On("FindPending", 100).Return(nil, errors.New("db down")).Once()
+
+		err := newMockRelay(mockRepo, new(mocks.Sink)).Publish(context.Background())
+
+		assert.Equal(t, "db down", err.Error())
+	})
+}
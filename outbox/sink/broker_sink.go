@@ -0,0 +1,64 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/imylam/delivery-test/eventbus"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/outbox"
+)
+
+type brokerSink struct {
+	publisher eventbus.EventPublisher
+}
+
+// NewBrokerSink returns a sink putting the order messages on a message broker
+// as events. The messages without an event, such as those of other
+// aggregates, are skipped
+func NewBrokerSink(publisher eventbus.EventPublisher) outbox.Sink {
+	return &brokerSink{publisher: publisher}
+}
+
+func (s *brokerSink) Publish(ctx context.Context, m *outbox.Message) error {
+	e, err := toEvent(m)
+	if err != nil || e == nil {
+		return err
+	}
+
+	return s.publisher.Publish(ctx, e)
+}
+
+// toEvent returns the event of the order message m, nil if m has none. The
+// event takes the ID of m so that a message published again gives an event
+// consumers already saw
+func toEvent(m *outbox.Message) (*eventbus.Event, error) {
+	if m.AggregateType != outbox.AggregateOrder {
+		return nil, nil
+	}
+
+	var o order.Order
+	payload := order.OutboxPayload{Order: &o}
+	if err := json.Unmarshal(m.Payload, &payload); err != nil {
+		return nil, err
+	}
+	o.TenantID = m.TenantID
+	o.OriginLat = payload.Origin.Lat
+	o.OriginLng = payload.Origin.Lng
+
+	var e *eventbus.Event
+	switch {
+	case m.EventType == order.EventOrderCreated:
+		e = eventbus.NewOrderPlaced(&o, m.CreatedAt)
+	case m.EventType == order.EventOrderStatusChanged && o.Status == order.StatusUnassigned:
+		e = eventbus.NewOrderReleased(&o, m.CreatedAt)
+	case m.EventType == order.EventOrderStatusChanged && o.Status == order.StatusTaken:
+		e = eventbus.NewOrderTaken(&o, m.CreatedAt)
+	default:
+		return nil, nil
+	}
+	e.ID = strconv.FormatInt(m.ID, 10)
+
+	return e, nil
+}
@@ -0,0 +1,85 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/eventbus"
+	_eventbusMocks "github.com/imylam/delivery-test/eventbus/mocks"
+	"github.com/imylam/delivery-test/eventbus/publisher"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/outbox"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBrokerSink(t *testing.T) {
+	createdAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	mockMessage := func(eventType, payload string) *outbox.Message {
+		return &outbox.Message{ID: 7, TenantID: "brand-a", AggregateType: outbox.AggregateOrder, AggregateID: 5,
+			EventType: eventType, Payload: json.RawMessage(payload), CreatedAt: createdAt}
+	}
+
+	t.Run("order-placed", func(t *testing.T) {
+		eventPublisher := publisher.NewChannelPublisher(1)
+		m := mockMessage(order.EventOrderCreated, `{"id":5,"distance":1200,"status":"UNASSIGNED","merchant_id":"merchant-1",`+
+			`"origin":{"lat":22.300789,"lng":114.167815}}`)
+
+		err := NewBrokerSink(eventPublisher).Publish(context.Background(), m)
+
+		assert.Equal(t, nil, err)
+		e := <-eventPublisher.Events()
+		assert.Equal(t, "7", e.ID)
+		assert.Equal(t, eventbus.EventOrderPlaced, e.Type)
+		assert.Equal(t, createdAt, e.OccurredAt)
+		assert.Equal(t, "brand-a", e.TenantID)
+		assert.Equal(t, eventbus.Order{ID: 5, Status: order.StatusUnassigned, Distance: 1200, MerchantID: "merchant-1",
+			Origin: eventbus.Location{Lat: 22.300789, Lng: 114.167815}}, e.Order)
+	})
+
+	t.Run("status-changed", func(t *testing.T) {
+		eventPublisher := publisher.NewChannelPublisher(2)
+		sink := NewBrokerSink(eventPublisher)
+
+		assert.Equal(t, nil, sink.Publish(context.Background(),
+			mockMessage(order.EventOrderStatusChanged, `{"id":5,"status":"UNASSIGNED","merchant_id":"merchant-1"}`)))
+		assert.Equal(t, nil, sink.Publish(context.Background(),
+			mockMessage(order.EventOrderStatusChanged, `{"id":5,"status":"TAKEN","merchant_id":"merchant-1","courier_id":"courier-1"}`)))
+
+		released := <-eventPublisher.Events()
+		taken := <-eventPublisher.Events()
+		assert.Equal(t, eventbus.EventOrderReleased, released.Type)
+		assert.Equal(t, eventbus.EventOrderTaken, taken.Type)
+		assert.Equal(t, "courier-1", taken.Order.CourierID)
+	})
+
+	t.Run("no-event", func(t *testing.T) {
+		mockPublisher := new(_eventbusMocks.EventPublisher)
+		m := mockMessage(order.EventOrderStatusChanged, `{"id":5,"status":"SCHEDULED"}`)
+
+		err := NewBrokerSink(mockPublisher).Publish(context.Background(), m)
+
+		assert.Equal(t, nil, err)
+		mockPublisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("broker-error", func(t *testing.T) {
+		mockPublisher := new(_eventbusMocks.EventPublisher)
+		mockPublisher.On("Publish", mock.Anything, mock.AnythingOfType("*eventbus.Event")).
+			Return(errors.New("broker unavailable")).Once()
+
+		err := NewBrokerSink(mockPublisher).Publish(context.Background(), mockMessage(order.EventOrderCreated, `{"id":5}`))
+
+		assert.Equal(t, errors.New("broker unavailable"), err)
+		mockPublisher.AssertExpectations(t)
+	})
+
+	t.Run("malformed-payload", func(t *testing.T) {
+		err := NewBrokerSink(new(_eventbusMocks.EventPublisher)).Publish(context.Background(), mockMessage(order.EventOrderCreated, `{`))
+
+		assert.Equal(t, false, err == nil)
+	})
+}
@@ -0,0 +1,61 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/imylam/delivery-test/outbox"
+)
+
+// HeaderMessageID carries the message ID, receivers skip the IDs they already handled
+const HeaderMessageID string = "Idempotency-Key"
+
+// maxResponseBody is how much of a response is read before the connection is reused
+const maxResponseBody int64 = 64 << 10
+
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSink returns a sink POSTing every message as JSON to url, any
+// response other than 2xx fails the message
+func NewHTTPSink(url string, timeout time.Duration) outbox.Sink {
+	return &httpSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *httpSink) Publish(ctx context.Context, m *outbox.Message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderMessageID, strconv.FormatInt(m.ID, 10))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/outbox"
+)
+
+func TestHTTPSink(t *testing.T) {
+	m := &outbox.Message{ID: 7, TenantID: "brand-a", AggregateType: outbox.AggregateOrder, AggregateID: 5,
+		EventType: "order.created", Payload: json.RawMessage(`{"id":5}`), CreatedAt: time.Now()}
+
+	t.Run("success", func(t *testing.T) {
+		var header http.Header
+		var received map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header.Clone()
+			body, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &received)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		err := NewHTTPSink(server.URL, time.Second).Publish(context.Background(), m)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "7", header.Get(HeaderMessageID))
+		assert.Equal(t, "application/json", header.Get("Content-Type"))
+		assert.Equal(t, "order.created", received["type"])
+		assert.Equal(t, float64(5), received["aggregate_id"])
+		assert.Equal(t, map[string]interface{}{"id": float64(5)}, received["payload"])
+	})
+
+	t.Run("unexpected-status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		err := NewHTTPSink(server.URL, time.Second).Publish(context.Background(), m)
+
+		assert.Equal(t, "unexpected status 503", err.Error())
+	})
+}
@@ -0,0 +1,28 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/outbox"
+	"go.uber.org/zap"
+)
+
+type logSink struct{}
+
+// NewLogSink returns a sink writing every message to the service log, it never fails
+func NewLogSink() outbox.Sink {
+	return &logSink{}
+}
+
+func (s *logSink) Publish(_ context.Context, m *outbox.Message) error {
+	logger.Logger.Info("outbox message",
+		zap.Int64("id", m.ID),
+		zap.String("tenant_id", m.TenantID),
+		zap.String("aggregate_type", m.AggregateType),
+		zap.Int64("aggregate_id", m.AggregateID),
+		zap.String("type", m.EventType),
+		zap.ByteString("payload", m.Payload))
+
+	return nil
+}
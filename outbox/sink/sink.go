@@ -0,0 +1,34 @@
+package sink
+
+import (
+	"fmt"
+
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/eventbus/publisher"
+	"github.com/imylam/delivery-test/outbox"
+)
+
+const (
+	SinkLog    string = "log"
+	SinkHTTP   string = "http"
+	SinkBroker string = "broker"
+)
+
+// New returns the sink named by cfg.Sink, the broker sink publishes to the
+// message broker of eventsCfg
+func New(cfg configs.OutboxConfig, eventsCfg configs.EventsConfig) (outbox.Sink, error) {
+	switch cfg.Sink {
+	case SinkLog:
+		return NewLogSink(), nil
+	case SinkHTTP:
+		return NewHTTPSink(cfg.HTTPURL, cfg.HTTPTimeout), nil
+	case SinkBroker:
+		eventPublisher, err := publisher.New(eventsCfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewBrokerSink(eventPublisher), nil
+	default:
+		return nil, fmt.Errorf("outbox: unknown sink %q", cfg.Sink)
+	}
+}
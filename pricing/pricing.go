@@ -0,0 +1,149 @@
+package pricing
+
+import (
+	"math"
+	"time"
+
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/order"
+)
+
+// Price is an amount in the minor unit of Currency along with the version of
+// the tariff it was computed with
+type Price struct {
+	Amount        int64
+	Currency      string
+	TariffVersion string
+}
+
+// Trip is what a delivery is priced on, At is when the order is placed
+type Trip struct {
+	Distance int
+	Duration time.Duration
+	At       time.Time
+}
+
+// Pricer prices deliveries
+type Pricer interface {
+	// Price returns the price of trip for a tenant, order.ErrNoTariff when it has no tariff
+	Price(string, Trip) (Price, error)
+}
+
+type pricer struct {
+	tariffs map[string]*tariff
+	// fallback is the tariff of tenants without one, nil when there is none
+	fallback *tariff
+}
+
+type tariff struct {
+	configs.TariffConfig
+	loc     *time.Location
+	periods []period
+}
+
+// period is a TariffPeriod with its bounds in minutes since midnight
+type period struct {
+	from, to   int
+	multiplier float64
+}
+
+// New creates a Pricer with the tariffs of cfg, validated beforehand by configs.Load
+func New(cfg configs.PricingConfig) (Pricer, error) {
+	p := &pricer{tariffs: make(map[string]*tariff, len(cfg.Tariffs))}
+
+	for tenant, tc := range cfg.Tariffs {
+		t, err := newTariff(tc)
+		if err != nil {
+			return nil, err
+		}
+		p.tariffs[tenant] = t
+	}
+	if cfg.Default != nil {
+		t, err := newTariff(*cfg.Default)
+		if err != nil {
+			return nil, err
+		}
+		p.fallback = t
+	}
+
+	return p, nil
+}
+
+func newTariff(cfg configs.TariffConfig) (*tariff, error) {
+	loc, err := time.LoadLocation(cfg.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &tariff{TariffConfig: cfg, loc: loc}
+	for _, p := range cfg.Periods {
+		from, err := minuteOfDay(p.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := minuteOfDay(p.To)
+		if err != nil {
+			return nil, err
+		}
+		t.periods = append(t.periods, period{from: from, to: to, multiplier: p.Multiplier})
+	}
+
+	return t, nil
+}
+
+// Price prices trip with the tariff of tenant, or the default tariff
+func (p *pricer) Price(tenant string, trip Trip) (Price, error) {
+	t, ok := p.tariffs[tenant]
+	if !ok {
+		t = p.fallback
+	}
+	if t == nil {
+		return Price{}, order.ErrNoTariff
+	}
+
+	return Price{Amount: t.price(trip), Currency: t.Currency, TariffVersion: t.Version}, nil
+}
+
+func (t *tariff) price(trip Trip) int64 {
+	amount := float64(t.BaseFare) +
+		float64(t.PerKM)*float64(trip.Distance)/1000 +
+		float64(t.PerMinute)*trip.Duration.Minutes()
+	amount = math.Max(amount, float64(t.Minimum))
+
+	if m := t.multiplier(trip.At); m > 0 {
+		amount *= m
+	}
+	if t.Surge > 0 {
+		amount *= t.Surge
+	}
+
+	return int64(math.Round(amount))
+}
+
+// multiplier returns the multiplier of the first period at falls in, 0 if none
+func (t *tariff) multiplier(at time.Time) float64 {
+	local := at.In(t.loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	for _, p := range t.periods {
+		in := minute >= p.from && minute < p.to
+		if p.to <= p.from {
+			// spans midnight
+			in = minute >= p.from || minute < p.to
+		}
+		if in {
+			return p.multiplier
+		}
+	}
+
+	return 0
+}
+
+func minuteOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
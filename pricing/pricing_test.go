@@ -0,0 +1,62 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/order"
+)
+
+func TestPrice(t *testing.T) {
+	hkt := time.FixedZone("HKT", 8*60*60)
+	noon := time.Date(2022, 10, 1, 12, 0, 0, 0, hkt)
+
+	p, err := New(configs.PricingConfig{
+		Default: &configs.TariffConfig{
+			Version: "v1", Currency: "HKD", BaseFare: 2000, PerKM: 500, Minimum: 3000, TimeZone: "Asia/Hong_Kong",
+			Periods: []configs.TariffPeriod{
+				{From: "22:00", To: "06:00", Multiplier: 1.5},
+				{From: "18:00", To: "20:00", Multiplier: 1.2},
+			},
+		},
+		Tariffs: map[string]configs.TariffConfig{
+			"brand-a": {Version: "v2", Currency: "USD", BaseFare: 100, PerKM: 100, PerMinute: 10, Surge: 2},
+		},
+	})
+	assert.Equal(t, nil, err)
+
+	tests := []struct {
+		name   string
+		tenant string
+		trip   Trip
+		want   Price
+	}{
+		{"default-tariff", "brand-b", Trip{Distance: 5000, At: noon}, Price{Amount: 4500, Currency: "HKD", TariffVersion: "v1"}},
+		{"minimum", "brand-b", Trip{Distance: 1000, At: noon}, Price{Amount: 3000, Currency: "HKD", TariffVersion: "v1"}},
+		{"period", "brand-b", Trip{Distance: 5000, At: time.Date(2022, 10, 1, 19, 30, 0, 0, hkt)}, Price{Amount: 5400, Currency: "HKD", TariffVersion: "v1"}},
+		{"period-spanning-midnight", "brand-b", Trip{Distance: 5000, At: time.Date(2022, 10, 1, 2, 0, 0, 0, hkt)}, Price{Amount: 6750, Currency: "HKD", TariffVersion: "v1"}},
+		{"period-in-tariff-time-zone", "brand-b", Trip{Distance: 5000, At: time.Date(2022, 10, 1, 15, 0, 0, 0, time.UTC)}, Price{Amount: 6750, Currency: "HKD", TariffVersion: "v1"}},
+		{"period-end-excluded", "brand-b", Trip{Distance: 5000, At: time.Date(2022, 10, 1, 6, 0, 0, 0, hkt)}, Price{Amount: 4500, Currency: "HKD", TariffVersion: "v1"}},
+		{"tenant-tariff-with-duration-and-surge", "brand-a", Trip{Distance: 2500, Duration: 90 * time.Second, At: noon}, Price{Amount: 730, Currency: "USD", TariffVersion: "v2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, err := p.Price(tt.tenant, tt.trip)
+
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tt.want, price)
+		})
+	}
+}
+
+func TestPriceNoTariff(t *testing.T) {
+	p, err := New(configs.PricingConfig{Tariffs: map[string]configs.TariffConfig{"brand-a": {Version: "v1", Currency: "HKD"}}})
+	assert.Equal(t, nil, err)
+
+	_, err = p.Price("brand-b", Trip{Distance: 1000})
+
+	assert.Equal(t, order.ErrNoTariff, err)
+}
@@ -0,0 +1,234 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/order"
+)
+
+// earthRadius is the mean radius of the earth in meters
+const earthRadius float64 = 6371000
+
+// Validator checks orders against the business rules of their tenant
+type Validator interface {
+	// Validate returns an *order.ValidationError listing every rule o placed
+	// at breaks, nil when it breaks none
+	Validate(o *order.Order, at time.Time) error
+}
+
+// Chain is a Validator checking every order against the same rules, in order
+type Chain []order.Rule
+
+// Validate checks o against every rule of the chain
+func (c Chain) Validate(o *order.Order, at time.Time) error {
+	var violations []order.Violation
+	for _, r := range c {
+		if v := r.Check(o, at); v != nil {
+			violations = append(violations, *v)
+		}
+	}
+	if len(violations) > 0 {
+		return &order.ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+type validator struct {
+	chains map[string]Chain
+	// fallback is the chain of tenants without one, empty when there is none
+	fallback Chain
+}
+
+// New creates a Validator with the rules of cfg, validated beforehand by configs.Load
+func New(cfg configs.RulesConfig) (Validator, error) {
+	v := &validator{chains: make(map[string]Chain, len(cfg.Tenants))}
+
+	for tenant, rc := range cfg.Tenants {
+		c, err := NewChain(rc)
+		if err != nil {
+			return nil, err
+		}
+		v.chains[tenant] = c
+	}
+	if cfg.Default != nil {
+		c, err := NewChain(*cfg.Default)
+		if err != nil {
+			return nil, err
+		}
+		v.fallback = c
+	}
+
+	return v, nil
+}
+
+// Validate checks o with the rules of its tenant, or the default rules
+func (v *validator) Validate(o *order.Order, at time.Time) error {
+	c, ok := v.chains[o.TenantID]
+	if !ok {
+		c = v.fallback
+	}
+
+	return c.Validate(o, at)
+}
+
+// NewChain returns the rules of cfg
+func NewChain(cfg configs.RuleSetConfig) (Chain, error) {
+	var c Chain
+	if cfg.MinDistance > 0 {
+		c = append(c, MinDistance(cfg.MinDistance))
+	}
+	if cfg.MaxDistance > 0 {
+		c = append(c, MaxDistance(cfg.MaxDistance))
+	}
+	if cfg.DistinctEnds {
+		c = append(c, DistinctEnds{})
+	}
+	for _, z := range cfg.BlockedZones {
+		c = append(c, BlockedZone(z))
+	}
+	if cfg.OperatingHours != nil {
+		h, err := NewOperatingHours(*cfg.OperatingHours)
+		if err != nil {
+			return nil, err
+		}
+		c = append(c, h)
+	}
+
+	return c, nil
+}
+
+// MinDistance rejects orders travelling less meters
+type MinDistance int
+
+func (r MinDistance) Check(o *order.Order, _ time.Time) *order.Violation {
+	if o.Distance >= int(r) {
+		return nil
+	}
+
+	return &order.Violation{
+		Rule:    order.RuleMinDistance,
+		Message: fmt.Sprintf("distance of %d m is under the minimum of %d m", o.Distance, int(r)),
+	}
+}
+
+// MaxDistance rejects orders travelling more meters
+type MaxDistance int
+
+func (r MaxDistance) Check(o *order.Order, _ time.Time) *order.Violation {
+	if o.Distance <= int(r) {
+		return nil
+	}
+
+	return &order.Violation{
+		Rule:    order.RuleMaxDistance,
+		Message: fmt.Sprintf("distance of %d m is over the maximum of %d m", o.Distance, int(r)),
+	}
+}
+
+// DistinctEnds rejects orders starting where they end
+type DistinctEnds struct{}
+
+func (DistinctEnds) Check(o *order.Order, _ time.Time) *order.Violation {
+	if o.OriginLat != o.DestinationLat || o.OriginLng != o.DestinationLng {
+		return nil
+	}
+
+	return &order.Violation{Rule: order.RuleDistinctEnds, Message: "origin and destination are the same"}
+}
+
+// BlockedZone rejects orders starting, stopping or ending in the circle of the zone
+type BlockedZone configs.ZoneConfig
+
+func (r BlockedZone) Check(o *order.Order, _ time.Time) *order.Violation {
+	for _, s := range o.Stops {
+		if r.contains(s.Lat, s.Lng) {
+			return &order.Violation{Rule: order.RuleBlockedZone, Message: fmt.Sprintf("stop %d is in the blocked zone %s", s.Index, r.Name)}
+		}
+	}
+
+	var end string
+	switch {
+	case r.contains(o.OriginLat, o.OriginLng):
+		end = "origin"
+	case r.contains(o.DestinationLat, o.DestinationLng):
+		end = "destination"
+	default:
+		return nil
+	}
+
+	return &order.Violation{Rule: order.RuleBlockedZone, Message: fmt.Sprintf("%s is in the blocked zone %s", end, r.Name)}
+}
+
+func (r BlockedZone) contains(lat, lng float64) bool {
+	return distance(r.Lat, r.Lng, lat, lng) <= float64(r.Radius)
+}
+
+// OperatingHours rejects orders placed outside the hours
+type OperatingHours struct {
+	cfg      configs.OperatingHoursConfig
+	loc      *time.Location
+	from, to int
+}
+
+// NewOperatingHours returns the rule of cfg
+func NewOperatingHours(cfg configs.OperatingHoursConfig) (*OperatingHours, error) {
+	loc, err := time.LoadLocation(cfg.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	from, err := minuteOfDay(cfg.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := minuteOfDay(cfg.To)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OperatingHours{cfg: cfg, loc: loc, from: from, to: to}, nil
+}
+
+func (r *OperatingHours) Check(_ *order.Order, at time.Time) *order.Violation {
+	local := at.In(r.loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	open := minute >= r.from && minute < r.to
+	if r.to <= r.from {
+		// spans midnight
+		open = minute >= r.from || minute < r.to
+	}
+	if open {
+		return nil
+	}
+
+	return &order.Violation{
+		Rule: order.RuleOperatingHours,
+		Message: fmt.Sprintf("placed at %s, outside the operating hours from %s to %s",
+			local.Format("15:04"), r.cfg.From, r.cfg.To),
+	}
+}
+
+// distance returns the great-circle distance in meters between two points
+func distance(lat1, lng1, lat2, lng2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+func minuteOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,103 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/order"
+)
+
+func TestValidate(t *testing.T) {
+	hkt := time.FixedZone("HKT", 8*60*60)
+	noon := time.Date(2022, 10, 1, 12, 0, 0, 0, hkt)
+
+	v, err := New(configs.RulesConfig{
+		Default: &configs.RuleSetConfig{MaxDistance: 50000},
+		Tenants: map[string]configs.RuleSetConfig{
+			"brand-a": {
+				MinDistance: 500, MaxDistance: 20000, DistinctEnds: true,
+				BlockedZones:   []configs.ZoneConfig{{Name: "airport", Lat: 22.308, Lng: 113.9185, Radius: 3000}},
+				OperatingHours: &configs.OperatingHoursConfig{From: "08:00", To: "02:00", TimeZone: "Asia/Hong_Kong"},
+			},
+		},
+	})
+	assert.Equal(t, nil, err)
+
+	tests := []struct {
+		name  string
+		order order.Order
+		at    time.Time
+		want  []order.Violation
+	}{
+		{"valid", mockOrder("brand-a", 22.3, 114.17, 22.28, 114.16, 3000), noon, nil},
+		{"under-minimum", mockOrder("brand-a", 22.3, 114.17, 22.3, 114.171, 100), noon, []order.Violation{
+			{Rule: order.RuleMinDistance, Message: "distance of 100 m is under the minimum of 500 m"},
+		}},
+		{"over-maximum", mockOrder("brand-a", 22.3, 114.17, 22.5, 114.17, 25000), noon, []order.Violation{
+			{Rule: order.RuleMaxDistance, Message: "distance of 25000 m is over the maximum of 20000 m"},
+		}},
+		{"same-ends", mockOrder("brand-a", 22.3, 114.17, 22.3, 114.17, 0), noon, []order.Violation{
+			{Rule: order.RuleMinDistance, Message: "distance of 0 m is under the minimum of 500 m"},
+			{Rule: order.RuleDistinctEnds, Message: "origin and destination are the same"},
+		}},
+		{"blocked-destination", mockOrder("brand-a", 22.3, 114.17, 22.31, 113.93, 19000), noon, []order.Violation{
+			{Rule: order.RuleBlockedZone, Message: "destination is in the blocked zone airport"},
+		}},
+		{"blocked-stop", func() order.Order {
+			o := mockOrder("brand-a", 22.3, 114.17, 22.28, 114.16, 19000)
+			o.Stops = []order.Stop{{Index: 0, Lat: 22.3, Lng: 114.17}, {Index: 1, Lat: 22.31, Lng: 113.93}, {Index: 2, Lat: 22.28, Lng: 114.16}}
+			return o
+		}(), noon, []order.Violation{
+			{Rule: order.RuleBlockedZone, Message: "stop 1 is in the blocked zone airport"},
+		}},
+		{"open-after-midnight", mockOrder("brand-a", 22.3, 114.17, 22.28, 114.16, 3000), time.Date(2022, 10, 2, 1, 30, 0, 0, hkt), nil},
+		{"closed", mockOrder("brand-a", 22.3, 114.17, 22.28, 114.16, 3000), time.Date(2022, 10, 2, 2, 0, 0, 0, hkt), []order.Violation{
+			{Rule: order.RuleOperatingHours, Message: "placed at 02:00, outside the operating hours from 08:00 to 02:00"},
+		}},
+		{"closed-in-rule-time-zone", mockOrder("brand-a", 22.3, 114.17, 22.28, 114.16, 3000), time.Date(2022, 10, 1, 20, 0, 0, 0, time.UTC), []order.Violation{
+			{Rule: order.RuleOperatingHours, Message: "placed at 04:00, outside the operating hours from 08:00 to 02:00"},
+		}},
+		{"default-rules", mockOrder("brand-b", 22.3, 114.17, 22.3, 114.17, 60000), noon, []order.Violation{
+			{Rule: order.RuleMaxDistance, Message: "distance of 60000 m is over the maximum of 50000 m"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(&tt.order, tt.at)
+
+			if tt.want == nil {
+				assert.Equal(t, nil, err)
+				return
+			}
+			assert.Equal(t, &order.ValidationError{Violations: tt.want}, err)
+		})
+	}
+}
+
+func TestValidateNoRules(t *testing.T) {
+	v, err := New(configs.RulesConfig{})
+	assert.Equal(t, nil, err)
+
+	o := mockOrder("brand-a", 0, 0, 0, 0, 0)
+
+	assert.Equal(t, nil, v.Validate(&o, time.Now()))
+}
+
+func TestDistance(t *testing.T) {
+	// one degree of latitude is about 111 km
+	d := distance(0, 0, 1, 0)
+
+	assert.Equal(t, true, d > 111000 && d < 111300)
+}
+
+func mockOrder(tenant string, originLat, originLng, destLat, destLng float64, distance int) order.Order {
+	return order.Order{
+		TenantID:  tenant,
+		OriginLat: originLat, OriginLng: originLng,
+		DestinationLat: destLat, DestinationLng: destLng,
+		Distance: distance,
+	}
+}
@@ -0,0 +1,14 @@
+package rest
+
+import "github.com/imylam/delivery-test/servicearea"
+
+// ServiceAreaRequest represents the object of create and update service area request params
+type ServiceAreaRequest struct {
+	Name     string               `json:"name"`
+	Geometry servicearea.Geometry `json:"geometry"`
+}
+
+// ServiceAreaURI represents the object of service area request uri params
+type ServiceAreaURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
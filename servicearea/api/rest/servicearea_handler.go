@@ -0,0 +1,153 @@
+package rest
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/common/middleware"
+	resterrors "github.com/imylam/delivery-test/common/rest_errors"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/servicearea"
+	"github.com/imylam/delivery-test/servicearea/usecase"
+	"go.uber.org/zap"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	errInvalidResquestParams string = "invalid request params"
+	errInternalServer        string = "internal server error"
+	errUnauthorized          string = "unauthorized"
+	errForbidden             string = "forbidden"
+	errTenantRequired        string = "tenant required"
+	errServiceAreaNotFound   string = "service area not found"
+)
+
+// serviceAreaHandler represents the httphandler for handling requests relating to service areas
+type serviceAreaHandler struct {
+	areaUC servicearea.ServiceAreaUsecase
+}
+
+// NewServiceAreaHandler will initialize the service area endpoints, for admins only
+func NewServiceAreaHandler(g *gin.Engine, areaUC servicearea.ServiceAreaUsecase) {
+	handler := &serviceAreaHandler{
+		areaUC: areaUC,
+	}
+
+	areas := g.Group("/admin/service-areas", middleware.ResolveTenant(), middleware.RequirePermission(auth.PermissionAdmin))
+	areas.POST("", handler.createServiceArea)
+	areas.GET("", handler.listServiceAreas)
+	areas.GET("/:id", handler.getServiceArea)
+	areas.PUT("/:id", handler.updateServiceArea)
+	areas.DELETE("/:id", handler.deleteServiceArea)
+}
+
+func (h *serviceAreaHandler) createServiceArea(c *gin.Context) {
+	var req ServiceAreaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	area := &servicearea.ServiceArea{Name: req.Name, Geometry: req.Geometry}
+	err := h.areaUC.CreateServiceArea(c.Request.Context(), area)
+	if restErr := h.toRestError(err, "fail to create service area"); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+
+	c.Header("HTTP", "201")
+	c.JSON(http.StatusCreated, area)
+}
+
+func (h *serviceAreaHandler) listServiceAreas(c *gin.Context) {
+	areas, err := h.areaUC.ListServiceAreas(c.Request.Context())
+	if restErr := h.toRestError(err, "fail to list service areas"); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+
+	c.Header("HTTP", "200")
+	c.JSON(http.StatusOK, areas)
+}
+
+func (h *serviceAreaHandler) getServiceArea(c *gin.Context) {
+	var uri ServiceAreaURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	area, err := h.areaUC.GetServiceArea(c.Request.Context(), uri.ID)
+	if restErr := h.toRestError(err, "fail to get service area"); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+
+	c.Header("HTTP", "200")
+	c.JSON(http.StatusOK, area)
+}
+
+func (h *serviceAreaHandler) updateServiceArea(c *gin.Context) {
+	var uri ServiceAreaURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+	var req ServiceAreaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	area := &servicearea.ServiceArea{ID: uri.ID, Name: req.Name, Geometry: req.Geometry}
+	err := h.areaUC.UpdateServiceArea(c.Request.Context(), area)
+	if restErr := h.toRestError(err, "fail to update service area"); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+
+	c.Header("HTTP", "200")
+	c.JSON(http.StatusOK, area)
+}
+
+func (h *serviceAreaHandler) deleteServiceArea(c *gin.Context) {
+	var uri ServiceAreaURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	err := h.areaUC.DeleteServiceArea(c.Request.Context(), uri.ID)
+	if restErr := h.toRestError(err, "fail to delete service area"); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+
+	c.Header("HTTP", "204")
+	c.Status(http.StatusNoContent)
+}
+
+// toRestError converts errors returned by the usecase into rest errors, it
+// returns nil when err is nil
+func (h *serviceAreaHandler) toRestError(err error, logMsg string) resterrors.RestError {
+	switch err {
+	case nil:
+		return nil
+	case auth.ErrNoIdentity:
+		return resterrors.NewUnauthorizedError(errUnauthorized)
+	case auth.ErrForbidden:
+		return resterrors.NewForbiddenError(errForbidden)
+	case auth.ErrNoTenant:
+		return resterrors.NewBadRequestError(errTenantRequired)
+	case usecase.ErrInvalidName, servicearea.ErrGeometryType, servicearea.ErrNoPolygon,
+		servicearea.ErrInvalidRing, servicearea.ErrPosition:
+		return resterrors.NewBadRequestError(err.Error())
+	case sql.ErrNoRows:
+		return resterrors.NewNotFoundError(errServiceAreaNotFound)
+	default:
+		logger.Logger.Error(logMsg, zap.String("error", err.Error()))
+		return resterrors.NewInternalServerError(errInternalServer)
+	}
+}
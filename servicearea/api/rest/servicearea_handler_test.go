@@ -0,0 +1,258 @@
+package rest
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/common/middleware"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/openapi"
+	"github.com/imylam/delivery-test/servicearea"
+	"github.com/imylam/delivery-test/servicearea/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+const mockGeometry string = `{"type":"Polygon","coordinates":[[[114.15,22.28],[114.2,22.28],[114.2,22.33],[114.15,22.28]]]}`
+
+func TestCreateServiceArea(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("success", func(t *testing.T) {
+		body := `{"name":"Kowloon","geometry":` + mockGeometry + `}`
+
+		mockAreaUC := new(mocks.ServiceAreaUsecase)
+		mockAreaUC.On("CreateServiceArea", mock.Anything, mock.MatchedBy(func(a *servicearea.ServiceArea) bool {
+			return a.Name == "Kowloon" && a.Geometry.Type == servicearea.TypePolygon && len(a.Geometry.Polygons) == 1
+		})).Run(func(args mock.Arguments) {
+			a := args.Get(1).(*servicearea.ServiceArea)
+			a.ID, a.CreatedAt, a.UpdatedAt = 1, time.Now(), time.Now()
+		}).Return(nil).Once()
+
+		router := createGinRouterAs(auth.RoleAdmin)
+		NewServiceAreaHandler(router, mockAreaUC)
+
+		req, _ := http.NewRequest("POST", "/admin/service-areas", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var area servicearea.ServiceArea
+		json.Unmarshal(w.Body.Bytes(), &area)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, int64(1), area.ID)
+		assert.Equal(t, true, bytes.Contains(w.Body.Bytes(), []byte(`"geometry":`+mockGeometry)))
+		mockAreaUC.AssertExpectations(t)
+	})
+
+	t.Run("invalid-geometry", func(t *testing.T) {
+		body := `{"name":"Kowloon","geometry":{"type":"Polygon","coordinates":[[[114.15,22.28],[114.2,22.28],[114.2,22.33]]]}}`
+
+		mockAreaUC := new(mocks.ServiceAreaUsecase)
+		mockAreaUC.On("CreateServiceArea", mock.Anything, mock.Anything).Return(servicearea.ErrInvalidRing).Once()
+
+		router := createGinRouterAs(auth.RoleAdmin)
+		NewServiceAreaHandler(router, mockAreaUC)
+
+		req, _ := http.NewRequest("POST", "/admin/service-areas", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, true, bytes.Contains(w.Body.Bytes(), []byte(servicearea.ErrInvalidRing.Error())))
+	})
+
+	t.Run("point-rejected-by-spec", func(t *testing.T) {
+		body := `{"name":"Kowloon","geometry":{"type":"Point","coordinates":[114.15,22.28]}}`
+
+		router := createGinRouterAs(auth.RoleAdmin)
+		NewServiceAreaHandler(router, new(mocks.ServiceAreaUsecase))
+
+		req, _ := http.NewRequest("POST", "/admin/service-areas", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("merchant-forbidden", func(t *testing.T) {
+		body := `{"name":"Kowloon","geometry":` + mockGeometry + `}`
+
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewServiceAreaHandler(router, new(mocks.ServiceAreaUsecase))
+
+		req, _ := http.NewRequest("POST", "/admin/service-areas", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestListServiceAreas(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("success", func(t *testing.T) {
+		mockAreaUC := new(mocks.ServiceAreaUsecase)
+		mockAreaUC.On("ListServiceAreas", mock.Anything).Return([]servicearea.ServiceArea{createMockServiceArea(t)}, nil).Once()
+
+		router := createGinRouterAs(auth.RoleAdmin)
+		NewServiceAreaHandler(router, mockAreaUC)
+
+		req, _ := http.NewRequest("GET", "/admin/service-areas", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var areas []servicearea.ServiceArea
+		json.Unmarshal(w.Body.Bytes(), &areas)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, len(areas))
+		assert.Equal(t, true, areas[0].Geometry.Contains(22.29, 114.19))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockAreaUC := new(mocks.ServiceAreaUsecase)
+		mockAreaUC.On("ListServiceAreas", mock.Anything).Return(nil, errors.New("db down")).Once()
+
+		router := createGinRouterAs(auth.RoleAdmin)
+		NewServiceAreaHandler(router, mockAreaUC)
+
+		req, _ := http.NewRequest("GET", "/admin/service-areas", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestGetServiceArea(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("success", func(t *testing.T) {
+		area := createMockServiceArea(t)
+
+		mockAreaUC := new(mocks.ServiceAreaUsecase)
+		mockAreaUC.On("GetServiceArea", mock.Anything, int64(1)).Return(&area, nil).Once()
+
+		router := createGinRouterAs(auth.RoleAdmin)
+		NewServiceAreaHandler(router, mockAreaUC)
+
+		req, _ := http.NewRequest("GET", "/admin/service-areas/1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		mockAreaUC := new(mocks.ServiceAreaUsecase)
+		mockAreaUC.On("GetServiceArea", mock.Anything, int64(2)).Return(nil, sql.ErrNoRows).Once()
+
+		router := createGinRouterAs(auth.RoleAdmin)
+		NewServiceAreaHandler(router, mockAreaUC)
+
+		req, _ := http.NewRequest("GET", "/admin/service-areas/2", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestUpdateServiceArea(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	body := `{"name":"Kowloon East","geometry":` + mockGeometry + `}`
+
+	mockAreaUC := new(mocks.ServiceAreaUsecase)
+	mockAreaUC.On("UpdateServiceArea", mock.Anything, mock.MatchedBy(func(a *servicearea.ServiceArea) bool {
+		return a.ID == 1 && a.Name == "Kowloon East"
+	})).Run(func(args mock.Arguments) {
+		a := args.Get(1).(*servicearea.ServiceArea)
+		a.CreatedAt, a.UpdatedAt = time.Now(), time.Now()
+	}).Return(nil).Once()
+
+	router := createGinRouterAs(auth.RoleAdmin)
+	NewServiceAreaHandler(router, mockAreaUC)
+
+	req, _ := http.NewRequest("PUT", "/admin/service-areas/1", bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAreaUC.AssertExpectations(t)
+}
+
+func TestDeleteServiceArea(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("success", func(t *testing.T) {
+		mockAreaUC := new(mocks.ServiceAreaUsecase)
+		mockAreaUC.On("DeleteServiceArea", mock.Anything, int64(1)).Return(nil).Once()
+
+		router := createGinRouterAs(auth.RoleAdmin)
+		NewServiceAreaHandler(router, mockAreaUC)
+
+		req, _ := http.NewRequest("DELETE", "/admin/service-areas/1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("invalid-id", func(t *testing.T) {
+		router := createGinRouterAs(auth.RoleAdmin)
+		NewServiceAreaHandler(router, new(mocks.ServiceAreaUsecase))
+
+		req, _ := http.NewRequest("DELETE", "/admin/service-areas/0", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+var spec = loadSpec()
+
+func loadSpec() *openapi3.T {
+	doc, err := openapi.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	return doc
+}
+
+func createGinRouterAs(role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(middleware.HandleRestError)
+	router.Use(func(c *gin.Context) {
+		identity := &auth.Identity{Subject: role + "-1", Roles: []string{role}, Tenant: "brand-a"}
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+	})
+	// every request and response of the handler tests must match the spec
+	router.Use(middleware.ValidateOpenAPI(openapi.NewValidator(spec, true)))
+
+	return router
+}
+
+func createMockServiceArea(t *testing.T) servicearea.ServiceArea {
+	area := servicearea.ServiceArea{ID: 1, Name: "Kowloon", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := json.Unmarshal([]byte(mockGeometry), &area.Geometry); err != nil {
+		t.Fatal(err)
+	}
+
+	return area
+}
@@ -0,0 +1,175 @@
+package servicearea
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// GeoJSON geometry types of service areas
+const (
+	TypePolygon      string = "Polygon"
+	TypeMultiPolygon string = "MultiPolygon"
+)
+
+var (
+	ErrGeometryType = errors.New("geometry must be a GeoJSON Polygon or MultiPolygon")
+	ErrNoPolygon    = errors.New("geometry must have a polygon")
+	ErrInvalidRing  = errors.New("polygon rings must be closed and have at least 4 positions")
+	ErrPosition     = errors.New("positions must be a longitude between -180 and 180 and a latitude between -90 and 90")
+)
+
+// Geometry is a GeoJSON Polygon or MultiPolygon, a Polygon has a single
+// polygon. Rings crossing the antimeridian are not supported
+type Geometry struct {
+	Type     string
+	Polygons []Polygon
+}
+
+// Polygon is a list of linear rings, the first is the outline and the others
+// are holes in it
+type Polygon [][]Position
+
+// Position is a longitude and a latitude, in that order as GeoJSON has them,
+// and optionally an altitude
+type Position []float64
+
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// Validate checks g is a GeoJSON Polygon or MultiPolygon of closed rings of
+// valid positions
+func (g *Geometry) Validate() error {
+	if g.Type != TypePolygon && g.Type != TypeMultiPolygon {
+		return ErrGeometryType
+	}
+	if len(g.Polygons) == 0 {
+		return ErrNoPolygon
+	}
+
+	for _, p := range g.Polygons {
+		if len(p) == 0 {
+			return ErrNoPolygon
+		}
+		for _, ring := range p {
+			if len(ring) < 4 {
+				return ErrInvalidRing
+			}
+			for _, pos := range ring {
+				if len(pos) < 2 || len(pos) > 3 || pos[0] < -180 || pos[0] > 180 || pos[1] < -90 || pos[1] > 90 {
+					return ErrPosition
+				}
+			}
+			first, last := ring[0], ring[len(ring)-1]
+			if first[0] != last[0] || first[1] != last[1] {
+				return ErrInvalidRing
+			}
+		}
+	}
+
+	return nil
+}
+
+// Contains tells whether the point at lat, lng is inside a polygon of g and
+// not in one of its holes
+func (g *Geometry) Contains(lat, lng float64) bool {
+	for _, p := range g.Polygons {
+		if len(p) == 0 || !ringContains(p[0], lat, lng) {
+			continue
+		}
+
+		inHole := false
+		for _, hole := range p[1:] {
+			inHole = inHole || ringContains(hole, lat, lng)
+		}
+		if !inHole {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ringContains casts a ray from the point along its latitude and counts the
+// edges of ring it crosses, an odd count is inside
+func ringContains(ring []Position, lat, lng float64) bool {
+	in := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			in = !in
+		}
+	}
+
+	return in
+}
+
+// MarshalJSON implements json.Marshaler
+func (g Geometry) MarshalJSON() ([]byte, error) {
+	var coordinates interface{} = g.Polygons
+	if g.Type == TypePolygon && len(g.Polygons) == 1 {
+		coordinates = g.Polygons[0]
+	}
+	if g.Polygons == nil {
+		coordinates = []Polygon{}
+	}
+	raw, err := json.Marshal(coordinates)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(geoJSON{Type: g.Type, Coordinates: raw})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, the coordinates of types other
+// than Polygon and MultiPolygon are left out for Validate to reject
+func (g *Geometry) UnmarshalJSON(b []byte) error {
+	var raw geoJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	*g = Geometry{Type: raw.Type}
+	if len(raw.Coordinates) == 0 {
+		return nil
+	}
+	switch raw.Type {
+	case TypePolygon:
+		var p Polygon
+		if err := json.Unmarshal(raw.Coordinates, &p); err != nil {
+			return err
+		}
+		g.Polygons = []Polygon{p}
+	case TypeMultiPolygon:
+		if err := json.Unmarshal(raw.Coordinates, &g.Polygons); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Value implements driver.Valuer, geometries are stored as GeoJSON
+func (g Geometry) Value() (driver.Value, error) {
+	b, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (g *Geometry) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return g.UnmarshalJSON([]byte(v))
+	case []byte:
+		return g.UnmarshalJSON(v)
+	default:
+		return errors.New("servicearea: cannot scan geometry")
+	}
+}
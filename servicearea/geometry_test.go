@@ -0,0 +1,102 @@
+package servicearea
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// kowloon is a square around Tsim Sha Tsui with a hole in its middle
+const kowloon string = `{"type":"Polygon","coordinates":[` +
+	`[[114.15,22.28],[114.2,22.28],[114.2,22.33],[114.15,22.33],[114.15,22.28]],` +
+	`[[114.17,22.3],[114.18,22.3],[114.18,22.31],[114.17,22.31],[114.17,22.3]]]}`
+
+func TestGeometryJSON(t *testing.T) {
+	var g Geometry
+	err := json.Unmarshal([]byte(kowloon), &g)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TypePolygon, g.Type)
+	assert.Equal(t, 1, len(g.Polygons))
+	assert.Equal(t, 2, len(g.Polygons[0]))
+
+	b, err := json.Marshal(g)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, kowloon, string(b))
+
+	multi := `{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]],[[[2,2],[3,2],[3,3],[2,2]]]]}`
+	err = json.Unmarshal([]byte(multi), &g)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(g.Polygons))
+
+	b, err = json.Marshal(g)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, multi, string(b))
+
+	err = json.Unmarshal([]byte(`{"type":"Polygon","coordinates":[0,0]}`), &g)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestGeometryValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		geometry string
+		want     error
+	}{
+		{"polygon", kowloon, nil},
+		{"multi-polygon", `{"type":"MultiPolygon","coordinates":[[[[0,0,10],[1,0,10],[1,1,10],[0,0,10]]]]}`, nil},
+		{"point", `{"type":"Point","coordinates":[114.17,22.3]}`, ErrGeometryType},
+		{"no-coordinates", `{"type":"Polygon"}`, ErrNoPolygon},
+		{"no-ring", `{"type":"MultiPolygon","coordinates":[[]]}`, ErrNoPolygon},
+		{"too-few-positions", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`, ErrInvalidRing},
+		{"open-ring", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}`, ErrInvalidRing},
+		{"latitude-first", `{"type":"Polygon","coordinates":[[[22.3,114.1],[22.3,114.2],[22.4,114.2],[22.3,114.1]]]}`, ErrPosition},
+		{"short-position", `{"type":"Polygon","coordinates":[[[0],[1,0],[1,1],[0]]]}`, ErrPosition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g Geometry
+			err := json.Unmarshal([]byte(tt.geometry), &g)
+			assert.Equal(t, nil, err)
+
+			assert.Equal(t, tt.want, g.Validate())
+		})
+	}
+}
+
+func TestGeometryContains(t *testing.T) {
+	var g Geometry
+	err := json.Unmarshal([]byte(kowloon), &g)
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, true, g.Contains(22.29, 114.16))
+	assert.Equal(t, false, g.Contains(22.305, 114.175))
+	assert.Equal(t, false, g.Contains(22.34, 114.16))
+	assert.Equal(t, false, g.Contains(51.5, -0.12))
+
+	g.Polygons = append(g.Polygons, Polygon{{{114.174, 22.304}, {114.176, 22.304}, {114.176, 22.306}, {114.174, 22.304}}})
+	assert.Equal(t, true, g.Contains(22.3045, 114.1755))
+}
+
+func TestCovers(t *testing.T) {
+	var g Geometry
+	err := json.Unmarshal([]byte(kowloon), &g)
+	assert.Equal(t, nil, err)
+	areas := []ServiceArea{{Name: "kowloon", Geometry: g}}
+
+	assert.Equal(t, true, Covers(nil, 51.5, -0.12))
+	assert.Equal(t, true, Covers(areas, 22.29, 114.16))
+	assert.Equal(t, false, Covers(areas, 51.5, -0.12))
+}
+
+func TestGeometryScan(t *testing.T) {
+	var g Geometry
+	err := g.Scan([]byte(kowloon))
+	assert.Equal(t, nil, err)
+
+	v, err := g.Value()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, kowloon, v)
+
+	assert.NotEqual(t, nil, g.Scan(nil))
+}
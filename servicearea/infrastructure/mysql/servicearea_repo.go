@@ -0,0 +1,119 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/imylam/delivery-test/servicearea"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// errNoTenant guards against queries escaping the tenant scope
+var errNoTenant = errors.New("service area repository: tenant required")
+
+type serviceAreaRepoMysql struct {
+	MysqlConn *sqlx.DB
+}
+
+// NewServiceAreaRepositoryMysql will create an object that represent the servicearea.ServiceAreaRepository interface
+func NewServiceAreaRepositoryMysql(mysqlConn *sqlx.DB) servicearea.ServiceAreaRepository {
+	return &serviceAreaRepoMysql{mysqlConn}
+}
+
+func (repo *serviceAreaRepoMysql) Create(area *servicearea.ServiceArea) error {
+	q1 := "INSERT INTO service_areas (tenant_id, name, geometry, created_at, updated_at) VALUES (?,?,?,now(6),now(6))"
+	q2 := "SELECT * FROM service_areas WHERE tenant_id=? AND id=?"
+
+	if area.TenantID == "" {
+		return errNoTenant
+	}
+
+	result, err := repo.MysqlConn.Exec(q1, area.TenantID, area.Name, area.Geometry)
+	if err != nil {
+		return err
+	}
+
+	area.ID, err = result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	return repo.MysqlConn.QueryRowx(q2, area.TenantID, area.ID).StructScan(area)
+}
+
+func (repo *serviceAreaRepoMysql) Update(area *servicearea.ServiceArea) error {
+	q := "UPDATE service_areas SET name=?, geometry=?, updated_at=now(6) WHERE tenant_id=? AND id=?"
+
+	if area.TenantID == "" {
+		return errNoTenant
+	}
+
+	result, err := repo.MysqlConn.Exec(q, area.Name, area.Geometry, area.TenantID, area.ID)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result)
+}
+
+func (repo *serviceAreaRepoMysql) Delete(tenantID string, id int64) error {
+	q := "DELETE FROM service_areas WHERE tenant_id=? AND id=?"
+
+	if tenantID == "" {
+		return errNoTenant
+	}
+
+	result, err := repo.MysqlConn.Exec(q, tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result)
+}
+
+func (repo *serviceAreaRepoMysql) FindByID(tenantID string, id int64) (*servicearea.ServiceArea, error) {
+	q := "SELECT * FROM service_areas WHERE tenant_id=? AND id=?"
+
+	if tenantID == "" {
+		return nil, errNoTenant
+	}
+
+	var area servicearea.ServiceArea
+	err := repo.MysqlConn.QueryRowx(q, tenantID, id).StructScan(&area)
+	if err != nil {
+		return nil, err
+	}
+
+	return &area, nil
+}
+
+// FindAll lists the service areas of a tenant, every order placed is checked against them
+func (repo *serviceAreaRepoMysql) FindAll(tenantID string) ([]servicearea.ServiceArea, error) {
+	q := "SELECT * FROM service_areas WHERE tenant_id=? ORDER BY id"
+
+	if tenantID == "" {
+		return nil, errNoTenant
+	}
+
+	areas := []servicearea.ServiceArea{}
+	err := repo.MysqlConn.Select(&areas, q, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return areas, nil
+}
+
+// expectRow returns sql.ErrNoRows when a statement changed nothing
+func expectRow(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
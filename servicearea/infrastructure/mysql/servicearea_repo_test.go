@@ -0,0 +1,162 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/servicearea"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	mockTenantID string = "brand-a"
+	mockGeometry string = `{"type":"Polygon","coordinates":[[[114.15,22.28],[114.2,22.28],[114.2,22.33],[114.15,22.28]]]}`
+)
+
+var serviceAreaColumns = []string{"id", "tenant_id", "name", "geometry", "created_at", "updated_at"}
+
+func TestCreateServiceArea(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	t.Run("success", func(t *testing.T) {
+		area := &servicearea.ServiceArea{TenantID: mockTenantID, Name: "Kowloon", Geometry: mockGeometryValue(t)}
+
+		mock.ExpectExec("INSERT INTO service_areas \\(tenant_id, name, geometry, created_at, updated_at\\)").
+			WithArgs(mockTenantID, "Kowloon", mockGeometry).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectQuery("SELECT \\* FROM service_areas WHERE tenant_id=\\? AND id=\\?").
+			WithArgs(mockTenantID, int64(2)).
+			WillReturnRows(sqlmock.NewRows(serviceAreaColumns).
+				AddRow(2, mockTenantID, "Kowloon", []byte(mockGeometry), time.Now(), time.Now()))
+
+		repo := NewServiceAreaRepositoryMysql(sqlxDB)
+		err := repo.Create(area)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(2), area.ID)
+		assert.Equal(t, servicearea.TypePolygon, area.Geometry.Type)
+		assert.Equal(t, false, area.CreatedAt.IsZero())
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewServiceAreaRepositoryMysql(sqlxDB)
+		err := repo.Create(&servicearea.ServiceArea{})
+
+		assert.Equal(t, errNoTenant, err)
+	})
+}
+
+func TestUpdateServiceArea(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q := "UPDATE service_areas SET name=\\?, geometry=\\?, updated_at=now\\(6\\) WHERE tenant_id=\\? AND id=\\?"
+	area := &servicearea.ServiceArea{ID: 2, TenantID: mockTenantID, Name: "Kowloon", Geometry: mockGeometryValue(t)}
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(q).WithArgs("Kowloon", mockGeometry, mockTenantID, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		repo := NewServiceAreaRepositoryMysql(sqlxDB)
+		err := repo.Update(area)
+
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		mock.ExpectExec(q).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := NewServiceAreaRepositoryMysql(sqlxDB)
+		err := repo.Update(area)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
+
+func TestDeleteServiceArea(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q := "DELETE FROM service_areas WHERE tenant_id=\\? AND id=\\?"
+
+	mock.ExpectExec(q).WithArgs(mockTenantID, int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(q).WithArgs(mockTenantID, int64(3)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := NewServiceAreaRepositoryMysql(sqlxDB)
+
+	assert.Equal(t, nil, repo.Delete(mockTenantID, 2))
+	assert.Equal(t, sql.ErrNoRows, repo.Delete(mockTenantID, 3))
+	assert.Equal(t, errNoTenant, repo.Delete("", 2))
+	assert.Equal(t, nil, mock.ExpectationsWereMet())
+}
+
+func TestFindAllServiceAreas(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM service_areas WHERE tenant_id=\\? ORDER BY id").
+			WithArgs(mockTenantID).
+			WillReturnRows(sqlmock.NewRows(serviceAreaColumns).
+				AddRow(1, mockTenantID, "Kowloon", []byte(mockGeometry), time.Now(), time.Now()).
+				AddRow(2, mockTenantID, "Hong Kong Island", mockGeometry, time.Now(), time.Now()))
+
+		repo := NewServiceAreaRepositoryMysql(sqlxDB)
+		areas, err := repo.FindAll(mockTenantID)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, len(areas))
+		assert.Equal(t, true, areas[1].Geometry.Contains(22.29, 114.19))
+	})
+
+	t.Run("none", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM service_areas").WillReturnRows(sqlmock.NewRows(serviceAreaColumns))
+
+		repo := NewServiceAreaRepositoryMysql(sqlxDB)
+		areas, err := repo.FindAll(mockTenantID)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []servicearea.ServiceArea{}, areas)
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewServiceAreaRepositoryMysql(sqlxDB)
+		_, err := repo.FindAll("")
+
+		assert.Equal(t, errNoTenant, err)
+	})
+}
+
+func mockGeometryValue(t *testing.T) servicearea.Geometry {
+	var g servicearea.Geometry
+	if err := json.Unmarshal([]byte(mockGeometry), &g); err != nil {
+		t.Fatal(err)
+	}
+
+	return g
+}
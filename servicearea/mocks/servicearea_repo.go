@@ -0,0 +1,99 @@
+package mocks
+
+import (
+	"github.com/imylam/delivery-test/servicearea"
+	"github.com/stretchr/testify/mock"
+)
+
+// ServiceAreaRepository is a mock type for the ServiceAreaRepository type
+type ServiceAreaRepository struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: area
+func (_m *ServiceAreaRepository) Create(area *servicearea.ServiceArea) error {
+	ret := _m.Called(area)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*servicearea.ServiceArea) error); ok {
+		r0 = rf(area)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: area
+func (_m *ServiceAreaRepository) Update(area *servicearea.ServiceArea) error {
+	ret := _m.Called(area)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*servicearea.ServiceArea) error); ok {
+		r0 = rf(area)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: tenantID, id
+func (_m *ServiceAreaRepository) Delete(tenantID string, id int64) error {
+	ret := _m.Called(tenantID, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
+		r0 = rf(tenantID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindByID provides a mock function with given fields: tenantID, id
+func (_m *ServiceAreaRepository) FindByID(tenantID string, id int64) (*servicearea.ServiceArea, error) {
+	ret := _m.Called(tenantID, id)
+
+	var r0 *servicearea.ServiceArea
+	if rf, ok := ret.Get(0).(func(string, int64) *servicearea.ServiceArea); ok {
+		r0 = rf(tenantID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*servicearea.ServiceArea)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int64) error); ok {
+		r1 = rf(tenantID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindAll provides a mock function with given fields: tenantID
+func (_m *ServiceAreaRepository) FindAll(tenantID string) ([]servicearea.ServiceArea, error) {
+	ret := _m.Called(tenantID)
+
+	var r0 []servicearea.ServiceArea
+	if rf, ok := ret.Get(0).(func(string) []servicearea.ServiceArea); ok {
+		r0 = rf(tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]servicearea.ServiceArea)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
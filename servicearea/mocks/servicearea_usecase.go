@@ -0,0 +1,101 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/imylam/delivery-test/servicearea"
+	"github.com/stretchr/testify/mock"
+)
+
+// ServiceAreaUsecase is a mock type for the ServiceAreaUsecase type
+type ServiceAreaUsecase struct {
+	mock.Mock
+}
+
+// CreateServiceArea provides a mock function with given fields: ctx, area
+func (_m *ServiceAreaUsecase) CreateServiceArea(ctx context.Context, area *servicearea.ServiceArea) error {
+	ret := _m.Called(ctx, area)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *servicearea.ServiceArea) error); ok {
+		r0 = rf(ctx, area)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListServiceAreas provides a mock function with given fields: ctx
+func (_m *ServiceAreaUsecase) ListServiceAreas(ctx context.Context) ([]servicearea.ServiceArea, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []servicearea.ServiceArea
+	if rf, ok := ret.Get(0).(func(context.Context) []servicearea.ServiceArea); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]servicearea.ServiceArea)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetServiceArea provides a mock function with given fields: ctx, id
+func (_m *ServiceAreaUsecase) GetServiceArea(ctx context.Context, id int64) (*servicearea.ServiceArea, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *servicearea.ServiceArea
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *servicearea.ServiceArea); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*servicearea.ServiceArea)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateServiceArea provides a mock function with given fields: ctx, area
+func (_m *ServiceAreaUsecase) UpdateServiceArea(ctx context.Context, area *servicearea.ServiceArea) error {
+	ret := _m.Called(ctx, area)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *servicearea.ServiceArea) error); ok {
+		r0 = rf(ctx, area)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteServiceArea provides a mock function with given fields: ctx, id
+func (_m *ServiceAreaUsecase) DeleteServiceArea(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,51 @@
+package servicearea
+
+import (
+	"context"
+	"time"
+)
+
+// ServiceArea represents an area a tenant delivers in. A tenant without
+// service areas delivers anywhere, one with some only inside them
+type ServiceArea struct {
+	ID        int64     `json:"id" db:"id"`
+	TenantID  string    `json:"-" db:"tenant_id"`
+	Name      string    `json:"name" db:"name"`
+	Geometry  Geometry  `json:"geometry" db:"geometry"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Covers tells whether the point at lat, lng is inside one of areas, any
+// point is when there are none
+func Covers(areas []ServiceArea, lat, lng float64) bool {
+	if len(areas) == 0 {
+		return true
+	}
+	for _, a := range areas {
+		if a.Geometry.Contains(lat, lng) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceAreaUsecase represents ServiceArea Usecase, the caller is read from the context
+type ServiceAreaUsecase interface {
+	CreateServiceArea(context.Context, *ServiceArea) error
+	ListServiceAreas(context.Context) ([]ServiceArea, error)
+	GetServiceArea(context.Context, int64) (*ServiceArea, error)
+	UpdateServiceArea(context.Context, *ServiceArea) error
+	DeleteServiceArea(context.Context, int64) error
+}
+
+// ServiceAreaRepository represents ServiceArea Repository, every method is
+// scoped to the tenant given as first argument, Create and Update to the
+// TenantID of the area
+type ServiceAreaRepository interface {
+	Create(*ServiceArea) error
+	Update(*ServiceArea) error
+	Delete(string, int64) error
+	FindByID(string, int64) (*ServiceArea, error)
+	FindAll(string) ([]ServiceArea, error)
+}
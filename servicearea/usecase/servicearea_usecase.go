@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/servicearea"
+)
+
+// maxNameLength is the length of the name column
+const maxNameLength int = 255
+
+var ErrInvalidName = errors.New("name must be between 1 and 255 characters")
+
+type serviceAreaUsecase struct {
+	areaRepo servicearea.ServiceAreaRepository
+}
+
+// NewServiceAreaUsecase will create new a serviceAreaUsecase object representation of servicearea.ServiceAreaUsecase interface
+func NewServiceAreaUsecase(areaRepo servicearea.ServiceAreaRepository) servicearea.ServiceAreaUsecase {
+	return &serviceAreaUsecase{
+		areaRepo: areaRepo,
+	}
+}
+
+// CreateServiceArea adds an area to the ones the tenant delivers in, the
+// first one restricts orders to it
+func (uc *serviceAreaUsecase) CreateServiceArea(ctx context.Context, area *servicearea.ServiceArea) error {
+	caller, err := authorize(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validate(area); err != nil {
+		return err
+	}
+
+	area.TenantID = caller.Tenant
+	return uc.areaRepo.Create(area)
+}
+
+func (uc *serviceAreaUsecase) ListServiceAreas(ctx context.Context) ([]servicearea.ServiceArea, error) {
+	caller, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.areaRepo.FindAll(caller.Tenant)
+}
+
+func (uc *serviceAreaUsecase) GetServiceArea(ctx context.Context, id int64) (*servicearea.ServiceArea, error) {
+	caller, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.areaRepo.FindByID(caller.Tenant, id)
+}
+
+// UpdateServiceArea replaces the name and the geometry of an area
+func (uc *serviceAreaUsecase) UpdateServiceArea(ctx context.Context, area *servicearea.ServiceArea) error {
+	caller, err := authorize(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validate(area); err != nil {
+		return err
+	}
+
+	found, err := uc.areaRepo.FindByID(caller.Tenant, area.ID)
+	if err != nil {
+		return err
+	}
+	found.Name = area.Name
+	found.Geometry = area.Geometry
+
+	err = uc.areaRepo.Update(found)
+	if err != nil {
+		return err
+	}
+
+	*area = *found
+	return nil
+}
+
+// DeleteServiceArea deletes an area, deleting the last one lifts the restriction on orders
+func (uc *serviceAreaUsecase) DeleteServiceArea(ctx context.Context, id int64) error {
+	caller, err := authorize(ctx)
+	if err != nil {
+		return err
+	}
+
+	return uc.areaRepo.Delete(caller.Tenant, id)
+}
+
+// authorize returns the caller if they may manage the service areas of a tenant
+func authorize(ctx context.Context) (*auth.Identity, error) {
+	caller, err := auth.Authorize(ctx, auth.PermissionAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if caller.Tenant == "" {
+		return nil, auth.ErrNoTenant
+	}
+
+	return caller, nil
+}
+
+func validate(area *servicearea.ServiceArea) error {
+	area.Name = strings.TrimSpace(area.Name)
+	if area.Name == "" || len(area.Name) > maxNameLength {
+		return ErrInvalidName
+	}
+
+	return area.Geometry.Validate()
+}
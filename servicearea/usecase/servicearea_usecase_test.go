@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/servicearea"
+	"github.com/imylam/delivery-test/servicearea/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+const mockTenantID string = "brand-a"
+
+var mockGeometry = servicearea.Geometry{Type: servicearea.TypePolygon, Polygons: []servicearea.Polygon{
+	{{{114.15, 22.28}, {114.2, 22.28}, {114.2, 22.33}, {114.15, 22.28}}},
+}}
+
+func TestCreateServiceArea(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockAreaRepo := new(mocks.ServiceAreaRepository)
+		mockAreaRepo.On("Create", mock.AnythingOfType("*servicearea.ServiceArea")).Return(nil).Once()
+
+		area := &servicearea.ServiceArea{Name: " Kowloon ", Geometry: mockGeometry}
+		uc := NewServiceAreaUsecase(mockAreaRepo)
+		err := uc.CreateServiceArea(mockAdminCtx(), area)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, mockTenantID, area.TenantID)
+		assert.Equal(t, "Kowloon", area.Name)
+		mockAreaRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid-name", func(t *testing.T) {
+		uc := NewServiceAreaUsecase(new(mocks.ServiceAreaRepository))
+
+		for _, name := range []string{"", "  ", strings.Repeat("a", 256)} {
+			err := uc.CreateServiceArea(mockAdminCtx(), &servicearea.ServiceArea{Name: name, Geometry: mockGeometry})
+			assert.Equal(t, ErrInvalidName, err)
+		}
+	})
+
+	t.Run("invalid-geometry", func(t *testing.T) {
+		uc := NewServiceAreaUsecase(new(mocks.ServiceAreaRepository))
+		err := uc.CreateServiceArea(mockAdminCtx(), &servicearea.ServiceArea{Name: "Kowloon", Geometry: servicearea.Geometry{Type: "Point"}})
+
+		assert.Equal(t, servicearea.ErrGeometryType, err)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		uc := NewServiceAreaUsecase(new(mocks.ServiceAreaRepository))
+		err := uc.CreateServiceArea(mockCallerCtx("merchant-1", auth.RoleMerchant), &servicearea.ServiceArea{Name: "Kowloon", Geometry: mockGeometry})
+
+		assert.Equal(t, auth.ErrForbidden, err)
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "admin-1", Roles: []string{auth.RoleAdmin}})
+
+		uc := NewServiceAreaUsecase(new(mocks.ServiceAreaRepository))
+		err := uc.CreateServiceArea(ctx, &servicearea.ServiceArea{Name: "Kowloon", Geometry: mockGeometry})
+
+		assert.Equal(t, auth.ErrNoTenant, err)
+	})
+}
+
+func TestListServiceAreas(t *testing.T) {
+	mockAreaRepo := new(mocks.ServiceAreaRepository)
+	mockAreaRepo.On("FindAll", mockTenantID).Return([]servicearea.ServiceArea{{ID: 1, Name: "Kowloon"}}, nil).Once()
+
+	uc := NewServiceAreaUsecase(mockAreaRepo)
+	areas, err := uc.ListServiceAreas(mockAdminCtx())
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(areas))
+	mockAreaRepo.AssertExpectations(t)
+}
+
+func TestUpdateServiceArea(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockAreaRepo := new(mocks.ServiceAreaRepository)
+		mockAreaRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&servicearea.ServiceArea{ID: 1, TenantID: mockTenantID, Name: "Kowloon"}, nil).Once()
+		mockAreaRepo.On("Update", mock.MatchedBy(func(a *servicearea.ServiceArea) bool {
+			return a.ID == 1 && a.TenantID == mockTenantID && a.Name == "Kowloon East" && len(a.Geometry.Polygons) == 1
+		})).Return(nil).Once()
+
+		area := &servicearea.ServiceArea{ID: 1, Name: "Kowloon East", Geometry: mockGeometry}
+		uc := NewServiceAreaUsecase(mockAreaRepo)
+		err := uc.UpdateServiceArea(mockAdminCtx(), area)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, mockTenantID, area.TenantID)
+		mockAreaRepo.AssertExpectations(t)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		mockAreaRepo := new(mocks.ServiceAreaRepository)
+		mockAreaRepo.On("FindByID", mockTenantID, int64(2)).Return(nil, sql.ErrNoRows).Once()
+
+		uc := NewServiceAreaUsecase(mockAreaRepo)
+		err := uc.UpdateServiceArea(mockAdminCtx(), &servicearea.ServiceArea{ID: 2, Name: "Kowloon", Geometry: mockGeometry})
+
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
+
+func TestDeleteServiceArea(t *testing.T) {
+	mockAreaRepo := new(mocks.ServiceAreaRepository)
+	mockAreaRepo.On("Delete", mockTenantID, int64(1)).Return(nil).Once()
+
+	uc := NewServiceAreaUsecase(mockAreaRepo)
+	err := uc.DeleteServiceArea(mockAdminCtx(), 1)
+
+	assert.Equal(t, nil, err)
+	mockAreaRepo.AssertExpectations(t)
+}
+
+func mockAdminCtx() context.Context {
+	return mockCallerCtx("admin-1", auth.RoleAdmin)
+}
+
+func mockCallerCtx(subject string, role string) context.Context {
+	return auth.WithIdentity(context.Background(), &auth.Identity{Subject: subject, Roles: []string{role}, Tenant: mockTenantID})
+}
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// ErrPrivateAddress is returned when a webhook would reach an address of the
+// internal network
+var ErrPrivateAddress = errors.New("address is not public")
+
+// reservedNetworks lists the internal ranges net.IP does not classify itself
+var reservedNetworks = parseCIDRs(
+	"0.0.0.0/8",     // this network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+)
+
+// IsPublicIP tells whether webhooks may be sent to ip. Loopback, private,
+// link-local, multicast and other reserved addresses are refused, so that
+// subscriptions cannot reach the services of the internal network such as the
+// database or the cloud metadata endpoint
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+
+	for _, n := range reservedNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsPrivateHost tells whether the host of a webhook URL names the local
+// machine or is an address IsPublicIP refuses. Other host names are only
+// checked once resolved, by DialControl
+func IsPrivateHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && !IsPublicIP(ip)
+}
+
+// DialControl is a net.Dialer Control refusing connections to addresses
+// IsPublicIP refuses. It checks the address actually dialed, so a host name
+// resolving to an internal address, even after the URL was validated, cannot
+// get around the check
+func DialControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return ErrPrivateAddress
+	}
+
+	return nil
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+
+	return nets
+}
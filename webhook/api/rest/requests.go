@@ -0,0 +1,28 @@
+package rest
+
+// SubscriptionRequest represents the object of create and update webhook request params.
+// MerchantID is only taken from admins creating a subscription
+type SubscriptionRequest struct {
+	URL        string   `json:"url"`
+	Events     []string `json:"events,omitempty"`
+	Secret     string   `json:"secret,omitempty"`
+	Active     *bool    `json:"active,omitempty"`
+	MerchantID string   `json:"merchant_id,omitempty"`
+}
+
+// SubscriptionURI represents the object of webhook request uri params
+type SubscriptionURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// DeliveryURI represents the object of webhook delivery request uri params
+type DeliveryURI struct {
+	ID         int64 `uri:"id" binding:"required,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+}
+
+// ListDeliveryRequest represents the object of list webhook deliveries request params
+type ListDeliveryRequest struct {
+	Page  int `form:"page" binding:"required,min=1"`
+	Limit int `form:"limit" binding:"required,min=1,max=100"`
+}
@@ -0,0 +1,221 @@
+package rest
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/common/middleware"
+	resterrors "github.com/imylam/delivery-test/common/rest_errors"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/webhook"
+	"github.com/imylam/delivery-test/webhook/usecase"
+	"go.uber.org/zap"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	errInvalidResquestParams string = "invalid request params"
+	errInternalServer        string = "internal server error"
+	errUnauthorized          string = "unauthorized"
+	errForbidden             string = "forbidden"
+	errTenantRequired        string = "tenant required"
+	errWebhookNotFound       string = "webhook not found"
+	errDeliveryNotFound      string = "dead delivery not found"
+)
+
+// webhookHandler represents the httphandler for handling requests relating to webhooks
+type webhookHandler struct {
+	webhookUC webhook.WebhookUsecase
+}
+
+// NewWebhookHandler will initialize the webhook endpoints
+func NewWebhookHandler(g *gin.Engine, webhookUC webhook.WebhookUsecase) {
+	handler := &webhookHandler{
+		webhookUC: webhookUC,
+	}
+
+	webhooks := g.Group("/webhooks", middleware.ResolveTenant(), middleware.RequirePermission(auth.PermissionWebhook))
+	webhooks.POST("", handler.createSubscription)
+	webhooks.GET("", handler.listSubscriptions)
+	webhooks.GET("/:id", handler.getSubscription)
+	webhooks.PUT("/:id", handler.updateSubscription)
+	webhooks.DELETE("/:id", handler.deleteSubscription)
+	webhooks.GET("/:id/deliveries", handler.listDeliveries)
+	webhooks.POST("/:id/deliveries/:delivery_id/retry", handler.retryDelivery)
+}
+
+func (h *webhookHandler) createSubscription(c *gin.Context) {
+	var req SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	sub := toSubscription(req)
+	sub.MerchantID = req.MerchantID
+
+	err := h.webhookUC.CreateSubscription(c.Request.Context(), sub)
+	if restErr := h.toRestError(err, "fail to create webhook"); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+
+	c.Header("HTTP", "201")
+	c.JSON(http.StatusCreated, sub)
+}
+
+func (h *webhookHandler) listSubscriptions(c *gin.Context) {
+	subs, err := h.webhookUC.ListSubscriptions(c.Request.Context())
+	if restErr := h.toRestError(err, "fail to list webhooks"); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+
+	c.Header("HTTP", "200")
+	c.JSON(http.StatusOK, subs)
+}
+
+func (h *webhookHandler) getSubscription(c *gin.Context) {
+	var uri SubscriptionURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	sub, err := h.webhookUC.GetSubscription(c.Request.Context(), uri.ID)
+	if restErr := h.toRestError(err, "fail to get webhook"); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+
+	c.Header("HTTP", "200")
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *webhookHandler) updateSubscription(c *gin.Context) {
+	var uri SubscriptionURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+	var req SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	sub := toSubscription(req)
+	sub.ID = uri.ID
+
+	err := h.webhookUC.UpdateSubscription(c.Request.Context(), sub)
+	if restErr := h.toRestError(err, "fail to update webhook"); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+
+	c.Header("HTTP", "200")
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *webhookHandler) deleteSubscription(c *gin.Context) {
+	var uri SubscriptionURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	err := h.webhookUC.DeleteSubscription(c.Request.Context(), uri.ID)
+	if restErr := h.toRestError(err, "fail to delete webhook"); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+
+	c.Header("HTTP", "204")
+	c.Status(http.StatusNoContent)
+}
+
+func (h *webhookHandler) listDeliveries(c *gin.Context) {
+	var uri SubscriptionURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+	var req ListDeliveryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	deliveries, err := h.webhookUC.ListDeliveries(c.Request.Context(), uri.ID, req.Page, req.Limit)
+	if restErr := h.toRestError(err, "fail to list webhook deliveries"); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+
+	c.Header("HTTP", "200")
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *webhookHandler) retryDelivery(c *gin.Context) {
+	var uri DeliveryURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(resterrors.NewBadRequestError(errInvalidResquestParams))
+		return
+	}
+
+	err := h.webhookUC.RetryDelivery(c.Request.Context(), uri.ID, uri.DeliveryID)
+	if err == sql.ErrNoRows {
+		// the subscription was found, or the usecase would have failed before
+		c.Error(resterrors.NewNotFoundError(errDeliveryNotFound))
+		return
+	}
+	if restErr := h.toRestError(err, "fail to retry webhook delivery"); restErr != nil {
+		c.Error(restErr)
+		return
+	}
+
+	c.Header("HTTP", "204")
+	c.Status(http.StatusNoContent)
+}
+
+// toRestError converts errors returned by the usecase into rest errors, it
+// returns nil when err is nil
+func (h *webhookHandler) toRestError(err error, logMsg string) resterrors.RestError {
+	switch err {
+	case nil:
+		return nil
+	case auth.ErrNoIdentity:
+		return resterrors.NewUnauthorizedError(errUnauthorized)
+	case auth.ErrForbidden:
+		return resterrors.NewForbiddenError(errForbidden)
+	case auth.ErrNoTenant:
+		return resterrors.NewBadRequestError(errTenantRequired)
+	case usecase.ErrInvalidURL, usecase.ErrPrivateURL, usecase.ErrUnknownEvent:
+		return resterrors.NewBadRequestError(err.Error())
+	case sql.ErrNoRows:
+		return resterrors.NewNotFoundError(errWebhookNotFound)
+	default:
+		logger.Logger.Error(logMsg, zap.String("error", err.Error()))
+		return resterrors.NewInternalServerError(errInternalServer)
+	}
+}
+
+func toSubscription(req SubscriptionRequest) *webhook.Subscription {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	events := webhook.Events{}
+	if req.Events != nil {
+		events = req.Events
+	}
+
+	return &webhook.Subscription{
+		URL:    req.URL,
+		Events: events,
+		Secret: req.Secret,
+		Active: active,
+	}
+}
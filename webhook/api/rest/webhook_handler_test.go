@@ -0,0 +1,363 @@
+package rest
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/common/middleware"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/openapi"
+	"github.com/imylam/delivery-test/webhook"
+	"github.com/imylam/delivery-test/webhook/mocks"
+	"github.com/imylam/delivery-test/webhook/usecase"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateSubscription(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("success", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(SubscriptionRequest{URL: "https://example.com/hook", Events: []string{"order.created"}})
+
+		mockWebhookUC := new(mocks.WebhookUsecase)
+		mockWebhookUC.On("CreateSubscription", mock.Anything, mock.MatchedBy(func(s *webhook.Subscription) bool {
+			return s.URL == "https://example.com/hook" && s.Active && len(s.Events) == 1
+		})).Run(func(args mock.Arguments) {
+			s := args.Get(1).(*webhook.Subscription)
+			s.ID, s.OwnerID, s.Secret = 1, "merchant-1", "s3cret"
+			s.CreatedAt, s.UpdatedAt = time.Now(), time.Now()
+		}).Return(nil).Once()
+
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, mockWebhookUC)
+
+		req, _ := http.NewRequest("POST", "/webhooks", bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var sub webhook.Subscription
+		json.Unmarshal(w.Body.Bytes(), &sub)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, int64(1), sub.ID)
+		assert.Equal(t, "s3cret", sub.Secret)
+		mockWebhookUC.AssertExpectations(t)
+	})
+
+	t.Run("empty-events", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(SubscriptionRequest{URL: "https://example.com/hook"})
+
+		mockWebhookUC := new(mocks.WebhookUsecase)
+		mockWebhookUC.On("CreateSubscription", mock.Anything, mock.AnythingOfType("*webhook.Subscription")).Return(nil).Once()
+
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, mockWebhookUC)
+
+		req, _ := http.NewRequest("POST", "/webhooks", bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, true, bytes.Contains(w.Body.Bytes(), []byte(`"events":[]`)))
+	})
+
+	t.Run("invalid-url", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(SubscriptionRequest{URL: "example.com"})
+
+		mockWebhookUC := new(mocks.WebhookUsecase)
+		mockWebhookUC.On("CreateSubscription", mock.Anything, mock.Anything).Return(usecase.ErrInvalidURL).Once()
+
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, mockWebhookUC)
+
+		req, _ := http.NewRequest("POST", "/webhooks", bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, true, bytes.Contains(w.Body.Bytes(), []byte(usecase.ErrInvalidURL.Error())))
+	})
+
+	t.Run("unknown-event-rejected-by-spec", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(SubscriptionRequest{URL: "https://example.com/hook", Events: []string{"order.deleted"}})
+
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, new(mocks.WebhookUsecase))
+
+		req, _ := http.NewRequest("POST", "/webhooks", bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("courier-forbidden", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(SubscriptionRequest{URL: "https://example.com/hook"})
+
+		router := createGinRouterAs(auth.RoleCourier)
+		NewWebhookHandler(router, new(mocks.WebhookUsecase))
+
+		req, _ := http.NewRequest("POST", "/webhooks", bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("usecase-error", func(t *testing.T) {
+		jsonBytes, _ := json.Marshal(SubscriptionRequest{URL: "https://example.com/hook"})
+
+		mockWebhookUC := new(mocks.WebhookUsecase)
+		mockWebhookUC.On("CreateSubscription", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, mockWebhookUC)
+
+		req, _ := http.NewRequest("POST", "/webhooks", bytes.NewReader(jsonBytes))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestListSubscriptions(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	mockWebhookUC := new(mocks.WebhookUsecase)
+	mockWebhookUC.On("ListSubscriptions", mock.Anything).
+		Return([]webhook.Subscription{createMockSubscription()}, nil).Once()
+
+	router := createGinRouterAs(auth.RoleMerchant)
+	NewWebhookHandler(router, mockWebhookUC)
+
+	req, _ := http.NewRequest("GET", "/webhooks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var subs []webhook.Subscription
+	json.Unmarshal(w.Body.Bytes(), &subs)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, len(subs))
+}
+
+func TestGetSubscription(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("success", func(t *testing.T) {
+		sub := createMockSubscription()
+
+		mockWebhookUC := new(mocks.WebhookUsecase)
+		mockWebhookUC.On("GetSubscription", mock.Anything, int64(1)).Return(&sub, nil).Once()
+
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, mockWebhookUC)
+
+		req, _ := http.NewRequest("GET", "/webhooks/1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		mockWebhookUC := new(mocks.WebhookUsecase)
+		mockWebhookUC.On("GetSubscription", mock.Anything, int64(1)).Return(nil, sql.ErrNoRows).Once()
+
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, mockWebhookUC)
+
+		req, _ := http.NewRequest("GET", "/webhooks/1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("not-owner", func(t *testing.T) {
+		mockWebhookUC := new(mocks.WebhookUsecase)
+		mockWebhookUC.On("GetSubscription", mock.Anything, int64(1)).Return(nil, auth.ErrForbidden).Once()
+
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, mockWebhookUC)
+
+		req, _ := http.NewRequest("GET", "/webhooks/1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("invalid-id", func(t *testing.T) {
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, new(mocks.WebhookUsecase))
+
+		req, _ := http.NewRequest("GET", "/webhooks/abc", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestUpdateSubscription(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	active := false
+	jsonBytes, _ := json.Marshal(SubscriptionRequest{URL: "https://example.com/new", Active: &active})
+
+	mockWebhookUC := new(mocks.WebhookUsecase)
+	mockWebhookUC.On("UpdateSubscription", mock.Anything, mock.MatchedBy(func(s *webhook.Subscription) bool {
+		return s.ID == 1 && s.URL == "https://example.com/new" && !s.Active
+	})).Run(func(args mock.Arguments) {
+		*args.Get(1).(*webhook.Subscription) = createMockSubscription()
+	}).Return(nil).Once()
+
+	router := createGinRouterAs(auth.RoleMerchant)
+	NewWebhookHandler(router, mockWebhookUC)
+
+	req, _ := http.NewRequest("PUT", "/webhooks/1", bytes.NewReader(jsonBytes))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockWebhookUC.AssertExpectations(t)
+}
+
+func TestDeleteSubscription(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	mockWebhookUC := new(mocks.WebhookUsecase)
+	mockWebhookUC.On("DeleteSubscription", mock.Anything, int64(1)).Return(nil).Once()
+
+	router := createGinRouterAs(auth.RoleMerchant)
+	NewWebhookHandler(router, mockWebhookUC)
+
+	req, _ := http.NewRequest("DELETE", "/webhooks/1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockWebhookUC.AssertExpectations(t)
+}
+
+func TestListDeliveries(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("success", func(t *testing.T) {
+		delivery := webhook.Delivery{ID: 9, SubscriptionID: 1, EventID: 4, EventType: "order.created",
+			Payload: json.RawMessage(`{"event_id":4}`), Status: webhook.DeliveryDead, Attempts: 8, LastStatusCode: 500,
+			LastError: "unexpected status 500", NextAttemptAt: time.Now(), CreatedAt: time.Now(), UpdatedAt: time.Now()}
+
+		mockWebhookUC := new(mocks.WebhookUsecase)
+		mockWebhookUC.On("ListDeliveries", mock.Anything, int64(1), 2, 10).Return([]webhook.Delivery{delivery}, nil).Once()
+
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, mockWebhookUC)
+
+		req, _ := http.NewRequest("GET", "/webhooks/1/deliveries?page=2&limit=10", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var deliveries []webhook.Delivery
+		json.Unmarshal(w.Body.Bytes(), &deliveries)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, len(deliveries))
+		assert.Equal(t, webhook.DeliveryDead, deliveries[0].Status)
+	})
+
+	t.Run("missing-page", func(t *testing.T) {
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, new(mocks.WebhookUsecase))
+
+		req, _ := http.NewRequest("GET", "/webhooks/1/deliveries?limit=10", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestRetryDelivery(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("success", func(t *testing.T) {
+		mockWebhookUC := new(mocks.WebhookUsecase)
+		mockWebhookUC.On("RetryDelivery", mock.Anything, int64(1), int64(9)).Return(nil).Once()
+
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, mockWebhookUC)
+
+		req, _ := http.NewRequest("POST", "/webhooks/1/deliveries/9/retry", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("not-dead", func(t *testing.T) {
+		mockWebhookUC := new(mocks.WebhookUsecase)
+		mockWebhookUC.On("RetryDelivery", mock.Anything, int64(1), int64(9)).Return(sql.ErrNoRows).Once()
+
+		router := createGinRouterAs(auth.RoleMerchant)
+		NewWebhookHandler(router, mockWebhookUC)
+
+		req, _ := http.NewRequest("POST", "/webhooks/1/deliveries/9/retry", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, true, bytes.Contains(w.Body.Bytes(), []byte(errDeliveryNotFound)))
+	})
+}
+
+var spec = loadSpec()
+
+func loadSpec() *openapi3.T {
+	doc, err := openapi.Load()
+	if err != nil {
+		panic(err)
+	}
+	return doc
+}
+
+// createGinRouterAs creates a router whose requests are all made by a caller holding role
+func createGinRouterAs(role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(middleware.HandleRestError)
+	router.Use(func(c *gin.Context) {
+		identity := &auth.Identity{Subject: role + "-1", Roles: []string{role}, Tenant: "brand-a"}
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+	})
+	// every request and response of the handler tests must match the spec
+	router.Use(middleware.ValidateOpenAPI(openapi.NewValidator(spec, true)))
+
+	return router
+}
+
+func createMockSubscription() webhook.Subscription {
+	return webhook.Subscription{
+		ID:         1,
+		OwnerID:    "merchant-1",
+		MerchantID: "merchant-1",
+		URL:        "https://example.com/hook",
+		Events:     webhook.Events{},
+		Active:     true,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+}
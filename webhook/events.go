@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+)
+
+// Events is the list of event types a subscription wants, stored as a comma
+// separated list. An empty list matches every event
+type Events []string
+
+// Contains tells whether eventType is in the list
+func (e Events) Contains(eventType string) bool {
+	if len(e) == 0 {
+		return true
+	}
+	for _, t := range e {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements driver.Valuer
+func (e Events) Value() (driver.Value, error) {
+	return strings.Join(e, ","), nil
+}
+
+// Scan implements sql.Scanner
+func (e *Events) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return errors.New("webhook: cannot scan events")
+	}
+
+	*e = Events{}
+	if s != "" {
+		*e = strings.Split(s, ",")
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package mysql
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/imylam/delivery-test/webhook"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// deliveryColumns leaves out the lease columns, which only matter to Claim
+const deliveryColumns string = "id, tenant_id, subscription_id, event_id, event_type, payload, status, attempts, " +
+	"last_status_code, last_error, next_attempt_at, created_at, updated_at"
+
+type deliveryRepoMysql struct {
+	MysqlConn *sqlx.DB
+}
+
+// NewDeliveryRepositoryMysql will create an object that represent the webhook.DeliveryRepository interface
+func NewDeliveryRepositoryMysql(mysqlConn *sqlx.DB) webhook.DeliveryRepository {
+	return &deliveryRepoMysql{mysqlConn}
+}
+
+// CreateBatch inserts deliveries, skipping those already created for the same subscription and event
+func (repo *deliveryRepoMysql) CreateBatch(deliveries []webhook.Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	rows := make([]string, 0, len(deliveries))
+	args := make([]interface{}, 0, 7*len(deliveries))
+	for _, d := range deliveries {
+		if d.TenantID == "" {
+			return errNoTenant
+		}
+		rows = append(rows, "(?,?,?,?,?,?,?,now(6),now(6))")
+		args = append(args, d.TenantID, d.SubscriptionID, d.EventID, d.EventType, []byte(d.Payload), d.Status, d.NextAttemptAt)
+	}
+
+	q := "INSERT IGNORE INTO webhook_deliveries " +
+		"(tenant_id, subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at) VALUES " +
+		strings.Join(rows, ",")
+
+	_, err := repo.MysqlConn.Exec(q, args...)
+	return err
+}
+
+// FindBySubscription lists the deliveries of a subscription, latest first
+func (repo *deliveryRepoMysql) FindBySubscription(tenantID string, subscriptionID int64, limit, offset int) ([]webhook.Delivery, error) {
+	q := "SELECT " + deliveryColumns + " FROM webhook_deliveries WHERE tenant_id=? AND subscription_id=? ORDER BY id DESC LIMIT ? OFFSET ?"
+
+	if tenantID == "" {
+		return nil, errNoTenant
+	}
+
+	deliveries := []webhook.Delivery{}
+	err := repo.MysqlConn.Select(&deliveries, q, tenantID, subscriptionID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Retry makes a dead delivery pending again with no attempts
+func (repo *deliveryRepoMysql) Retry(tenantID string, subscriptionID, id int64) error {
+	q := "UPDATE webhook_deliveries SET status=?, attempts=0, next_attempt_at=now(6), updated_at=now(6) " +
+		"WHERE tenant_id=? AND subscription_id=? AND id=? AND status=?"
+
+	if tenantID == "" {
+		return errNoTenant
+	}
+
+	result, err := repo.MysqlConn.Exec(q, webhook.DeliveryPending, tenantID, subscriptionID, id, webhook.DeliveryDead)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result)
+}
+
+// Claim leases up to limit pending deliveries that are due, deliveries whose
+// lease ran out, e.g. because their worker died, can be claimed again
+func (repo *deliveryRepoMysql) Claim(limit int, lease time.Duration) ([]webhook.Delivery, error) {
+	q1 := "UPDATE webhook_deliveries SET locked_by=?, locked_until=now(6) + INTERVAL ? MICROSECOND " +
+		"WHERE status=? AND next_attempt_at<=now(6) AND (locked_until IS NULL OR locked_until<now(6)) " +
+		"ORDER BY next_attempt_at LIMIT ?"
+	q2 := "SELECT " + deliveryColumns + " FROM webhook_deliveries WHERE locked_by=? AND status=? ORDER BY id"
+
+	token, err := newLockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := repo.MysqlConn.Exec(q1, token, lease.Microseconds(), webhook.DeliveryPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectRow(result); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	deliveries := []webhook.Delivery{}
+	err = repo.MysqlConn.Select(&deliveries, q2, token, webhook.DeliveryPending)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (repo *deliveryRepoMysql) Complete(id int64, attempt webhook.Attempt) error {
+	return repo.record(id, webhook.DeliverySucceeded, attempt, nil)
+}
+
+// Fail records a failed attempt, the delivery is tried again at next
+func (repo *deliveryRepoMysql) Fail(id int64, attempt webhook.Attempt, next time.Time) error {
+	return repo.record(id, webhook.DeliveryPending, attempt, &next)
+}
+
+// Bury records the last failed attempt and leaves the delivery dead
+func (repo *deliveryRepoMysql) Bury(id int64, attempt webhook.Attempt) error {
+	return repo.record(id, webhook.DeliveryDead, attempt, nil)
+}
+
+func (repo *deliveryRepoMysql) record(id int64, status string, attempt webhook.Attempt, next *time.Time) error {
+	q := "UPDATE webhook_deliveries SET status=?, attempts=attempts+1, last_status_code=?, last_error=?, " +
+		"next_attempt_at=COALESCE(?, next_attempt_at), locked_by=NULL, locked_until=NULL, updated_at=now(6) WHERE id=?"
+
+	_, err := repo.MysqlConn.Exec(q, status, attempt.StatusCode, attempt.Error, next, id)
+	return err
+}
+
+func (repo *deliveryRepoMysql) Cursor(tenantID string) (int64, error) {
+	q := "SELECT position FROM webhook_cursors WHERE tenant_id=?"
+
+	if tenantID == "" {
+		return 0, errNoTenant
+	}
+
+	var position int64
+	err := repo.MysqlConn.Get(&position, q, tenantID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return position, nil
+}
+
+// AdvanceCursor moves the cursor of a tenant from one position to another, it
+// is left alone if another worker moved it in the meantime
+func (repo *deliveryRepoMysql) AdvanceCursor(tenantID string, from, to int64) error {
+	q := "INSERT INTO webhook_cursors (tenant_id, position) VALUES (?,?) " +
+		"ON DUPLICATE KEY UPDATE position=IF(position=?, VALUES(position), position)"
+
+	if tenantID == "" {
+		return errNoTenant
+	}
+
+	_, err := repo.MysqlConn.Exec(q, tenantID, to, from)
+	return err
+}
+
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
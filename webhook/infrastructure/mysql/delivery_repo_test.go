@@ -0,0 +1,224 @@
+package mysql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/webhook"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+var deliveryRowColumns = []string{"id", "tenant_id", "subscription_id", "event_id", "event_type", "payload", "status", "attempts",
+	"last_status_code", "last_error", "next_attempt_at", "created_at", "updated_at"}
+
+func TestCreateBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	now := time.Now()
+
+	t.Run("success", func(t *testing.T) {
+		deliveries := []webhook.Delivery{
+			{TenantID: mockTenantID, SubscriptionID: 1, EventID: 4, EventType: "order.created", Payload: []byte(`{}`), Status: webhook.DeliveryPending, NextAttemptAt: now},
+			{TenantID: mockTenantID, SubscriptionID: 2, EventID: 4, EventType: "order.created", Payload: []byte(`{}`), Status: webhook.DeliveryPending, NextAttemptAt: now},
+		}
+
+		mock.ExpectExec("INSERT IGNORE INTO webhook_deliveries (.+) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?,\\?,now\\(6\\),now\\(6\\)\\),\\(").
+			WithArgs(mockTenantID, int64(1), int64(4), "order.created", []byte(`{}`), webhook.DeliveryPending, now,
+				mockTenantID, int64(2), int64(4), "order.created", []byte(`{}`), webhook.DeliveryPending, now).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		repo := NewDeliveryRepositoryMysql(sqlxDB)
+		err := repo.CreateBatch(deliveries)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		repo := NewDeliveryRepositoryMysql(sqlxDB)
+		err := repo.CreateBatch(nil)
+
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewDeliveryRepositoryMysql(sqlxDB)
+		err := repo.CreateBatch([]webhook.Delivery{{SubscriptionID: 1}})
+
+		assert.Equal(t, errNoTenant, err)
+	})
+}
+
+func TestFindBySubscription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectQuery("SELECT (.+) FROM webhook_deliveries WHERE tenant_id=\\? AND subscription_id=\\? ORDER BY id DESC LIMIT \\? OFFSET \\?").
+		WithArgs(mockTenantID, int64(1), 10, 20).
+		WillReturnRows(sqlmock.NewRows(deliveryRowColumns).
+			AddRow(9, mockTenantID, 1, 4, "order.created", []byte(`{"event_id":4}`), webhook.DeliveryDead, 8, 500, "unexpected status 500", time.Now(), time.Now(), time.Now()))
+
+	repo := NewDeliveryRepositoryMysql(sqlxDB)
+	deliveries, err := repo.FindBySubscription(mockTenantID, 1, 10, 20)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(deliveries))
+	assert.Equal(t, `{"event_id":4}`, string(deliveries[0].Payload))
+	assert.Equal(t, webhook.DeliveryDead, deliveries[0].Status)
+}
+
+func TestRetryDelivery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q := "UPDATE webhook_deliveries SET status=\\?, attempts=0, (.+) WHERE tenant_id=\\? AND subscription_id=\\? AND id=\\? AND status=\\?"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(q).WithArgs(webhook.DeliveryPending, mockTenantID, int64(1), int64(9), webhook.DeliveryDead).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		repo := NewDeliveryRepositoryMysql(sqlxDB)
+		err := repo.Retry(mockTenantID, 1, 9)
+
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("not-dead", func(t *testing.T) {
+		mock.ExpectExec(q).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := NewDeliveryRepositoryMysql(sqlxDB)
+		err := repo.Retry(mockTenantID, 1, 9)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
+
+func TestClaim(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q1 := "UPDATE webhook_deliveries SET locked_by=\\?, locked_until=now\\(6\\) \\+ INTERVAL \\? MICROSECOND " +
+		"WHERE status=\\? AND next_attempt_at<=now\\(6\\) AND \\(locked_until IS NULL OR locked_until<now\\(6\\)\\) ORDER BY next_attempt_at LIMIT \\?"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(q1).WithArgs(sqlmock.AnyArg(), int64(60000000), webhook.DeliveryPending, 20).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT (.+) FROM webhook_deliveries WHERE locked_by=\\? AND status=\\? ORDER BY id").
+			WithArgs(sqlmock.AnyArg(), webhook.DeliveryPending).
+			WillReturnRows(sqlmock.NewRows(deliveryRowColumns).
+				AddRow(9, mockTenantID, 1, 4, "order.created", []byte(`{}`), webhook.DeliveryPending, 0, 0, "", time.Now(), time.Now(), time.Now()))
+
+		repo := NewDeliveryRepositoryMysql(sqlxDB)
+		deliveries, err := repo.Claim(20, time.Minute)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(deliveries))
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nothing-due", func(t *testing.T) {
+		mock.ExpectExec(q1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := NewDeliveryRepositoryMysql(sqlxDB)
+		deliveries, err := repo.Claim(20, time.Minute)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(deliveries))
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRecordAttempt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q := "UPDATE webhook_deliveries SET status=\\?, attempts=attempts\\+1, last_status_code=\\?, last_error=\\?, " +
+		"next_attempt_at=COALESCE\\(\\?, next_attempt_at\\), locked_by=NULL, locked_until=NULL, (.+) WHERE id=\\?"
+	next := time.Now().Add(time.Minute)
+
+	mock.ExpectExec(q).WithArgs(webhook.DeliverySucceeded, 200, "", nil, int64(9)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(q).WithArgs(webhook.DeliveryPending, 500, "unexpected status 500", next, int64(9)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(q).WithArgs(webhook.DeliveryDead, 0, "timeout", nil, int64(9)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := NewDeliveryRepositoryMysql(sqlxDB)
+	assert.Equal(t, nil, repo.Complete(9, webhook.Attempt{StatusCode: 200}))
+	assert.Equal(t, nil, repo.Fail(9, webhook.Attempt{StatusCode: 500, Error: "unexpected status 500"}, next))
+	assert.Equal(t, nil, repo.Bury(9, webhook.Attempt{Error: "timeout"}))
+	assert.Equal(t, nil, mock.ExpectationsWereMet())
+}
+
+func TestCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q := "SELECT position FROM webhook_cursors WHERE tenant_id=\\?"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(q).WithArgs(mockTenantID).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(42))
+
+		repo := NewDeliveryRepositoryMysql(sqlxDB)
+		position, err := repo.Cursor(mockTenantID)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(42), position)
+	})
+
+	t.Run("no-cursor", func(t *testing.T) {
+		mock.ExpectQuery(q).WithArgs(mockTenantID).WillReturnRows(sqlmock.NewRows([]string{"position"}))
+
+		repo := NewDeliveryRepositoryMysql(sqlxDB)
+		position, err := repo.Cursor(mockTenantID)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(0), position)
+	})
+}
+
+func TestAdvanceCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectExec("INSERT INTO webhook_cursors (.+) ON DUPLICATE KEY UPDATE position=IF\\(position=\\?, VALUES\\(position\\), position\\)").
+		WithArgs(mockTenantID, int64(7), int64(3)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	repo := NewDeliveryRepositoryMysql(sqlxDB)
+	err = repo.AdvanceCursor(mockTenantID, 3, 7)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, mock.ExpectationsWereMet())
+}
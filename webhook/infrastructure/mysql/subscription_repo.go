@@ -0,0 +1,175 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/imylam/delivery-test/webhook"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// errNoTenant guards against queries escaping the tenant scope
+var errNoTenant = errors.New("webhook repository: tenant required")
+
+type subscriptionRepoMysql struct {
+	MysqlConn *sqlx.DB
+}
+
+// NewSubscriptionRepositoryMysql will create an object that represent the webhook.SubscriptionRepository interface
+func NewSubscriptionRepositoryMysql(mysqlConn *sqlx.DB) webhook.SubscriptionRepository {
+	return &subscriptionRepoMysql{mysqlConn}
+}
+
+// Create inserts sub and, for the first subscription of a tenant, starts the
+// fan-out cursor at the latest order event so past events are not sent
+func (repo *subscriptionRepoMysql) Create(sub *webhook.Subscription) error {
+	q1 := "INSERT INTO webhook_subscriptions (tenant_id, owner_id, merchant_id, url, events, secret, active, created_at, updated_at) " +
+		"VALUES (?,?,?,?,?,?,?,now(6),now(6))"
+	q2 := "INSERT IGNORE INTO webhook_cursors (tenant_id, position) " +
+		"SELECT ?, COALESCE(MAX(position), 0) FROM order_events WHERE tenant_id=?"
+	q3 := "SELECT * FROM webhook_subscriptions WHERE tenant_id=? AND id=?"
+
+	if sub.TenantID == "" {
+		return errNoTenant
+	}
+
+	tx, err := repo.MysqlConn.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(q1, sub.TenantID, sub.OwnerID, sub.MerchantID, sub.URL, sub.Events, sub.Secret, sub.Active)
+	if err != nil {
+		return err
+	}
+
+	sub.ID, err = result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(q2, sub.TenantID, sub.TenantID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowx(q3, sub.TenantID, sub.ID).StructScan(sub)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *subscriptionRepoMysql) Update(sub *webhook.Subscription) error {
+	q := "UPDATE webhook_subscriptions SET url=?, events=?, secret=?, active=?, updated_at=now(6) WHERE tenant_id=? AND id=?"
+
+	if sub.TenantID == "" {
+		return errNoTenant
+	}
+
+	result, err := repo.MysqlConn.Exec(q, sub.URL, sub.Events, sub.Secret, sub.Active, sub.TenantID, sub.ID)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result)
+}
+
+func (repo *subscriptionRepoMysql) Delete(tenantID string, id int64) error {
+	q := "DELETE FROM webhook_subscriptions WHERE tenant_id=? AND id=?"
+
+	if tenantID == "" {
+		return errNoTenant
+	}
+
+	result, err := repo.MysqlConn.Exec(q, tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result)
+}
+
+func (repo *subscriptionRepoMysql) FindByID(tenantID string, id int64) (*webhook.Subscription, error) {
+	q := "SELECT * FROM webhook_subscriptions WHERE tenant_id=? AND id=?"
+
+	if tenantID == "" {
+		return nil, errNoTenant
+	}
+
+	var sub webhook.Subscription
+	err := repo.MysqlConn.QueryRowx(q, tenantID, id).StructScan(&sub)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+// FindAll lists the subscriptions of a tenant, only those of ownerID unless it is empty
+func (repo *subscriptionRepoMysql) FindAll(tenantID string, ownerID string) ([]webhook.Subscription, error) {
+	q := "SELECT * FROM webhook_subscriptions WHERE tenant_id=?"
+	args := []interface{}{tenantID}
+
+	if tenantID == "" {
+		return nil, errNoTenant
+	}
+	if ownerID != "" {
+		q += " AND owner_id=?"
+		args = append(args, ownerID)
+	}
+
+	subs := []webhook.Subscription{}
+	err := repo.MysqlConn.Select(&subs, q+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (repo *subscriptionRepoMysql) FindActive(tenantID string) ([]webhook.Subscription, error) {
+	q := "SELECT * FROM webhook_subscriptions WHERE tenant_id=? AND active=TRUE ORDER BY id"
+
+	if tenantID == "" {
+		return nil, errNoTenant
+	}
+
+	subs := []webhook.Subscription{}
+	err := repo.MysqlConn.Select(&subs, q, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// ActiveTenants lists the tenants with an active subscription, for the worker
+// going through every tenant
+func (repo *subscriptionRepoMysql) ActiveTenants() ([]string, error) {
+	q := "SELECT DISTINCT tenant_id FROM webhook_subscriptions WHERE active=TRUE"
+
+	tenants := []string{}
+	err := repo.MysqlConn.Select(&tenants, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return tenants, nil
+}
+
+// expectRow returns sql.ErrNoRows when a statement changed nothing
+func expectRow(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package mysql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/webhook"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+const mockTenantID string = "brand-a"
+
+var subscriptionColumns = []string{"id", "tenant_id", "owner_id", "merchant_id", "url", "events", "secret", "active", "created_at", "updated_at"}
+
+func TestCreateSubscription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	t.Run("success", func(t *testing.T) {
+		sub := &webhook.Subscription{TenantID: mockTenantID, OwnerID: "merchant-1", MerchantID: "merchant-1",
+			URL: "https://example.com/hook", Events: webhook.Events{"order.created", "order.status_changed"}, Secret: "s3cret", Active: true}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO webhook_subscriptions").
+			WithArgs(mockTenantID, "merchant-1", "merchant-1", "https://example.com/hook", "order.created,order.status_changed", "s3cret", true).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectExec("INSERT IGNORE INTO webhook_cursors \\(tenant_id, position\\) SELECT \\?, COALESCE\\(MAX\\(position\\), 0\\) FROM order_events").
+			WithArgs(mockTenantID, mockTenantID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT \\* FROM webhook_subscriptions WHERE tenant_id=\\? AND id=\\?").
+			WithArgs(mockTenantID, int64(3)).
+			WillReturnRows(sqlmock.NewRows(subscriptionColumns).
+				AddRow(3, mockTenantID, "merchant-1", "merchant-1", "https://example.com/hook", "order.created,order.status_changed", "s3cret", true, time.Now(), time.Now()))
+		mock.ExpectCommit()
+
+		repo := NewSubscriptionRepositoryMysql(sqlxDB)
+		err := repo.Create(sub)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(3), sub.ID)
+		assert.Equal(t, webhook.Events{"order.created", "order.status_changed"}, sub.Events)
+		assert.Equal(t, false, sub.CreatedAt.IsZero())
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewSubscriptionRepositoryMysql(sqlxDB)
+		err := repo.Create(&webhook.Subscription{})
+
+		assert.Equal(t, errNoTenant, err)
+	})
+}
+
+func TestUpdateSubscription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	q := "UPDATE webhook_subscriptions SET url=\\?, events=\\?, secret=\\?, active=\\?, updated_at=now\\(6\\) WHERE tenant_id=\\? AND id=\\?"
+	sub := &webhook.Subscription{ID: 3, TenantID: mockTenantID, URL: "https://example.com/hook", Events: webhook.Events{}, Secret: "s3cret"}
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(q).WithArgs("https://example.com/hook", "", "s3cret", false, mockTenantID, int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		repo := NewSubscriptionRepositoryMysql(sqlxDB)
+		err := repo.Update(sub)
+
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		mock.ExpectExec(q).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := NewSubscriptionRepositoryMysql(sqlxDB)
+		err := repo.Update(sub)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
+
+func TestDeleteSubscription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectExec("DELETE FROM webhook_subscriptions WHERE tenant_id=\\? AND id=\\?").
+		WithArgs(mockTenantID, int64(3)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := NewSubscriptionRepositoryMysql(sqlxDB)
+	err = repo.Delete(mockTenantID, 3)
+
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func TestFindAllSubscriptions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	t.Run("owner", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM webhook_subscriptions WHERE tenant_id=\\? AND owner_id=\\? ORDER BY id").
+			WithArgs(mockTenantID, "merchant-1").
+			WillReturnRows(sqlmock.NewRows(subscriptionColumns).
+				AddRow(3, mockTenantID, "merchant-1", "merchant-1", "https://example.com/hook", "", "s3cret", true, time.Now(), time.Now()))
+
+		repo := NewSubscriptionRepositoryMysql(sqlxDB)
+		subs, err := repo.FindAll(mockTenantID, "merchant-1")
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(subs))
+		assert.Equal(t, webhook.Events{}, subs[0].Events)
+	})
+
+	t.Run("tenant", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM webhook_subscriptions WHERE tenant_id=\\? ORDER BY id").
+			WithArgs(mockTenantID).
+			WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+
+		repo := NewSubscriptionRepositoryMysql(sqlxDB)
+		subs, err := repo.FindAll(mockTenantID, "")
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(subs))
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		repo := NewSubscriptionRepositoryMysql(sqlxDB)
+		_, err := repo.FindAll("", "")
+
+		assert.Equal(t, errNoTenant, err)
+	})
+}
+
+func TestActiveTenants(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("unexpected error '%s' when opening sqlmock database connection", err.Error())
+		return
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectQuery("SELECT DISTINCT tenant_id FROM webhook_subscriptions WHERE active=TRUE").
+		WillReturnRows(sqlmock.NewRows([]string{"tenant_id"}).AddRow("brand-a").AddRow("brand-b"))
+
+	repo := NewSubscriptionRepositoryMysql(sqlxDB)
+	tenants, err := repo.ActiveTenants()
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"brand-a", "brand-b"}, tenants)
+}
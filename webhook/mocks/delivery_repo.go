@@ -0,0 +1,164 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/imylam/delivery-test/webhook"
+	"github.com/stretchr/testify/mock"
+)
+
+// DeliveryRepository is a mock type for the DeliveryRepository type
+type DeliveryRepository struct {
+	mock.Mock
+}
+
+// CreateBatch provides a mock function with given fields: deliveries
+func (_m *DeliveryRepository) CreateBatch(deliveries []webhook.Delivery) error {
+	ret := _m.Called(deliveries)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]webhook.Delivery) error); ok {
+		r0 = rf(deliveries)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindBySubscription provides a mock function with given fields: tenantID, subscriptionID, limit, offset
+func (_m *DeliveryRepository) FindBySubscription(tenantID string, subscriptionID int64, limit int, offset int) ([]webhook.Delivery, error) {
+	ret := _m.Called(tenantID, subscriptionID, limit, offset)
+
+	var r0 []webhook.Delivery
+	if rf, ok := ret.Get(0).(func(string, int64, int, int) []webhook.Delivery); ok {
+		r0 = rf(tenantID, subscriptionID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhook.Delivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int64, int, int) error); ok {
+		r1 = rf(tenantID, subscriptionID, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Retry provides a mock function with given fields: tenantID, subscriptionID, id
+func (_m *DeliveryRepository) Retry(tenantID string, subscriptionID int64, id int64) error {
+	ret := _m.Called(tenantID, subscriptionID, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int64, int64) error); ok {
+		r0 = rf(tenantID, subscriptionID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Claim provides a mock function with given fields: limit, lease
+func (_m *DeliveryRepository) Claim(limit int, lease time.Duration) ([]webhook.Delivery, error) {
+	ret := _m.Called(limit, lease)
+
+	var r0 []webhook.Delivery
+	if rf, ok := ret.Get(0).(func(int, time.Duration) []webhook.Delivery); ok {
+		r0 = rf(limit, lease)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhook.Delivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, time.Duration) error); ok {
+		r1 = rf(limit, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Complete provides a mock function with given fields: id, attempt
+func (_m *DeliveryRepository) Complete(id int64, attempt webhook.Attempt) error {
+	ret := _m.Called(id, attempt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, webhook.Attempt) error); ok {
+		r0 = rf(id, attempt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Fail provides a mock function with given fields: id, attempt, next
+func (_m *DeliveryRepository) Fail(id int64, attempt webhook.Attempt, next time.Time) error {
+	ret := _m.Called(id, attempt, next)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, webhook.Attempt, time.Time) error); ok {
+		r0 = rf(id, attempt, next)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Bury provides a mock function with given fields: id, attempt
+func (_m *DeliveryRepository) Bury(id int64, attempt webhook.Attempt) error {
+	ret := _m.Called(id, attempt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, webhook.Attempt) error); ok {
+		r0 = rf(id, attempt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Cursor provides a mock function with given fields: tenantID
+func (_m *DeliveryRepository) Cursor(tenantID string) (int64, error) {
+	ret := _m.Called(tenantID)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(string) int64); ok {
+		r0 = rf(tenantID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AdvanceCursor provides a mock function with given fields: tenantID, from, to
+func (_m *DeliveryRepository) AdvanceCursor(tenantID string, from int64, to int64) error {
+	ret := _m.Called(tenantID, from, to)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int64, int64) error); ok {
+		r0 = rf(tenantID, from, to)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,145 @@
+package mocks
+
+import (
+	"github.com/imylam/delivery-test/webhook"
+	"github.com/stretchr/testify/mock"
+)
+
+// SubscriptionRepository is a mock type for the SubscriptionRepository type
+type SubscriptionRepository struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: sub
+func (_m *SubscriptionRepository) Create(sub *webhook.Subscription) error {
+	ret := _m.Called(sub)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*webhook.Subscription) error); ok {
+		r0 = rf(sub)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: sub
+func (_m *SubscriptionRepository) Update(sub *webhook.Subscription) error {
+	ret := _m.Called(sub)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*webhook.Subscription) error); ok {
+		r0 = rf(sub)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: tenantID, id
+func (_m *SubscriptionRepository) Delete(tenantID string, id int64) error {
+	ret := _m.Called(tenantID, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
+		r0 = rf(tenantID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindByID provides a mock function with given fields: tenantID, id
+func (_m *SubscriptionRepository) FindByID(tenantID string, id int64) (*webhook.Subscription, error) {
+	ret := _m.Called(tenantID, id)
+
+	var r0 *webhook.Subscription
+	if rf, ok := ret.Get(0).(func(string, int64) *webhook.Subscription); ok {
+		r0 = rf(tenantID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*webhook.Subscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int64) error); ok {
+		r1 = rf(tenantID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindAll provides a mock function with given fields: tenantID, ownerID
+func (_m *SubscriptionRepository) FindAll(tenantID string, ownerID string) ([]webhook.Subscription, error) {
+	ret := _m.Called(tenantID, ownerID)
+
+	var r0 []webhook.Subscription
+	if rf, ok := ret.Get(0).(func(string, string) []webhook.Subscription); ok {
+		r0 = rf(tenantID, ownerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhook.Subscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(tenantID, ownerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindActive provides a mock function with given fields: tenantID
+func (_m *SubscriptionRepository) FindActive(tenantID string) ([]webhook.Subscription, error) {
+	ret := _m.Called(tenantID)
+
+	var r0 []webhook.Subscription
+	if rf, ok := ret.Get(0).(func(string) []webhook.Subscription); ok {
+		r0 = rf(tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhook.Subscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ActiveTenants provides a mock function with given fields:
+func (_m *SubscriptionRepository) ActiveTenants() ([]string, error) {
+	ret := _m.Called()
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,138 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/imylam/delivery-test/webhook"
+	"github.com/stretchr/testify/mock"
+)
+
+// WebhookUsecase is a mock type for the WebhookUsecase type
+type WebhookUsecase struct {
+	mock.Mock
+}
+
+// CreateSubscription provides a mock function with given fields: ctx, sub
+func (_m *WebhookUsecase) CreateSubscription(ctx context.Context, sub *webhook.Subscription) error {
+	ret := _m.Called(ctx, sub)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *webhook.Subscription) error); ok {
+		r0 = rf(ctx, sub)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListSubscriptions provides a mock function with given fields: ctx
+func (_m *WebhookUsecase) ListSubscriptions(ctx context.Context) ([]webhook.Subscription, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []webhook.Subscription
+	if rf, ok := ret.Get(0).(func(context.Context) []webhook.Subscription); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhook.Subscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubscription provides a mock function with given fields: ctx, id
+func (_m *WebhookUsecase) GetSubscription(ctx context.Context, id int64) (*webhook.Subscription, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *webhook.Subscription
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *webhook.Subscription); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*webhook.Subscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateSubscription provides a mock function with given fields: ctx, sub
+func (_m *WebhookUsecase) UpdateSubscription(ctx context.Context, sub *webhook.Subscription) error {
+	ret := _m.Called(ctx, sub)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *webhook.Subscription) error); ok {
+		r0 = rf(ctx, sub)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteSubscription provides a mock function with given fields: ctx, id
+func (_m *WebhookUsecase) DeleteSubscription(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListDeliveries provides a mock function with given fields: ctx, subscriptionID, page, limit
+func (_m *WebhookUsecase) ListDeliveries(ctx context.Context, subscriptionID int64, page int, limit int) ([]webhook.Delivery, error) {
+	ret := _m.Called(ctx, subscriptionID, page, limit)
+
+	var r0 []webhook.Delivery
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int, int) []webhook.Delivery); ok {
+		r0 = rf(ctx, subscriptionID, page, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhook.Delivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int, int) error); ok {
+		r1 = rf(ctx, subscriptionID, page, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetryDelivery provides a mock function with given fields: ctx, subscriptionID, deliveryID
+func (_m *WebhookUsecase) RetryDelivery(ctx context.Context, subscriptionID int64, deliveryID int64) error {
+	ret := _m.Called(ctx, subscriptionID, deliveryID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, subscriptionID, deliveryID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+const (
+	HeaderDeliveryID string = "X-Webhook-Delivery"
+	HeaderEvent      string = "X-Webhook-Event"
+	HeaderTimestamp  string = "X-Webhook-Timestamp"
+	HeaderSignature  string = "X-Webhook-Signature"
+
+	signaturePrefix string = "sha256="
+)
+
+// Sign returns the signature sent in the X-Webhook-Signature header: the hex
+// encoded HMAC-SHA256 of "<timestamp>.<body>" keyed with the subscription secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify tells whether signature was made by Sign with the same arguments,
+// receivers should also reject timestamps too far in the past
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/webhook"
+)
+
+// secretSize is the number of random bytes of generated secrets
+const secretSize int = 32
+
+var (
+	ErrInvalidURL   = errors.New("url must be an absolute http or https URL")
+	ErrPrivateURL   = errors.New("url must not point to a loopback, private or link-local address")
+	ErrUnknownEvent = errors.New("unknown event type")
+)
+
+// eventTypes lists the events subscriptions can ask for
+var eventTypes = []string{order.EventOrderCreated, order.EventOrderStatusChanged}
+
+type webhookUsecase struct {
+	subscriptionRepo webhook.SubscriptionRepository
+	deliveryRepo     webhook.DeliveryRepository
+	cfg              configs.WebhookConfig
+}
+
+// NewWebhookUsecase will create new a webhookUsecase object representation of webhook.WebhookUsecase interface
+func NewWebhookUsecase(subscriptionRepo webhook.SubscriptionRepository,
+	deliveryRepo webhook.DeliveryRepository, cfg configs.WebhookConfig) webhook.WebhookUsecase {
+
+	return &webhookUsecase{
+		subscriptionRepo: subscriptionRepo,
+		deliveryRepo:     deliveryRepo,
+		cfg:              cfg,
+	}
+}
+
+// CreateSubscription creates a subscription owned by the caller. Merchants are
+// only notified of their own orders, admins of every order of the tenant
+// unless they name a merchant. A secret is generated if none is given, it is
+// only ever returned here
+func (uc *webhookUsecase) CreateSubscription(ctx context.Context, sub *webhook.Subscription) error {
+	caller, err := authorize(ctx)
+	if err != nil {
+		return err
+	}
+	if err := uc.validate(sub); err != nil {
+		return err
+	}
+
+	sub.TenantID = caller.Tenant
+	sub.OwnerID = caller.Subject
+	if !caller.HasRole(auth.RoleAdmin) {
+		sub.MerchantID = caller.Subject
+	}
+	if sub.Secret == "" {
+		sub.Secret, err = newSecret()
+		if err != nil {
+			return err
+		}
+	}
+
+	return uc.subscriptionRepo.Create(sub)
+}
+
+// ListSubscriptions lists every subscription of the tenant to admins and their own to merchants
+func (uc *webhookUsecase) ListSubscriptions(ctx context.Context) ([]webhook.Subscription, error) {
+	caller, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerID := caller.Subject
+	if caller.HasRole(auth.RoleAdmin) {
+		ownerID = ""
+	}
+
+	subs, err := uc.subscriptionRepo.FindAll(caller.Tenant, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+
+	return subs, nil
+}
+
+// GetSubscription returns a subscription without its secret
+func (uc *webhookUsecase) GetSubscription(ctx context.Context, id int64) (*webhook.Subscription, error) {
+	_, sub, err := uc.findSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.Secret = ""
+	return sub, nil
+}
+
+// UpdateSubscription replaces the url, events and active flag of a
+// subscription, and its secret when one is given
+func (uc *webhookUsecase) UpdateSubscription(ctx context.Context, sub *webhook.Subscription) error {
+	_, found, err := uc.findSubscription(ctx, sub.ID)
+	if err != nil {
+		return err
+	}
+	if err := uc.validate(sub); err != nil {
+		return err
+	}
+
+	found.URL = sub.URL
+	found.Events = sub.Events
+	found.Active = sub.Active
+	if sub.Secret != "" {
+		found.Secret = sub.Secret
+	}
+
+	err = uc.subscriptionRepo.Update(found)
+	if err != nil {
+		return err
+	}
+
+	*sub = *found
+	sub.Secret = ""
+	return nil
+}
+
+// DeleteSubscription deletes a subscription along with its deliveries
+func (uc *webhookUsecase) DeleteSubscription(ctx context.Context, id int64) error {
+	caller, _, err := uc.findSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return uc.subscriptionRepo.Delete(caller.Tenant, id)
+}
+
+// ListDeliveries lists the deliveries of a subscription, latest first
+func (uc *webhookUsecase) ListDeliveries(ctx context.Context, subscriptionID int64, page, limit int) ([]webhook.Delivery, error) {
+	caller, _, err := uc.findSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * limit
+	return uc.deliveryRepo.FindBySubscription(caller.Tenant, subscriptionID, limit, offset)
+}
+
+// RetryDelivery sends a dead delivery again with a fresh set of attempts, it
+// returns sql.ErrNoRows if the subscription has no such dead delivery
+func (uc *webhookUsecase) RetryDelivery(ctx context.Context, subscriptionID, deliveryID int64) error {
+	caller, _, err := uc.findSubscription(ctx, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	return uc.deliveryRepo.Retry(caller.Tenant, subscriptionID, deliveryID)
+}
+
+// findSubscription returns a subscription of the caller tenant if the caller owns it or is an admin
+func (uc *webhookUsecase) findSubscription(ctx context.Context, id int64) (*auth.Identity, *webhook.Subscription, error) {
+	caller, err := authorize(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sub, err := uc.subscriptionRepo.FindByID(caller.Tenant, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !caller.HasRole(auth.RoleAdmin) && sub.OwnerID != caller.Subject {
+		return nil, nil, auth.ErrForbidden
+	}
+
+	return caller, sub, nil
+}
+
+// authorize returns the caller if they may manage webhooks of a tenant
+func authorize(ctx context.Context) (*auth.Identity, error) {
+	caller, err := auth.Authorize(ctx, auth.PermissionWebhook)
+	if err != nil {
+		return nil, err
+	}
+	if caller.Tenant == "" {
+		return nil, auth.ErrNoTenant
+	}
+
+	return caller, nil
+}
+
+func (uc *webhookUsecase) validate(sub *webhook.Subscription) error {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidURL
+	}
+	if !uc.cfg.AllowPrivateNetworks && webhook.IsPrivateHost(u.Hostname()) {
+		return ErrPrivateURL
+	}
+
+	for _, e := range sub.Events {
+		known := false
+		for _, t := range eventTypes {
+			known = known || e == t
+		}
+		if !known {
+			return ErrUnknownEvent
+		}
+	}
+
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
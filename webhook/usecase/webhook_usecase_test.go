@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/common/auth"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/webhook"
+	"github.com/imylam/delivery-test/webhook/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+const mockTenantID string = "brand-a"
+
+func TestCreateSubscription(t *testing.T) {
+	t.Run("merchant", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("Create", mock.AnythingOfType("*webhook.Subscription")).Return(nil).Once()
+
+		sub := &webhook.Subscription{URL: "https://example.com/hook", Events: webhook.Events{order.EventOrderCreated}, MerchantID: "merchant-2"}
+		uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		err := uc.CreateSubscription(mockMerchantCtx(), sub)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, mockTenantID, sub.TenantID)
+		assert.Equal(t, "merchant-1", sub.OwnerID)
+		assert.Equal(t, "merchant-1", sub.MerchantID)
+		assert.Equal(t, secretSize*2, len(sub.Secret))
+		mockSubRepo.AssertExpectations(t)
+	})
+
+	t.Run("admin-keeps-merchant-and-secret", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("Create", mock.AnythingOfType("*webhook.Subscription")).Return(nil).Once()
+
+		sub := &webhook.Subscription{URL: "http://example.com/hook", MerchantID: "merchant-2", Secret: "s3cret"}
+		uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		err := uc.CreateSubscription(mockAdminCtx(), sub)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "admin-1", sub.OwnerID)
+		assert.Equal(t, "merchant-2", sub.MerchantID)
+		assert.Equal(t, "s3cret", sub.Secret)
+		mockSubRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid-url", func(t *testing.T) {
+		uc := NewWebhookUsecase(new(mocks.SubscriptionRepository), new(mocks.DeliveryRepository), configs.WebhookConfig{})
+
+		for _, u := range []string{"", "example.com/hook", "ftp://example.com", "https://"} {
+			err := uc.CreateSubscription(mockMerchantCtx(), &webhook.Subscription{URL: u})
+			assert.Equal(t, ErrInvalidURL, err)
+		}
+	})
+
+	t.Run("private-url", func(t *testing.T) {
+		uc := NewWebhookUsecase(new(mocks.SubscriptionRepository), new(mocks.DeliveryRepository), configs.WebhookConfig{})
+
+		for _, u := range []string{"http://localhost:8080/hook", "http://127.0.0.1/hook", "http://10.0.0.5/hook", "http://192.168.1.1",
+			"http://169.254.169.254/latest/meta-data", "http://[::1]:3306", "http://[fd00::1]/hook", "http://0.0.0.0"} {
+			err := uc.CreateSubscription(mockMerchantCtx(), &webhook.Subscription{URL: u})
+			assert.Equal(t, ErrPrivateURL, err)
+		}
+	})
+
+	t.Run("private-url-allowed", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("Create", mock.AnythingOfType("*webhook.Subscription")).Return(nil).Once()
+
+		uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{AllowPrivateNetworks: true})
+		err := uc.CreateSubscription(mockMerchantCtx(), &webhook.Subscription{URL: "http://127.0.0.1:8081/hook"})
+
+		assert.Equal(t, nil, err)
+		mockSubRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown-event", func(t *testing.T) {
+		uc := NewWebhookUsecase(new(mocks.SubscriptionRepository), new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		err := uc.CreateSubscription(mockMerchantCtx(), &webhook.Subscription{URL: "https://example.com", Events: webhook.Events{"order.deleted"}})
+
+		assert.Equal(t, ErrUnknownEvent, err)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		uc := NewWebhookUsecase(new(mocks.SubscriptionRepository), new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		err := uc.CreateSubscription(mockCallerCtx("courier-1", auth.RoleCourier), &webhook.Subscription{URL: "https://example.com"})
+
+		assert.Equal(t, auth.ErrForbidden, err)
+	})
+
+	t.Run("no-tenant", func(t *testing.T) {
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "merchant-1", Roles: []string{auth.RoleMerchant}})
+
+		uc := NewWebhookUsecase(new(mocks.SubscriptionRepository), new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		err := uc.CreateSubscription(ctx, &webhook.Subscription{URL: "https://example.com"})
+
+		assert.Equal(t, auth.ErrNoTenant, err)
+	})
+}
+
+func TestListSubscriptions(t *testing.T) {
+	t.Run("merchant-sees-own", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindAll", mockTenantID, "merchant-1").
+			Return([]webhook.Subscription{{ID: 1, Secret: "s3cret"}}, nil).Once()
+
+		uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		subs, err := uc.ListSubscriptions(mockMerchantCtx())
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(subs))
+		assert.Equal(t, "", subs[0].Secret)
+		mockSubRepo.AssertExpectations(t)
+	})
+
+	t.Run("admin-sees-all", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindAll", mockTenantID, "").Return([]webhook.Subscription{}, nil).Once()
+
+		uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		_, err := uc.ListSubscriptions(mockAdminCtx())
+
+		assert.Equal(t, nil, err)
+		mockSubRepo.AssertExpectations(t)
+	})
+}
+
+func TestGetSubscription(t *testing.T) {
+	t.Run("owner", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, OwnerID: "merchant-1", Secret: "s3cret"}, nil).Once()
+
+		uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		sub, err := uc.GetSubscription(mockMerchantCtx(), 1)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "", sub.Secret)
+	})
+
+	t.Run("other-merchant", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, OwnerID: "merchant-2"}, nil).Once()
+
+		uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		_, err := uc.GetSubscription(mockMerchantCtx(), 1)
+
+		assert.Equal(t, auth.ErrForbidden, err)
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, OwnerID: "merchant-2"}, nil).Once()
+
+		uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		_, err := uc.GetSubscription(mockAdminCtx(), 1)
+
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).Return(nil, sql.ErrNoRows).Once()
+
+		uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		_, err := uc.GetSubscription(mockMerchantCtx(), 1)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
+
+func TestUpdateSubscription(t *testing.T) {
+	t.Run("keeps-secret", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, TenantID: mockTenantID, OwnerID: "merchant-1", MerchantID: "merchant-1", Secret: "s3cret", Active: true}, nil).Once()
+		mockSubRepo.On("Update", mock.MatchedBy(func(s *webhook.Subscription) bool {
+			return s.Secret == "s3cret" && s.URL == "https://example.com/new" && !s.Active && s.MerchantID == "merchant-1"
+		})).Return(nil).Once()
+
+		sub := &webhook.Subscription{ID: 1, URL: "https://example.com/new", Active: false}
+		uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		err := uc.UpdateSubscription(mockMerchantCtx(), sub)
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "", sub.Secret)
+		assert.Equal(t, "merchant-1", sub.OwnerID)
+		mockSubRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid-url", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, OwnerID: "merchant-1"}, nil).Once()
+
+		uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		err := uc.UpdateSubscription(mockMerchantCtx(), &webhook.Subscription{ID: 1, URL: "nope"})
+
+		assert.Equal(t, ErrInvalidURL, err)
+	})
+}
+
+func TestDeleteSubscription(t *testing.T) {
+	mockSubRepo := new(mocks.SubscriptionRepository)
+	mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+		Return(&webhook.Subscription{ID: 1, OwnerID: "merchant-1"}, nil).Once()
+	mockSubRepo.On("Delete", mockTenantID, int64(1)).Return(nil).Once()
+
+	uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{})
+	err := uc.DeleteSubscription(mockMerchantCtx(), 1)
+
+	assert.Equal(t, nil, err)
+	mockSubRepo.AssertExpectations(t)
+}
+
+func TestListDeliveries(t *testing.T) {
+	mockSubRepo := new(mocks.SubscriptionRepository)
+	mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+		Return(&webhook.Subscription{ID: 1, OwnerID: "merchant-1"}, nil).Once()
+	mockDeliveryRepo := new(mocks.DeliveryRepository)
+	mockDeliveryRepo.On("FindBySubscription", mockTenantID, int64(1), 10, 20).
+		Return([]webhook.Delivery{{ID: 5}}, nil).Once()
+
+	uc := NewWebhookUsecase(mockSubRepo, mockDeliveryRepo, configs.WebhookConfig{})
+	deliveries, err := uc.ListDeliveries(mockMerchantCtx(), 1, 3, 10)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(deliveries))
+	mockDeliveryRepo.AssertExpectations(t)
+}
+
+func TestRetryDelivery(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, OwnerID: "merchant-1"}, nil).Once()
+		mockDeliveryRepo := new(mocks.DeliveryRepository)
+		mockDeliveryRepo.On("Retry", mockTenantID, int64(1), int64(5)).Return(nil).Once()
+
+		uc := NewWebhookUsecase(mockSubRepo, mockDeliveryRepo, configs.WebhookConfig{})
+		err := uc.RetryDelivery(mockMerchantCtx(), 1, 5)
+
+		assert.Equal(t, nil, err)
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, OwnerID: "merchant-2"}, nil).Once()
+
+		uc := NewWebhookUsecase(mockSubRepo, new(mocks.DeliveryRepository), configs.WebhookConfig{})
+		err := uc.RetryDelivery(mockMerchantCtx(), 1, 5)
+
+		assert.Equal(t, auth.ErrForbidden, err)
+	})
+}
+
+func mockMerchantCtx() context.Context {
+	return mockCallerCtx("merchant-1", auth.RoleMerchant)
+}
+
+func mockAdminCtx() context.Context {
+	return mockCallerCtx("admin-1", auth.RoleAdmin)
+}
+
+func mockCallerCtx(subject string, role string) context.Context {
+	return auth.WithIdentity(context.Background(), &auth.Identity{Subject: subject, Roles: []string{role}, Tenant: mockTenantID})
+}
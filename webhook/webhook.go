@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/imylam/delivery-test/order"
+)
+
+const (
+	DeliveryPending   string = "PENDING"
+	DeliverySucceeded string = "SUCCEEDED"
+	DeliveryDead      string = "DEAD"
+)
+
+// Subscription represents an endpoint notified of the order events of a tenant.
+// MerchantID limits it to the orders of a merchant, empty for every order
+type Subscription struct {
+	ID         int64     `json:"id" db:"id"`
+	TenantID   string    `json:"-" db:"tenant_id"`
+	OwnerID    string    `json:"owner_id" db:"owner_id"`
+	MerchantID string    `json:"merchant_id,omitempty" db:"merchant_id"`
+	URL        string    `json:"url" db:"url"`
+	Events     Events    `json:"events" db:"events"`
+	Secret     string    `json:"secret,omitempty" db:"secret"`
+	Active     bool      `json:"active" db:"active"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Matches tells whether the subscription wants e, events that happened before
+// the subscription was created never match
+func (s *Subscription) Matches(e *order.OrderEvent) bool {
+	if !s.Active || e.CreatedAt.Before(s.CreatedAt) {
+		return false
+	}
+	if s.MerchantID != "" && s.MerchantID != e.MerchantID {
+		return false
+	}
+
+	return s.Events.Contains(e.Type)
+}
+
+// Delivery represents an event sent, or to be sent, to a subscription. The
+// payload is built once so every attempt sends the same bytes
+type Delivery struct {
+	ID             int64           `json:"id" db:"id"`
+	TenantID       string          `json:"-" db:"tenant_id"`
+	SubscriptionID int64           `json:"subscription_id" db:"subscription_id"`
+	EventID        int64           `json:"event_id" db:"event_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	LastStatusCode int             `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      string          `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// Attempt represents the outcome of sending a delivery once
+type Attempt struct {
+	StatusCode int
+	Error      string
+}
+
+// WebhookUsecase represents Webhook Usecase, the caller is read from the context
+type WebhookUsecase interface {
+	CreateSubscription(context.Context, *Subscription) error
+	ListSubscriptions(context.Context) ([]Subscription, error)
+	GetSubscription(context.Context, int64) (*Subscription, error)
+	UpdateSubscription(context.Context, *Subscription) error
+	DeleteSubscription(context.Context, int64) error
+	ListDeliveries(context.Context, int64, int, int) ([]Delivery, error)
+	RetryDelivery(context.Context, int64, int64) error
+}
+
+// SubscriptionRepository represents Subscription Repository, every method but
+// ActiveTenants is scoped to the tenant given as first argument, Create and
+// Update to the TenantID of the subscription
+type SubscriptionRepository interface {
+	Create(*Subscription) error
+	Update(*Subscription) error
+	Delete(string, int64) error
+	FindByID(string, int64) (*Subscription, error)
+	FindAll(string, string) ([]Subscription, error)
+	FindActive(string) ([]Subscription, error)
+	ActiveTenants() ([]string, error)
+}
+
+// DeliveryRepository represents Delivery Repository. Deliveries are claimed by
+// one worker at a time for a lease, so workers on every instance share them.
+// The cursor of a tenant is the position of the last order event turned into
+// deliveries
+type DeliveryRepository interface {
+	CreateBatch([]Delivery) error
+	FindBySubscription(string, int64, int, int) ([]Delivery, error)
+	Retry(string, int64, int64) error
+	Claim(int, time.Duration) ([]Delivery, error)
+	Complete(int64, Attempt) error
+	Fail(int64, Attempt, time.Time) error
+	Bury(int64, Attempt) error
+	Cursor(string) (int64, error)
+	AdvanceCursor(string, int64, int64) error
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/order"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event_id":4}`)
+	signature := "sha256=4b2276386e82cfece3bad4fad89d628e949a68edc56013d548d1167e16abad74"
+
+	assert.Equal(t, signature, Sign("s3cret", 1664625600, body))
+	assert.Equal(t, true, Verify("s3cret", 1664625600, body, signature))
+	assert.Equal(t, false, Verify("other", 1664625600, body, signature))
+	assert.Equal(t, false, Verify("s3cret", 1664625601, body, signature))
+	assert.Equal(t, false, Verify("s3cret", 1664625600, []byte(`{"event_id":5}`), signature))
+}
+
+func TestMatches(t *testing.T) {
+	createdAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	event := &order.OrderEvent{Type: order.EventOrderCreated, MerchantID: "merchant-1", CreatedAt: createdAt}
+
+	tests := []struct {
+		name string
+		sub  Subscription
+		want bool
+	}{
+		{"every-event", Subscription{Active: true, CreatedAt: createdAt}, true},
+		{"event-type", Subscription{Active: true, Events: Events{order.EventOrderCreated}}, true},
+		{"other-event-type", Subscription{Active: true, Events: Events{order.EventOrderStatusChanged}}, false},
+		{"merchant", Subscription{Active: true, MerchantID: "merchant-1"}, true},
+		{"other-merchant", Subscription{Active: true, MerchantID: "merchant-2"}, false},
+		{"inactive", Subscription{}, false},
+		{"created-after-event", Subscription{Active: true, CreatedAt: createdAt.Add(time.Millisecond)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.sub.Matches(event))
+		})
+	}
+}
+
+func TestIsPrivateHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		private bool
+	}{
+		{"example.com", false},
+		{"93.184.216.34", false},
+		{"2606:2800:220:1:248:1893:25c8:1946", false},
+		{"localhost", true},
+		{"api.localhost.", true},
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.0.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"fe80::1", true},
+		{"fd12:3456::1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			assert.Equal(t, tt.private, IsPrivateHost(tt.host))
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	"github.com/imylam/delivery-test/webhook"
+	"go.uber.org/zap"
+)
+
+const (
+	userAgent string = "delivery-webhooks/1.0"
+
+	// maxResponseBody is how much of a response is read before the connection is reused
+	maxResponseBody int64 = 64 << 10
+	// maxErrorLength bounds the error stored on a delivery
+	maxErrorLength int = 255
+
+	errSubscriptionInactive string = "subscription inactive"
+)
+
+// Payload represents the body POSTed to subscriptions
+type Payload struct {
+	EventID   int64       `json:"event_id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Order     order.Order `json:"order"`
+}
+
+// Worker turns order events into deliveries for the matching subscriptions
+// and sends them, retrying failures with an exponential backoff until they
+// succeed or run out of attempts and are left dead
+type Worker struct {
+	subscriptionRepo webhook.SubscriptionRepository
+	deliveryRepo     webhook.DeliveryRepository
+	eventRepo        order.OrderEventRepository
+	client           *http.Client
+	cfg              configs.WebhookConfig
+	now              func() time.Time
+}
+
+// NewWorker creates a Worker with the settings of cfg
+func NewWorker(subscriptionRepo webhook.SubscriptionRepository, deliveryRepo webhook.DeliveryRepository,
+	eventRepo order.OrderEventRepository, cfg configs.WebhookConfig) *Worker {
+
+	return &Worker{
+		subscriptionRepo: subscriptionRepo,
+		deliveryRepo:     deliveryRepo,
+		eventRepo:        eventRepo,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: newTransport(cfg),
+			// a redirect is a failed delivery, the subscription should be updated
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+// newTransport dials subscriptions directly and, unless cfg allows private
+// networks, refuses the connections to internal addresses. Proxies are not
+// used since the address dialed would then be the proxy's
+func newTransport(cfg configs.WebhookConfig) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.AllowPrivateNetworks {
+		return t
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: webhook.DialControl}
+	t.Proxy = nil
+	t.DialContext = dialer.DialContext
+
+	return t
+}
+
+// Run fans out and sends deliveries every poll interval until ctx is done
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.FanOut(); err != nil {
+			logger.Logger.Error("fail to fan out webhook deliveries", zap.String("error", err.Error()))
+		}
+		if err := w.Deliver(ctx); err != nil {
+			logger.Logger.Error("fail to send webhook deliveries", zap.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FanOut creates a delivery for every subscription matching the events each
+// tenant had since its cursor. Deliveries are unique per subscription and
+// event, so workers racing on the same events do not send them twice
+func (w *Worker) FanOut() error {
+	tenants, err := w.subscriptionRepo.ActiveTenants()
+	if err != nil {
+		return err
+	}
+
+	// a tenant failing does not hold the others back
+	var firstErr error
+	for _, tenant := range tenants {
+		if err := w.fanOutTenant(tenant); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("tenant %s: %w", tenant, err)
+		}
+	}
+
+	return firstErr
+}
+
+func (w *Worker) fanOutTenant(tenant string) error {
+	cursor, err := w.deliveryRepo.Cursor(tenant)
+	if err != nil {
+		return err
+	}
+
+	events, err := w.eventRepo.FindAfter(tenant, order.EventFilter{}, cursor, w.cfg.BatchSize)
+	if err != nil || len(events) == 0 {
+		return err
+	}
+
+	subs, err := w.subscriptionRepo.FindActive(tenant)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []webhook.Delivery
+	for i := range events {
+		e := &events[i]
+		for j := range subs {
+			if !subs[j].Matches(e) {
+				continue
+			}
+
+			payload, err := json.Marshal(Payload{EventID: e.ID, Type: e.Type, CreatedAt: e.CreatedAt, Order: e.Order()})
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, webhook.Delivery{
+				TenantID:       tenant,
+				SubscriptionID: subs[j].ID,
+				EventID:        e.ID,
+				EventType:      e.Type,
+				Payload:        payload,
+				Status:         webhook.DeliveryPending,
+				NextAttemptAt:  w.now(),
+			})
+		}
+	}
+
+	if len(deliveries) > 0 {
+		if err := w.deliveryRepo.CreateBatch(deliveries); err != nil {
+			return err
+		}
+	}
+
+	return w.deliveryRepo.AdvanceCursor(tenant, cursor, events[len(events)-1].Position)
+}
+
+// Deliver claims the deliveries due and sends them concurrently
+func (w *Worker) Deliver(ctx context.Context) error {
+	deliveries, err := w.deliveryRepo.Claim(w.cfg.BatchSize, w.cfg.Lease)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func(d *webhook.Delivery) {
+			defer wg.Done()
+			if err := w.deliver(ctx, d); err != nil {
+				logger.Logger.Error("fail to record webhook delivery",
+					zap.Int64("delivery_id", d.ID), zap.String("error", err.Error()))
+			}
+		}(&deliveries[i])
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// deliver sends d once and records the outcome
+func (w *Worker) deliver(ctx context.Context, d *webhook.Delivery) error {
+	sub, err := w.subscriptionRepo.FindByID(d.TenantID, d.SubscriptionID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == sql.ErrNoRows || !sub.Active {
+		return w.deliveryRepo.Bury(d.ID, webhook.Attempt{Error: errSubscriptionInactive})
+	}
+
+	attempt := w.send(ctx, sub, d)
+	if attempt.Error == "" {
+		return w.deliveryRepo.Complete(d.ID, attempt)
+	}
+
+	attempts := d.Attempts + 1
+	logger.Logger.Debug("webhook delivery failed",
+		zap.Int64("delivery_id", d.ID), zap.Int("attempts", attempts), zap.String("error", attempt.Error))
+
+	if attempts >= w.cfg.MaxAttempts {
+		return w.deliveryRepo.Bury(d.ID, attempt)
+	}
+
+	return w.deliveryRepo.Fail(d.ID, attempt, w.now().Add(w.backoff(attempts)))
+}
+
+// send POSTs the payload of d to the subscription, signed with its secret
+func (w *Worker) send(ctx context.Context, sub *webhook.Subscription, d *webhook.Delivery) webhook.Attempt {
+	timestamp := w.now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return webhook.Attempt{Error: truncate(err.Error())}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(webhook.HeaderDeliveryID, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(webhook.HeaderEvent, d.EventType)
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(sub.Secret, timestamp, d.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return webhook.Attempt{Error: truncate(err.Error())}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return webhook.Attempt{StatusCode: resp.StatusCode, Error: "unexpected status " + strconv.Itoa(resp.StatusCode)}
+	}
+
+	return webhook.Attempt{StatusCode: resp.StatusCode}
+}
+
+// backoff returns the wait before the next attempt of a delivery that failed
+// attempts times: the base doubled after each failure, up to the max
+func (w *Worker) backoff(attempts int) time.Duration {
+	wait := w.cfg.BackoffBase
+	for i := 1; i < attempts && wait < w.cfg.BackoffMax; i++ {
+		wait *= 2
+	}
+	if wait > w.cfg.BackoffMax {
+		wait = w.cfg.BackoffMax
+	}
+
+	return wait
+}
+
+func truncate(s string) string {
+	if len(s) > maxErrorLength {
+		return s[:maxErrorLength]
+	}
+	return s
+}
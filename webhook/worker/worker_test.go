@@ -0,0 +1,348 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/imylam/delivery-test/configs"
+	"github.com/imylam/delivery-test/logger"
+	"github.com/imylam/delivery-test/order"
+	_orderMocks "github.com/imylam/delivery-test/order/mocks"
+	"github.com/imylam/delivery-test/webhook"
+	"github.com/imylam/delivery-test/webhook/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	mockTenantID string = "brand-a"
+	mockSecret   string = "s3cret"
+)
+
+var mockNow = time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func mockConfig() configs.WebhookConfig {
+	return configs.WebhookConfig{
+		PollInterval: time.Second,
+		BatchSize:    20,
+		Timeout:      time.Second,
+		Lease:        time.Minute,
+		MaxAttempts:  3,
+		BackoffBase:  10 * time.Second,
+		BackoffMax:   time.Minute,
+		// receivers are test servers on the loopback interface
+		AllowPrivateNetworks: true,
+	}
+}
+
+func newMockWorker(subRepo *mocks.SubscriptionRepository, deliveryRepo *mocks.DeliveryRepository,
+	eventRepo *_orderMocks.OrderEventRepository) *Worker {
+
+	w := NewWorker(subRepo, deliveryRepo, eventRepo, mockConfig())
+	w.now = func() time.Time { return mockNow }
+	return w
+}
+
+func TestFanOut(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	t.Run("matching-subscriptions", func(t *testing.T) {
+		events := []order.OrderEvent{
+			{ID: 4, TenantID: mockTenantID, Type: order.EventOrderCreated, OrderID: 1, MerchantID: "merchant-1", Status: "UNASSIGNED", CreatedAt: mockNow, Position: 4},
+			{ID: 7, TenantID: mockTenantID, Type: order.EventOrderStatusChanged, OrderID: 2, MerchantID: "merchant-2", Status: "TAKEN", CourierID: "courier-1", CreatedAt: mockNow, Position: 7},
+		}
+		subs := []webhook.Subscription{
+			{ID: 1, MerchantID: "merchant-1", Active: true, CreatedAt: mockNow.Add(-time.Hour)},
+			{ID: 2, Events: webhook.Events{order.EventOrderStatusChanged}, Active: true, CreatedAt: mockNow.Add(-time.Hour)},
+			{ID: 3, Active: true, CreatedAt: mockNow.Add(time.Second)},
+		}
+
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("ActiveTenants").Return([]string{mockTenantID}, nil).Once()
+		mockSubRepo.On("FindActive", mockTenantID).Return(subs, nil).Once()
+		mockEventRepo := new(_orderMocks.OrderEventRepository)
+		mockEventRepo.On("FindAfter", mockTenantID, order.EventFilter{}, int64(3), 20).Return(events, nil).Once()
+		mockDeliveryRepo := new(mocks.DeliveryRepository)
+		mockDeliveryRepo.On("Cursor", mockTenantID).Return(int64(3), nil).Once()
+		mockDeliveryRepo.On("CreateBatch", mock.MatchedBy(func(ds []webhook.Delivery) bool {
+			if len(ds) != 2 {
+				return false
+			}
+			var payload Payload
+			if err := json.Unmarshal(ds[1].Payload, &payload); err != nil {
+				return false
+			}
+			return ds[0].SubscriptionID == 1 && ds[0].EventID == 4 &&
+				ds[1].SubscriptionID == 2 && ds[1].EventID == 7 && ds[1].Status == webhook.DeliveryPending &&
+				payload.Type == order.EventOrderStatusChanged && payload.Order.CourierID == "courier-1"
+		})).Return(nil).Once()
+		mockDeliveryRepo.On("AdvanceCursor", mockTenantID, int64(3), int64(7)).Return(nil).Once()
+
+		err := newMockWorker(mockSubRepo, mockDeliveryRepo, mockEventRepo).FanOut()
+
+		assert.Equal(t, nil, err)
+		mockSubRepo.AssertExpectations(t)
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("no-events", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("ActiveTenants").Return([]string{mockTenantID}, nil).Once()
+		mockEventRepo := new(_orderMocks.OrderEventRepository)
+		mockEventRepo.On("FindAfter", mockTenantID, order.EventFilter{}, int64(3), 20).Return([]order.OrderEvent{}, nil).Once()
+		mockDeliveryRepo := new(mocks.DeliveryRepository)
+		mockDeliveryRepo.On("Cursor", mockTenantID).Return(int64(3), nil).Once()
+
+		err := newMockWorker(mockSubRepo, mockDeliveryRepo, mockEventRepo).FanOut()
+
+		assert.Equal(t, nil, err)
+		mockDeliveryRepo.AssertNotCalled(t, "AdvanceCursor", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("no-match-still-advances", func(t *testing.T) {
+		events := []order.OrderEvent{{ID: 4, Type: order.EventOrderCreated, MerchantID: "merchant-2", CreatedAt: mockNow, Position: 4}}
+
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("ActiveTenants").Return([]string{mockTenantID}, nil).Once()
+		mockSubRepo.On("FindActive", mockTenantID).
+			Return([]webhook.Subscription{{ID: 1, MerchantID: "merchant-1", Active: true}}, nil).Once()
+		mockEventRepo := new(_orderMocks.OrderEventRepository)
+		mockEventRepo.On("FindAfter", mockTenantID, order.EventFilter{}, int64(0), 20).Return(events, nil).Once()
+		mockDeliveryRepo := new(mocks.DeliveryRepository)
+		mockDeliveryRepo.On("Cursor", mockTenantID).Return(int64(0), nil).Once()
+		mockDeliveryRepo.On("AdvanceCursor", mockTenantID, int64(0), int64(4)).Return(nil).Once()
+
+		err := newMockWorker(mockSubRepo, mockDeliveryRepo, mockEventRepo).FanOut()
+
+		assert.Equal(t, nil, err)
+		mockDeliveryRepo.AssertNotCalled(t, "CreateBatch", mock.Anything)
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("late-commit-delivered", func(t *testing.T) {
+		// event 8 was written before event 9 but committed well after it was fanned out
+		late := []order.OrderEvent{{ID: 8, Type: order.EventOrderCreated, MerchantID: "merchant-1", CreatedAt: mockNow.Add(-time.Minute), Position: 6}}
+
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("ActiveTenants").Return([]string{mockTenantID}, nil).Once()
+		mockSubRepo.On("FindActive", mockTenantID).
+			Return([]webhook.Subscription{{ID: 1, Active: true, CreatedAt: mockNow.Add(-time.Hour)}}, nil).Once()
+		mockEventRepo := new(_orderMocks.OrderEventRepository)
+		mockEventRepo.On("FindAfter", mockTenantID, order.EventFilter{}, int64(5), 20).Return(late, nil).Once()
+		mockDeliveryRepo := new(mocks.DeliveryRepository)
+		mockDeliveryRepo.On("Cursor", mockTenantID).Return(int64(5), nil).Once()
+		mockDeliveryRepo.On("CreateBatch", mock.MatchedBy(func(ds []webhook.Delivery) bool {
+			return len(ds) == 1 && ds[0].EventID == 8
+		})).Return(nil).Once()
+		mockDeliveryRepo.On("AdvanceCursor", mockTenantID, int64(5), int64(6)).Return(nil).Once()
+
+		err := newMockWorker(mockSubRepo, mockDeliveryRepo, mockEventRepo).FanOut()
+
+		assert.Equal(t, nil, err)
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+}
+
+// receiver is a webhook endpoint answering with the status codes it is given
+// in turn, it records the requests whose signature is valid
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	received []http.Header
+	invalid  int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if !webhook.Verify(mockSecret, timestamp, body, r.Header.Get(webhook.HeaderSignature)) {
+		rc.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	rc.received = append(rc.received, r.Header.Clone())
+
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func TestDeliver(t *testing.T) {
+	logger.Init(logger.Config{})
+
+	payload := json.RawMessage(`{"event_id":4,"type":"order.created"}`)
+	newDelivery := func(attempts int) webhook.Delivery {
+		return webhook.Delivery{ID: 9, TenantID: mockTenantID, SubscriptionID: 1, EventID: 4,
+			EventType: order.EventOrderCreated, Payload: payload, Status: webhook.DeliveryPending, Attempts: attempts}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		rc := &receiver{}
+		server := httptest.NewServer(rc)
+		defer server.Close()
+
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, URL: server.URL, Secret: mockSecret, Active: true}, nil).Once()
+		mockDeliveryRepo := new(mocks.DeliveryRepository)
+		mockDeliveryRepo.On("Claim", 20, time.Minute).Return([]webhook.Delivery{newDelivery(0)}, nil).Once()
+		mockDeliveryRepo.On("Complete", int64(9), webhook.Attempt{StatusCode: http.StatusOK}).Return(nil).Once()
+
+		err := newMockWorker(mockSubRepo, mockDeliveryRepo, nil).Deliver(context.Background())
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, rc.invalid)
+		assert.Equal(t, 1, len(rc.received))
+		assert.Equal(t, "9", rc.received[0].Get(webhook.HeaderDeliveryID))
+		assert.Equal(t, order.EventOrderCreated, rc.received[0].Get(webhook.HeaderEvent))
+		assert.Equal(t, strconv.FormatInt(mockNow.Unix(), 10), rc.received[0].Get(webhook.HeaderTimestamp))
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("retries-with-backoff-until-success", func(t *testing.T) {
+		rc := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+		server := httptest.NewServer(rc)
+		defer server.Close()
+
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, URL: server.URL, Secret: mockSecret, Active: true}, nil)
+		mockDeliveryRepo := new(mocks.DeliveryRepository)
+		mockDeliveryRepo.On("Claim", 20, time.Minute).Return([]webhook.Delivery{newDelivery(0)}, nil).Once()
+		mockDeliveryRepo.On("Claim", 20, time.Minute).Return([]webhook.Delivery{newDelivery(1)}, nil).Once()
+		mockDeliveryRepo.On("Claim", 20, time.Minute).Return([]webhook.Delivery{newDelivery(2)}, nil).Once()
+		mockDeliveryRepo.On("Fail", int64(9), webhook.Attempt{StatusCode: 500, Error: "unexpected status 500"}, mockNow.Add(10*time.Second)).
+			Return(nil).Once()
+		mockDeliveryRepo.On("Fail", int64(9), webhook.Attempt{StatusCode: 502, Error: "unexpected status 502"}, mockNow.Add(20*time.Second)).
+			Return(nil).Once()
+		mockDeliveryRepo.On("Complete", int64(9), webhook.Attempt{StatusCode: http.StatusOK}).Return(nil).Once()
+
+		w := newMockWorker(mockSubRepo, mockDeliveryRepo, nil)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, nil, w.Deliver(context.Background()))
+		}
+
+		assert.Equal(t, 3, len(rc.received))
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("dead-after-max-attempts", func(t *testing.T) {
+		rc := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+		server := httptest.NewServer(rc)
+		defer server.Close()
+
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, URL: server.URL, Secret: mockSecret, Active: true}, nil).Once()
+		mockDeliveryRepo := new(mocks.DeliveryRepository)
+		mockDeliveryRepo.On("Claim", 20, time.Minute).Return([]webhook.Delivery{newDelivery(2)}, nil).Once()
+		mockDeliveryRepo.On("Bury", int64(9), webhook.Attempt{StatusCode: 503, Error: "unexpected status 503"}).Return(nil).Once()
+
+		err := newMockWorker(mockSubRepo, mockDeliveryRepo, nil).Deliver(context.Background())
+
+		assert.Equal(t, nil, err)
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("private-address-refused", func(t *testing.T) {
+		rc := &receiver{}
+		server := httptest.NewServer(rc)
+		defer server.Close()
+
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, URL: server.URL, Secret: mockSecret, Active: true}, nil).Once()
+		mockDeliveryRepo := new(mocks.DeliveryRepository)
+		mockDeliveryRepo.On("Claim", 20, time.Minute).Return([]webhook.Delivery{newDelivery(0)}, nil).Once()
+		mockDeliveryRepo.On("Fail", int64(9), mock.MatchedBy(func(a webhook.Attempt) bool {
+			return a.StatusCode == 0 && strings.Contains(a.Error, webhook.ErrPrivateAddress.Error())
+		}), mockNow.Add(10*time.Second)).Return(nil).Once()
+
+		cfg := mockConfig()
+		cfg.AllowPrivateNetworks = false
+		w := NewWorker(mockSubRepo, mockDeliveryRepo, nil, cfg)
+		w.now = func() time.Time { return mockNow }
+		err := w.Deliver(context.Background())
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(rc.received))
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("redirect-fails", func(t *testing.T) {
+		server := httptest.NewServer(http.RedirectHandler("https://example.com", http.StatusFound))
+		defer server.Close()
+
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, URL: server.URL, Secret: mockSecret, Active: true}, nil).Once()
+		mockDeliveryRepo := new(mocks.DeliveryRepository)
+		mockDeliveryRepo.On("Claim", 20, time.Minute).Return([]webhook.Delivery{newDelivery(0)}, nil).Once()
+		mockDeliveryRepo.On("Fail", int64(9), webhook.Attempt{StatusCode: 302, Error: "unexpected status 302"}, mockNow.Add(10*time.Second)).
+			Return(nil).Once()
+
+		err := newMockWorker(mockSubRepo, mockDeliveryRepo, nil).Deliver(context.Background())
+
+		assert.Equal(t, nil, err)
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("subscription-gone", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).Return(nil, sql.ErrNoRows).Once()
+		mockDeliveryRepo := new(mocks.DeliveryRepository)
+		mockDeliveryRepo.On("Claim", 20, time.Minute).Return([]webhook.Delivery{newDelivery(0)}, nil).Once()
+		mockDeliveryRepo.On("Bury", int64(9), webhook.Attempt{Error: errSubscriptionInactive}).Return(nil).Once()
+
+		err := newMockWorker(mockSubRepo, mockDeliveryRepo, nil).Deliver(context.Background())
+
+		assert.Equal(t, nil, err)
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("subscription-inactive", func(t *testing.T) {
+		mockSubRepo := new(mocks.SubscriptionRepository)
+		mockSubRepo.On("FindByID", mockTenantID, int64(1)).
+			Return(&webhook.Subscription{ID: 1, URL: "https://example.com", Active: false}, nil).Once()
+		mockDeliveryRepo := new(mocks.DeliveryRepository)
+		mockDeliveryRepo.On("Claim", 20, time.Minute).Return([]webhook.Delivery{newDelivery(0)}, nil).Once()
+		mockDeliveryRepo.On("Bury", int64(9), webhook.Attempt{Error: errSubscriptionInactive}).Return(nil).Once()
+
+		err := newMockWorker(mockSubRepo, mockDeliveryRepo, nil).Deliver(context.Background())
+
+		assert.Equal(t, nil, err)
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	w := newMockWorker(nil, nil, nil)
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{4, time.Minute},
+		{30, time.Minute},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, w.backoff(tt.attempts))
+	}
+}